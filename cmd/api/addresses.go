@@ -0,0 +1,250 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/faizisyellow/indocoffee/internal/models"
+	"github.com/faizisyellow/indocoffee/internal/service"
+	"github.com/faizisyellow/indocoffee/internal/service/dto"
+	errorService "github.com/faizisyellow/indocoffee/internal/service/error"
+	"github.com/faizisyellow/indocoffee/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// @Summary		Add address
+// @Description	Save new address to the address book, the first address becomes the default one
+// @Tags			Addresses
+// @Accept			json
+// @Produce		json
+// @Security		JWT
+// @Param			payload	body		dto.CreateAddressRequest	true	"Payload create new address"
+// @Success		201		{object}	main.Envelope{data=models.Address,error=nil}
+// @Failure		400		{object}	main.Envelope{data=nil,error=string}
+// @Failure		401		{object}	main.Envelope{data=nil,error=string}
+// @Failure		409		{object}	main.Envelope{data=nil,error=string}
+// @Failure		500		{object}	main.Envelope{data=nil,error=string}
+// @Router			/users/addresses [post]
+func (app *Application) CreateAddressHandler(w http.ResponseWriter, r *http.Request) {
+	var req dto.CreateAddressRequest
+	if err := ReadHttpJson(w, r, &req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := Validate.Struct(req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	user, err := utils.GetContentFromContext[*models.User](r, UsrCtx)
+	if err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	addr, err := app.Services.AddressesService.Create(r.Context(), user.Id, req)
+	if err != nil {
+		addressesError(w, r, err)
+		return
+	}
+
+	ResponseSuccess(w, r, addr, http.StatusCreated)
+}
+
+// @Summary		Get addresses
+// @Description	Get every address of the address book, the default address first
+// @Tags			Addresses
+// @Accept			json
+// @Produce		json
+// @Security		JWT
+// @Success		200	{object}	main.Envelope{data=[]models.Address,error=nil}
+// @Failure		401	{object}	main.Envelope{data=nil,error=string}
+// @Failure		500	{object}	main.Envelope{data=nil,error=string}
+// @Router			/users/addresses [get]
+func (app *Application) GetAddressesHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := utils.GetContentFromContext[*models.User](r, UsrCtx)
+	if err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	addrs, err := app.Services.AddressesService.FindAll(r.Context(), user.Id)
+	if err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	if addrs == nil {
+		addrs = []models.Address{}
+	}
+
+	ResponseSuccess(w, r, addrs, http.StatusOK)
+}
+
+// @Summary		Get address
+// @Description	Get address by id
+// @Tags			Addresses
+// @Accept			json
+// @Produce		json
+// @Security		JWT
+// @Param			id	path		int	true	"Address id"
+// @Success		200	{object}	main.Envelope{data=models.Address,error=nil}
+// @Failure		400	{object}	main.Envelope{data=nil,error=string}
+// @Failure		401	{object}	main.Envelope{data=nil,error=string}
+// @Failure		404	{object}	main.Envelope{data=nil,error=string}
+// @Failure		500	{object}	main.Envelope{data=nil,error=string}
+// @Router			/users/addresses/{id} [get]
+func (app *Application) GetAddressHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	user, err := utils.GetContentFromContext[*models.User](r, UsrCtx)
+	if err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	addr, err := app.Services.AddressesService.FindById(r.Context(), user.Id, id)
+	if err != nil {
+		addressesError(w, r, err)
+		return
+	}
+
+	ResponseSuccess(w, r, addr, http.StatusOK)
+}
+
+// @Summary		Update address
+// @Description	Update address by id, only the given fields are changed
+// @Tags			Addresses
+// @Accept			json
+// @Produce		json
+// @Security		JWT
+// @Param			id		path		int							true	"Address id"
+// @Param			payload	body		dto.UpdateAddressRequest	true	"Payload update address"
+// @Success		200		{object}	main.Envelope{data=models.Address,error=nil}
+// @Failure		400		{object}	main.Envelope{data=nil,error=string}
+// @Failure		401		{object}	main.Envelope{data=nil,error=string}
+// @Failure		404		{object}	main.Envelope{data=nil,error=string}
+// @Failure		500		{object}	main.Envelope{data=nil,error=string}
+// @Router			/users/addresses/{id} [patch]
+func (app *Application) UpdateAddressHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	var req dto.UpdateAddressRequest
+	if err := ReadHttpJson(w, r, &req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := Validate.Struct(req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	user, err := utils.GetContentFromContext[*models.User](r, UsrCtx)
+	if err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	addr, err := app.Services.AddressesService.Update(r.Context(), user.Id, id, req)
+	if err != nil {
+		addressesError(w, r, err)
+		return
+	}
+
+	ResponseSuccess(w, r, addr, http.StatusOK)
+}
+
+// @Summary		Set default address
+// @Description	Make the address the default one used at checkout
+// @Tags			Addresses
+// @Accept			json
+// @Produce		json
+// @Security		JWT
+// @Param			id	path		int	true	"Address id"
+// @Success		200	{object}	main.Envelope{data=string,error=nil}
+// @Failure		400	{object}	main.Envelope{data=nil,error=string}
+// @Failure		401	{object}	main.Envelope{data=nil,error=string}
+// @Failure		404	{object}	main.Envelope{data=nil,error=string}
+// @Failure		500	{object}	main.Envelope{data=nil,error=string}
+// @Router			/users/addresses/{id}/default [patch]
+func (app *Application) SetDefaultAddressHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	user, err := utils.GetContentFromContext[*models.User](r, UsrCtx)
+	if err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := app.Services.AddressesService.SetDefault(r.Context(), user.Id, id); err != nil {
+		addressesError(w, r, err)
+		return
+	}
+
+	ResponseSuccess(w, r, "default address changed", http.StatusOK)
+}
+
+// @Summary		Delete address
+// @Description	Delete address by id
+// @Tags			Addresses
+// @Accept			json
+// @Produce		json
+// @Security		JWT
+// @Param			id	path	int	true	"Address id"
+// @Success		204
+// @Failure		400	{object}	main.Envelope{data=nil,error=string}
+// @Failure		401	{object}	main.Envelope{data=nil,error=string}
+// @Failure		404	{object}	main.Envelope{data=nil,error=string}
+// @Failure		500	{object}	main.Envelope{data=nil,error=string}
+// @Router			/users/addresses/{id} [delete]
+func (app *Application) DeleteAddressHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	user, err := utils.GetContentFromContext[*models.User](r, UsrCtx)
+	if err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := app.Services.AddressesService.Delete(r.Context(), user.Id, id); err != nil {
+		addressesError(w, r, err)
+		return
+	}
+
+	ResponseSuccess(w, r, nil, http.StatusNoContent)
+}
+
+func addressesError(w http.ResponseWriter, r *http.Request, err error) {
+	if strings.Contains(err.Error(), "phone validation") {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	switch errorService.GetError(err).E {
+	case service.ErrAddressNotFound:
+		ResponseClientError(w, r, err, http.StatusNotFound)
+	case service.ErrAddressLimit:
+		ResponseClientError(w, r, err, http.StatusConflict)
+	default:
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,393 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/faizisyellow/indocoffee/internal/models"
+	"github.com/faizisyellow/indocoffee/internal/repository"
+	"github.com/faizisyellow/indocoffee/internal/service"
+	"github.com/faizisyellow/indocoffee/internal/service/dto"
+	errorService "github.com/faizisyellow/indocoffee/internal/service/error"
+	"github.com/faizisyellow/indocoffee/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// @Summary		Get users
+// @Description	Get users, filtered by role, active state and email or username
+// @Tags			Admin
+// @Accept			json
+// @Produce		json
+// @Security		JWT
+// @Param			role	query		string	false	"role id"
+// @Param			active	query		string	false	"active state true | false"
+// @Param			search	query		string	false	"part of email or username"
+// @Param			sort	query		string	false	"sort users by created asc(oldest) | desc(latest)"
+// @Param			limit	query		string	false	"limit each page"
+// @Param			offset	query		string	false	"skip rows"
+// @Success		200		{object}	main.Envelope{data=[]dto.AdminUserResponse,error=nil}
+// @Failure		400		{object}	main.Envelope{data=nil,error=string}
+// @Failure		401		{object}	main.Envelope{data=nil,error=string}
+// @Failure		403		{object}	main.Envelope{data=nil,error=string}
+// @Failure		500		{object}	main.Envelope{data=nil,error=string}
+// @Router			/admin/users [get]
+func (app *Application) AdminGetUsersHandler(w http.ResponseWriter, r *http.Request) {
+	requestQuery := repository.QueryUsers{
+		Limit:  r.URL.Query().Get("limit"),
+		Offset: r.URL.Query().Get("offset"),
+		Sort:   r.URL.Query().Get("sort"),
+		Role:   r.URL.Query().Get("role"),
+		Active: r.URL.Query().Get("active"),
+		Search: r.URL.Query().Get("search"),
+	}
+
+	paginateUsers, err := repository.PaginatedUsersQuery{Limit: 10, Sort: "desc"}.Parse(requestQuery)
+	if err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := Validate.Struct(paginateUsers); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	usrs, err := app.Services.UsersService.FindUsers(r.Context(), paginateUsers)
+	if err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	response := []dto.AdminUserResponse{}
+	for _, usr := range usrs {
+		response = append(response, adminUserResponse(usr))
+	}
+
+	ResponseSuccess(w, r, response, http.StatusOK)
+}
+
+// @Summary		Get user
+// @Description	Get user by Id
+// @Tags			Admin
+// @Accept			json
+// @Produce		json
+// @Security		JWT
+// @Param			id	path		int	true	"User id"
+// @Success		200	{object}	main.Envelope{data=dto.AdminUserResponse,error=nil}
+// @Failure		400	{object}	main.Envelope{data=nil,error=string}
+// @Failure		401	{object}	main.Envelope{data=nil,error=string}
+// @Failure		403	{object}	main.Envelope{data=nil,error=string}
+// @Failure		404	{object}	main.Envelope{data=nil,error=string}
+// @Failure		500	{object}	main.Envelope{data=nil,error=string}
+// @Router			/admin/users/{id} [get]
+func (app *Application) AdminGetUserHandler(w http.ResponseWriter, r *http.Request) {
+
+	usrId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	usr, err := app.Services.UsersService.FindUserById(r.Context(), usrId)
+	if err != nil {
+		app.adminUsersError(w, r, err)
+		return
+	}
+
+	ResponseSuccess(w, r, adminUserResponse(*usr), http.StatusOK)
+}
+
+// @Summary		Change user's role
+// @Description	Move user to another role, admins can not change their own role
+// @Tags			Admin
+// @Accept			json
+// @Produce		json
+// @Security		JWT
+// @Param			id		path		int								true	"User id"
+// @Param			payload	body		service.ChangeUserRoleRequest	true	"Payload to change role"
+// @Success		200		{object}	main.Envelope{data=string,error=nil}
+// @Failure		400		{object}	main.Envelope{data=nil,error=string}
+// @Failure		401		{object}	main.Envelope{data=nil,error=string}
+// @Failure		403		{object}	main.Envelope{data=nil,error=string}
+// @Failure		404		{object}	main.Envelope{data=nil,error=string}
+// @Failure		500		{object}	main.Envelope{data=nil,error=string}
+// @Router			/admin/users/{id}/role [patch]
+func (app *Application) AdminChangeUserRoleHandler(w http.ResponseWriter, r *http.Request) {
+
+	usrId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	var req service.ChangeUserRoleRequest
+	if err := ReadHttpJson(w, r, &req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := Validate.Struct(req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	admin, err := utils.GetContentFromContext[*models.User](r, UsrCtx)
+	if err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := app.Services.UsersService.ChangeRole(r.Context(), admin.Id, usrId, req); err != nil {
+		app.adminUsersError(w, r, err)
+		return
+	}
+
+	ResponseSuccess(w, r, "user's role changed", http.StatusOK)
+}
+
+// @Summary		Deactivate user
+// @Description	Deactivate user and revoke every session of the user
+// @Tags			Admin
+// @Accept			json
+// @Produce		json
+// @Security		JWT
+// @Param			id	path		int	true	"User id"
+// @Success		200	{object}	main.Envelope{data=string,error=nil}
+// @Failure		400	{object}	main.Envelope{data=nil,error=string}
+// @Failure		401	{object}	main.Envelope{data=nil,error=string}
+// @Failure		403	{object}	main.Envelope{data=nil,error=string}
+// @Failure		404	{object}	main.Envelope{data=nil,error=string}
+// @Failure		500	{object}	main.Envelope{data=nil,error=string}
+// @Router			/admin/users/{id}/deactivate [patch]
+func (app *Application) AdminDeactivateUserHandler(w http.ResponseWriter, r *http.Request) {
+
+	usrId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	admin, err := utils.GetContentFromContext[*models.User](r, UsrCtx)
+	if err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := app.Services.UsersService.Deactivate(r.Context(), admin.Id, usrId); err != nil {
+		app.adminUsersError(w, r, err)
+		return
+	}
+
+	ResponseSuccess(w, r, "user deactivated", http.StatusOK)
+}
+
+// @Summary		Reactivate user
+// @Description	Reactivate user that was deactivated
+// @Tags			Admin
+// @Accept			json
+// @Produce		json
+// @Security		JWT
+// @Param			id	path		int	true	"User id"
+// @Success		200	{object}	main.Envelope{data=string,error=nil}
+// @Failure		400	{object}	main.Envelope{data=nil,error=string}
+// @Failure		401	{object}	main.Envelope{data=nil,error=string}
+// @Failure		403	{object}	main.Envelope{data=nil,error=string}
+// @Failure		404	{object}	main.Envelope{data=nil,error=string}
+// @Failure		500	{object}	main.Envelope{data=nil,error=string}
+// @Router			/admin/users/{id}/reactivate [patch]
+func (app *Application) AdminReactivateUserHandler(w http.ResponseWriter, r *http.Request) {
+
+	usrId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := app.Services.UsersService.Reactivate(r.Context(), usrId); err != nil {
+		app.adminUsersError(w, r, err)
+		return
+	}
+
+	ResponseSuccess(w, r, "user reactivated", http.StatusOK)
+}
+
+// @Summary		Force password reset
+// @Description	Invalidate user's password, revoke every session and email a password reset token
+// @Tags			Admin
+// @Accept			json
+// @Produce		json
+// @Security		JWT
+// @Param			id	path		int	true	"User id"
+// @Success		202	{object}	main.Envelope{data=string,error=nil}
+// @Failure		400	{object}	main.Envelope{data=nil,error=string}
+// @Failure		401	{object}	main.Envelope{data=nil,error=string}
+// @Failure		403	{object}	main.Envelope{data=nil,error=string}
+// @Failure		404	{object}	main.Envelope{data=nil,error=string}
+// @Failure		500	{object}	main.Envelope{data=nil,error=string}
+// @Router			/admin/users/{id}/password-reset [post]
+func (app *Application) AdminForcePasswordResetHandler(w http.ResponseWriter, r *http.Request) {
+
+	usrId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := app.Services.UsersService.ForcePasswordReset(r.Context(), usrId); err != nil {
+		app.adminUsersError(w, r, err)
+		return
+	}
+
+	ResponseSuccess(w, r, "password reset email sent", http.StatusAccepted)
+}
+
+// @Summary		Unlock user
+// @Description	Lift the lockout of user after too many failed sign ins
+// @Tags			Admin
+// @Accept			json
+// @Produce		json
+// @Security		JWT
+// @Param			id	path		int	true	"User id"
+// @Success		200	{object}	main.Envelope{data=string,error=nil}
+// @Failure		400	{object}	main.Envelope{data=nil,error=string}
+// @Failure		401	{object}	main.Envelope{data=nil,error=string}
+// @Failure		403	{object}	main.Envelope{data=nil,error=string}
+// @Failure		404	{object}	main.Envelope{data=nil,error=string}
+// @Failure		500	{object}	main.Envelope{data=nil,error=string}
+// @Router			/admin/users/{id}/unlock [post]
+func (app *Application) AdminUnlockUserHandler(w http.ResponseWriter, r *http.Request) {
+
+	usrId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := app.Services.UsersService.Unlock(r.Context(), usrId); err != nil {
+		app.adminUsersError(w, r, err)
+		return
+	}
+
+	ResponseSuccess(w, r, "user unlocked", http.StatusOK)
+}
+
+// @Summary		Get audit log
+// @Description	Get privileged actions newest first, filtered by actor, target and time range
+// @Tags			Admin
+// @Accept			json
+// @Produce		json
+// @Security		JWT
+// @Param			actor		query		string	false	"actor user id"
+// @Param			target_type	query		string	false	"target type e.g. product | role | order"
+// @Param			target_id	query		string	false	"target id"
+// @Param			from		query		string	false	"RFC 3339 time the actions were done from"
+// @Param			to			query		string	false	"RFC 3339 time the actions were done until"
+// @Param			limit		query		string	false	"limit each page"
+// @Param			offset		query		string	false	"skip rows"
+// @Success		200			{object}	main.Envelope{data=[]models.AuditLog,error=nil}
+// @Failure		400			{object}	main.Envelope{data=nil,error=string}
+// @Failure		401			{object}	main.Envelope{data=nil,error=string}
+// @Failure		403			{object}	main.Envelope{data=nil,error=string}
+// @Failure		500			{object}	main.Envelope{data=nil,error=string}
+// @Router			/admin/audit [get]
+func (app *Application) AdminGetAuditHandler(w http.ResponseWriter, r *http.Request) {
+	requestQuery := repository.QueryAudit{
+		Limit:      r.URL.Query().Get("limit"),
+		Offset:     r.URL.Query().Get("offset"),
+		ActorId:    r.URL.Query().Get("actor"),
+		TargetType: r.URL.Query().Get("target_type"),
+		TargetId:   r.URL.Query().Get("target_id"),
+		From:       r.URL.Query().Get("from"),
+		To:         r.URL.Query().Get("to"),
+	}
+
+	paginateAudit, err := repository.PaginatedAuditQuery{Limit: 20}.Parse(requestQuery)
+	if err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := Validate.Struct(paginateAudit); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	logs, err := app.Services.AuditService.FindAll(r.Context(), paginateAudit)
+	if err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	ResponseSuccess(w, r, logs, http.StatusOK)
+}
+
+// @Summary		Get orphaned media
+// @Description	Report the stored files no product image refers to and older than the grace period, nothing is deleted
+// @Tags			Admin
+// @Accept			json
+// @Produce		json
+// @Security		JWT
+// @Success		200	{object}	main.Envelope{data=dto.MediaGarbageReport,error=nil}
+// @Failure		401	{object}	main.Envelope{data=nil,error=string}
+// @Failure		403	{object}	main.Envelope{data=nil,error=string}
+// @Failure		500	{object}	main.Envelope{data=nil,error=string}
+// @Router			/admin/media/orphans [get]
+func (app *Application) AdminGetOrphanedMediaHandler(w http.ResponseWriter, r *http.Request) {
+	report, err := app.Services.MediaService.CollectGarbage(r.Context(), true)
+	if err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	ResponseSuccess(w, r, report, http.StatusOK)
+}
+
+// @Summary		Delete orphaned media
+// @Description	Delete the stored files no product image refers to and older than the grace period
+// @Tags			Admin
+// @Accept			json
+// @Produce		json
+// @Security		JWT
+// @Success		200	{object}	main.Envelope{data=dto.MediaGarbageReport,error=nil}
+// @Failure		401	{object}	main.Envelope{data=nil,error=string}
+// @Failure		403	{object}	main.Envelope{data=nil,error=string}
+// @Failure		500	{object}	main.Envelope{data=nil,error=string}
+// @Router			/admin/media/orphans [delete]
+func (app *Application) AdminDeleteOrphanedMediaHandler(w http.ResponseWriter, r *http.Request) {
+	report, err := app.Services.MediaService.CollectGarbage(r.Context(), false)
+	if err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	ResponseSuccess(w, r, report, http.StatusOK)
+}
+
+func (app *Application) adminUsersError(w http.ResponseWriter, r *http.Request, err error) {
+	switch errorService.GetError(err).E {
+	case service.ErrUserNotFound, service.ErrNotFoundRole:
+		ResponseClientError(w, r, err, http.StatusNotFound)
+	case service.ErrUserManageSelf, service.ErrUserNotDeactivated:
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+	default:
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+	}
+}
+
+func adminUserResponse(usr models.User) dto.AdminUserResponse {
+	response := dto.AdminUserResponse{
+		Id:            usr.Id,
+		Username:      usr.Username,
+		Email:         usr.Email,
+		IsActive:      usr.IsActive != nil && *usr.IsActive,
+		Role:          dto.AdminUserRole{Id: usr.RoleId},
+		CreatedAt:     usr.CreatedAt,
+		DeactivatedAt: usr.DeactivatedAt,
+	}
+
+	if usr.Role != nil {
+		response.Role.Name = usr.Role.Name
+	}
+
+	return response
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/faizisyellow/indocoffee/internal/auth"
+	rateLimiter "github.com/faizisyellow/indocoffee/internal/limiter/rate"
 	"github.com/faizisyellow/indocoffee/internal/logger"
 	"github.com/faizisyellow/indocoffee/internal/service"
 	"go.uber.org/zap"
@@ -26,6 +27,9 @@ type DBConf struct {
 
 type JwtConfig struct {
 	SecretKey string
+	// KeysDir holds the PEM keys, when set tokens are signed with ActiveKey instead of SecretKey.
+	KeysDir   string
+	ActiveKey string
 	Iss       string
 	Sub       string
 	Exp       time.Duration
@@ -40,7 +44,10 @@ type Application struct {
 	Services       service.Service
 	JwtAuth        JwtConfig
 	Authentication auth.Authenticator
+	RateLimiter    rateLimiter.RateLimiter
 	Logger         *zap.SugaredLogger
+	// Media serves the files of the local storage, nil when they are stored elsewhere.
+	Media http.Handler
 }
 
 func (app *Application) Run(mux http.Handler) error {
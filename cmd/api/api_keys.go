@@ -0,0 +1,115 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/faizisyellow/indocoffee/internal/models"
+	"github.com/faizisyellow/indocoffee/internal/service"
+	"github.com/faizisyellow/indocoffee/internal/service/dto"
+	errorService "github.com/faizisyellow/indocoffee/internal/service/error"
+	"github.com/faizisyellow/indocoffee/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// @Summary		Create api key
+// @Description	Create an api key acting with the permissions of the role, the key is only shown once
+// @Tags			Admin
+// @Accept			json
+// @Produce		json
+// @Security		JWT
+// @Param			payload	body		dto.CreateApiKeyRequest	true	"Name, role and expiry of the key"
+// @Success		201		{object}	main.Envelope{data=dto.CreatedApiKey,error=nil}
+// @Failure		400		{object}	main.Envelope{data=nil,error=string}
+// @Failure		401		{object}	main.Envelope{data=nil,error=string}
+// @Failure		403		{object}	main.Envelope{data=nil,error=string}
+// @Failure		404		{object}	main.Envelope{data=nil,error=string}
+// @Failure		500		{object}	main.Envelope{data=nil,error=string}
+// @Router			/admin/api-keys [post]
+func (app *Application) CreateApiKeyHandler(w http.ResponseWriter, r *http.Request) {
+
+	var req dto.CreateApiKeyRequest
+
+	if err := ReadHttpJson(w, r, &req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := Validate.Struct(req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	admin, err := utils.GetContentFromContext[*models.User](r, UsrCtx)
+	if err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	created, err := app.Services.ApiKeysService.Create(r.Context(), admin.Id, req)
+	if err != nil {
+		apiKeysError(w, r, err)
+		return
+	}
+
+	ResponseSuccess(w, r, created, http.StatusCreated)
+}
+
+// @Summary		Get api keys
+// @Description	Get every api key with its last use, revoked and expired keys included
+// @Tags			Admin
+// @Produce		json
+// @Security		JWT
+// @Success		200	{object}	main.Envelope{data=[]models.ApiKey,error=nil}
+// @Failure		401	{object}	main.Envelope{data=nil,error=string}
+// @Failure		403	{object}	main.Envelope{data=nil,error=string}
+// @Failure		500	{object}	main.Envelope{data=nil,error=string}
+// @Router			/admin/api-keys [get]
+func (app *Application) GetApiKeysHandler(w http.ResponseWriter, r *http.Request) {
+
+	keys, err := app.Services.ApiKeysService.FindAll(r.Context())
+	if err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	ResponseSuccess(w, r, keys, http.StatusOK)
+}
+
+// @Summary		Revoke api key
+// @Description	Revoke an api key, requests with it are rejected right away
+// @Tags			Admin
+// @Produce		json
+// @Security		JWT
+// @Param			id	path	int	true	"Api key id"
+// @Success		204
+// @Failure		400	{object}	main.Envelope{data=nil,error=string}
+// @Failure		401	{object}	main.Envelope{data=nil,error=string}
+// @Failure		403	{object}	main.Envelope{data=nil,error=string}
+// @Failure		404	{object}	main.Envelope{data=nil,error=string}
+// @Failure		500	{object}	main.Envelope{data=nil,error=string}
+// @Router			/admin/api-keys/{id} [delete]
+func (app *Application) RevokeApiKeyHandler(w http.ResponseWriter, r *http.Request) {
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := app.Services.ApiKeysService.Revoke(r.Context(), id); err != nil {
+		apiKeysError(w, r, err)
+		return
+	}
+
+	ResponseSuccess(w, r, nil, http.StatusNoContent)
+}
+
+func apiKeysError(w http.ResponseWriter, r *http.Request, err error) {
+	switch errorService.GetError(err).E {
+	case service.ErrApiKeyNotFound, service.ErrNotFoundRole:
+		ResponseClientError(w, r, err, http.StatusNotFound)
+	default:
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+	}
+}
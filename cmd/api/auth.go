@@ -2,10 +2,14 @@ package main
 
 import (
 	"fmt"
+	"net"
 	"net/http"
 	"time"
 
+	"github.com/faizisyellow/indocoffee/internal/auth"
+	"github.com/faizisyellow/indocoffee/internal/models"
 	"github.com/faizisyellow/indocoffee/internal/service"
+	"github.com/faizisyellow/indocoffee/internal/service/dto"
 	errorService "github.com/faizisyellow/indocoffee/internal/service/error"
 	"github.com/faizisyellow/indocoffee/internal/utils"
 	"github.com/go-chi/chi/v5"
@@ -19,7 +23,7 @@ import (
 // @Accept			json
 // @Produce		json
 // @Param			payload	body		service.RegisterRequest	true	"Payload to Sign Up"
-// @Success		201		{object}	main.Envelope{data=service.RegisterResponse,error=nil}
+// @Success		201		{object}	main.Envelope{data=string,error=nil}
 // @Failure		400		{object}	main.Envelope{data=nil,error=string}
 // @Failure		409		{object}	main.Envelope{data=nil,error=string}
 // @Failure		500		{object}	main.Envelope{data=nil,error=string}
@@ -38,7 +42,7 @@ func (app *Application) SignUpHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res, err := app.Services.UsersService.RegisterAccount(r.Context(), req)
+	err := app.Services.UsersService.RegisterAccount(r.Context(), req)
 	if err != nil {
 		errorValue := errorService.GetError(err)
 		switch errorValue.E {
@@ -52,7 +56,7 @@ func (app *Application) SignUpHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ResponseSuccess(w, r, res, http.StatusCreated)
+	ResponseSuccess(w, r, "account created, please check your email to activate it", http.StatusCreated)
 
 }
 
@@ -92,9 +96,125 @@ func (app *Application) ActivateAccountHandler(w http.ResponseWriter, r *http.Re
 
 }
 
+// @Summary		Resend Activation
+// @Description	Send a new activation token to an account that is not activated yet, the previous token stops working
+// @Tags			Auth
+// @Accept			json
+// @Produce		json
+// @Param			payload	body		service.ResendActivationRequest	true	"Email of the account"
+// @Success		202		{object}	main.Envelope{data=string,error=nil}
+// @Failure		400		{object}	main.Envelope{data=nil,error=string}
+// @Failure		429		{object}	main.Envelope{data=nil,error=string}
+// @Failure		500		{object}	main.Envelope{data=nil,error=string}
+// @Router			/authentication/activation/resend [post]
+func (app *Application) ResendActivationHandler(w http.ResponseWriter, r *http.Request) {
+
+	var req service.ResendActivationRequest
+
+	if err := ReadHttpJson(w, r, &req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := Validate.Struct(req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	err := app.Services.UsersService.ResendActivation(r.Context(), req)
+	if err != nil {
+		errorValue := errorService.GetError(err)
+		switch errorValue.E {
+		case service.ErrActivationLimited:
+			ResponseClientError(w, r, err, http.StatusTooManyRequests)
+		default:
+			ResponseServerError(w, r, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	ResponseSuccess(w, r, "if the account is waiting for activation, a new activation token has been sent", http.StatusAccepted)
+}
+
+// @Summary		Forgot Password
+// @Description	Request a password reset token for an account, the response is the same whether the email is registered or not
+// @Tags			Auth
+// @Accept			json
+// @Produce		json
+// @Param			payload	body		service.ForgotPasswordRequest	true	"Email of the account"
+// @Success		202		{object}	main.Envelope{data=string,error=nil}
+// @Failure		400		{object}	main.Envelope{data=nil,error=string}
+// @Failure		500		{object}	main.Envelope{data=nil,error=string}
+// @Router			/authentication/forgot-password [post]
+func (app *Application) ForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+
+	var req service.ForgotPasswordRequest
+
+	if err := ReadHttpJson(w, r, &req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := Validate.Struct(req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	err := app.Services.UsersService.ForgotPassword(r.Context(), req)
+	if err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	ResponseSuccess(w, r, "if the email is registered, a password reset token has been sent", http.StatusAccepted)
+}
+
+// @Summary		Reset Password
+// @Description	Set a new password with a password reset token, every session of the account is signed out
+// @Tags			Auth
+// @Accept			json
+// @Produce		json
+// @Param			payload	body		service.ResetPasswordRequest	true	"Password reset token and the new password"
+// @Success		200		{object}	main.Envelope{data=string,error=nil}
+// @Failure		400		{object}	main.Envelope{data=nil,error=string}
+// @Failure		404		{object}	main.Envelope{data=nil,error=string}
+// @Failure		500		{object}	main.Envelope{data=nil,error=string}
+// @Router			/authentication/reset-password [post]
+func (app *Application) ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+
+	var req service.ResetPasswordRequest
+
+	if err := ReadHttpJson(w, r, &req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := Validate.Struct(req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	err := app.Services.UsersService.ResetPassword(r.Context(), req)
+	if err != nil {
+		errorValue := errorService.GetError(err)
+		switch errorValue.E {
+		case service.ErrTokenResetNotFound, service.ErrUserNotFound:
+			ResponseClientError(w, r, err, http.StatusNotFound)
+		case utils.ErrInvalidPasswordSignature:
+			ResponseClientError(w, r, err, http.StatusBadRequest)
+		default:
+			ResponseServerError(w, r, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	ResponseSuccess(w, r, "password has been reset, please sign in again", http.StatusOK)
+}
+
 type LoginResponse struct {
-	Token string `json:"token"`
-	User  struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	User         struct {
 		Id       int    `json:"id"`
 		Email    string `json:"email"`
 		Rolename string `json:"role_name"`
@@ -108,8 +228,12 @@ type LoginResponse struct {
 // @Produce		json
 // @Param			payload	body		service.LoginRequest	true	"Email and Passoword to Sign in Account"
 // @Success		200		{object}	main.Envelope{data=main.LoginResponse,error=nil}
+// @Success		202		{object}	main.Envelope{data=dto.TwoFactorChallenge,error=nil}	"Two factor code is needed, see /authentication/two-factor/verify"
 // @Failure		400		{object}	main.Envelope{data=nil,error=string}
+// @Failure		403		{object}	main.Envelope{data=nil,error=string}
 // @Failure		404		{object}	main.Envelope{data=nil,error=string}
+// @Failure		423		{object}	main.Envelope{data=nil,error=string}
+// @Failure		429		{object}	main.Envelope{data=nil,error=string}
 // @Failure		500		{object}	main.Envelope{data=nil,error=string}
 // @Router			/authentication/sign-in [post]
 func (app *Application) SignInHandler(w http.ResponseWriter, r *http.Request) {
@@ -126,7 +250,7 @@ func (app *Application) SignInHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	req.Ip = r.RemoteAddr
+	req.Ip = clientIp(r)
 
 	user, err := app.Services.UsersService.Login(r.Context(), req)
 	if err != nil {
@@ -136,8 +260,12 @@ func (app *Application) SignInHandler(w http.ResponseWriter, r *http.Request) {
 			ResponseClientError(w, r, err, http.StatusNotFound)
 		case service.ErrUserNotActivated:
 			ResponseClientError(w, r, err, http.StatusBadRequest)
+		case service.ErrUserDeactivated:
+			ResponseClientError(w, r, err, http.StatusForbidden)
 		case service.ErrUserLimited:
 			ResponseClientError(w, r, err, http.StatusTooManyRequests)
+		case service.ErrUserLocked:
+			ResponseClientError(w, r, err, http.StatusLocked)
 		case bcrypt.ErrMismatchedHashAndPassword:
 			ResponseClientError(w, r, fmt.Errorf("email or password incorrect"), http.StatusBadRequest)
 		default:
@@ -146,24 +274,39 @@ func (app *Application) SignInHandler(w http.ResponseWriter, r *http.Request) {
 
 		return
 	}
-	now := time.Now()
 
-	claims := jwt.MapClaims{
-		"iss": app.JwtAuth.Iss,
-		"sub": app.JwtAuth.Sub,
-		"iat": now.Unix(),
-		"exp": now.Add(app.JwtAuth.Exp).Unix(),
-		"id":  user.Id,
+	challenge, err := app.Services.TwoFactorService.Begin(r.Context(), *user, req.Ip)
+	if err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	if challenge != nil {
+		ResponseSuccess(w, r, challenge, http.StatusAccepted)
+		return
 	}
 
-	token, err := app.Authentication.GenerateToken(claims)
+	app.signIn(w, r, user)
+}
+
+// signIn starts a new session of the user and responds with its tokens.
+func (app *Application) signIn(w http.ResponseWriter, r *http.Request, user *models.User) {
+
+	session, err := app.Services.SessionsService.Create(r.Context(), user.Id)
+	if err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	token, err := app.generateAccessToken(user.Id, session.SessionId)
 	if err != nil {
 		ResponseServerError(w, r, err, http.StatusInternalServerError)
 		return
 	}
 
 	ResponseSuccess(w, r, LoginResponse{
-		Token: token,
+		Token:        token,
+		RefreshToken: session.RefreshToken,
 		User: struct {
 			Id       int    `json:"id"`
 			Email    string `json:"email"`
@@ -175,3 +318,122 @@ func (app *Application) SignInHandler(w http.ResponseWriter, r *http.Request) {
 		},
 	}, http.StatusOK)
 }
+
+// @Summary		Refresh Token
+// @Description	Exchange a refresh token for a new access token and a new refresh token
+// @Tags			Auth
+// @Accept			json
+// @Produce		json
+// @Param			payload	body		dto.RefreshTokenRequest	true	"Refresh token from sign in or previous refresh"
+// @Success		200		{object}	main.Envelope{data=dto.RefreshTokenResponse,error=nil}
+// @Failure		400		{object}	main.Envelope{data=nil,error=string}
+// @Failure		401		{object}	main.Envelope{data=nil,error=string}
+// @Failure		500		{object}	main.Envelope{data=nil,error=string}
+// @Router			/authentication/refresh [post]
+func (app *Application) RefreshTokenHandler(w http.ResponseWriter, r *http.Request) {
+
+	var req dto.RefreshTokenRequest
+
+	if err := ReadHttpJson(w, r, &req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := Validate.Struct(req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	session, err := app.Services.SessionsService.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		errorValue := errorService.GetError(err)
+		switch errorValue.E {
+		case service.ErrSessionInvalid, service.ErrSessionExpired, service.ErrSessionRevoked, service.ErrSessionReused:
+			ResponseClientError(w, r, err, http.StatusUnauthorized)
+		default:
+			ResponseServerError(w, r, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	token, err := app.generateAccessToken(session.UserId, session.SessionId)
+	if err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	ResponseSuccess(w, r, dto.RefreshTokenResponse{
+		Token:        token,
+		RefreshToken: session.RefreshToken,
+	}, http.StatusOK)
+}
+
+// @Summary		Sign out Account
+// @Description	Revoke the current session, its access and refresh tokens stop working
+// @Tags			Auth
+// @Produce		json
+// @Security		JWT
+// @Success		204
+// @Failure		401	{object}	main.Envelope{data=nil,error=string}
+// @Failure		500	{object}	main.Envelope{data=nil,error=string}
+// @Router			/authentication/sign-out [post]
+func (app *Application) SignOutHandler(w http.ResponseWriter, r *http.Request) {
+
+	sessionId, err := utils.GetContentFromContext[string](r, SessionCtx)
+	if err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := app.Services.SessionsService.Revoke(r.Context(), sessionId); err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	ResponseSuccess(w, r, nil, http.StatusNoContent)
+}
+
+// generateAccessToken signs a short-lived access token bound to a session.
+func (app *Application) generateAccessToken(usrId int, sessionId string) (string, error) {
+
+	now := time.Now()
+
+	claims := jwt.MapClaims{
+		"iss": app.JwtAuth.Iss,
+		"sub": app.JwtAuth.Sub,
+		"iat": now.Unix(),
+		"exp": now.Add(app.JwtAuth.Exp).Unix(),
+		"id":  usrId,
+		"sid": sessionId,
+	}
+
+	return app.Authentication.GenerateToken(claims)
+}
+
+// JWKSHandler publishes the public keys used to sign access tokens,
+// it is empty when tokens are signed with the shared secret.
+func (app *Application) JWKSHandler(w http.ResponseWriter, r *http.Request) {
+
+	jwks := auth.JWKS{Keys: []auth.JWK{}}
+	if publisher, ok := app.Authentication.(auth.KeyPublisher); ok {
+		jwks = publisher.JWKS()
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=300")
+
+	if err := WriteHttpJson(w, jwks, http.StatusOK); err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+	}
+}
+
+// clientIp is the ip of the client without the port,
+// so every connection of the client counts as the same.
+func clientIp(r *http.Request) string {
+
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return ip
+}
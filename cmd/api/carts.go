@@ -2,14 +2,12 @@ package main
 
 import (
 	"net/http"
-	"strconv"
 
 	"github.com/faizisyellow/indocoffee/internal/models"
 	"github.com/faizisyellow/indocoffee/internal/service"
 	"github.com/faizisyellow/indocoffee/internal/service/dto"
 	errorService "github.com/faizisyellow/indocoffee/internal/service/error"
 	"github.com/faizisyellow/indocoffee/internal/utils"
-	"github.com/go-chi/chi/v5"
 )
 
 // @Summary		Add cart
@@ -47,7 +45,7 @@ func (app *Application) CreateCartsHandler(w http.ResponseWriter, r *http.Reques
 	if err := app.Services.CartsService.Create(r.Context(), request, user.Id); err != nil {
 		errService := errorService.GetError(err)
 		switch errService.E {
-		case service.ErrNotFoundProduct:
+		case service.ErrNotFoundVariant:
 			ResponseClientError(w, r, err, http.StatusNotFound)
 		case service.ErrConflictItemCart:
 			ResponseClientError(w, r, err, http.StatusConflict)
@@ -75,15 +73,13 @@ func (app *Application) CreateCartsHandler(w http.ResponseWriter, r *http.Reques
 // @Failure		500	{object}	main.Envelope{data=nil,error=string}
 // @Router			/carts/{id}/increment [patch]
 func (app *Application) IncrementCartsItemHandler(w http.ResponseWriter, r *http.Request) {
-	idParam := chi.URLParam(r, "id")
-
-	id, err := strconv.Atoi(idParam)
+	cart, err := utils.GetContentFromContext[models.Cart](r, CartCtx)
 	if err != nil {
-		ResponseClientError(w, r, err, http.StatusBadRequest)
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
 		return
 	}
 
-	if err := app.Services.CartsService.IncrementItem(r.Context(), id); err != nil {
+	if err := app.Services.CartsService.IncrementItem(r.Context(), cart); err != nil {
 		errService := errorService.GetError(err)
 		switch errService.E {
 		case service.ErrCartNotFound:
@@ -114,15 +110,13 @@ func (app *Application) IncrementCartsItemHandler(w http.ResponseWriter, r *http
 // @Failure		500	{object}	main.Envelope{data=nil,error=string}
 // @Router			/carts/{id}/decrement [patch]
 func (app *Application) DecrementCartsHandler(w http.ResponseWriter, r *http.Request) {
-	idParam := chi.URLParam(r, "id")
-
-	id, err := strconv.Atoi(idParam)
+	cart, err := utils.GetContentFromContext[models.Cart](r, CartCtx)
 	if err != nil {
-		ResponseClientError(w, r, err, http.StatusBadRequest)
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
 		return
 	}
 
-	if err := app.Services.CartsService.DecrementItem(r.Context(), id); err != nil {
+	if err := app.Services.CartsService.DecrementItem(r.Context(), cart); err != nil {
 		errService := errorService.GetError(err)
 		switch errService.E {
 		case service.ErrCartNotFound:
@@ -154,15 +148,13 @@ func (app *Application) DecrementCartsHandler(w http.ResponseWriter, r *http.Req
 // @Failure		500	{object}	main.Envelope{data=nil,error=string}
 // @Router			/carts/{id} [delete]
 func (app *Application) DeleteCartsHandler(w http.ResponseWriter, r *http.Request) {
-	idParam := chi.URLParam(r, "id")
-
-	id, err := strconv.Atoi(idParam)
+	cart, err := utils.GetContentFromContext[models.Cart](r, CartCtx)
 	if err != nil {
-		ResponseClientError(w, r, err, http.StatusBadRequest)
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
 		return
 	}
 
-	if err := app.Services.CartsService.Destroy(r.Context(), id); err != nil {
+	if err := app.Services.CartsService.Destroy(r.Context(), cart); err != nil {
 		errService := errorService.GetError(err)
 		switch errService.E {
 		case service.ErrCartNotFound:
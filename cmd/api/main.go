@@ -1,29 +1,43 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"strconv"
 	"time"
 
 	"github.com/faizisyellow/indocoffee/docs"
 	"github.com/faizisyellow/indocoffee/internal/auth"
+	"github.com/faizisyellow/indocoffee/internal/cache"
 	"github.com/faizisyellow/indocoffee/internal/db"
 	loginLimiter "github.com/faizisyellow/indocoffee/internal/limiter/login"
+	rateLimiter "github.com/faizisyellow/indocoffee/internal/limiter/rate"
 	"github.com/faizisyellow/indocoffee/internal/logger"
+	"github.com/faizisyellow/indocoffee/internal/mailer/smtp"
+	"github.com/faizisyellow/indocoffee/internal/repository/addresses"
+	"github.com/faizisyellow/indocoffee/internal/repository/apikeys"
+	"github.com/faizisyellow/indocoffee/internal/repository/audits"
 	"github.com/faizisyellow/indocoffee/internal/repository/beans"
 	"github.com/faizisyellow/indocoffee/internal/repository/carts"
 	"github.com/faizisyellow/indocoffee/internal/repository/forms"
 	"github.com/faizisyellow/indocoffee/internal/repository/invitations"
 	"github.com/faizisyellow/indocoffee/internal/repository/orders"
 	"github.com/faizisyellow/indocoffee/internal/repository/products"
+	"github.com/faizisyellow/indocoffee/internal/repository/resets"
 	"github.com/faizisyellow/indocoffee/internal/repository/roles"
+	"github.com/faizisyellow/indocoffee/internal/repository/sessions"
+	"github.com/faizisyellow/indocoffee/internal/repository/twofactors"
 	"github.com/faizisyellow/indocoffee/internal/repository/users"
 	"github.com/faizisyellow/indocoffee/internal/service"
+	"github.com/faizisyellow/indocoffee/internal/uploader"
+	"github.com/faizisyellow/indocoffee/internal/uploader/imaging"
+	"github.com/faizisyellow/indocoffee/internal/uploader/local"
+	"github.com/faizisyellow/indocoffee/internal/uploader/s3"
 	"github.com/faizisyellow/indocoffee/internal/uploader/uploadthing"
 	"github.com/faizisyellow/indocoffee/internal/utils"
-	"github.com/google/uuid"
 	"github.com/oklog/ulid/v2"
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
@@ -45,6 +59,11 @@ import (
 //	@in							header
 //	@name						Authorization
 
+//	@securityDefinitions.apiKey	ApiKey
+//	@in							header
+//	@name						Authorization
+//	@description				"ApiKey <key>", created by an admin for integrations
+
 // @schemes	http https
 // @BasePath	/v1
 func main() {
@@ -73,9 +92,9 @@ func main() {
 	defer dbs.Close()
 	logger.Logger.Infow("database connection pool has established")
 
-	ud := utils.UUID{Plaintoken: uuid.New().String()}
+	ud := utils.UUID{}
 
-	upt := uploadthing.New(
+	var storage uploader.Uploader = uploadthing.New(
 		os.Getenv("UPLOADTHING_API_KEY"),
 		os.Getenv("UPLOADTHING_PRESIGNED_URL"),
 		os.Getenv("UPLOADTHING_POOL_UPLOAD_URL"),
@@ -85,9 +104,42 @@ func main() {
 		os.Getenv("UPLOADTHING_META_URL"),
 		os.Getenv("UPLOADTHING_CALLBACK_URL"),
 		os.Getenv("UPLOADTHING_DELETE_URL"),
+		os.Getenv("UPLOADTHING_LIST_URL"),
 		os.Getenv("UPLOADTHING_APP_ID"),
 	)
 
+	var media http.Handler
+
+	switch os.Getenv("STORAGE") {
+	// an s3 compatible bucket such as minio instead of uploadthing
+	case "s3":
+		storage = s3.New(s3.Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			Prefix:    os.Getenv("S3_PREFIX"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			PathStyle: os.Getenv("S3_PATH_STYLE") == "true",
+			PublicUrl: os.Getenv("S3_PUBLIC_URL"),
+		})
+	// files on the disk served from /media
+	case "local":
+		mediaUrl := os.Getenv("MEDIA_URL")
+		if mediaUrl == "" {
+			mediaUrl = fmt.Sprintf("http://%v/media", net.JoinHostPort(os.Getenv("HOST"), os.Getenv("PORT")))
+		}
+
+		mediaDir := os.Getenv("MEDIA_DIR")
+		if mediaDir == "" {
+			mediaDir = "media"
+		}
+
+		localStorage := &local.Storage{Dir: mediaDir, BaseUrl: mediaUrl, Secret: []byte(os.Getenv("MEDIA_SECRET"))}
+		storage = localStorage
+		media = localStorage
+	}
+
 	rdbname, err := strconv.Atoi(os.Getenv("REDIS_DB"))
 	if err != nil {
 		logger.Logger.Fatalw("error loading .env file", zap.Error(err))
@@ -102,10 +154,28 @@ func main() {
 
 	defer rdb.Close()
 
+	loginPolicy, err := loginLimiter.PolicyFromEnv()
+	if err != nil {
+		logger.Logger.Fatalw("error parsing login policy", zap.Error(err))
+	}
+
 	loginRateLimiter := loginLimiter.RedisLoginLimiter{
-		Rdb:      rdb,
-		Limit:    2, // start from 0
-		Duration: 12 * time.Hour,
+		Rdb:    rdb,
+		Policy: loginPolicy,
+	}
+
+	// the redis tier shares cached roles between instances
+	appCache := cache.Tiered{Local: &cache.InMemoryCache{}, LocalTTL: 30 * time.Second}
+	if os.Getenv("CACHE_REDIS") == "true" {
+		appCache.Remote = &cache.RedisCache{Rdb: rdb, Prefix: "cache:"}
+	}
+
+	smtpMailer := smtp.SMTPMailer{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     os.Getenv("SMTP_PORT"),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("MAIL_FROM"),
 	}
 
 	services := service.New(
@@ -116,22 +186,62 @@ func main() {
 		&forms.FormsRepository{Db: dbs},
 		&roles.RolesRepository{Db: dbs},
 		&products.ProductRepository{Db: dbs},
-		upt,
+		storage,
+		imaging.NewPipeline(),
 		&db.TransactionDB{Db: dbs},
 		ud,
-		utils.Ulid(ulid.Make().String),
+		utils.Ulid(func() string { return ulid.Make().String() }),
 		&carts.CartsRepository{Db: dbs},
 		&orders.OrdersRepository{Db: dbs},
+		&sessions.SessionsRepository{Db: dbs},
+		&resets.ResetsRepository{Db: dbs},
+		&smtpMailer,
+		&appCache,
+		&addresses.AddressesRepository{Db: dbs},
+		&twofactors.TwoFactorsRepository{Db: dbs},
+		service.TwoFactorPolicy{
+			Issuer:   "Indocoffee",
+			Required: os.Getenv("TWO_FACTOR_REQUIRED") == "true",
+		},
+		&apikeys.ApiKeysRepository{Db: dbs},
+		&audits.AuditsRepository{Db: dbs},
 	)
 
+	// orphaned media is collected in the background when an interval is set such as 6h
+	if interval := os.Getenv("MEDIA_GC_INTERVAL"); interval != "" {
+		mediaGcInterval, err := time.ParseDuration(interval)
+		if err != nil {
+			logger.Logger.Fatalw("error parsing media gc interval", zap.Error(err))
+		}
+
+		mediaGcCtx, cancelMediaGc := context.WithCancel(context.Background())
+		defer cancelMediaGc()
+
+		go services.MediaService.Run(mediaGcCtx, mediaGcInterval)
+	}
+
 	jwtTokenConfig := JwtConfig{
 		SecretKey: os.Getenv("SECRET_KEY"),
+		KeysDir:   os.Getenv("JWT_KEYS_DIR"),
+		ActiveKey: os.Getenv("JWT_ACTIVE_KEY"),
 		Iss:       "authentication",
 		Sub:       "user",
-		Exp:       24 * time.Hour,
+		Exp:       15 * time.Minute,
 	}
 
-	jwtAuthentication := auth.New(jwtTokenConfig.SecretKey, jwtTokenConfig.Iss, jwtTokenConfig.Sub)
+	var jwtAuthentication auth.Authenticator = auth.New(jwtTokenConfig.SecretKey, jwtTokenConfig.Iss, jwtTokenConfig.Sub)
+
+	if jwtTokenConfig.KeysDir != "" {
+		keys, err := auth.LoadKeys(jwtTokenConfig.KeysDir)
+		if err != nil {
+			logger.Logger.Fatalw("error loading jwt keys", zap.Error(err))
+		}
+
+		jwtAuthentication, err = auth.NewAsymmetric(keys, jwtTokenConfig.ActiveKey, jwtTokenConfig.Iss, jwtTokenConfig.Sub)
+		if err != nil {
+			logger.Logger.Fatalw("error loading jwt keys", zap.Error(err))
+		}
+	}
 
 	application := Application{
 		Port:           os.Getenv("PORT"),
@@ -140,7 +250,9 @@ func main() {
 		Services:       *services,
 		JwtAuth:        jwtTokenConfig,
 		Authentication: jwtAuthentication,
+		RateLimiter:    &rateLimiter.RedisRateLimiter{Rdb: rdb},
 		Logger:         logger.Logger,
+		Media:          media,
 
 		//http:domain:port/version/swagger/*
 		SwaggerUrl: fmt.Sprintf("http://%v/v%v/swagger/doc.json", net.JoinHostPort(os.Getenv("HOST"), os.Getenv("PORT")), 1),
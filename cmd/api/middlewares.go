@@ -11,6 +11,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/faizisyellow/indocoffee/internal/audit"
 	"github.com/faizisyellow/indocoffee/internal/keys"
 	"github.com/faizisyellow/indocoffee/internal/models"
 	"github.com/faizisyellow/indocoffee/internal/service"
@@ -23,18 +24,6 @@ import (
 
 type Middleware func(http.Handler) http.HandlerFunc
 
-type roleState int
-
-const (
-	Customer roleState = iota
-	Admin
-	SuperAdmin
-)
-
-func (m roleState) String() string {
-	return []string{"customer", "admin", "super admin"}[m]
-}
-
 func NewHandlerFunc(mw ...Middleware) func(http.HandlerFunc) http.HandlerFunc {
 	return func(h http.HandlerFunc) http.HandlerFunc {
 		next := h
@@ -48,8 +37,13 @@ func NewHandlerFunc(mw ...Middleware) func(http.HandlerFunc) http.HandlerFunc {
 }
 
 var (
-	UsrCtx             keys.User = "user"
-	ErrForbiddenAction           = errors.New("you don’t have permission for this action.")
+	UsrCtx              keys.User    = "user"
+	SessionCtx          keys.Session = "session"
+	OrderCtx            keys.Order   = "order"
+	CartCtx             keys.Cart    = "cart"
+	ApiKeyCtx           keys.ApiKey  = "api_key"
+	ErrForbiddenAction               = errors.New("you don’t have permission for this action.")
+	ErrApiKeyNotAllowed              = errors.New("api keys can not act on an account, sign in instead")
 )
 
 func (app *Application) AuthMiddleware(next http.Handler) http.HandlerFunc {
@@ -70,8 +64,13 @@ func (app *Application) AuthMiddleware(next http.Handler) http.HandlerFunc {
 			return
 		}
 
+		if parts[0] == "ApiKey" {
+			app.authenticateApiKey(w, r, next, parts[1])
+			return
+		}
+
 		if parts[0] != "Bearer" {
-			ResponseClientError(w, r, fmt.Errorf("authorization is malformed: authentication use Bearer"), http.StatusBadRequest)
+			ResponseClientError(w, r, fmt.Errorf("authorization is malformed: authentication use Bearer or ApiKey"), http.StatusBadRequest)
 			return
 		}
 		token := parts[1]
@@ -94,9 +93,15 @@ func (app *Application) AuthMiddleware(next http.Handler) http.HandlerFunc {
 			return
 		}
 
+		sessionId, ok := claim["sid"].(string)
+		if !ok {
+			ResponseClientError(w, r, fmt.Errorf("authorization is malformed: session is missing"), http.StatusUnauthorized)
+			return
+		}
+
 		ctx := r.Context()
 
-		user, err := app.Services.UsersService.FindUserById(ctx, int(usrId))
+		user, err := app.Services.UsersService.FindIdentity(ctx, int(usrId))
 		if err != nil {
 			errService := errorService.GetError(err)
 			switch errService.E {
@@ -108,13 +113,67 @@ func (app *Application) AuthMiddleware(next http.Handler) http.HandlerFunc {
 			return
 		}
 
+		if err := app.Services.SessionsService.Verify(ctx, sessionId, user.Id); err != nil {
+			errService := errorService.GetError(err)
+			switch errService.E {
+			case service.ErrSessionRevoked:
+				ResponseClientError(w, r, err, http.StatusUnauthorized)
+			default:
+				ResponseServerError(w, r, err, http.StatusInternalServerError)
+			}
+			return
+		}
+
 		ctx = context.WithValue(ctx, UsrCtx, user)
+		ctx = context.WithValue(ctx, SessionCtx, sessionId)
+		ctx = audit.WithActor(ctx, audit.Actor{Id: user.Id, Name: user.Username})
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 
 	}
 }
 
+// authenticateApiKey passes down a service principal for the api key,
+// a user without id whose permissions are the ones of the key's role.
+func (app *Application) authenticateApiKey(w http.ResponseWriter, r *http.Request, next http.Handler, plaintext string) {
+
+	key, err := app.Services.ApiKeysService.Authenticate(r.Context(), plaintext)
+	if err != nil {
+		errService := errorService.GetError(err)
+		switch errService.E {
+		case service.ErrApiKeyInvalid, service.ErrApiKeyExpired, service.ErrApiKeyRevoked:
+			ResponseClientError(w, r, err, http.StatusUnauthorized)
+		default:
+			ResponseServerError(w, r, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	principal := &models.User{Username: key.Name, RoleId: key.RoleId}
+
+	ctx := context.WithValue(r.Context(), UsrCtx, principal)
+	ctx = context.WithValue(ctx, ApiKeyCtx, key)
+	ctx = audit.WithActor(ctx, audit.Actor{Name: key.Name, ApiKeyId: key.Id})
+
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// RejectApiKey keeps api keys away from routes acting on the signed in account,
+// a service principal has no account of its own.
+func (app *Application) RejectApiKey(next http.Handler) http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.Header.Get("Authorization"), "ApiKey ") {
+			ResponseClientError(w, r, ErrApiKeyNotAllowed, http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// CheckOwnerCart allows only the owner of the cart.
+// The loaded cart is passed down with CartCtx.
 func (app *Application) CheckOwnerCart(next http.Handler) http.HandlerFunc {
 
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -149,30 +208,9 @@ func (app *Application) CheckOwnerCart(next http.Handler) http.HandlerFunc {
 			return
 		}
 
-		next.ServeHTTP(w, r)
-	}
-}
-
-func (app *Application) OnlyActionByCustomer(next http.Handler) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		user, err := utils.GetContentFromContext[*models.User](r, UsrCtx)
-		if err != nil {
-			ResponseServerError(w, r, err, http.StatusInternalServerError)
-			return
-		}
-
-		allowed, err := app.checkRolePresedence(r.Context(), user, "customer", "customer_only")
-		if err != nil {
-			ResponseServerError(w, r, err, http.StatusBadRequest)
-			return
-		}
-
-		if !allowed {
-			ResponseClientError(w, r, ErrForbiddenAction, http.StatusForbidden)
-			return
-		}
+		ctx := context.WithValue(r.Context(), CartCtx, cart)
 
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, r.WithContext(ctx))
 	}
 }
 
@@ -185,17 +223,6 @@ func (app *Application) CheckOwnerCartsToOrders(next http.Handler) http.HandlerF
 			return
 		}
 
-		allowed, err := app.checkRolePresedence(r.Context(), user, "customer", "customer_only")
-		if err != nil {
-			ResponseServerError(w, r, err, http.StatusBadRequest)
-			return
-		}
-
-		if !allowed {
-			ResponseClientError(w, r, ErrForbiddenAction, http.StatusForbidden)
-			return
-		}
-
 		bodyBytes, err := io.ReadAll(r.Body)
 		if err != nil {
 			ResponseClientError(w, r, err, http.StatusBadRequest)
@@ -235,129 +262,80 @@ func (app *Application) CheckOwnerCartsToOrders(next http.Handler) http.HandlerF
 	}
 }
 
-func (app *Application) AuthorizeManageOrder(next http.Handler) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		user, err := utils.GetContentFromContext[*models.User](r, UsrCtx)
-		if err != nil {
-			ResponseServerError(w, r, err, http.StatusInternalServerError)
-			return
-		}
-		allowed, err := app.checkRolePresedence(r.Context(), user, "admin", "")
-		if err != nil {
-			ResponseServerError(w, r, err, http.StatusBadRequest)
-			return
-		}
+// CheckOwnerOrder allows the customer of the order,
+// or anyone granted the permission to act on every order.
+// The loaded order is passed down with OrderCtx.
+func (app *Application) CheckOwnerOrder(permission string) Middleware {
+	return func(next http.Handler) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
 
-		if !allowed {
-			ResponseClientError(w, r, ErrForbiddenAction, http.StatusForbidden)
-			return
-		}
+			user, err := utils.GetContentFromContext[*models.User](r, UsrCtx)
+			if err != nil {
+				ResponseServerError(w, r, err, http.StatusInternalServerError)
+				return
+			}
 
-		next.ServeHTTP(w, r)
-	}
-}
+			ctx := r.Context()
 
-func (app *Application) CheckOwnerOrder(next http.Handler) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
+			order, err := app.Services.OrdersService.FindById(ctx, chi.URLParam(r, "id"))
+			if err != nil {
+				errService := errorService.GetError(err)
+				switch errService.E {
+				case service.ErrOrdersNotFound:
+					ResponseClientError(w, r, err, http.StatusNotFound)
+				default:
+					ResponseServerError(w, r, err, http.StatusInternalServerError)
+				}
+				return
+			}
 
-		user, err := utils.GetContentFromContext[*models.User](r, UsrCtx)
-		if err != nil {
-			ResponseServerError(w, r, err, http.StatusInternalServerError)
-			return
-		}
+			ctx = context.WithValue(ctx, OrderCtx, order)
 
-		ctx := r.Context()
+			// an api key has no account and an anonymized order no customer, both have id zero
+			if user.Id != 0 && order.CustomerId == user.Id {
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
 
-		order, err := app.Services.OrdersService.FindById(ctx, chi.URLParam(r, "id"))
-		if err != nil {
-			errService := errorService.GetError(err)
-			switch errService.E {
-			case service.ErrOrdersNotFound:
-				ResponseClientError(w, r, err, http.StatusNotFound)
-			default:
+			allowed, err := app.Services.RolesService.HasPermission(ctx, user.RoleId, permission)
+			if err != nil {
 				ResponseServerError(w, r, err, http.StatusInternalServerError)
+				return
 			}
-			return
-		}
-
-		if order.CustomerId == user.Id {
-			next.ServeHTTP(w, r)
-			return
-		}
 
-		allowed, err := app.checkRolePresedence(ctx, user, "admin", "")
-		if err != nil {
-			ResponseServerError(w, r, err, http.StatusBadRequest)
-			return
-		}
+			if !allowed {
+				ResponseClientError(w, r, ErrForbiddenAction, http.StatusForbidden)
+				return
+			}
 
-		if !allowed {
-			ResponseClientError(w, r, ErrForbiddenAction, http.StatusForbidden)
-			return
+			next.ServeHTTP(w, r.WithContext(ctx))
 		}
-
-		next.ServeHTTP(w, r)
 	}
 }
 
-func (app *Application) CheckAuthorization(rolaname string) func(next http.Handler) http.HandlerFunc {
+// RequirePermission allows only users whose role has been granted the permission.
+// It must run after AuthMiddleware.
+func (app *Application) RequirePermission(permission string) Middleware {
 	return func(next http.Handler) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
-			if rolaname != Admin.String() && rolaname != Customer.String() && rolaname != SuperAdmin.String() {
-				ResponseServerError(w, r, errors.New("unknown user"), http.StatusBadRequest)
-				return
-			}
-
 			user, err := utils.GetContentFromContext[*models.User](r, UsrCtx)
 			if err != nil {
 				ResponseServerError(w, r, err, http.StatusInternalServerError)
 				return
 			}
 
-			ctx := r.Context()
-			role, err := app.Services.RolesService.FindByName(ctx, rolaname)
+			allowed, err := app.Services.RolesService.HasPermission(r.Context(), user.RoleId, permission)
 			if err != nil {
-				ResponseServerError(w, r, err, http.StatusBadRequest)
+				ResponseServerError(w, r, err, http.StatusInternalServerError)
 				return
 			}
 
-			userRole, err := app.Services.RolesService.FindById(ctx, user.RoleId)
-			if err != nil {
-				ResponseServerError(w, r, err, http.StatusBadRequest)
+			if !allowed {
+				ResponseClientError(w, r, ErrForbiddenAction, http.StatusForbidden)
 				return
 			}
 
-			if role.Name == Customer.String() {
-				if userRole.Level >= role.Level {
-					ResponseClientError(w, r, ErrForbiddenAction, http.StatusForbidden)
-					return
-				}
-			} else {
-				if userRole.Level <= role.Level {
-					ResponseClientError(w, r, ErrForbiddenAction, http.StatusForbidden)
-					return
-				}
-			}
 			next.ServeHTTP(w, r)
 		}
 	}
 }
-
-func (app *Application) checkRolePresedence(ctx context.Context, user *models.User, rolename string, event string) (bool, error) {
-	role, err := app.Services.RolesService.FindByName(ctx, rolename)
-	if err != nil {
-		return false, err
-	}
-
-	userRole, err := app.Services.RolesService.FindById(ctx, user.RoleId)
-	if err != nil {
-		return false, err
-	}
-
-	if event == "customer_only" {
-		return userRole.Level <= role.Level, nil
-	}
-
-	// default
-	return userRole.Level >= role.Level, nil
-}
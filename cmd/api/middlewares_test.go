@@ -1,10 +1,20 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/faizisyellow/indocoffee/internal/cache"
+	"github.com/faizisyellow/indocoffee/internal/models"
+	"github.com/faizisyellow/indocoffee/internal/repository/apikeys"
+	"github.com/faizisyellow/indocoffee/internal/repository/orders"
+	"github.com/faizisyellow/indocoffee/internal/repository/roles"
+	"github.com/faizisyellow/indocoffee/internal/service"
+	"github.com/faizisyellow/indocoffee/internal/service/dto"
+	"github.com/go-chi/chi/v5"
 )
 
 func FirstMiddleware(next http.Handler) http.HandlerFunc {
@@ -35,3 +45,142 @@ func TestMiddlewareChain(t *testing.T) {
 	handler := NewHandlerFunc(FirstMiddleware, SecondMiddleware)(finalHandler)
 	handler.ServeHTTP(httptest.NewRecorder(), request)
 }
+
+func TestRequirePermission(t *testing.T) {
+	app := setupTestApplication(t)
+	app.Services.RolesService = &service.RolesServices{
+		Cache: &cache.InMemoryCache{},
+		RolesStore: &roles.InMemoryRoles{
+			Roles: []models.RolesModel{
+				{Id: 1, Name: "customer", Permissions: []string{service.PERMISSION_CARTS_WRITE}},
+				{Id: 2, Name: "roaster", Permissions: []string{service.PERMISSION_ORDERS_ROAST}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		roleId   int
+		expected int
+	}{
+		{"role granted the permission", 2, http.StatusOK},
+		{"role without the permission", 1, http.StatusForbidden},
+		{"role that does not exist", 3, http.StatusForbidden},
+	}
+
+	handler := NewHandlerFunc(app.RequirePermission(service.PERMISSION_ORDERS_ROAST))(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPatch, "/", nil)
+			request = request.WithContext(context.WithValue(request.Context(), UsrCtx, &models.User{Id: 1, RoleId: tt.roleId}))
+
+			response := httptest.NewRecorder()
+			handler.ServeHTTP(response, request)
+
+			if response.Code != tt.expected {
+				t.Errorf("expected status %v but got %v", tt.expected, response.Code)
+			}
+		})
+	}
+}
+
+func TestApiKeyAuthentication(t *testing.T) {
+	app := setupTestApplication(t)
+	app.Services.RolesService = &service.RolesServices{
+		Cache: &cache.InMemoryCache{},
+		RolesStore: &roles.InMemoryRoles{
+			Roles: []models.RolesModel{
+				{Id: 1, Name: "customer", Permissions: []string{service.PERMISSION_CARTS_WRITE}},
+				{Id: 4, Name: "warehouse", Permissions: []string{service.PERMISSION_ORDERS_SHIP}},
+			},
+		},
+	}
+
+	apiKeys := &service.ApiKeysService{
+		ApiKeysStore: &apikeys.InMemoryApiKeys{},
+		RolesStore:   app.Services.RolesService.(*service.RolesServices).RolesStore,
+		Transaction:  transactionFake{},
+	}
+	app.Services.ApiKeysService = apiKeys
+
+	warehouse, err := apiKeys.Create(context.Background(), 1, dto.CreateApiKeyRequest{Name: "warehouse scanner", RoleId: 4})
+	if err != nil {
+		t.Fatalf("should not be error but got: %v", err)
+	}
+
+	customer, err := apiKeys.Create(context.Background(), 1, dto.CreateApiKeyRequest{Name: "storefront", RoleId: 1})
+	if err != nil {
+		t.Fatalf("should not be error but got: %v", err)
+	}
+
+	handler := NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_ORDERS_SHIP))(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name          string
+		authorization string
+		expected      int
+	}{
+		{"key whose role has the permission", "ApiKey " + warehouse.Key, http.StatusOK},
+		{"key whose role lacks the permission", "ApiKey " + customer.Key, http.StatusForbidden},
+		{"unknown key", "ApiKey ick_unknown", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPatch, "/", nil)
+			request.Header.Set("Authorization", tt.authorization)
+
+			response := httptest.NewRecorder()
+			handler.ServeHTTP(response, request)
+
+			if response.Code != tt.expected {
+				t.Errorf("expected status %v but got %v", tt.expected, response.Code)
+			}
+		})
+	}
+
+	t.Run("key does not own an anonymized order", func(t *testing.T) {
+		app.Services.OrdersService = &service.OrdersService{
+			OrderStore: &orders.InMemoryOrders{Orders: []models.Order{{Id: "ORD-1", CustomerName: orders.AnonymousCustomer}}},
+		}
+
+		handler := NewHandlerFunc(app.AuthMiddleware, app.CheckOwnerOrder(service.PERMISSION_ORDERS_SHIP))(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.Header.Set("Authorization", "ApiKey "+customer.Key)
+
+		routeCtx := chi.NewRouteContext()
+		routeCtx.URLParams.Add("id", "ORD-1")
+		request = request.WithContext(context.WithValue(request.Context(), chi.RouteCtxKey, routeCtx))
+
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+
+		if response.Code != http.StatusForbidden {
+			t.Errorf("expected status %v but got %v", http.StatusForbidden, response.Code)
+		}
+	})
+
+	t.Run("revoked key", func(t *testing.T) {
+		if err := apiKeys.Revoke(context.Background(), warehouse.ApiKey.Id); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		request := httptest.NewRequest(http.MethodPatch, "/", nil)
+		request.Header.Set("Authorization", "ApiKey "+warehouse.Key)
+
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+
+		if response.Code != http.StatusUnauthorized {
+			t.Errorf("expected status %v but got %v", http.StatusUnauthorized, response.Code)
+		}
+	})
+}
@@ -5,16 +5,15 @@ import (
 	"os"
 	"time"
 
+	"github.com/faizisyellow/indocoffee/internal/service"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
-	"github.com/go-chi/httprate"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
 func (app *Application) Mux() http.Handler {
 
-	adminRole := Admin.String()
 	r := chi.NewRouter()
 
 	r.Use(middleware.RequestID)
@@ -24,84 +23,174 @@ func (app *Application) Mux() http.Handler {
 		AllowedOrigins:   []string{os.Getenv("CLIENT")},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Idempotency-Key"},
-		ExposedHeaders:   []string{"Link"},
+		ExposedHeaders:   []string{"Link", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After"},
 		AllowCredentials: false,
 		MaxAge:           300, // Maximum value not ignored by any of major browsers
 	}))
 	r.Use(middleware.Timeout(60 * time.Second))
-	r.Use(httprate.Limit(
-		99,
-		30*time.Minute,
-		httprate.WithKeyFuncs(httprate.KeyByIP, httprate.KeyByEndpoint),
-	))
+
+	r.With(app.RateLimit(CatalogRateLimit)).Get("/.well-known/jwks.json", app.JWKSHandler)
+
+	if app.Media != nil {
+		media := http.StripPrefix("/media", app.Media)
+		r.With(app.RateLimit(CatalogRateLimit)).Get("/media/*", media.ServeHTTP)
+		r.With(app.RateLimit(CatalogRateLimit)).Head("/media/*", media.ServeHTTP)
+		// uploads to urls signed by the storage, the signature authorizes them
+		r.With(app.RateLimit(DefaultRateLimit)).Put("/media/*", media.ServeHTTP)
+	}
 
 	r.Route("/v1", func(r chi.Router) {
 		r.Route("/users", func(r chi.Router) {
+			r.Use(app.RateLimit(DefaultRateLimit))
+			r.Use(app.RejectApiKey)
+
 			r.Get("/profile", NewHandlerFunc(app.AuthMiddleware)(app.GetUserProfileHandler))
+			r.Patch("/profile", NewHandlerFunc(app.AuthMiddleware)(app.UpdateUserProfileHandler))
+			r.Post("/password", NewHandlerFunc(app.AuthMiddleware)(app.ChangePasswordHandler))
+			r.Post("/email", NewHandlerFunc(app.AuthMiddleware)(app.ChangeEmailHandler))
+			r.Post("/email/confirm/{token}", app.ConfirmEmailChangeHandler)
+			r.Post("/two-factor", NewHandlerFunc(app.AuthMiddleware)(app.EnrollTwoFactorHandler))
+			r.Post("/two-factor/confirm", NewHandlerFunc(app.AuthMiddleware)(app.ConfirmTwoFactorHandler))
+			r.Delete("/two-factor", NewHandlerFunc(app.AuthMiddleware)(app.DisableTwoFactorHandler))
 			r.Get("/cart", NewHandlerFunc(app.AuthMiddleware)(app.FindUsersCartHandler))
 			r.Get("/orders", NewHandlerFunc(app.AuthMiddleware)(app.FindUsersOrdersHandler))
+			r.Get("/export", NewHandlerFunc(app.AuthMiddleware)(app.ExportAccountHandler))
 			r.Delete("/delete", NewHandlerFunc(app.AuthMiddleware)(app.DeleteAccountHandler))
+
+			r.Route("/addresses", func(r chi.Router) {
+				r.Get("/", NewHandlerFunc(app.AuthMiddleware)(app.GetAddressesHandler))
+				r.Post("/", NewHandlerFunc(app.AuthMiddleware)(app.CreateAddressHandler))
+				r.Get("/{id}", NewHandlerFunc(app.AuthMiddleware)(app.GetAddressHandler))
+				r.Patch("/{id}", NewHandlerFunc(app.AuthMiddleware)(app.UpdateAddressHandler))
+				r.Patch("/{id}/default", NewHandlerFunc(app.AuthMiddleware)(app.SetDefaultAddressHandler))
+				r.Delete("/{id}", NewHandlerFunc(app.AuthMiddleware)(app.DeleteAddressHandler))
+			})
 		})
 
-		r.Get("/swagger/*", httpSwagger.Handler(
+		r.With(app.RateLimit(CatalogRateLimit)).Get("/swagger/*", httpSwagger.Handler(
 			httpSwagger.URL(app.SwaggerUrl)))
 
 		r.Route("/authentication", func(r chi.Router) {
+			r.Use(app.RateLimit(AuthenticationRateLimit))
+
 			r.Post("/sign-up", app.SignUpHandler)
+			r.Post("/activation/resend", app.ResendActivationHandler)
 			r.Post("/activation/{token}", app.ActivateAccountHandler)
 			r.Post("/sign-in", app.SignInHandler)
+			r.Post("/two-factor/verify", app.VerifyTwoFactorHandler)
+			r.Post("/two-factor/enroll", app.EnrollTwoFactorChallengeHandler)
+			r.Post("/refresh", app.RefreshTokenHandler)
+			r.With(app.RejectApiKey).Post("/sign-out", NewHandlerFunc(app.AuthMiddleware)(app.SignOutHandler))
+			r.Post("/forgot-password", app.ForgotPasswordHandler)
+			r.Post("/reset-password", app.ResetPasswordHandler)
 		})
 
 		r.Route("/roles", func(r chi.Router) {
-			r.Post("/", NewHandlerFunc(app.AuthMiddleware, app.CheckAuthorization(adminRole))(app.CreateRolesHandler))
-			r.Get("/", NewHandlerFunc(app.AuthMiddleware, app.CheckAuthorization(adminRole))(app.GetAllRolesHandler))
-			r.Get("/{id}", NewHandlerFunc(app.AuthMiddleware, app.CheckAuthorization(adminRole))(app.GetRolesHandler))
-			r.Patch("/{id}", NewHandlerFunc(app.AuthMiddleware, app.CheckAuthorization(adminRole))(app.UpdateRolesHandler))
-			r.Delete("/{id}", NewHandlerFunc(app.AuthMiddleware, app.CheckAuthorization(adminRole))(app.DeleteRolesHandler))
-			r.Delete("/trash", NewHandlerFunc(app.AuthMiddleware, app.CheckAuthorization(adminRole))(app.TrashRolesHandler))
+			r.Use(app.RateLimit(DefaultRateLimit))
+
+			r.Post("/", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_ROLES_MANAGE))(app.CreateRolesHandler))
+			r.Get("/", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_ROLES_MANAGE))(app.GetAllRolesHandler))
+			r.Get("/permissions", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_ROLES_MANAGE))(app.GetPermissionsHandler))
+			r.Get("/{id}", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_ROLES_MANAGE))(app.GetRolesHandler))
+			r.Patch("/{id}", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_ROLES_MANAGE))(app.UpdateRolesHandler))
+			r.Delete("/{id}", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_ROLES_MANAGE))(app.DeleteRolesHandler))
+			r.Delete("/trash", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_ROLES_MANAGE))(app.TrashRolesHandler))
 		})
 
 		r.Route("/beans", func(r chi.Router) {
+			r.Use(app.RateLimitReads(CatalogRateLimit, DefaultRateLimit))
+
 			r.Get("/", app.GetAllBeansHandler)
-			r.Post("/", NewHandlerFunc(app.AuthMiddleware, app.CheckAuthorization(adminRole))(app.CreateBeansHandler))
-			r.Get("/{id}", NewHandlerFunc(app.AuthMiddleware, app.CheckAuthorization(adminRole))(app.GetBeansHandler))
-			r.Patch("/{id}", NewHandlerFunc(app.AuthMiddleware, app.CheckAuthorization(adminRole))(app.UpdateBeansHandler))
-			r.Delete("/{id}", NewHandlerFunc(app.AuthMiddleware, app.CheckAuthorization(adminRole))(app.DeleteBeansHandler))
-			r.Delete("/trash", NewHandlerFunc(app.AuthMiddleware, app.CheckAuthorization(adminRole))(app.TrashBeansHandler))
+			r.Post("/", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_BEANS_WRITE))(app.CreateBeansHandler))
+			r.Get("/{id}", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_BEANS_WRITE))(app.GetBeansHandler))
+			r.Patch("/{id}", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_BEANS_WRITE))(app.UpdateBeansHandler))
+			r.Delete("/{id}", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_BEANS_WRITE))(app.DeleteBeansHandler))
+			r.Delete("/trash", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_BEANS_WRITE))(app.TrashBeansHandler))
 		})
 
 		r.Route("/forms", func(r chi.Router) {
+			r.Use(app.RateLimitReads(CatalogRateLimit, DefaultRateLimit))
+
 			r.Get("/", app.GetAllFormsHandler)
-			r.Post("/", NewHandlerFunc(app.AuthMiddleware, app.CheckAuthorization(adminRole))(app.CreateFormsHandler))
-			r.Get("/{id}", NewHandlerFunc(app.AuthMiddleware, app.CheckAuthorization(adminRole))(app.GetFormsHandler))
-			r.Patch("/{id}", NewHandlerFunc(app.AuthMiddleware, app.CheckAuthorization(adminRole))(app.UpdateFormsHandler))
-			r.Delete("/{id}", NewHandlerFunc(app.AuthMiddleware, app.CheckAuthorization(adminRole))(app.DeleteFormsHandler))
-			r.Delete("/trash", NewHandlerFunc(app.AuthMiddleware, app.CheckAuthorization(adminRole))(app.TrashFormsHandler))
+			r.Post("/", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_FORMS_WRITE))(app.CreateFormsHandler))
+			r.Get("/{id}", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_FORMS_WRITE))(app.GetFormsHandler))
+			r.Patch("/{id}", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_FORMS_WRITE))(app.UpdateFormsHandler))
+			r.Delete("/{id}", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_FORMS_WRITE))(app.DeleteFormsHandler))
+			r.Delete("/trash", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_FORMS_WRITE))(app.TrashFormsHandler))
 		})
 
 		r.Route("/products", func(r chi.Router) {
+			r.Use(app.RateLimitReads(CatalogRateLimit, DefaultRateLimit))
+
 			r.Get("/", app.GetProductsHandler)
 			r.Get("/{id}", app.GetProductHandler)
-			r.Post("/", NewHandlerFunc(app.AuthMiddleware, app.CheckAuthorization(adminRole))(app.CreateProductsHandler))
-			r.Patch("/{id}", NewHandlerFunc(app.AuthMiddleware, app.CheckAuthorization(adminRole))(app.UpdateProductHandler))
-			r.Delete("/{id}", NewHandlerFunc(app.AuthMiddleware, app.CheckAuthorization(adminRole))(app.DeleteProductHandler))
+			r.Post("/", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_PRODUCTS_WRITE))(app.CreateProductsHandler))
+			r.Patch("/{id}", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_PRODUCTS_WRITE))(app.UpdateProductHandler))
+			r.Delete("/{id}", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_PRODUCTS_WRITE))(app.DeleteProductHandler))
+			r.Post("/{id}/variants", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_PRODUCTS_WRITE))(app.CreateVariantHandler))
+			r.Patch("/{id}/variants/{variantId}", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_PRODUCTS_WRITE))(app.UpdateVariantHandler))
+			r.Delete("/{id}/variants/{variantId}", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_PRODUCTS_WRITE))(app.DeleteVariantHandler))
+			r.Post("/uploads", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_PRODUCTS_WRITE))(app.PresignProductImageHandler))
+			r.Post("/{id}/images", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_PRODUCTS_WRITE))(app.CreateProductImageHandler))
+			r.Patch("/{id}/images/order", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_PRODUCTS_WRITE))(app.ReorderProductImagesHandler))
+			r.Patch("/{id}/images/{imageId}/primary", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_PRODUCTS_WRITE))(app.SetPrimaryProductImageHandler))
+			r.Delete("/{id}/images/{imageId}", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_PRODUCTS_WRITE))(app.DeleteProductImageHandler))
 		})
 
 		r.Route("/carts", func(r chi.Router) {
-			r.Post("/", NewHandlerFunc(app.AuthMiddleware, app.OnlyActionByCustomer)(app.CreateCartsHandler))
+			r.Use(app.RateLimit(DefaultRateLimit))
+			r.Use(app.RejectApiKey)
+
+			r.Post("/", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_CARTS_WRITE))(app.CreateCartsHandler))
 			r.Patch("/{id}/increment", NewHandlerFunc(app.AuthMiddleware, app.CheckOwnerCart)(app.IncrementCartsItemHandler))
 			r.Patch("/{id}/decrement", NewHandlerFunc(app.AuthMiddleware, app.CheckOwnerCart)(app.DecrementCartsHandler))
 			r.Delete("/{id}", NewHandlerFunc(app.AuthMiddleware, app.CheckOwnerCart)(app.DeleteCartsHandler))
 		})
 
 		r.Route("/orders", func(r chi.Router) {
-			r.Post("/", NewHandlerFunc(app.AuthMiddleware, app.CheckOwnerCartsToOrders)(app.CreateOrdersHandler))
-			r.Patch("/{id}/roast", NewHandlerFunc(app.AuthMiddleware, app.AuthorizeManageOrder)(app.ExecuteItemsHandler))
-			r.Patch("/{id}/cancel", NewHandlerFunc(app.AuthMiddleware, app.CheckOwnerOrder)(app.CancelOrderHandler))
-			r.Patch("/{id}/ship", NewHandlerFunc(app.AuthMiddleware, app.AuthorizeManageOrder)(app.ShipOrderHandler))
-			r.Patch("/{id}/complete", NewHandlerFunc(app.AuthMiddleware, app.CheckOwnerOrder)(app.CompleteOrderHandler))
-			r.Get("/{id}", NewHandlerFunc(app.AuthMiddleware, app.CheckOwnerOrder)(app.GetOrderHandler))
-			r.Get("/", NewHandlerFunc(app.AuthMiddleware, app.AuthorizeManageOrder)(app.GetOrdersHandler))
+			r.Use(app.RateLimit(DefaultRateLimit))
+			r.With(app.RejectApiKey, app.RateLimit(OrdersRateLimit)).Post("/", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_ORDERS_CREATE), app.CheckOwnerCartsToOrders)(app.CreateOrdersHandler))
+			r.Patch("/{id}/roast", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_ORDERS_ROAST))(app.ExecuteItemsHandler))
+			r.Patch("/{id}/cancel", NewHandlerFunc(app.AuthMiddleware, app.CheckOwnerOrder(service.PERMISSION_ORDERS_CANCEL))(app.CancelOrderHandler))
+			r.Patch("/{id}/ship", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_ORDERS_SHIP))(app.ShipOrderHandler))
+			r.Patch("/{id}/complete", NewHandlerFunc(app.AuthMiddleware, app.CheckOwnerOrder(service.PERMISSION_ORDERS_COMPLETE))(app.CompleteOrderHandler))
+			r.Get("/{id}", NewHandlerFunc(app.AuthMiddleware, app.CheckOwnerOrder(service.PERMISSION_ORDERS_READ))(app.GetOrderHandler))
+			r.Get("/", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_ORDERS_READ))(app.GetOrdersHandler))
+		})
+
+		r.Route("/admin/users", func(r chi.Router) {
+			r.Use(app.RateLimit(DefaultRateLimit))
+			r.Use(app.RejectApiKey)
+
+			r.Get("/", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_USERS_MANAGE))(app.AdminGetUsersHandler))
+			r.Get("/{id}", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_USERS_MANAGE))(app.AdminGetUserHandler))
+			r.Patch("/{id}/role", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_USERS_MANAGE))(app.AdminChangeUserRoleHandler))
+			r.Patch("/{id}/deactivate", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_USERS_MANAGE))(app.AdminDeactivateUserHandler))
+			r.Patch("/{id}/reactivate", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_USERS_MANAGE))(app.AdminReactivateUserHandler))
+			r.Post("/{id}/password-reset", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_USERS_MANAGE))(app.AdminForcePasswordResetHandler))
+			r.Post("/{id}/unlock", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_USERS_MANAGE))(app.AdminUnlockUserHandler))
+		})
+
+		r.Route("/admin/audit", func(r chi.Router) {
+			r.Use(app.RateLimit(DefaultRateLimit))
+
+			r.Get("/", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_AUDIT_READ))(app.AdminGetAuditHandler))
+		})
+
+		r.Route("/admin/media", func(r chi.Router) {
+			r.Use(app.RateLimit(DefaultRateLimit))
+
+			r.Get("/orphans", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_MEDIA_MANAGE))(app.AdminGetOrphanedMediaHandler))
+			r.Delete("/orphans", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_MEDIA_MANAGE))(app.AdminDeleteOrphanedMediaHandler))
+		})
+
+		r.Route("/admin/api-keys", func(r chi.Router) {
+			r.Use(app.RateLimit(DefaultRateLimit))
+			r.Use(app.RejectApiKey)
+
+			r.Post("/", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_API_KEYS_MANAGE))(app.CreateApiKeyHandler))
+			r.Get("/", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_API_KEYS_MANAGE))(app.GetApiKeysHandler))
+			r.Delete("/{id}", NewHandlerFunc(app.AuthMiddleware, app.RequirePermission(service.PERMISSION_API_KEYS_MANAGE))(app.RevokeApiKeyHandler))
 		})
 
 	})
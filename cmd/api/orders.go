@@ -75,6 +75,8 @@ func (app *Application) CreateOrdersHandler(w http.ResponseWriter, r *http.Reque
 			ResponseClientError(w, r, err, http.StatusBadRequest)
 		case service.ErrOrdersConflict:
 			ResponseClientError(w, r, err, http.StatusConflict)
+		case service.ErrAddressNotFound:
+			ResponseClientError(w, r, err, http.StatusNotFound)
 		default:
 			ResponseServerError(w, r, err, http.StatusInternalServerError)
 		}
@@ -91,6 +93,7 @@ func (app *Application) CreateOrdersHandler(w http.ResponseWriter, r *http.Reque
 // @Produce		json
 // @Param			id	path	string	true	"Order id"
 // @Security		JWT
+// @Security		ApiKey
 // @Success		200	{object}	main.Envelope{data=string,error=nil}
 // @Failure		400	{object}	main.Envelope{data=nil,error=string}
 // @Failure		403	{object}	main.Envelope{data=nil,error=string}
@@ -122,6 +125,7 @@ func (app *Application) ExecuteItemsHandler(w http.ResponseWriter, r *http.Reque
 // @Produce		json
 // @Param			id	path	string	true	"Order id"
 // @Security		JWT
+// @Security		ApiKey
 // @Success		200	{object}	main.Envelope{data=string,error=nil}
 // @Failure		400	{object}	main.Envelope{data=nil,error=string}
 // @Failure		403	{object}	main.Envelope{data=nil,error=string}
@@ -129,7 +133,13 @@ func (app *Application) ExecuteItemsHandler(w http.ResponseWriter, r *http.Reque
 // @Failure		500	{object}	main.Envelope{data=nil,error=string}
 // @Router			/orders/{id}/cancel [patch]
 func (app *Application) CancelOrderHandler(w http.ResponseWriter, r *http.Request) {
-	if err := app.Services.OrdersService.CancelOrder(r.Context(), chi.URLParam(r, "id")); err != nil {
+	order, err := utils.GetContentFromContext[models.Order](r, OrderCtx)
+	if err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := app.Services.OrdersService.CancelOrder(r.Context(), order); err != nil {
 		errValue := errorService.GetError(err)
 		switch errValue.E {
 		case service.ErrOrdersNotFound:
@@ -152,6 +162,7 @@ func (app *Application) CancelOrderHandler(w http.ResponseWriter, r *http.Reques
 // @Produce		json
 // @Param			id	path	string	true	"Order id"
 // @Security		JWT
+// @Security		ApiKey
 // @Success		200	{object}	main.Envelope{data=string,error=nil}
 // @Failure		400	{object}	main.Envelope{data=nil,error=string}
 // @Failure		403	{object}	main.Envelope{data=nil,error=string}
@@ -182,6 +193,7 @@ func (app *Application) ShipOrderHandler(w http.ResponseWriter, r *http.Request)
 // @Produce		json
 // @Param			id	path	string	true	"Order id"
 // @Security		JWT
+// @Security		ApiKey
 // @Success		200	{object}	main.Envelope{data=string,error=nil}
 // @Failure		400	{object}	main.Envelope{data=nil,error=string}
 // @Failure		403	{object}	main.Envelope{data=nil,error=string}
@@ -189,7 +201,13 @@ func (app *Application) ShipOrderHandler(w http.ResponseWriter, r *http.Request)
 // @Failure		500	{object}	main.Envelope{data=nil,error=string}
 // @Router			/orders/{id}/complete [patch]
 func (app *Application) CompleteOrderHandler(w http.ResponseWriter, r *http.Request) {
-	if err := app.Services.OrdersService.CompleteOrder(r.Context(), chi.URLParam(r, "id")); err != nil {
+	order, err := utils.GetContentFromContext[models.Order](r, OrderCtx)
+	if err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := app.Services.OrdersService.CompleteOrder(r.Context(), order); err != nil {
 		errValue := errorService.GetError(err)
 		switch errValue.E {
 		case service.ErrOrdersNotFound:
@@ -212,6 +230,7 @@ func (app *Application) CompleteOrderHandler(w http.ResponseWriter, r *http.Requ
 // @Produce		json
 // @Param			id	path	string	true	"Order id"
 // @Security		JWT
+// @Security		ApiKey
 // @Success		200	{object}	main.Envelope{data=dto.GetOrderResponse,error=nil}
 // @Failure		400	{object}	main.Envelope{data=nil,error=string}
 // @Failure		401	{object}	main.Envelope{data=nil,error=string}
@@ -220,20 +239,9 @@ func (app *Application) CompleteOrderHandler(w http.ResponseWriter, r *http.Requ
 // @Failure		500	{object}	main.Envelope{data=nil,error=string}
 // @Router			/orders/{id} [get]
 func (app *Application) GetOrderHandler(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
-	if id == "" {
-		ResponseClientError(w, r, errors.New("path param is empty"), http.StatusBadRequest)
-	}
-
-	order, err := app.Services.OrdersService.FindById(r.Context(), id)
+	order, err := utils.GetContentFromContext[models.Order](r, OrderCtx)
 	if err != nil {
-		errValue := errorService.GetError(err)
-		switch errValue.E {
-		case service.ErrOrdersNotFound:
-			ResponseClientError(w, r, err, http.StatusNotFound)
-		default:
-			ResponseServerError(w, r, err, http.StatusInternalServerError)
-		}
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
 		return
 	}
 
@@ -248,6 +256,9 @@ func (app *Application) GetOrderHandler(w http.ResponseWriter, r *http.Request)
 		AlternativePhoneNumber: order.AlternativePhoneNumber,
 		Street:                 order.Street,
 		City:                   order.City,
+		Province:               order.Province,
+		PostalCode:             order.PostalCode,
+		DeliveryNotes:          order.DeliveryNotes,
 		CreatedAt:              order.CreatedAt,
 	}, http.StatusOK)
 }
@@ -258,6 +269,7 @@ func (app *Application) GetOrderHandler(w http.ResponseWriter, r *http.Request)
 // @Accept			json
 // @Produce		json
 // @Security		JWT
+// @Security		ApiKey
 // @Param			status	query		string	false	"status order"
 // @Param			sort	query		string	false	"sort order by created asc(oldest) | desc(latest)"
 // @Param			limit	query		string	false	"limit each page"
@@ -306,6 +318,9 @@ func (app *Application) GetOrdersHandler(w http.ResponseWriter, r *http.Request)
 			AlternativePhoneNumber: order.AlternativePhoneNumber,
 			Street:                 order.Street,
 			City:                   order.City,
+			Province:               order.Province,
+			PostalCode:             order.PostalCode,
+			DeliveryNotes:          order.DeliveryNotes,
 			CreatedAt:              order.CreatedAt,
 		})
 	}
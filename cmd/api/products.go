@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/faizisyellow/indocoffee/internal/models"
 	"github.com/faizisyellow/indocoffee/internal/repository"
 	"github.com/faizisyellow/indocoffee/internal/service"
 	"github.com/faizisyellow/indocoffee/internal/service/dto"
@@ -20,9 +21,9 @@ import (
 // @Accept			mpfd
 // @Produce		json
 //
-// @Param			metadata	formData	string	true	"Create product JSON string"	example({"roasted":"light","price":10.2,"quantity":50,"bean":1,"form":1})
+// @Param			metadata	formData	string	true	"Create product JSON string, image_key instead of the file for an image uploaded to a presigned target"	example({"name":"Gayo Wine","origin":"Aceh","process":"natural","altitude":1400,"tasting_notes":["red wine","dark chocolate"],"roasted":"light","bean":1,"form":1,"variants":[{"sku":"GAYO-WINE-200","weight":200,"price":10.2,"quantity":50}]})
 // @Security		JWT
-// @Param			file	formData	file	true	"Image file"
+// @Param			file	formData	file	false	"Image file, required without image_key"
 // @Success		201		{object}	main.Envelope{data=string,error=nil}
 // @Failure		400		{object}	main.Envelope{data=nil,error=string}
 // @Failure		401		{object}	main.Envelope{data=nil,error=string}
@@ -51,43 +52,54 @@ func (app *Application) CreateProductsHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	file, handler, err := r.FormFile("file")
+	file, handler, err := GetOptionalFormFile(r, "file")
 	if err != nil {
 		ResponseClientError(w, r, err, http.StatusBadRequest)
 		return
 	}
-	defer file.Close()
 
-	if handler.Size > 2<<20 {
-		ResponseClientError(w, r, errors.New("file too big"), http.StatusBadRequest)
+	// the image is either the file or uploaded before to a presigned target
+	if file == nil && request.ImageKey == "" {
+		ResponseClientError(w, r, http.ErrMissingFile, http.StatusBadRequest)
 		return
 	}
 
-	if handler.Size < 512 {
-		ResponseClientError(w, r, errors.New("file too small or empty"), http.StatusBadRequest)
-		return
-	}
+	var uploaded uploader.FileInput
 
-	limitedReader := io.LimitReader(file, 2<<20)
-	fileBytes, err := io.ReadAll(limitedReader)
-	if err != nil {
-		ResponseClientError(w, r, err, http.StatusInternalServerError)
-		return
-	}
+	if file != nil && handler != nil {
+		defer file.Close()
 
-	uploaded := uploader.FileInput{
-		Name:     handler.Filename,
-		Size:     int64(len(fileBytes)),
-		MimeType: http.DetectContentType(fileBytes),
-		Content:  fileBytes,
+		if handler.Size > 2<<20 {
+			ResponseClientError(w, r, errors.New("file too big"), http.StatusBadRequest)
+			return
+		}
+
+		if handler.Size < 512 {
+			ResponseClientError(w, r, errors.New("file too small or empty"), http.StatusBadRequest)
+			return
+		}
+
+		limitedReader := io.LimitReader(file, 2<<20)
+		fileBytes, err := io.ReadAll(limitedReader)
+		if err != nil {
+			ResponseClientError(w, r, err, http.StatusInternalServerError)
+			return
+		}
+
+		uploaded = uploader.FileInput{
+			Name:     handler.Filename,
+			Size:     int64(len(fileBytes)),
+			MimeType: http.DetectContentType(fileBytes),
+			Content:  fileBytes,
+		}
 	}
 
 	if err := app.Services.ProductsService.Create(r.Context(), request, uploaded); err != nil {
 		errValue := errorService.GetError(err)
 		switch errValue.E {
-		case service.ErrFileNotSupportedProducts:
+		case service.ErrFileNotSupportedProducts, service.ErrTooManyPixelsProducts:
 			ResponseClientError(w, r, err, http.StatusBadRequest)
-		case service.ErrFileTooBigProducts:
+		case service.ErrFileTooBigProducts, service.ErrNotFoundUpload, service.ErrImageSourceProducts:
 			ResponseClientError(w, r, err, http.StatusBadRequest)
 		case service.ErrReferenceFailedProducts:
 			ResponseClientError(w, r, err, http.StatusBadRequest)
@@ -136,13 +148,20 @@ func (app *Application) GetProductHandler(w http.ResponseWriter, r *http.Request
 	}
 
 	response := dto.GetProductResponse{
-		Id:       product.Id,
-		Roasted:  product.Roasted,
-		Price:    product.Price,
-		Quantity: product.Quantity,
-		Image:    product.Image,
-		BeanId:   product.BeanId,
-		FormId:   product.FormId,
+		Id:              product.Id,
+		Name:            product.Name,
+		Description:     product.Description,
+		Origin:          product.Origin,
+		Process:         product.Process,
+		Altitude:        product.Altitude,
+		TastingNotes:    product.TastingNotes,
+		Roasted:         product.Roasted,
+		Image:           product.Image,
+		ImageRenditions: product.ImageRenditions,
+		BeanId:          product.BeanId,
+		FormId:          product.FormId,
+		Variants:        variantsResponse(product.Variants),
+		Images:          imagesResponse(product.Images),
 	}
 	response.Bean.Name = product.BeansModel.Name
 	response.Form.Name = product.FormsModel.Name
@@ -157,10 +176,11 @@ func (app *Application) GetProductHandler(w http.ResponseWriter, r *http.Request
 // @Produce		json
 // @Param			limit	query		string	false	"limit each page"
 // @Param			offset	query		string	false	"skip rows"
-// @Param			sort	query		string	false	"sort product by price"
-// @Param			roast	query		string	false	"roasted coffee"
+// @Param			sort	query		string	false	"sort product by its cheapest variant price"
+// @Param			roast	query		string	false	"roasted coffee light | medium | dark"
 // @Param			form	query		string	false	"what kind of form of the coffee (form id)"
 // @Param			bean	query		string	false	"what kind of bean of the coffee (bean id)"
+// @Param			q		query		string	false	"search the name, description, tasting notes, bean and form, the most relevant first"
 // @Success		200		{object}	main.Envelope{data=[]dto.GetProductsResponse,error=nil}
 // @Success		400		{object}	main.Envelope{data=nil,error=string}
 // @Failure		500		{object}	main.Envelope{data=nil,error=string}
@@ -174,6 +194,7 @@ func (app *Application) GetProductsHandler(w http.ResponseWriter, r *http.Reques
 		Roast:  queryValue.Get("roast"),
 		Form:   queryValue.Get("form"),
 		Bean:   queryValue.Get("bean"),
+		Q:      queryValue.Get("q"),
 	}
 
 	paginateProductQuery, err := repository.PaginatedProductsQuery{Limit: 8, Sort: "asc"}.Parse(query)
@@ -196,13 +217,18 @@ func (app *Application) GetProductsHandler(w http.ResponseWriter, r *http.Reques
 	var response []dto.GetProductsResponse
 	for _, product := range products {
 		res := dto.GetProductsResponse{
-			Id:       product.Id,
-			Roasted:  product.Roasted,
-			Price:    product.Price,
-			Quantity: product.Quantity,
-			Image:    product.Image,
-			BeanId:   product.BeanId,
-			FormId:   product.FormId,
+			Id:              product.Id,
+			Name:            product.Name,
+			Origin:          product.Origin,
+			Process:         product.Process,
+			TastingNotes:    product.TastingNotes,
+			Roasted:         product.Roasted,
+			Image:           product.Image,
+			ImageRenditions: product.ImageRenditions,
+			BeanId:          product.BeanId,
+			FormId:          product.FormId,
+			Variants:        variantsResponse(product.Variants),
+			Snippet:         product.Snippet,
 		}
 		res.Bean.Name = product.BeansModel.Name
 		res.Form.Name = product.FormsModel.Name
@@ -219,7 +245,7 @@ func (app *Application) GetProductsHandler(w http.ResponseWriter, r *http.Reques
 // @Accept			mpfd
 // @Produce		json
 //
-// @Param			metadata	formData	string	true	"Update product JSON string"	example({"roasted":"medium","quantity":50})
+// @Param			metadata	formData	string	true	"Update product JSON string, image_key instead of the file for an image uploaded to a presigned target"	example({"description":"Aceh highland arabica","roasted":"medium"})
 // @Security		JWT
 // @Param			file	formData	file	false	"Image file"
 // @Param			id		path		int		true	"Product id"
@@ -306,9 +332,9 @@ func (app *Application) UpdateProductHandler(w http.ResponseWriter, r *http.Requ
 			ResponseClientError(w, r, err, http.StatusConflict)
 		case service.ErrReferenceFailedProducts:
 			ResponseClientError(w, r, err, http.StatusBadRequest)
-		case service.ErrFileNotSupportedProducts:
+		case service.ErrFileNotSupportedProducts, service.ErrTooManyPixelsProducts:
 			ResponseClientError(w, r, err, http.StatusBadRequest)
-		case service.ErrFileTooBigProducts:
+		case service.ErrFileTooBigProducts, service.ErrNotFoundUpload, service.ErrImageSourceProducts:
 			ResponseClientError(w, r, err, http.StatusBadRequest)
 		default:
 			ResponseServerError(w, r, err, http.StatusInternalServerError)
@@ -356,3 +382,408 @@ func (app *Application) DeleteProductHandler(w http.ResponseWriter, r *http.Requ
 
 	ResponseSuccess(w, r, nil, http.StatusNoContent)
 }
+
+// @Summary		Add product variant
+// @Description	Add a new sellable variant (sku, weight, price and stock) to a product
+// @Tags			Products
+// @Accept			json
+// @Produce		json
+// @Security		JWT
+// @Param			id		path		int							true	"Product id"
+// @Param			payload	body		dto.CreateVariantRequest	true	"Payload create new variant"
+// @Success		201		{object}	main.Envelope{data=int,error=nil}
+// @Failure		400		{object}	main.Envelope{data=nil,error=string}
+// @Failure		401		{object}	main.Envelope{data=nil,error=string}
+// @Failure		403		{object}	main.Envelope{data=nil,error=string}
+// @Failure		404		{object}	main.Envelope{data=nil,error=string}
+// @Failure		409		{object}	main.Envelope{data=nil,error=string}
+// @Failure		500		{object}	main.Envelope{data=nil,error=string}
+// @Router			/products/{id}/variants [post]
+func (app *Application) CreateVariantHandler(w http.ResponseWriter, r *http.Request) {
+	productId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	var req dto.CreateVariantRequest
+	if err := ReadHttpJson(w, r, &req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := Validate.Struct(req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	id, err := app.Services.ProductsService.CreateVariant(r.Context(), productId, req)
+	if err != nil {
+		variantsError(w, r, err)
+		return
+	}
+
+	ResponseSuccess(w, r, id, http.StatusCreated)
+}
+
+// @Summary		Edit product variant
+// @Description	Update the sku, weight, price or stock of a product variant
+// @Tags			Products
+// @Accept			json
+// @Produce		json
+// @Security		JWT
+// @Param			id			path		int							true	"Product id"
+// @Param			variantId	path		int							true	"Variant id"
+// @Param			payload		body		dto.UpdateVariantRequest	true	"Payload update variant"
+// @Success		200			{object}	main.Envelope{data=string,error=nil}
+// @Failure		400			{object}	main.Envelope{data=nil,error=string}
+// @Failure		401			{object}	main.Envelope{data=nil,error=string}
+// @Failure		403			{object}	main.Envelope{data=nil,error=string}
+// @Failure		404			{object}	main.Envelope{data=nil,error=string}
+// @Failure		409			{object}	main.Envelope{data=nil,error=string}
+// @Failure		500			{object}	main.Envelope{data=nil,error=string}
+// @Router			/products/{id}/variants/{variantId} [patch]
+func (app *Application) UpdateVariantHandler(w http.ResponseWriter, r *http.Request) {
+	productId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	variantId, err := strconv.Atoi(chi.URLParam(r, "variantId"))
+	if err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	var req dto.UpdateVariantRequest
+	if err := ReadHttpJson(w, r, &req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := Validate.Struct(req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := app.Services.ProductsService.UpdateVariant(r.Context(), productId, variantId, req); err != nil {
+		variantsError(w, r, err)
+		return
+	}
+
+	ResponseSuccess(w, r, "success update variant", http.StatusOK)
+}
+
+// @Summary		Delete product variant
+// @Description	Delete a variant of a product, the last variant of a product can not be deleted
+// @Tags			Products
+// @Accept			json
+// @Produce		json
+// @Security		JWT
+// @Param			id			path	int	true	"Product id"
+// @Param			variantId	path	int	true	"Variant id"
+// @Success		204
+// @Failure		400	{object}	main.Envelope{data=nil,error=string}
+// @Failure		401	{object}	main.Envelope{data=nil,error=string}
+// @Failure		403	{object}	main.Envelope{data=nil,error=string}
+// @Failure		404	{object}	main.Envelope{data=nil,error=string}
+// @Failure		409	{object}	main.Envelope{data=nil,error=string}
+// @Failure		500	{object}	main.Envelope{data=nil,error=string}
+// @Router			/products/{id}/variants/{variantId} [delete]
+func (app *Application) DeleteVariantHandler(w http.ResponseWriter, r *http.Request) {
+	productId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	variantId, err := strconv.Atoi(chi.URLParam(r, "variantId"))
+	if err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := app.Services.ProductsService.DeleteVariant(r.Context(), productId, variantId); err != nil {
+		variantsError(w, r, err)
+		return
+	}
+
+	ResponseSuccess(w, r, nil, http.StatusNoContent)
+}
+
+// @Summary		Presign product image upload
+// @Description	Get a short lived target to upload a product image to directly, then send its key as image_key when creating or updating a product
+// @Tags			Products
+// @Accept			json
+// @Produce		json
+// @Security		JWT
+// @Param			payload	body		dto.PresignImageRequest	true	"Image to upload"
+// @Success		201		{object}	main.Envelope{data=dto.UploadTargetResponse,error=nil}
+// @Failure		400		{object}	main.Envelope{data=nil,error=string}
+// @Failure		401		{object}	main.Envelope{data=nil,error=string}
+// @Failure		403		{object}	main.Envelope{data=nil,error=string}
+// @Failure		500		{object}	main.Envelope{data=nil,error=string}
+// @Failure		501		{object}	main.Envelope{data=nil,error=string}
+// @Router			/products/uploads [post]
+func (app *Application) PresignProductImageHandler(w http.ResponseWriter, r *http.Request) {
+	var request dto.PresignImageRequest
+	if err := ReadHttpJson(w, r, &request); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := Validate.Struct(request); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	target, err := app.Services.ProductsService.PresignImage(r.Context(), request)
+	if err != nil {
+		switch errorService.GetError(err).E {
+		case service.ErrPresignNotSupported:
+			ResponseClientError(w, r, err, http.StatusNotImplemented)
+		default:
+			ResponseServerError(w, r, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	ResponseSuccess(w, r, target, http.StatusCreated)
+}
+
+// @Summary		Add product image
+// @Description	Add an image to the gallery of a product, the first image of a product becomes its primary image
+// @Tags			Products
+// @Accept			mpfd
+// @Produce		json
+// @Security		JWT
+// @Param			id		path		int		true	"Product id"
+// @Param			file	formData	file	true	"Image file"
+// @Success		201		{object}	main.Envelope{data=dto.ImageResponse,error=nil}
+// @Failure		400		{object}	main.Envelope{data=nil,error=string}
+// @Failure		401		{object}	main.Envelope{data=nil,error=string}
+// @Failure		403		{object}	main.Envelope{data=nil,error=string}
+// @Failure		404		{object}	main.Envelope{data=nil,error=string}
+// @Failure		409		{object}	main.Envelope{data=nil,error=string}
+// @Failure		500		{object}	main.Envelope{data=nil,error=string}
+// @Router			/products/{id}/images [post]
+func (app *Application) CreateProductImageHandler(w http.ResponseWriter, r *http.Request) {
+	productId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	// Limit request body to 3 MB
+	r.Body = http.MaxBytesReader(w, r.Body, 3<<20+1024)
+
+	if err := r.ParseMultipartForm(3 << 20); err != nil {
+		ResponseClientError(w, r, errors.New("file too big"), http.StatusBadRequest)
+		return
+	}
+
+	file, handler, err := r.FormFile("file")
+	if err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	if handler.Size > 2<<20 {
+		ResponseClientError(w, r, errors.New("file too big"), http.StatusBadRequest)
+		return
+	}
+
+	if handler.Size < 512 {
+		ResponseClientError(w, r, errors.New("file too small or empty"), http.StatusBadRequest)
+		return
+	}
+
+	fileBytes, err := io.ReadAll(io.LimitReader(file, 2<<20))
+	if err != nil {
+		ResponseClientError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	image, err := app.Services.ProductsService.AddImage(r.Context(), productId, uploader.FileInput{
+		Name:     handler.Filename,
+		Size:     int64(len(fileBytes)),
+		MimeType: http.DetectContentType(fileBytes),
+		Content:  fileBytes,
+	})
+	if err != nil {
+		imagesError(w, r, err)
+		return
+	}
+
+	ResponseSuccess(w, r, imagesResponse([]models.Image{image})[0], http.StatusCreated)
+}
+
+// @Summary		Reorder product images
+// @Description	Order the gallery of a product, every image of the product must be listed once
+// @Tags			Products
+// @Accept			json
+// @Produce		json
+// @Security		JWT
+// @Param			id		path		int							true	"Product id"
+// @Param			payload	body		dto.ReorderImagesRequest	true	"Image ids in their new order"
+// @Success		200		{object}	main.Envelope{data=string,error=nil}
+// @Failure		400		{object}	main.Envelope{data=nil,error=string}
+// @Failure		401		{object}	main.Envelope{data=nil,error=string}
+// @Failure		403		{object}	main.Envelope{data=nil,error=string}
+// @Failure		404		{object}	main.Envelope{data=nil,error=string}
+// @Failure		500		{object}	main.Envelope{data=nil,error=string}
+// @Router			/products/{id}/images/order [patch]
+func (app *Application) ReorderProductImagesHandler(w http.ResponseWriter, r *http.Request) {
+	productId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	var req dto.ReorderImagesRequest
+	if err := ReadHttpJson(w, r, &req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := Validate.Struct(req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := app.Services.ProductsService.ReorderImages(r.Context(), productId, req); err != nil {
+		imagesError(w, r, err)
+		return
+	}
+
+	ResponseSuccess(w, r, "success reorder images", http.StatusOK)
+}
+
+// @Summary		Set primary product image
+// @Description	Make an image of the gallery the primary image of the product
+// @Tags			Products
+// @Accept			json
+// @Produce		json
+// @Security		JWT
+// @Param			id		path		int	true	"Product id"
+// @Param			imageId	path		int	true	"Image id"
+// @Success		200		{object}	main.Envelope{data=string,error=nil}
+// @Failure		400		{object}	main.Envelope{data=nil,error=string}
+// @Failure		401		{object}	main.Envelope{data=nil,error=string}
+// @Failure		403		{object}	main.Envelope{data=nil,error=string}
+// @Failure		404		{object}	main.Envelope{data=nil,error=string}
+// @Failure		500		{object}	main.Envelope{data=nil,error=string}
+// @Router			/products/{id}/images/{imageId}/primary [patch]
+func (app *Application) SetPrimaryProductImageHandler(w http.ResponseWriter, r *http.Request) {
+	productId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	imageId, err := strconv.Atoi(chi.URLParam(r, "imageId"))
+	if err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := app.Services.ProductsService.SetPrimaryImage(r.Context(), productId, imageId); err != nil {
+		imagesError(w, r, err)
+		return
+	}
+
+	ResponseSuccess(w, r, "success set primary image", http.StatusOK)
+}
+
+// @Summary		Delete product image
+// @Description	Delete an image from the gallery of a product, the primary image can not be deleted
+// @Tags			Products
+// @Accept			json
+// @Produce		json
+// @Security		JWT
+// @Param			id		path	int	true	"Product id"
+// @Param			imageId	path	int	true	"Image id"
+// @Success		204
+// @Failure		400	{object}	main.Envelope{data=nil,error=string}
+// @Failure		401	{object}	main.Envelope{data=nil,error=string}
+// @Failure		403	{object}	main.Envelope{data=nil,error=string}
+// @Failure		404	{object}	main.Envelope{data=nil,error=string}
+// @Failure		409	{object}	main.Envelope{data=nil,error=string}
+// @Failure		500	{object}	main.Envelope{data=nil,error=string}
+// @Router			/products/{id}/images/{imageId} [delete]
+func (app *Application) DeleteProductImageHandler(w http.ResponseWriter, r *http.Request) {
+	productId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	imageId, err := strconv.Atoi(chi.URLParam(r, "imageId"))
+	if err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := app.Services.ProductsService.DeleteImage(r.Context(), productId, imageId); err != nil {
+		imagesError(w, r, err)
+		return
+	}
+
+	ResponseSuccess(w, r, nil, http.StatusNoContent)
+}
+
+func imagesResponse(images []models.Image) []dto.ImageResponse {
+	response := []dto.ImageResponse{}
+	for _, image := range images {
+		response = append(response, dto.ImageResponse{
+			Id:         image.Id,
+			Url:        image.Url,
+			Renditions: image.Renditions,
+			Position:   image.Position,
+			IsPrimary:  image.IsPrimary,
+		})
+	}
+
+	return response
+}
+
+func imagesError(w http.ResponseWriter, r *http.Request, err error) {
+	switch errorService.GetError(err).E {
+	case service.ErrNotFoundProduct, service.ErrNotFoundImage:
+		ResponseClientError(w, r, err, http.StatusNotFound)
+	case service.ErrFileNotSupportedProducts, service.ErrFileTooBigProducts, service.ErrTooManyPixelsProducts, service.ErrImageOrder:
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+	case service.ErrImageLimit, service.ErrPrimaryImage:
+		ResponseClientError(w, r, err, http.StatusConflict)
+	default:
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+	}
+}
+
+func variantsResponse(variants []models.Variant) []dto.VariantResponse {
+	response := []dto.VariantResponse{}
+	for _, variant := range variants {
+		response = append(response, dto.VariantResponse{
+			Id:       variant.Id,
+			Sku:      variant.Sku,
+			Weight:   variant.Weight,
+			Price:    variant.Price,
+			Quantity: variant.Quantity,
+		})
+	}
+
+	return response
+}
+
+func variantsError(w http.ResponseWriter, r *http.Request, err error) {
+	switch errorService.GetError(err).E {
+	case service.ErrNotFoundProduct, service.ErrNotFoundVariant:
+		ResponseClientError(w, r, err, http.StatusNotFound)
+	case service.ErrConflictVariant, service.ErrLastVariant:
+		ResponseClientError(w, r, err, http.StatusConflict)
+	default:
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+	}
+}
@@ -22,11 +22,13 @@ func TestProducts(t *testing.T) {
 		var (
 			app     = setupTestApplication(t)
 			request = dto.CreateProductMetadataRequest{
-				Roasted:  "light",
-				Price:    17.5,
-				Quantity: 10,
-				Bean:     1,
-				Form:     1,
+				Name:    "Lizzy Blend",
+				Roasted: "light",
+				Bean:    1,
+				Form:    1,
+				Variants: []dto.CreateVariantRequest{
+					{Sku: "LIZZY-250", Weight: 250, Price: 17.5, Quantity: 10},
+				},
 			}
 		)
 
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	rateLimiter "github.com/faizisyellow/indocoffee/internal/limiter/rate"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+// RateLimitPolicy is a limit counted apart from the other policies.
+type RateLimitPolicy struct {
+	Name  string
+	Limit rateLimiter.Limit
+}
+
+var (
+	DefaultRateLimit        = RateLimitPolicy{Name: "default", Limit: rateLimiter.Limit{Requests: 100, Window: time.Minute}}
+	CatalogRateLimit        = RateLimitPolicy{Name: "catalog", Limit: rateLimiter.Limit{Requests: 300, Window: time.Minute}}
+	AuthenticationRateLimit = RateLimitPolicy{Name: "authentication", Limit: rateLimiter.Limit{Requests: 10, Window: time.Minute}}
+	OrdersRateLimit         = RateLimitPolicy{Name: "orders", Limit: rateLimiter.Limit{Requests: 5, Window: time.Minute}}
+	ErrRateLimited          = errors.New("too many requests, please try again later")
+)
+
+// RateLimit limits the requests of every user, api key or anonymous ip under the policy.
+// The limit is reported with RateLimit-* headers and Retry-After once it is reached.
+func (app *Application) RateLimit(policy RateLimitPolicy) func(http.Handler) http.Handler {
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+			key := policy.Name + ":" + app.rateLimitKey(r)
+
+			result, err := app.RateLimiter.Allow(r.Context(), key, policy.Limit)
+			if err != nil {
+				// keep serving when the limiter is down rather than failing every request
+				app.Logger.Errorw("error rate limiting request", zap.String("Policy", policy.Name), zap.Error(err))
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			header := w.Header()
+			header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			header.Set("RateLimit-Reset", seconds(result.Reset))
+			header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%v", policy.Limit.Requests, seconds(policy.Limit.Window)))
+
+			if !result.Allowed {
+				header.Set("Retry-After", seconds(result.RetryAfter))
+				ResponseClientError(w, r, ErrRateLimited, http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RateLimitReads limits reading requests under reads and the others under writes.
+func (app *Application) RateLimitReads(reads, writes RateLimitPolicy) func(http.Handler) http.Handler {
+
+	return func(next http.Handler) http.Handler {
+		var (
+			readsLimited  = app.RateLimit(reads)(next)
+			writesLimited = app.RateLimit(writes)(next)
+		)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead:
+				readsLimited.ServeHTTP(w, r)
+			default:
+				writesLimited.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// rateLimitKey is who the request counts against, the signed in user when the token is valid,
+// the api key when it authenticates, the ip otherwise so made up credentials share its limit.
+func (app *Application) rateLimitKey(r *http.Request) string {
+
+	scheme, credential, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+
+	switch scheme {
+	case "Bearer":
+		token, err := app.Authentication.VerifyToken(credential)
+		if err != nil {
+			break
+		}
+
+		claim, ok := token.Claims.(jwt.MapClaims)
+		if !ok {
+			break
+		}
+
+		if usrId, ok := claim["id"].(float64); ok {
+			return fmt.Sprintf("user:%d", int(usrId))
+		}
+	case "ApiKey":
+		key, err := app.Services.ApiKeysService.Authenticate(r.Context(), credential)
+		if err != nil {
+			break
+		}
+
+		return fmt.Sprintf("api-key:%d", key.Id)
+	}
+
+	return "ip:" + clientIp(r)
+}
+
+// seconds formats the duration as whole seconds, rounded up.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
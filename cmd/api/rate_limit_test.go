@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/auth"
+	rateLimiter "github.com/faizisyellow/indocoffee/internal/limiter/rate"
+	"github.com/faizisyellow/indocoffee/internal/models"
+	"github.com/faizisyellow/indocoffee/internal/repository/apikeys"
+	"github.com/faizisyellow/indocoffee/internal/repository/roles"
+	"github.com/faizisyellow/indocoffee/internal/service"
+	"github.com/faizisyellow/indocoffee/internal/service/dto"
+)
+
+func TestRateLimit(t *testing.T) {
+	app := setupTestApplication(t)
+	app.JwtAuth = JwtConfig{Iss: "authentication", Sub: "user", Exp: time.Minute}
+	app.Authentication = auth.New("secret", app.JwtAuth.Iss, app.JwtAuth.Sub)
+
+	policy := RateLimitPolicy{Name: "test", Limit: rateLimiter.Limit{Requests: 2, Window: time.Minute}}
+
+	send := func(handler http.Handler, authorization string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.RemoteAddr = "10.0.0.1:4242"
+		if authorization != "" {
+			request.Header.Set("Authorization", authorization)
+		}
+
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+
+		return response
+	}
+
+	newHandler := func() http.Handler {
+		app.RateLimiter = &rateLimiter.InMemoryRateLimiter{}
+
+		return app.RateLimit(policy)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+	}
+
+	t.Run("reject anonymous requests over the limit", func(t *testing.T) {
+		handler := newHandler()
+
+		for i := range policy.Limit.Requests {
+			response := send(handler, "")
+			if response.Code != http.StatusOK {
+				t.Fatalf("request %v: expected status %v but got %v", i+1, http.StatusOK, response.Code)
+			}
+		}
+
+		response := send(handler, "")
+		if response.Code != http.StatusTooManyRequests {
+			t.Fatalf("expected status %v but got %v", http.StatusTooManyRequests, response.Code)
+		}
+
+		if response.Header().Get("RateLimit-Limit") != "2" || response.Header().Get("RateLimit-Remaining") != "0" {
+			t.Errorf("expected the limit to be reported but got headers: %v", response.Header())
+		}
+
+		if response.Header().Get("Retry-After") != "60" {
+			t.Errorf("expected to retry after 60 seconds but got %q", response.Header().Get("Retry-After"))
+		}
+	})
+
+	t.Run("count signed in users behind the same ip apart", func(t *testing.T) {
+		handler := newHandler()
+
+		for range policy.Limit.Requests {
+			send(handler, "")
+		}
+
+		for _, usrId := range []int{1, 2} {
+			token, err := app.generateAccessToken(usrId, "01JSESSION")
+			if err != nil {
+				t.Fatalf("should not be error but got: %v", err)
+			}
+
+			response := send(handler, "Bearer "+token)
+			if response.Code != http.StatusOK {
+				t.Errorf("user %v: expected status %v but got %v", usrId, http.StatusOK, response.Code)
+			}
+		}
+
+		// an invalid token counts against the ip
+		response := send(handler, "Bearer forged")
+		if response.Code != http.StatusTooManyRequests {
+			t.Errorf("expected status %v but got %v", http.StatusTooManyRequests, response.Code)
+		}
+	})
+
+	t.Run("count authenticated api keys apart", func(t *testing.T) {
+		handler := newHandler()
+
+		apiKeys := &service.ApiKeysService{
+			ApiKeysStore: &apikeys.InMemoryApiKeys{},
+			RolesStore:   &roles.InMemoryRoles{Roles: []models.RolesModel{{Id: 4, Name: "warehouse"}}},
+			Transaction:  transactionFake{},
+		}
+		app.Services.ApiKeysService = apiKeys
+
+		for range policy.Limit.Requests {
+			send(handler, "")
+		}
+
+		for _, name := range []string{"warehouse", "storefront"} {
+			created, err := apiKeys.Create(context.Background(), 1, dto.CreateApiKeyRequest{Name: name, RoleId: 4})
+			if err != nil {
+				t.Fatalf("should not be error but got: %v", err)
+			}
+
+			response := send(handler, "ApiKey "+created.Key)
+			if response.Code != http.StatusOK {
+				t.Errorf("key %v: expected status %v but got %v", name, http.StatusOK, response.Code)
+			}
+		}
+
+		// made up keys count against the ip
+		for _, key := range []string{"ick_made_up", "anything"} {
+			response := send(handler, "ApiKey "+key)
+			if response.Code != http.StatusTooManyRequests {
+				t.Errorf("key %v: expected status %v but got %v", key, http.StatusTooManyRequests, response.Code)
+			}
+		}
+	})
+}
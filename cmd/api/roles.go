@@ -42,6 +42,8 @@ func (app *Application) CreateRolesHandler(w http.ResponseWriter, r *http.Reques
 		switch errorValue.E {
 		case service.ErrConflictRole:
 			ResponseClientError(w, r, err, http.StatusConflict)
+		case service.ErrUnknownPermission:
+			ResponseClientError(w, r, err, http.StatusBadRequest)
 		default:
 			ResponseServerError(w, r, err, http.StatusInternalServerError)
 		}
@@ -71,7 +73,7 @@ func (app *Application) GetAllRolesHandler(w http.ResponseWriter, r *http.Reques
 
 	response := make([]dto.RolesResponse, 0)
 	for _, role := range roles {
-		response = append(response, dto.RolesResponse{Id: role.Id, Name: role.Name, Level: role.Level})
+		response = append(response, dto.RolesResponse{Id: role.Id, Name: role.Name, Level: role.Level, Permissions: role.Permissions})
 	}
 
 	ResponseSuccess(w, r, response, http.StatusOK)
@@ -117,8 +119,9 @@ func (app *Application) GetRolesHandler(w http.ResponseWriter, r *http.Request)
 
 	response := dto.RolesResponse{
 		Id:    roleId,
-		Name:  role.Name,
-		Level: role.Level,
+		Name:        role.Name,
+		Level:       role.Level,
+		Permissions: role.Permissions,
 	}
 
 	ResponseSuccess(w, r, response, http.StatusOK)
@@ -172,7 +175,7 @@ func (app *Application) UpdateRolesHandler(w http.ResponseWriter, r *http.Reques
 			ResponseClientError(w, r, err, http.StatusNotFound)
 		case service.ErrConflictRole:
 			ResponseClientError(w, r, err, http.StatusConflict)
-		case service.ErrUpdateRole:
+		case service.ErrUpdateRole, service.ErrUnknownPermission:
 			ResponseClientError(w, r, err, http.StatusBadRequest)
 		default:
 			ResponseServerError(w, r, err, http.StatusInternalServerError)
@@ -250,3 +253,24 @@ func (app *Application) TrashRolesHandler(w http.ResponseWriter, r *http.Request
 
 	ResponseSuccess(w, r, nil, http.StatusNoContent)
 }
+
+//	@Summary		Get permissions
+//	@Description	Get every permission that can be granted to a role
+//	@Tags			Roles
+//	@Produce		json
+//	@Security		JWT
+//	@Success		200	{object}	main.Envelope{data=[]models.PermissionModel,error=nil}
+//	@Failure		401	{object}	main.Envelope{data=nil,error=string}
+//	@Failure		403	{object}	main.Envelope{data=nil,error=string}
+//	@Failure		500	{object}	main.Envelope{data=nil,error=string}
+//	@Router			/roles/permissions [get]
+func (app *Application) GetPermissionsHandler(w http.ResponseWriter, r *http.Request) {
+
+	permissions, err := app.Services.RolesService.FindPermissions(r.Context())
+	if err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	ResponseSuccess(w, r, permissions, http.StatusOK)
+}
@@ -1,11 +1,16 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"testing"
 
 	"github.com/faizisyellow/indocoffee/internal/logger"
+	mailLocal "github.com/faizisyellow/indocoffee/internal/mailer/local"
+	"github.com/faizisyellow/indocoffee/internal/repository/audits"
 	"github.com/faizisyellow/indocoffee/internal/repository/products"
 	"github.com/faizisyellow/indocoffee/internal/service"
+	"github.com/faizisyellow/indocoffee/internal/uploader/imaging"
 	"github.com/faizisyellow/indocoffee/internal/uploader/local"
 )
 
@@ -22,12 +27,29 @@ func setupTestApplication(t *testing.T) *Application {
 			nil,
 			nil,
 			&products.InMemoryProducts{},
-			&local.TempUpload{},
+			&local.TempUpload{LocSavePath: t.TempDir()},
+			imaging.NewPipeline(),
+			transactionFake{},
 			nil,
 			nil,
 			nil,
 			nil,
 			nil,
+			nil,
+			&mailLocal.InMemoryMailer{},
+			nil,
+			nil,
+			nil,
+			service.TwoFactorPolicy{},
+			nil,
+			&audits.InMemoryAudits{},
 		),
 	}
 }
+
+// transactionFake runs the function without a transaction, for in memory stores.
+type transactionFake struct{}
+
+func (transactionFake) WithTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return fn(nil)
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/faizisyellow/indocoffee/internal/models"
+	"github.com/faizisyellow/indocoffee/internal/service"
+	"github.com/faizisyellow/indocoffee/internal/service/dto"
+	errorService "github.com/faizisyellow/indocoffee/internal/service/error"
+	"github.com/faizisyellow/indocoffee/internal/utils"
+)
+
+// @Summary		Verify two factor
+// @Description	Finish the sign in with a code of the authenticator app or a recovery code
+// @Tags			Auth
+// @Accept			json
+// @Produce		json
+// @Param			payload	body		dto.VerifyTwoFactorRequest	true	"Challenge token from sign in and the code"
+// @Success		200		{object}	main.Envelope{data=main.LoginResponse,error=nil}
+// @Failure		400		{object}	main.Envelope{data=nil,error=string}
+// @Failure		401		{object}	main.Envelope{data=nil,error=string}
+// @Failure		403		{object}	main.Envelope{data=nil,error=string}
+// @Failure		423		{object}	main.Envelope{data=nil,error=string}
+// @Failure		429		{object}	main.Envelope{data=nil,error=string}
+// @Failure		500		{object}	main.Envelope{data=nil,error=string}
+// @Router			/authentication/two-factor/verify [post]
+func (app *Application) VerifyTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+
+	var req dto.VerifyTwoFactorRequest
+
+	if err := ReadHttpJson(w, r, &req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := Validate.Struct(req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	req.Ip = clientIp(r)
+
+	user, err := app.Services.TwoFactorService.Verify(r.Context(), req)
+	if err != nil {
+		twoFactorError(w, r, err)
+		return
+	}
+
+	app.signIn(w, r, user)
+}
+
+// @Summary		Enroll two factor while signing in
+// @Description	Enroll an account whose role requires two factor, the enrollment is enabled by verifying its first code
+// @Tags			Auth
+// @Accept			json
+// @Produce		json
+// @Param			payload	body		dto.TwoFactorChallengeRequest	true	"Challenge token from sign in"
+// @Success		200		{object}	main.Envelope{data=dto.TwoFactorEnrollment,error=nil}
+// @Failure		400		{object}	main.Envelope{data=nil,error=string}
+// @Failure		401		{object}	main.Envelope{data=nil,error=string}
+// @Failure		409		{object}	main.Envelope{data=nil,error=string}
+// @Failure		500		{object}	main.Envelope{data=nil,error=string}
+// @Router			/authentication/two-factor/enroll [post]
+func (app *Application) EnrollTwoFactorChallengeHandler(w http.ResponseWriter, r *http.Request) {
+
+	var req dto.TwoFactorChallengeRequest
+
+	if err := ReadHttpJson(w, r, &req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := Validate.Struct(req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	enrollment, err := app.Services.TwoFactorService.EnrollWithChallenge(r.Context(), req)
+	if err != nil {
+		twoFactorError(w, r, err)
+		return
+	}
+
+	ResponseSuccess(w, r, enrollment, http.StatusOK)
+}
+
+// @Summary		Enroll two factor
+// @Description	Generate a TOTP secret and recovery codes, two factor is enabled once the first code is confirmed
+// @Tags			Users
+// @Produce		json
+// @Security		JWT
+// @Success		200	{object}	main.Envelope{data=dto.TwoFactorEnrollment,error=nil}
+// @Failure		401	{object}	main.Envelope{data=nil,error=string}
+// @Failure		409	{object}	main.Envelope{data=nil,error=string}
+// @Failure		500	{object}	main.Envelope{data=nil,error=string}
+// @Router			/users/two-factor [post]
+func (app *Application) EnrollTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+
+	user, err := utils.GetContentFromContext[*models.User](r, UsrCtx)
+	if err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	enrollment, err := app.Services.TwoFactorService.Enroll(r.Context(), user.Id)
+	if err != nil {
+		twoFactorError(w, r, err)
+		return
+	}
+
+	ResponseSuccess(w, r, enrollment, http.StatusOK)
+}
+
+// @Summary		Confirm two factor
+// @Description	Enable two factor with the first code of the authenticator app
+// @Tags			Users
+// @Accept			json
+// @Produce		json
+// @Security		JWT
+// @Param			payload	body		dto.ConfirmTwoFactorRequest	true	"Code of the authenticator app"
+// @Success		200		{object}	main.Envelope{data=string,error=nil}
+// @Failure		400		{object}	main.Envelope{data=nil,error=string}
+// @Failure		401		{object}	main.Envelope{data=nil,error=string}
+// @Failure		404		{object}	main.Envelope{data=nil,error=string}
+// @Failure		409		{object}	main.Envelope{data=nil,error=string}
+// @Failure		500		{object}	main.Envelope{data=nil,error=string}
+// @Router			/users/two-factor/confirm [post]
+func (app *Application) ConfirmTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+
+	var req dto.ConfirmTwoFactorRequest
+
+	if err := ReadHttpJson(w, r, &req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := Validate.Struct(req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	user, err := utils.GetContentFromContext[*models.User](r, UsrCtx)
+	if err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := app.Services.TwoFactorService.Confirm(r.Context(), user.Id, req); err != nil {
+		twoFactorError(w, r, err)
+		return
+	}
+
+	ResponseSuccess(w, r, "two factor has been enabled", http.StatusOK)
+}
+
+// @Summary		Disable two factor
+// @Description	Disable two factor with the password and a code, not allowed when the role requires two factor
+// @Tags			Users
+// @Accept			json
+// @Produce		json
+// @Security		JWT
+// @Param			payload	body	dto.DisableTwoFactorRequest	true	"Password and a code of the authenticator app or a recovery code"
+// @Success		204
+// @Failure		400	{object}	main.Envelope{data=nil,error=string}
+// @Failure		401	{object}	main.Envelope{data=nil,error=string}
+// @Failure		403	{object}	main.Envelope{data=nil,error=string}
+// @Failure		404	{object}	main.Envelope{data=nil,error=string}
+// @Failure		500	{object}	main.Envelope{data=nil,error=string}
+// @Router			/users/two-factor [delete]
+func (app *Application) DisableTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+
+	var req dto.DisableTwoFactorRequest
+
+	if err := ReadHttpJson(w, r, &req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := Validate.Struct(req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	user, err := utils.GetContentFromContext[*models.User](r, UsrCtx)
+	if err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := app.Services.TwoFactorService.Disable(r.Context(), user.Id, req); err != nil {
+		twoFactorError(w, r, err)
+		return
+	}
+
+	ResponseSuccess(w, r, nil, http.StatusNoContent)
+}
+
+func twoFactorError(w http.ResponseWriter, r *http.Request, err error) {
+	switch errorService.GetError(err).E {
+	case service.ErrTwoFactorChallengeInvalid, service.ErrTwoFactorCodeInvalid:
+		ResponseClientError(w, r, err, http.StatusUnauthorized)
+	case service.ErrPasswordIncorrect:
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+	case service.ErrTwoFactorNotEnrolled, service.ErrUserNotFound:
+		ResponseClientError(w, r, err, http.StatusNotFound)
+	case service.ErrTwoFactorEnabled:
+		ResponseClientError(w, r, err, http.StatusConflict)
+	case service.ErrTwoFactorMandatory, service.ErrUserDeactivated:
+		ResponseClientError(w, r, err, http.StatusForbidden)
+	case service.ErrUserLimited:
+		ResponseClientError(w, r, err, http.StatusTooManyRequests)
+	case service.ErrUserLocked:
+		ResponseClientError(w, r, err, http.StatusLocked)
+	default:
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+	}
+}
@@ -1,12 +1,19 @@
 package main
 
 import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/faizisyellow/indocoffee/internal/models"
 	"github.com/faizisyellow/indocoffee/internal/repository"
+	"github.com/faizisyellow/indocoffee/internal/service"
 	"github.com/faizisyellow/indocoffee/internal/service/dto"
+	errorService "github.com/faizisyellow/indocoffee/internal/service/error"
 	"github.com/faizisyellow/indocoffee/internal/utils"
+	"github.com/go-chi/chi/v5"
 )
 
 //	@Summary		Get User Profile
@@ -39,8 +46,189 @@ func (app *Application) GetUserProfileHandler(w http.ResponseWriter, r *http.Req
 	ResponseSuccess(w, r, response, http.StatusOK)
 }
 
+//	@Summary		Update User Profile
+//	@Description	Update username of the User Who's log in
+//	@Tags			Users
+//	@Accept			json
+//	@Produce		json
+//	@Security		JWT
+//	@Param			payload	body		service.UpdateProfileRequest	true	"Payload to update profile"
+//	@Success		200		{object}	main.Envelope{data=dto.GetUsersProfileResponse,error=nil}
+//	@Failure		400		{object}	main.Envelope{data=nil,error=string}
+//	@Failure		401		{object}	main.Envelope{data=nil,error=string}
+//	@Failure		500		{object}	main.Envelope{data=nil,error=string}
+//	@Router			/users/profile [patch]
+func (app *Application) UpdateUserProfileHandler(w http.ResponseWriter, r *http.Request) {
+
+	var req service.UpdateProfileRequest
+	if err := ReadHttpJson(w, r, &req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := Validate.Struct(req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	user, err := utils.GetContentFromContext[*models.User](r, UsrCtx)
+	if err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	updated, err := app.Services.UsersService.UpdateProfile(r.Context(), user.Id, req)
+	if err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	response := dto.GetUsersProfileResponse{
+		Id:        updated.Id,
+		Username:  updated.Username,
+		Email:     updated.Email,
+		IsActive:  *updated.IsActive,
+		CreatedAt: updated.CreatedAt,
+	}
+
+	ResponseSuccess(w, r, response, http.StatusOK)
+}
+
+//	@Summary		Change User Password
+//	@Description	Change password of the User Who's log in, every session is signed out
+//	@Tags			Users
+//	@Accept			json
+//	@Produce		json
+//	@Security		JWT
+//	@Param			payload	body		service.ChangePasswordRequest	true	"Current and new password"
+//	@Success		200		{object}	main.Envelope{data=string,error=nil}
+//	@Failure		400		{object}	main.Envelope{data=nil,error=string}
+//	@Failure		401		{object}	main.Envelope{data=nil,error=string}
+//	@Failure		500		{object}	main.Envelope{data=nil,error=string}
+//	@Router			/users/password [post]
+func (app *Application) ChangePasswordHandler(w http.ResponseWriter, r *http.Request) {
+
+	var req service.ChangePasswordRequest
+	if err := ReadHttpJson(w, r, &req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := Validate.Struct(req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	user, err := utils.GetContentFromContext[*models.User](r, UsrCtx)
+	if err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	err = app.Services.UsersService.ChangePassword(r.Context(), user.Id, req)
+	if err != nil {
+		errorValue := errorService.GetError(err)
+		switch errorValue.E {
+		case service.ErrPasswordIncorrect, utils.ErrInvalidPasswordSignature:
+			ResponseClientError(w, r, err, http.StatusBadRequest)
+		default:
+			ResponseServerError(w, r, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	ResponseSuccess(w, r, "password changed, please sign in again", http.StatusOK)
+}
+
+//	@Summary		Change User Email
+//	@Description	Send a confirmation token to the new email, the email is changed once it is confirmed
+//	@Tags			Users
+//	@Accept			json
+//	@Produce		json
+//	@Security		JWT
+//	@Param			payload	body		service.ChangeEmailRequest	true	"New email and current password"
+//	@Success		202		{object}	main.Envelope{data=string,error=nil}
+//	@Failure		400		{object}	main.Envelope{data=nil,error=string}
+//	@Failure		401		{object}	main.Envelope{data=nil,error=string}
+//	@Failure		409		{object}	main.Envelope{data=nil,error=string}
+//	@Failure		500		{object}	main.Envelope{data=nil,error=string}
+//	@Router			/users/email [post]
+func (app *Application) ChangeEmailHandler(w http.ResponseWriter, r *http.Request) {
+
+	var req service.ChangeEmailRequest
+	if err := ReadHttpJson(w, r, &req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := Validate.Struct(req); err != nil {
+		ResponseClientError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	user, err := utils.GetContentFromContext[*models.User](r, UsrCtx)
+	if err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	err = app.Services.UsersService.RequestEmailChange(r.Context(), user.Id, req)
+	if err != nil {
+		errorValue := errorService.GetError(err)
+		switch errorValue.E {
+		case service.ErrPasswordIncorrect:
+			ResponseClientError(w, r, err, http.StatusBadRequest)
+		case service.ErrUserAlreadyExist:
+			ResponseClientError(w, r, err, http.StatusConflict)
+		default:
+			ResponseServerError(w, r, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	ResponseSuccess(w, r, "please check the new email to confirm the change", http.StatusAccepted)
+}
+
+//	@Summary		Confirm User Email
+//	@Description	Confirm the new email with the token sent to it
+//	@Tags			Users
+//	@Accept			json
+//	@Produce		json
+//	@Param			token	path		string	true	"Token sent to the new email"
+//	@Success		200		{object}	main.Envelope{data=string,error=nil}
+//	@Failure		400		{object}	main.Envelope{data=nil,error=string}
+//	@Failure		404		{object}	main.Envelope{data=nil,error=string}
+//	@Failure		409		{object}	main.Envelope{data=nil,error=string}
+//	@Failure		500		{object}	main.Envelope{data=nil,error=string}
+//	@Router			/users/email/confirm/{token} [post]
+func (app *Application) ConfirmEmailChangeHandler(w http.ResponseWriter, r *http.Request) {
+
+	token := chi.URLParam(r, "token")
+
+	if token == "" {
+		ResponseClientError(w, r, fmt.Errorf("token is required"), http.StatusBadRequest)
+		return
+	}
+
+	err := app.Services.UsersService.ConfirmEmailChange(r.Context(), token)
+	if err != nil {
+		errorValue := errorService.GetError(err)
+		switch errorValue.E {
+		case service.ErrTokenEmailNotFound, service.ErrUserNotFound:
+			ResponseClientError(w, r, err, http.StatusNotFound)
+		case service.ErrUserAlreadyExist:
+			ResponseClientError(w, r, err, http.StatusConflict)
+		default:
+			ResponseServerError(w, r, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	ResponseSuccess(w, r, "email changed successfully", http.StatusOK)
+}
+
 //	@Summary		Delete User Account
-//	@Description	Delete User Account
+//	@Description	Delete User Account, orders are kept without the personal data of the user
 //	@Tags			Users
 //	@Accept			json
 //	@Produce		json
@@ -48,6 +236,7 @@ func (app *Application) GetUserProfileHandler(w http.ResponseWriter, r *http.Req
 //	@Success		204
 //	@Failure		400	{object}	main.Envelope{data=nil,error=string}
 //	@Failure		401	{object}	main.Envelope{data=nil,error=string}
+//	@Failure		409	{object}	main.Envelope{data=nil,error=string}
 //	@Failure		500	{object}	main.Envelope{data=nil,error=string}
 //	@Router			/users/delete [delete]
 func (app *Application) DeleteAccountHandler(w http.ResponseWriter, r *http.Request) {
@@ -58,15 +247,106 @@ func (app *Application) DeleteAccountHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	err = app.Services.UsersService.DeleteAccount(r.Context(), user.Id)
+	err = app.Services.PrivacyService.DeleteAccount(r.Context(), user.Id)
 	if err != nil {
-		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		switch errorService.GetError(err).E {
+		case service.ErrPrivacyOrdersInProgress:
+			ResponseClientError(w, r, err, http.StatusConflict)
+		default:
+			ResponseServerError(w, r, err, http.StatusInternalServerError)
+		}
 		return
 	}
 
 	ResponseSuccess(w, r, nil, http.StatusNoContent)
 }
 
+//	@Summary		Export User Data
+//	@Description	Export profile, addresses, cart and orders of the User Who's log in, as json or as a zip of json files
+//	@Tags			Users
+//	@Produce		json
+//	@Produce		application/zip
+//	@Security		JWT
+//	@Param			format	query		string	false	"json or zip, json by default"
+//	@Success		200		{object}	main.Envelope{data=dto.UserExport,error=nil}
+//	@Failure		400		{object}	main.Envelope{data=nil,error=string}
+//	@Failure		401		{object}	main.Envelope{data=nil,error=string}
+//	@Failure		500		{object}	main.Envelope{data=nil,error=string}
+//	@Router			/users/export [get]
+func (app *Application) ExportAccountHandler(w http.ResponseWriter, r *http.Request) {
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "zip" {
+		ResponseClientError(w, r, fmt.Errorf("format must be json or zip"), http.StatusBadRequest)
+		return
+	}
+
+	user, err := utils.GetContentFromContext[*models.User](r, UsrCtx)
+	if err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	export, err := app.Services.PrivacyService.Export(r.Context(), user.Id)
+	if err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	if format != "zip" {
+		ResponseSuccess(w, r, export, http.StatusOK)
+		return
+	}
+
+	archive, err := exportArchive(export)
+	if err != nil {
+		ResponseServerError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="indocoffee-export.zip"`)
+	w.WriteHeader(http.StatusOK)
+	w.Write(archive)
+}
+
+// exportArchive zips every part of the export as its own json file.
+func exportArchive(export dto.UserExport) ([]byte, error) {
+
+	var (
+		buf     bytes.Buffer
+		archive = zip.NewWriter(&buf)
+		files   = []struct {
+			name string
+			data any
+		}{
+			{"profile.json", export.Profile},
+			{"addresses.json", export.Addresses},
+			{"cart.json", export.Cart},
+			{"orders.json", export.Orders},
+		}
+	)
+
+	for _, file := range files {
+		f, err := archive.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: export.ExportedAt})
+		if err != nil {
+			return nil, err
+		}
+
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
 //	@Summary		Get User's cart
 //	@Description	Get User's cart
 //	@Tags			Users
@@ -153,6 +433,9 @@ func (app *Application) FindUsersOrdersHandler(w http.ResponseWriter, r *http.Re
 			AlternativePhoneNumber: order.AlternativePhoneNumber,
 			Street:                 order.Street,
 			City:                   order.City,
+			Province:               order.Province,
+			PostalCode:             order.PostalCode,
+			DeliveryNotes:          order.DeliveryNotes,
 			CreatedAt:              order.CreatedAt,
 		})
 	}
@@ -0,0 +1,20 @@
+CREATE TABLE sessions(
+    id VARCHAR(64) NOT NULL,
+    user_id INT NOT NULL,
+    revoked_at DATETIME,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE refresh_tokens(
+    token VARBINARY(72) NOT NULL,
+    session_id VARCHAR(64) NOT NULL,
+    expire_at DATETIME NOT NULL,
+    used_at DATETIME,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (token),
+    FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_sessions_user_id ON sessions(user_id);
@@ -0,0 +1,8 @@
+CREATE TABLE password_resets(
+    token VARBINARY(72) NOT NULL,
+    user_id INT NOT NULL,
+    expire_at DATETIME NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (token),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+ALTER TABLE invitations
+    DROP INDEX idx_invitations_user_id,
+    DROP COLUMN created_at;
@@ -0,0 +1,3 @@
+ALTER TABLE invitations
+    ADD COLUMN created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    ADD INDEX idx_invitations_user_id (user_id);
@@ -0,0 +1,39 @@
+CREATE TABLE permissions(
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(32) NOT NULL UNIQUE,
+    description VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE role_permissions(
+    role_id INT NOT NULL,
+    permission_id INT NOT NULL,
+    PRIMARY KEY (role_id, permission_id),
+    FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE,
+    FOREIGN KEY (permission_id) REFERENCES permissions(id) ON DELETE CASCADE
+);
+
+INSERT INTO permissions(name, description) VALUES
+    ('roles:manage', 'Create, update and delete roles and their permissions'),
+    ('beans:write', 'Create, update and delete beans'),
+    ('forms:write', 'Create, update and delete forms'),
+    ('products:write', 'Create, update and delete products'),
+    ('carts:write', 'Add products to own cart'),
+    ('orders:create', 'Place orders from own cart'),
+    ('orders:read', 'Read every order'),
+    ('orders:roast', 'Move orders to roasting'),
+    ('orders:ship', 'Move orders to shipped'),
+    ('orders:cancel', 'Cancel any order'),
+    ('orders:complete', 'Complete any order');
+
+-- keep what the built-in roles were allowed to do by their level
+INSERT INTO role_permissions(role_id, permission_id)
+SELECT roles.id, permissions.id FROM roles JOIN permissions
+WHERE roles.name = 'customer' AND permissions.name IN ('carts:write', 'orders:create');
+
+INSERT INTO role_permissions(role_id, permission_id)
+SELECT roles.id, permissions.id FROM roles JOIN permissions
+WHERE roles.name = 'admin' AND permissions.name IN ('orders:read', 'orders:roast', 'orders:ship', 'orders:cancel', 'orders:complete');
+
+INSERT INTO role_permissions(role_id, permission_id)
+SELECT roles.id, permissions.id FROM roles JOIN permissions
+WHERE roles.name = 'super admin' AND permissions.name NOT IN ('carts:write', 'orders:create');
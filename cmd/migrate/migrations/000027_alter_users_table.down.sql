@@ -0,0 +1,3 @@
+DELETE FROM permissions WHERE name = 'users:manage';
+
+ALTER TABLE users DROP COLUMN deactivated_at;
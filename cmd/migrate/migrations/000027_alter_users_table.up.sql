@@ -0,0 +1,8 @@
+ALTER TABLE users ADD COLUMN deactivated_at TIMESTAMP NULL;
+
+INSERT INTO permissions(name, description) VALUES
+    ('users:manage', 'List users, change their role, deactivate them and force password resets');
+
+INSERT INTO role_permissions(role_id, permission_id)
+SELECT roles.id, permissions.id FROM roles JOIN permissions
+WHERE roles.name = 'super admin' AND permissions.name = 'users:manage';
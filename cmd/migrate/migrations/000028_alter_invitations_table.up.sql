@@ -0,0 +1,2 @@
+ALTER TABLE invitations
+    ADD COLUMN email VARCHAR(32) NULL;
@@ -0,0 +1,18 @@
+CREATE TABLE addresses(
+    id INT NOT NULL AUTO_INCREMENT,
+    user_id INT NOT NULL,
+    label VARCHAR(32) NOT NULL,
+    recipient_name VARCHAR(32) NOT NULL,
+    phone_number VARCHAR(18) NOT NULL,
+    street VARCHAR(255) NOT NULL,
+    city VARCHAR(64) NOT NULL,
+    province VARCHAR(64) NOT NULL,
+    postal_code VARCHAR(10) NOT NULL,
+    notes VARCHAR(255),
+    is_default BOOLEAN NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_addresses_user_id ON addresses(user_id);
@@ -0,0 +1,6 @@
+ALTER TABLE orders
+    DROP COLUMN delivery_notes,
+    DROP COLUMN postal_code,
+    DROP COLUMN province,
+    MODIFY COLUMN city VARCHAR(16) NOT NULL,
+    MODIFY COLUMN street VARCHAR(16) NOT NULL;
@@ -0,0 +1,6 @@
+ALTER TABLE orders
+    MODIFY COLUMN street VARCHAR(255) NOT NULL,
+    MODIFY COLUMN city VARCHAR(64) NOT NULL,
+    ADD COLUMN province VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN postal_code VARCHAR(10) NOT NULL DEFAULT '',
+    ADD COLUMN delivery_notes VARCHAR(255);
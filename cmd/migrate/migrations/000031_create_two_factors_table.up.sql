@@ -0,0 +1,29 @@
+CREATE TABLE two_factors(
+    user_id INT NOT NULL,
+    secret VARCHAR(64) NOT NULL,
+    last_step BIGINT NOT NULL DEFAULT 0,
+    enabled_at DATETIME,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE recovery_codes(
+    code VARBINARY(72) NOT NULL,
+    user_id INT NOT NULL,
+    used_at DATETIME,
+    PRIMARY KEY (code),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE two_factor_challenges(
+    token VARBINARY(72) NOT NULL,
+    user_id INT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    expire_at DATETIME NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (token),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_recovery_codes_user_id ON recovery_codes(user_id);
@@ -0,0 +1,21 @@
+CREATE TABLE api_keys(
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(64) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    hash VARBINARY(72) NOT NULL UNIQUE,
+    role_id INT NOT NULL,
+    created_by INT,
+    expire_at DATETIME,
+    last_used_at DATETIME,
+    revoked_at DATETIME,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (role_id) REFERENCES roles(id),
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+INSERT INTO permissions(name, description) VALUES
+    ('api-keys:manage', 'Create, list and revoke API keys of integrations');
+
+INSERT INTO role_permissions(role_id, permission_id)
+SELECT roles.id, permissions.id FROM roles JOIN permissions
+WHERE roles.name = 'super admin' AND permissions.name = 'api-keys:manage';
@@ -0,0 +1,5 @@
+ALTER TABLE orders DROP FOREIGN KEY fk_orders_users;
+
+ALTER TABLE orders
+    MODIFY COLUMN customer_id INT NOT NULL,
+    ADD CONSTRAINT orders_ibfk_1 FOREIGN KEY (customer_id) REFERENCES users(id);
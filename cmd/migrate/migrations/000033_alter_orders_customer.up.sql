@@ -0,0 +1,5 @@
+ALTER TABLE orders DROP FOREIGN KEY orders_ibfk_1;
+
+ALTER TABLE orders
+    MODIFY COLUMN customer_id INT NULL,
+    ADD CONSTRAINT fk_orders_users FOREIGN KEY (customer_id) REFERENCES users(id) ON DELETE SET NULL;
@@ -0,0 +1,26 @@
+CREATE TABLE audit_logs(
+    id BIGINT NOT NULL AUTO_INCREMENT,
+    actor_id INT,
+    actor_name VARCHAR(64) NOT NULL DEFAULT '',
+    api_key_id INT,
+    action VARCHAR(64) NOT NULL,
+    target_type VARCHAR(32) NOT NULL,
+    target_id VARCHAR(64) NOT NULL DEFAULT '',
+    before_data JSON,
+    after_data JSON,
+    request_id VARCHAR(128) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id)
+);
+
+-- actors and targets are not foreign keys, the log outlives what it points to
+CREATE INDEX idx_audit_logs_actor_id ON audit_logs(actor_id, created_at);
+CREATE INDEX idx_audit_logs_target ON audit_logs(target_type, target_id, created_at);
+CREATE INDEX idx_audit_logs_created_at ON audit_logs(created_at);
+
+INSERT INTO permissions(name, description) VALUES
+    ('audit:read', 'Read the audit log of privileged actions');
+
+INSERT INTO role_permissions(role_id, permission_id)
+SELECT roles.id, permissions.id FROM roles JOIN permissions
+WHERE roles.name = 'super admin' AND permissions.name = 'audit:read';
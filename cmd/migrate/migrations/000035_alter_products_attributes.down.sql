@@ -0,0 +1,11 @@
+ALTER TABLE products
+    DROP CHECK weight_positive,
+    DROP CHECK altitude_non_negative,
+    DROP COLUMN weight,
+    DROP COLUMN tasting_notes,
+    DROP COLUMN altitude,
+    DROP COLUMN process,
+    DROP COLUMN origin,
+    DROP COLUMN description,
+    DROP COLUMN name,
+    MODIFY COLUMN roasted ENUM('light','medium','dark') DEFAULT 'light';
@@ -0,0 +1,13 @@
+ALTER TABLE products
+    MODIFY COLUMN roasted ENUM('light','medium','dark') NOT NULL DEFAULT 'light',
+    ADD COLUMN name VARCHAR(128) NOT NULL DEFAULT '' AFTER id,
+    ADD COLUMN description TEXT AFTER name,
+    ADD COLUMN origin VARCHAR(64) NOT NULL DEFAULT '' AFTER description,
+    ADD COLUMN process ENUM('washed','natural','honey') AFTER origin,
+    -- meters above sea level, 0 when unknown
+    ADD COLUMN altitude INT NOT NULL DEFAULT 0 AFTER process,
+    ADD COLUMN tasting_notes JSON AFTER altitude,
+    -- grams of coffee in a pack
+    ADD COLUMN weight INT NOT NULL DEFAULT 250 AFTER tasting_notes,
+    ADD CONSTRAINT altitude_non_negative CHECK (altitude >= 0),
+    ADD CONSTRAINT weight_positive CHECK (weight > 0);
@@ -0,0 +1,77 @@
+ALTER TABLE products
+    ADD COLUMN price DECIMAL(10,2) AFTER roasted,
+    ADD COLUMN quantity INT NOT NULL DEFAULT 0 AFTER price,
+    ADD COLUMN weight INT NOT NULL DEFAULT 250 AFTER tasting_notes;
+
+-- a product keeps its lightest variant
+UPDATE products
+JOIN product_variants ON product_variants.id = (
+    SELECT id FROM product_variants AS lightest
+    WHERE lightest.product_id = products.id
+    ORDER BY lightest.weight LIMIT 1
+)
+SET products.price = product_variants.price,
+    products.quantity = product_variants.quantity,
+    products.weight = product_variants.weight;
+
+ALTER TABLE products
+    ADD CONSTRAINT quantity_non_negative CHECK (quantity >= 0),
+    ADD CONSTRAINT weight_positive CHECK (weight > 0);
+
+DROP TRIGGER IF EXISTS trg_cart_open_unique_insert;
+
+DROP TRIGGER IF EXISTS trg_cart_open_unique_update;
+
+ALTER TABLE cart_items ADD COLUMN product_id INT NULL AFTER user_id;
+
+UPDATE cart_items
+JOIN product_variants ON product_variants.id = cart_items.variant_id
+SET cart_items.product_id = product_variants.product_id;
+
+ALTER TABLE cart_items
+    DROP FOREIGN KEY fk_cart_items_variants,
+    DROP COLUMN variant_id,
+    MODIFY COLUMN product_id INT NOT NULL,
+    ADD CONSTRAINT cart_items_ibfk_2 FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE;
+
+CREATE TRIGGER trg_cart_open_unique_insert
+BEFORE INSERT ON cart_items
+FOR EACH ROW
+BEGIN
+  IF NEW.status = 'open' AND
+     EXISTS (
+       SELECT 1
+       FROM cart_items
+       WHERE user_id = NEW.user_id
+         AND product_id = NEW.product_id
+         AND status = 'open'
+     ) THEN
+    SIGNAL SQLSTATE '45000'
+      SET MESSAGE_TEXT = 'Duplicate open cart for this user/product (INSERT)';
+  END IF;
+END;
+
+CREATE TRIGGER trg_cart_open_unique_update
+BEFORE UPDATE ON cart_items
+FOR EACH ROW
+BEGIN
+  IF NEW.status = 'open' AND
+     EXISTS (
+       SELECT 1
+       FROM cart_items
+       WHERE user_id = NEW.user_id
+         AND product_id = NEW.product_id
+         AND status = 'open'
+         AND id <> NEW.id
+     ) THEN
+    SIGNAL SQLSTATE '45000'
+      SET MESSAGE_TEXT = 'Duplicate open cart for this user/product (UPDATE)';
+  END IF;
+END;
+
+UPDATE orders SET items = (
+    SELECT JSON_ARRAYAGG(JSON_REMOVE(item.value, '$.variant_id'))
+    FROM JSON_TABLE(orders.items, '$[*]' COLUMNS (value JSON PATH '$')) AS item
+);
+
+DROP TABLE IF EXISTS product_variants;
@@ -0,0 +1,85 @@
+CREATE TABLE product_variants(
+    id INT NOT NULL AUTO_INCREMENT,
+    product_id INT NOT NULL,
+    sku VARCHAR(64) NOT NULL,
+    -- grams of coffee in the bag
+    weight INT NOT NULL,
+    price DECIMAL(10,2) NOT NULL,
+    quantity INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    UNIQUE KEY unique_variant_sku (sku),
+    UNIQUE KEY unique_variant_weight (product_id, weight),
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE,
+    CONSTRAINT variant_quantity_non_negative CHECK (quantity >= 0),
+    CONSTRAINT variant_weight_positive CHECK (weight > 0)
+);
+
+-- every existing product becomes the only variant of itself
+INSERT INTO product_variants(product_id, sku, weight, price, quantity)
+SELECT id, CONCAT('IC-', id, '-', weight), weight, price, quantity FROM products;
+
+-- orders placed before variants point at the product, move their items to its variant
+UPDATE orders SET items = (
+    SELECT JSON_ARRAYAGG(JSON_SET(item.value, '$.variant_id', COALESCE(product_variants.id, 0)))
+    FROM JSON_TABLE(orders.items, '$[*]' COLUMNS (value JSON PATH '$', product_id INT PATH '$.id')) AS item
+    LEFT JOIN product_variants ON product_variants.product_id = item.product_id
+);
+
+DROP TRIGGER IF EXISTS trg_cart_open_unique_insert;
+
+DROP TRIGGER IF EXISTS trg_cart_open_unique_update;
+
+ALTER TABLE cart_items ADD COLUMN variant_id INT NULL AFTER product_id;
+
+UPDATE cart_items
+JOIN product_variants ON product_variants.product_id = cart_items.product_id
+SET cart_items.variant_id = product_variants.id;
+
+ALTER TABLE cart_items
+    DROP FOREIGN KEY cart_items_ibfk_2,
+    DROP COLUMN product_id,
+    MODIFY COLUMN variant_id INT NOT NULL,
+    ADD CONSTRAINT fk_cart_items_variants FOREIGN KEY (variant_id) REFERENCES product_variants(id) ON DELETE CASCADE;
+
+CREATE TRIGGER trg_cart_open_unique_insert
+BEFORE INSERT ON cart_items
+FOR EACH ROW
+BEGIN
+  IF NEW.status = 'open' AND
+     EXISTS (
+       SELECT 1
+       FROM cart_items
+       WHERE user_id = NEW.user_id
+         AND variant_id = NEW.variant_id
+         AND status = 'open'
+     ) THEN
+    SIGNAL SQLSTATE '45000'
+      SET MESSAGE_TEXT = 'Duplicate open cart for this user/variant (INSERT)';
+  END IF;
+END;
+
+CREATE TRIGGER trg_cart_open_unique_update
+BEFORE UPDATE ON cart_items
+FOR EACH ROW
+BEGIN
+  IF NEW.status = 'open' AND
+     EXISTS (
+       SELECT 1
+       FROM cart_items
+       WHERE user_id = NEW.user_id
+         AND variant_id = NEW.variant_id
+         AND status = 'open'
+         AND id <> NEW.id
+     ) THEN
+    SIGNAL SQLSTATE '45000'
+      SET MESSAGE_TEXT = 'Duplicate open cart for this user/variant (UPDATE)';
+  END IF;
+END;
+
+ALTER TABLE products
+    DROP CHECK quantity_non_negative,
+    DROP CHECK weight_positive,
+    DROP COLUMN price,
+    DROP COLUMN quantity,
+    DROP COLUMN weight;
@@ -0,0 +1,7 @@
+ALTER TABLE products ADD COLUMN image VARCHAR(255);
+
+UPDATE products
+JOIN product_images ON product_images.product_id = products.id AND product_images.is_primary = TRUE
+SET products.image = product_images.url;
+
+DROP TABLE IF EXISTS product_images;
@@ -0,0 +1,21 @@
+CREATE TABLE product_images(
+    id INT NOT NULL AUTO_INCREMENT,
+    product_id INT NOT NULL,
+    url VARCHAR(255) NOT NULL,
+    -- gallery order, lowest first
+    position INT NOT NULL DEFAULT 0,
+    is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+    -- only set for the primary image so a product can not have two of them
+    primary_of INT AS (IF(is_primary, product_id, NULL)) VIRTUAL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    UNIQUE KEY unique_primary_image (primary_of),
+    INDEX idx_product_images_position (product_id, position),
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+);
+
+-- the single image of every existing product becomes its primary image
+INSERT INTO product_images(product_id, url, position, is_primary)
+SELECT id, image, 0, TRUE FROM products WHERE image IS NOT NULL AND image <> '';
+
+ALTER TABLE products DROP COLUMN image;
@@ -0,0 +1 @@
+ALTER TABLE product_images DROP COLUMN renditions;
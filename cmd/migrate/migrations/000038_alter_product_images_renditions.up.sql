@@ -0,0 +1,2 @@
+-- urls of the resized copies keyed by rendition, NULL for images uploaded before them
+ALTER TABLE product_images ADD COLUMN renditions JSON AFTER url;
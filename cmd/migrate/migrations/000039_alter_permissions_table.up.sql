@@ -0,0 +1,6 @@
+INSERT INTO permissions(name, description) VALUES
+    ('media:manage', 'Report and delete stored files no product refers to');
+
+INSERT INTO role_permissions(role_id, permission_id)
+SELECT roles.id, permissions.id FROM roles JOIN permissions
+WHERE roles.name = 'super admin' AND permissions.name = 'media:manage';
@@ -0,0 +1,5 @@
+DROP INDEX idx_forms_name_search ON forms;
+DROP INDEX idx_beans_name_search ON beans;
+DROP INDEX idx_products_search ON products;
+
+ALTER TABLE products DROP COLUMN tasting_notes_text;
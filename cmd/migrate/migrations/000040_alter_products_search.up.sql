@@ -0,0 +1,7 @@
+-- full-text indexes can not index json, the tasting notes are indexed as their text
+ALTER TABLE products
+    ADD COLUMN tasting_notes_text TEXT GENERATED ALWAYS AS (CAST(tasting_notes AS CHAR)) STORED;
+
+CREATE FULLTEXT INDEX idx_products_search ON products(name, description, tasting_notes_text);
+CREATE FULLTEXT INDEX idx_beans_name_search ON beans(name);
+CREATE FULLTEXT INDEX idx_forms_name_search ON forms(name);
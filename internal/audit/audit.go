@@ -0,0 +1,150 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"reflect"
+
+	"github.com/faizisyellow/indocoffee/internal/keys"
+	"github.com/faizisyellow/indocoffee/internal/models"
+	"github.com/faizisyellow/indocoffee/internal/repository/audits"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const actorKey keys.Actor = "actor"
+
+// Recorder records the entry in tx, the transaction of the action.
+type Recorder interface {
+	Record(ctx context.Context, tx *sql.Tx, entry Entry) error
+}
+
+// Entry is a privileged action done to a target, Before is nil
+// when the target was created and After is nil when it was deleted.
+type Entry struct {
+	Action     string
+	TargetType string
+	TargetId   string
+	Before     any
+	After      any
+}
+
+// Actor is who did the action, either a user or an api key.
+type Actor struct {
+	Id       int
+	Name     string
+	ApiKeyId int
+}
+
+// WithActor returns a copy of ctx carrying the actor.
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+// ActorFrom gets the actor carried by ctx.
+func ActorFrom(ctx context.Context) (Actor, bool) {
+	actor, ok := ctx.Value(actorKey).(Actor)
+
+	return actor, ok
+}
+
+// StoreRecorder records entries to the audit log store with the
+// actor and the request id carried by the context.
+type StoreRecorder struct {
+	Store audits.Audits
+}
+
+func (s *StoreRecorder) Record(ctx context.Context, tx *sql.Tx, entry Entry) error {
+
+	before, after, err := Diff(entry.Before, entry.After)
+	if err != nil {
+		return err
+	}
+
+	log := models.AuditLog{
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetId:   entry.TargetId,
+		Before:     before,
+		After:      after,
+		RequestId:  middleware.GetReqID(ctx),
+	}
+
+	if actor, ok := ActorFrom(ctx); ok {
+		log.ActorName = actor.Name
+		if actor.Id != 0 {
+			log.ActorId = &actor.Id
+		}
+		if actor.ApiKeyId != 0 {
+			log.ApiKeyId = &actor.ApiKeyId
+		}
+	}
+
+	return s.Store.Insert(ctx, tx, log)
+}
+
+// Diff encodes before and after keeping only the fields that differ.
+// A nil side encodes to nil and the other side is kept whole.
+func Diff(before, after any) (json.RawMessage, json.RawMessage, error) {
+
+	beforeFields, err := fields(before)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	afterFields, err := fields(after)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if beforeFields != nil && afterFields != nil {
+		for key, value := range beforeFields {
+			if other, ok := afterFields[key]; ok && reflect.DeepEqual(value, other) {
+				delete(beforeFields, key)
+				delete(afterFields, key)
+			}
+		}
+	}
+
+	beforeData, err := encode(beforeFields)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	afterData, err := encode(afterFields)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return beforeData, afterData, nil
+}
+
+func fields(value any) (map[string]any, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if string(data) == "null" {
+		return nil, nil
+	}
+
+	result := map[string]any{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func encode(fields map[string]any) (json.RawMessage, error) {
+	if fields == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(fields)
+}
@@ -0,0 +1,81 @@
+package audit_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/faizisyellow/indocoffee/internal/audit"
+	"github.com/faizisyellow/indocoffee/internal/repository/audits"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+type product struct {
+	Name  string  `json:"name"`
+	Price float64 `json:"price"`
+}
+
+func TestDiff(t *testing.T) {
+	t.Run("keep only changed fields", func(t *testing.T) {
+		before, after, err := audit.Diff(product{Name: "arabica", Price: 10}, product{Name: "arabica", Price: 12})
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if string(before) != `{"price":10}` {
+			t.Errorf("expected before only the price but got: %s", before)
+		}
+
+		if string(after) != `{"price":12}` {
+			t.Errorf("expected after only the price but got: %s", after)
+		}
+	})
+
+	t.Run("keep whole side when the other is nil", func(t *testing.T) {
+		before, after, err := audit.Diff(nil, product{Name: "arabica", Price: 10})
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if before != nil {
+			t.Errorf("expected before to be nil but got: %s", before)
+		}
+
+		if string(after) != `{"name":"arabica","price":10}` {
+			t.Errorf("expected whole after but got: %s", after)
+		}
+	})
+}
+
+func TestStoreRecorder(t *testing.T) {
+	var (
+		store    = &audits.InMemoryAudits{}
+		recorder = &audit.StoreRecorder{Store: store}
+		ctx      = context.WithValue(context.Background(), middleware.RequestIDKey, "host/abc-000001")
+	)
+
+	ctx = audit.WithActor(ctx, audit.Actor{Id: 1, Name: "lizzy"})
+
+	err := recorder.Record(ctx, nil, audit.Entry{
+		Action:     "products.update",
+		TargetType: "product",
+		TargetId:   "1",
+		Before:     product{Name: "arabica", Price: 10},
+		After:      product{Name: "arabica", Price: 12},
+	})
+	if err != nil {
+		t.Fatalf("should not be error but got: %v", err)
+	}
+
+	if len(store.Logs) != 1 {
+		t.Fatalf("expected 1 log but got %v", len(store.Logs))
+	}
+
+	log := store.Logs[0]
+	if log.ActorId == nil || *log.ActorId != 1 || log.ActorName != "lizzy" || log.ApiKeyId != nil {
+		t.Errorf("expected the actor from context but got: %+v", log)
+	}
+
+	if log.RequestId != "host/abc-000001" {
+		t.Errorf("expected the request id from context but got: %v", log.RequestId)
+	}
+}
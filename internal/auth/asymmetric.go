@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// AsymmetricJwtAuthentication signs tokens with the active key and
+// verifies them with any key of the set, so rotating the active key
+// does not invalidate tokens signed by the previous one.
+type AsymmetricJwtAuthentication struct {
+	Keys   map[string]Key
+	Active string
+	Iss    string
+	Sub    string
+}
+
+func NewAsymmetric(keys []Key, active, iss, sub string) (*AsymmetricJwtAuthentication, error) {
+
+	set := make(map[string]Key, len(keys))
+	for _, key := range keys {
+		set[key.Id] = key
+	}
+
+	activeKey, ok := set[active]
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", ErrKeyNotFound, active)
+	}
+
+	if activeKey.Private == nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeyNotSigner, active)
+	}
+
+	return &AsymmetricJwtAuthentication{
+		Keys:   set,
+		Active: active,
+		Iss:    iss,
+		Sub:    sub,
+	}, nil
+}
+
+func (ja *AsymmetricJwtAuthentication) GenerateToken(claims jwt.Claims) (string, error) {
+
+	key := ja.Keys[ja.Active]
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.Id
+
+	return token.SignedString(key.Private)
+}
+
+func (ja *AsymmetricJwtAuthentication) VerifyToken(token string) (*jwt.Token, error) {
+
+	return jwt.Parse(
+		token,
+		ja.VerifyParsedToken,
+		jwt.WithIssuer(ja.Iss),
+		jwt.WithSubject(ja.Sub),
+		jwt.WithExpirationRequired(),
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Name, jwt.SigningMethodEdDSA.Alg()}),
+	)
+}
+
+func (ja *AsymmetricJwtAuthentication) VerifyParsedToken(token *jwt.Token) (any, error) {
+
+	kid, ok := token.Header["kid"].(string)
+	if !ok {
+		return nil, fmt.Errorf("token has no kid header")
+	}
+
+	key, ok := ja.Keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", ErrKeyNotFound, kid)
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpect signing method %v", token.Header["alg"])
+	}
+
+	return key.Public, nil
+}
+
+// JWKS returns the public part of every key, ordered by key id.
+func (ja *AsymmetricJwtAuthentication) JWKS() JWKS {
+
+	ids := make([]string, 0, len(ja.Keys))
+	for id := range ja.Keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	jwks := JWKS{Keys: make([]JWK, 0, len(ids))}
+	for _, id := range ids {
+		jwks.Keys = append(jwks.Keys, newJWK(ja.Keys[id]))
+	}
+
+	return jwks
+}
@@ -0,0 +1,162 @@
+package auth_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/auth"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestAsymmetricJwtAuthentication(t *testing.T) {
+	claims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss": "it just test",
+			"sub": "test",
+			"iat": time.Now().Unix(),
+			"exp": time.Now().Add(time.Minute).Unix(),
+			"id":  1,
+		}
+	}
+
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePem(t, filepath.Join(dir, "2025-01.pem"), "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePem(t, filepath.Join(dir, "2025-02.pem"), "PRIVATE KEY", der)
+
+	keys, err := auth.LoadKeys(dir)
+	if err != nil {
+		t.Fatalf("should not be error but got: %v", err)
+	}
+
+	if len(keys) != 2 {
+		t.Fatalf("expected 2 keys but got: %v", len(keys))
+	}
+
+	t.Run("token signed before rotation still verifies", func(t *testing.T) {
+		before, err := auth.NewAsymmetric(keys, "2025-01", "it just test", "test")
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		old, err := before.GenerateToken(claims())
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		after, err := auth.NewAsymmetric(keys, "2025-02", "it just test", "test")
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		token, err := after.VerifyToken(old)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if token.Header["kid"] != "2025-01" || token.Method.Alg() != "RS256" {
+			t.Errorf("expected RS256 token of 2025-01 but got: %v %v", token.Header["kid"], token.Method.Alg())
+		}
+
+		fresh, err := after.GenerateToken(claims())
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		token, err = before.VerifyToken(fresh)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if token.Header["kid"] != "2025-02" || token.Method.Alg() != "EdDSA" {
+			t.Errorf("expected EdDSA token of 2025-02 but got: %v %v", token.Header["kid"], token.Method.Alg())
+		}
+	})
+
+	t.Run("token of a removed key is rejected", func(t *testing.T) {
+		retired, err := auth.NewAsymmetric(keys[:1], "2025-01", "it just test", "test")
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		current, err := auth.NewAsymmetric(keys[1:], "2025-02", "it just test", "test")
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		old, err := retired.GenerateToken(claims())
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if _, err := current.VerifyToken(old); !errors.Is(err, auth.ErrKeyNotFound) {
+			t.Errorf("expected %v but got: %v", auth.ErrKeyNotFound, err)
+		}
+	})
+
+	t.Run("verification only key cannot be active", func(t *testing.T) {
+		der, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		public, err := auth.ParseKey("2024-12", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		_, err = auth.NewAsymmetric([]auth.Key{public}, "2024-12", "it just test", "test")
+		if !errors.Is(err, auth.ErrKeyNotSigner) {
+			t.Errorf("expected %v but got: %v", auth.ErrKeyNotSigner, err)
+		}
+	})
+
+	t.Run("publish every public key", func(t *testing.T) {
+		sut, err := auth.NewAsymmetric(keys, "2025-02", "it just test", "test")
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		jwks := sut.JWKS()
+		if len(jwks.Keys) != 2 {
+			t.Fatalf("expected 2 keys but got: %v", len(jwks.Keys))
+		}
+
+		if jwks.Keys[0].Kid != "2025-01" || jwks.Keys[0].Kty != "RSA" || jwks.Keys[0].N == "" || jwks.Keys[0].E != "AQAB" {
+			t.Errorf("unexpected RSA key: %+v", jwks.Keys[0])
+		}
+
+		if jwks.Keys[1].Kid != "2025-02" || jwks.Keys[1].Kty != "OKP" || jwks.Keys[1].Crv != "Ed25519" || jwks.Keys[1].X == "" {
+			t.Errorf("unexpected Ed25519 key: %+v", jwks.Keys[1])
+		}
+	})
+}
+
+func writePem(t *testing.T, path, kind string, der []byte) {
+	t.Helper()
+
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -6,3 +6,9 @@ type Authenticator interface {
 	GenerateToken(jwt.Claims) (string, error)
 	VerifyToken(token string) (*jwt.Token, error)
 }
+
+// KeyPublisher is implemented by authenticators whose tokens
+// can be verified by anyone holding the published public keys.
+type KeyPublisher interface {
+	JWKS() JWKS
+}
@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrKeyNotFound     = errors.New("auth: signing key not found")
+	ErrKeyNotSigner    = errors.New("auth: active key has no private key")
+	ErrKeyNotSupported = errors.New("auth: key type is not supported, use RSA or Ed25519")
+)
+
+// Key is a single key of the key set, Id is published as the token's kid header.
+type Key struct {
+	Id     string
+	Method jwt.SigningMethod
+	// Private is nil for keys that are kept for verification only.
+	Private crypto.Signer
+	Public  crypto.PublicKey
+}
+
+// LoadKeys reads every *.pem file in dir, the file name without extension becomes the key id.
+// A file may hold a PKCS#8/PKCS#1 private key or a PKIX public key,
+// keys that are rotated out only need their public key to be kept.
+func LoadKeys(dir string) ([]Key, error) {
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+
+	keys := make([]Key, 0, len(files))
+	for _, file := range files {
+
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := ParseKey(strings.TrimSuffix(filepath.Base(file), ".pem"), raw)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", filepath.Base(file), err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// ParseKey decodes a PEM encoded RSA or Ed25519 key.
+func ParseKey(id string, raw []byte) (Key, error) {
+
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return Key{}, errors.New("auth: no PEM block found")
+	}
+
+	var parsed any
+	var err error
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return Key{}, fmt.Errorf("auth: unexpected PEM block %v", block.Type)
+	}
+	if err != nil {
+		return Key{}, err
+	}
+
+	key := Key{Id: id}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodRS256, k, &k.PublicKey
+	case ed25519.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodEdDSA, k, k.Public()
+	case *rsa.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodRS256, k
+	case ed25519.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodEdDSA, k
+	default:
+		return Key{}, ErrKeyNotSupported
+	}
+
+	return key, nil
+}
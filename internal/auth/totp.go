@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP (RFC 6238) with the parameters every authenticator app supports:
+// HMAC-SHA1, 6 digits and 30 seconds steps.
+const (
+	TOTP_PERIOD = 30
+	TOTP_DIGITS = 6
+	// TOTP_SKEW is how many steps around the current one are accepted,
+	// for clocks that drift.
+	TOTP_SKEW = 1
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret returns a random 160 bits secret encoded in base32.
+func GenerateTOTPSecret() (string, error) {
+
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// TOTPStep returns the time step of t.
+func TOTPStep(t time.Time) int64 {
+	return t.Unix() / TOTP_PERIOD
+}
+
+// TOTPCode computes the code of the secret at the time step.
+func TOTPCode(secret string, step int64) (string, error) {
+
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%06d", value%1_000_000), nil
+}
+
+// ValidateTOTP checks the code against the steps around t that are newer than lastStep,
+// so an accepted code can not be replayed.
+// Returns the matched step and true on success.
+func ValidateTOTP(secret, code string, t time.Time, lastStep int64) (int64, bool) {
+
+	if len(code) != TOTP_DIGITS {
+		return 0, false
+	}
+
+	current := TOTPStep(t)
+	for step := current - TOTP_SKEW; step <= current+TOTP_SKEW; step++ {
+		if step <= lastStep {
+			continue
+		}
+
+		expected, err := TOTPCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// TOTPProvisioningURI returns the otpauth URI authenticator apps read from a QR code.
+func TOTPProvisioningURI(issuer, account, secret string) string {
+
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(TOTP_DIGITS))
+	query.Set("period", fmt.Sprint(TOTP_PERIOD))
+
+	label := url.PathEscape(issuer + ":" + account)
+
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
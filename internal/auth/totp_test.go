@@ -0,0 +1,65 @@
+package auth_test
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/auth"
+)
+
+func TestTOTP(t *testing.T) {
+	// the SHA1 seed of RFC 6238 appendix B
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	t.Run("match RFC 6238 test vectors", func(t *testing.T) {
+		vectors := []struct {
+			unix     int64
+			expected string
+		}{
+			{59, "287082"},
+			{1111111109, "081804"},
+			{1234567890, "005924"},
+			{2000000000, "279037"},
+		}
+
+		for _, v := range vectors {
+			code, err := auth.TOTPCode(secret, auth.TOTPStep(time.Unix(v.unix, 0)))
+			if err != nil {
+				t.Fatalf("should not be error but got: %v", err)
+			}
+
+			if code != v.expected {
+				t.Errorf("at %v expected %v but got %v", v.unix, v.expected, code)
+			}
+		}
+	})
+
+	t.Run("accept the previous step but not a replay", func(t *testing.T) {
+		now := time.Unix(1234567890, 0)
+
+		code, _ := auth.TOTPCode(secret, auth.TOTPStep(now)-1)
+
+		step, ok := auth.ValidateTOTP(secret, code, now, 0)
+		if !ok || step != auth.TOTPStep(now)-1 {
+			t.Fatalf("expected code of previous step to be accepted")
+		}
+
+		if _, ok := auth.ValidateTOTP(secret, code, now, step); ok {
+			t.Error("expected used code to be rejected")
+		}
+
+		if _, ok := auth.ValidateTOTP(secret, code, now.Add(time.Minute), 0); ok {
+			t.Error("expected code outside of the window to be rejected")
+		}
+	})
+
+	t.Run("provisioning uri", func(t *testing.T) {
+		uri := auth.TOTPProvisioningURI("Indocoffee", "lizzy@example.com", "ABC")
+
+		if !strings.HasPrefix(uri, "otpauth://totp/Indocoffee:lizzy@example.com?") || !strings.Contains(uri, "secret=ABC") {
+			t.Errorf("unexpected uri: %v", uri)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache stores values encoded as JSON under a key until the ttl passes.
+type Cache interface {
+	// Get decodes the cached value into dst.
+	// Returns false if the key is missing or has expired.
+	Get(ctx context.Context, key string, dst any) (bool, error)
+	Set(ctx context.Context, key string, value any, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// Tiered reads from the in-process cache first and falls back to the remote cache.
+// Remote is optional, without it Tiered behaves like Local.
+//
+// Local entries are only invalidated on the instance that deletes them,
+// so keep the local ttl short when several instances share the remote cache.
+type Tiered struct {
+	Local    Cache
+	LocalTTL time.Duration
+	Remote   Cache
+}
+
+func (t *Tiered) Get(ctx context.Context, key string, dst any) (bool, error) {
+
+	found, err := t.Local.Get(ctx, key, dst)
+	if err != nil || found || t.Remote == nil {
+		return found, err
+	}
+
+	found, err = t.Remote.Get(ctx, key, dst)
+	if err != nil || !found {
+		return found, err
+	}
+
+	return true, t.Local.Set(ctx, key, dst, t.localTTL(t.LocalTTL))
+}
+
+func (t *Tiered) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
+
+	if err := t.Local.Set(ctx, key, value, t.localTTL(ttl)); err != nil {
+		return err
+	}
+
+	if t.Remote == nil {
+		return nil
+	}
+
+	return t.Remote.Set(ctx, key, value, ttl)
+}
+
+func (t *Tiered) Delete(ctx context.Context, keys ...string) error {
+
+	if err := t.Local.Delete(ctx, keys...); err != nil {
+		return err
+	}
+
+	if t.Remote == nil {
+		return nil
+	}
+
+	return t.Remote.Delete(ctx, keys...)
+}
+
+func (t *Tiered) localTTL(ttl time.Duration) time.Duration {
+	if t.LocalTTL > 0 && (ttl <= 0 || t.LocalTTL < ttl) {
+		return t.LocalTTL
+	}
+
+	return ttl
+}
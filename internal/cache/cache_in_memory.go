@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+type item struct {
+	value    []byte
+	expireAt time.Time
+}
+
+// InMemoryCache is an in-process cache, expired entries are removed when read.
+type InMemoryCache struct {
+	mu    sync.RWMutex
+	items map[string]item
+}
+
+func (m *InMemoryCache) Get(ctx context.Context, key string, dst any) (bool, error) {
+
+	m.mu.RLock()
+	it, ok := m.items[key]
+	m.mu.RUnlock()
+
+	if !ok {
+		return false, nil
+	}
+
+	if !it.expireAt.IsZero() && time.Now().After(it.expireAt) {
+		m.mu.Lock()
+		delete(m.items, key)
+		m.mu.Unlock()
+		return false, nil
+	}
+
+	return true, json.Unmarshal(it.value, dst)
+}
+
+// Set stores the value, a ttl of zero never expires.
+func (m *InMemoryCache) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	it := item{value: encoded}
+	if ttl > 0 {
+		it.expireAt = time.Now().Add(ttl)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.items == nil {
+		m.items = make(map[string]item)
+	}
+
+	m.items[key] = it
+
+	return nil
+}
+
+func (m *InMemoryCache) Delete(ctx context.Context, keys ...string) error {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range keys {
+		delete(m.items, key)
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type RedisCache struct {
+	Rdb *redis.Client
+	// Prefix namespaces every key, so the cache can share a database.
+	Prefix string
+}
+
+func (r *RedisCache) Get(ctx context.Context, key string, dst any) (bool, error) {
+
+	value, err := r.Rdb.Get(ctx, r.Prefix+key).Bytes()
+	if err == redis.Nil {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, json.Unmarshal(value, dst)
+}
+
+func (r *RedisCache) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return r.Rdb.Set(ctx, r.Prefix+key, encoded, ttl).Err()
+}
+
+func (r *RedisCache) Delete(ctx context.Context, keys ...string) error {
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	prefixed := make([]string, 0, len(keys))
+	for _, key := range keys {
+		prefixed = append(prefixed, r.Prefix+key)
+	}
+
+	return r.Rdb.Del(ctx, prefixed...).Err()
+}
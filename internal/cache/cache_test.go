@@ -0,0 +1,122 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/cache"
+)
+
+func TestInMemoryCache(t *testing.T) {
+	testCache(t, func() cache.Cache { return &cache.InMemoryCache{} })
+}
+
+func TestTieredCache(t *testing.T) {
+	t.Run("without remote", func(t *testing.T) {
+		testCache(t, func() cache.Cache { return &cache.Tiered{Local: &cache.InMemoryCache{}} })
+	})
+
+	t.Run("with remote", func(t *testing.T) {
+		testCache(t, func() cache.Cache {
+			return &cache.Tiered{Local: &cache.InMemoryCache{}, LocalTTL: time.Minute, Remote: &cache.InMemoryCache{}}
+		})
+	})
+
+	t.Run("read through from remote", func(t *testing.T) {
+		var (
+			ctx    = context.Background()
+			remote = &cache.InMemoryCache{}
+			tiered = &cache.Tiered{Local: &cache.InMemoryCache{}, LocalTTL: time.Minute, Remote: remote}
+			got    []string
+		)
+
+		if err := remote.Set(ctx, "roles:1", []string{"orders:read"}, time.Hour); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		found, err := tiered.Get(ctx, "roles:1", &got)
+		if err != nil || !found || len(got) != 1 {
+			t.Fatalf("expected value from remote but got %v, %v, %v", got, found, err)
+		}
+
+		// still cached locally after the remote forgets it
+		if err := remote.Delete(ctx, "roles:1"); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if found, _ := tiered.Get(ctx, "roles:1", &got); !found {
+			t.Error("expected value to be cached locally")
+		}
+	})
+}
+
+func testCache(t *testing.T, newCache func() cache.Cache) {
+	t.Run("set and get", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+			c   = newCache()
+			got []string
+		)
+
+		if err := c.Set(ctx, "roles:1", []string{"orders:read", "orders:ship"}, time.Hour); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		found, err := c.Get(ctx, "roles:1", &got)
+		if err != nil || !found {
+			t.Fatalf("expected value to be found but got %v, %v", found, err)
+		}
+
+		if len(got) != 2 || got[1] != "orders:ship" {
+			t.Errorf("unexpected value: %v", got)
+		}
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		var got string
+
+		found, err := newCache().Get(context.Background(), "nothing", &got)
+		if err != nil || found {
+			t.Errorf("expected value not to be found but got %v, %v", found, err)
+		}
+	})
+
+	t.Run("expired key", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+			c   = newCache()
+			got string
+		)
+
+		if err := c.Set(ctx, "short", "lived", time.Millisecond); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		time.Sleep(5 * time.Millisecond)
+
+		if found, _ := c.Get(ctx, "short", &got); found {
+			t.Error("expected value to be expired")
+		}
+	})
+
+	t.Run("delete key", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+			c   = newCache()
+			got string
+		)
+
+		if err := c.Set(ctx, "roles:2", "admin", time.Hour); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if err := c.Delete(ctx, "roles:2"); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if found, _ := c.Get(ctx, "roles:2", &got); found {
+			t.Error("expected value to be deleted")
+		}
+	})
+}
@@ -2,3 +2,12 @@ package keys
 
 type User string
 
+type Session string
+
+type Order string
+
+type Cart string
+
+type ApiKey string
+
+type Actor string
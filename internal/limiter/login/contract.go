@@ -2,132 +2,254 @@ package loginLimiter
 
 import (
 	"context"
+	"fmt"
 	"testing"
+	"time"
 )
 
 type LoginLimiter interface {
-	CanLogin(ctx context.Context, key, email string) (bool, error)
-	SetAttemptLogin(ctx context.Context, key, email string, expireAt int) error
-}
-
-type ItemLoginLimiter struct {
-	Email        string `redis:"email"`
-	AttemptCount int    `redis:"attemptCount"`
+	// Check tells whether the ip may try to sign in to the account.
+	Check(ctx context.Context, ip, email string) (Decision, error)
+	// Fail records a failed sign in and returns what the next try has to wait.
+	Fail(ctx context.Context, ip, email string) (Decision, error)
+	// Succeed resets the counter of the account, the ip's failures are kept.
+	Succeed(ctx context.Context, ip, email string) error
+	// Unlock resets the account, lifting its lockout.
+	Unlock(ctx context.Context, email string) error
 }
 
 type Contract struct {
-	NewLoginLimiter func() (LoginLimiter, func())
+	NewLoginLimiter func(policy Policy) (LoginLimiter, func())
 }
 
 func (c Contract) Test(t *testing.T) {
-	t.Run("check attempt success login", func(t *testing.T) {
-		testCases := []struct {
-			name           string
-			key            string
-			requestPayload string
-		}{
-			{
-				name:           "should passed to login because email is correct",
-				key:            "x02b",
-				requestPayload: "lizzy@example.com",
-			},
-			{
-				name:           "should passed to login when the key is exist but request different email",
-				key:            "x02b",
-				requestPayload: "morelizzy@example.com",
-			},
-			{
-				name:           "should passed to login at second try",
-				key:            "x9ab",
-				requestPayload: "bob@example.com",
-			},
-		}
-
-		for _, tc := range testCases {
-			var (
-				ctx                    = context.Background()
-				loginLimiter, teardown = c.NewLoginLimiter()
-			)
-			t.Cleanup(func() {
-				teardown()
-			})
-
-			canLogin, err := loginLimiter.CanLogin(ctx, tc.key, tc.requestPayload)
-			if err != nil {
-				t.Fatalf("unexpected error: %v", err)
-			}
-			if !canLogin {
-				t.Fatalf("expected user to be allowed to try login")
-			}
+	policy := Policy{
+		IpLimit:          5,
+		AccountLimit:     2,
+		Backoff:          200 * time.Millisecond,
+		MaxBackoff:       time.Second,
+		LockoutThreshold: 6,
+		Window:           time.Minute,
+	}
+
+	t.Run("allow sign in without failures", func(t *testing.T) {
+		var (
+			ctx                    = context.Background()
+			loginLimiter, teardown = c.NewLoginLimiter(policy)
+		)
+		t.Cleanup(teardown)
+
+		decision, err := loginLimiter.Check(ctx, "10.0.0.1", "lizzy@example.com")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
 		}
 
+		if !decision.Allowed {
+			t.Fatalf("expected user to be allowed to try login")
+		}
 	})
 
-	t.Run("check attempt fail login because it's limited", func(t *testing.T) {
+	t.Run("back off the account after its limit", func(t *testing.T) {
 		var (
 			ctx                    = context.Background()
-			loginLimiter, teardown = c.NewLoginLimiter()
+			loginLimiter, teardown = c.NewLoginLimiter(policy)
 		)
-		t.Cleanup(func() {
-			teardown()
-		})
+		t.Cleanup(teardown)
 
-		canLogin, err := loginLimiter.CanLogin(ctx, "z77y", "charlie@example.com")
+		decision, err := loginLimiter.Fail(ctx, "10.0.0.1", "alice@example.com")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
 
-		if canLogin {
-			t.Fatalf("expected user to be not allowed to  login")
+		if !decision.Allowed {
+			t.Fatalf("expected user to be allowed after the first failure")
+		}
+
+		if _, err := loginLimiter.Fail(ctx, "10.0.0.2", "alice@example.com"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// the account is limited whichever ip it comes from
+		decision, err = loginLimiter.Check(ctx, "10.0.0.3", "alice@example.com")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if decision.Allowed || decision.Locked || decision.RetryAfter <= 0 || decision.RetryAfter > policy.Backoff {
+			t.Fatalf("expected the account to back off but got: %+v", decision)
+		}
+
+		time.Sleep(policy.Backoff + 50*time.Millisecond)
+
+		decision, err = loginLimiter.Check(ctx, "10.0.0.3", "alice@example.com")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !decision.Allowed {
+			t.Errorf("expected the account to be allowed after backing off but got: %+v", decision)
 		}
 	})
 
-	t.Run("should block after exceeding max attempts", func(t *testing.T) {
-		t.Run("should block after limit is on 3", func(t *testing.T) {
-			var (
-				ctx                    = context.Background()
-				loginLimiter, teardown = c.NewLoginLimiter()
-			)
-			t.Cleanup(func() {
-				teardown()
-			})
-
-			err := loginLimiter.SetAttemptLogin(ctx, "u123", "alice@example.com", 5)
-			if err != nil {
-				t.Fatalf("expected to be not error but got: %v", err.Error())
-			}
+	t.Run("double the backoff on every failure", func(t *testing.T) {
+		var (
+			ctx                    = context.Background()
+			loginLimiter, teardown = c.NewLoginLimiter(policy)
+			expected               = []time.Duration{0, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second}
+		)
+		t.Cleanup(teardown)
 
-			canLogin, err := loginLimiter.CanLogin(ctx, "u123", "alice@example.com")
+		for i, wait := range expected {
+			decision, err := loginLimiter.Fail(ctx, fmt.Sprintf("10.0.0.%d", i), "bob@example.com")
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
 
-			if canLogin {
-				t.Fatalf("expected user to be not allowed to  login")
+			if decision.RetryAfter > wait || decision.RetryAfter < wait-100*time.Millisecond {
+				t.Errorf("failure %v: expected to wait %v but got %v", i+1, wait, decision.RetryAfter)
 			}
-		})
-
-		t.Run("should block after try several times", func(t *testing.T) {
-			ctx := context.Background()
-			loginLimiter, teardown := c.NewLoginLimiter()
-			t.Cleanup(teardown)
-
-			for i := 0; i < 3; i++ {
-				err := loginLimiter.SetAttemptLogin(ctx, "x9ab", "bob@example.com", 5)
-				if err != nil {
-					t.Fatalf("expected no error, got: %v", err)
-				}
+		}
+	})
+
+	t.Run("limit the ip across accounts", func(t *testing.T) {
+		var (
+			ctx                    = context.Background()
+			loginLimiter, teardown = c.NewLoginLimiter(policy)
+		)
+		t.Cleanup(teardown)
+
+		for _, email := range []string{"a@example.com", "b@example.com", "c@example.com", "d@example.com", "e@example.com"} {
+			if _, err := loginLimiter.Fail(ctx, "10.0.0.9", email); err != nil {
+				t.Fatalf("unexpected error: %v", err)
 			}
+		}
 
-			canLogin, err := loginLimiter.CanLogin(ctx, "x9ab", "bob@example.com")
-			if err != nil {
+		decision, err := loginLimiter.Check(ctx, "10.0.0.9", "charlie@example.com")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if decision.Allowed || decision.RetryAfter <= 0 {
+			t.Errorf("expected the ip to be limited but got: %+v", decision)
+		}
+
+		decision, err = loginLimiter.Check(ctx, "10.0.0.10", "a@example.com")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !decision.Allowed {
+			t.Errorf("expected another ip to be allowed but got: %+v", decision)
+		}
+	})
+
+	t.Run("lock the account until it is unlocked", func(t *testing.T) {
+		var (
+			ctx                    = context.Background()
+			loginLimiter, teardown = c.NewLoginLimiter(policy)
+		)
+		t.Cleanup(teardown)
+
+		for i := range policy.LockoutThreshold {
+			if _, err := loginLimiter.Fail(ctx, fmt.Sprintf("10.0.0.%d", i), "mallory@example.com"); err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
+		}
 
-			if canLogin {
-				t.Fatalf("expected user to be blocked after exceeding max attempts")
+		decision, err := loginLimiter.Check(ctx, "10.0.1.1", "mallory@example.com")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if decision.Allowed || !decision.Locked {
+			t.Fatalf("expected the account to be locked but got: %+v", decision)
+		}
+
+		if err := loginLimiter.Unlock(ctx, "mallory@example.com"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		decision, err = loginLimiter.Check(ctx, "10.0.1.1", "mallory@example.com")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !decision.Allowed {
+			t.Errorf("expected the account to be allowed after unlocking but got: %+v", decision)
+		}
+	})
+
+	t.Run("successful sign in resets the counters", func(t *testing.T) {
+		var (
+			ctx                    = context.Background()
+			loginLimiter, teardown = c.NewLoginLimiter(policy)
+		)
+		t.Cleanup(teardown)
+
+		if _, err := loginLimiter.Fail(ctx, "10.0.0.1", "dave@example.com"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := loginLimiter.Succeed(ctx, "10.0.0.1", "dave@example.com"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		decision, err := loginLimiter.Fail(ctx, "10.0.0.1", "dave@example.com")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !decision.Allowed {
+			t.Errorf("expected failures before the sign in to be forgotten but got: %+v", decision)
+		}
+	})
+	t.Run("successful sign in keeps the failures of the ip", func(t *testing.T) {
+		var (
+			ctx                    = context.Background()
+			loginLimiter, teardown = c.NewLoginLimiter(policy)
+		)
+		t.Cleanup(teardown)
+
+		for _, email := range []string{"a@example.com", "b@example.com", "c@example.com", "d@example.com", "e@example.com"} {
+			if _, err := loginLimiter.Fail(ctx, "10.0.0.9", email); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		if err := loginLimiter.Succeed(ctx, "10.0.0.9", "erin@example.com"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		decision, err := loginLimiter.Check(ctx, "10.0.0.9", "f@example.com")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if decision.Allowed {
+			t.Errorf("expected the ip to stay limited but got: %+v", decision)
+		}
+	})
+
+	t.Run("count the account whatever the case of its email", func(t *testing.T) {
+		var (
+			ctx                    = context.Background()
+			loginLimiter, teardown = c.NewLoginLimiter(policy)
+		)
+		t.Cleanup(teardown)
+
+		for _, email := range []string{"Frank@example.com", "FRANK@EXAMPLE.COM"} {
+			if _, err := loginLimiter.Fail(ctx, "10.0.0.1", email); err != nil {
+				t.Fatalf("unexpected error: %v", err)
 			}
-		})
+		}
 
+		decision, err := loginLimiter.Check(ctx, "10.0.0.2", "frank@example.com")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if decision.Allowed {
+			t.Errorf("expected the account to back off but got: %+v", decision)
+		}
 	})
 }
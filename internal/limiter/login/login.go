@@ -11,56 +11,135 @@ import (
 )
 
 type RedisLoginLimiter struct {
-	Rdb      *redis.Client
-	Limit    int
-	Duration time.Duration
+	Rdb    *redis.Client
+	Policy Policy
 }
 
-func (r *RedisLoginLimiter) CanLogin(ctx context.Context, ip, email string) (bool, error) {
-	var item ItemLoginLimiter
-	err := r.Rdb.HGetAll(ctx, GetRedisKey(ip, email)).Scan(&item)
+type redisAccount struct {
+	Failures int `redis:"failures"`
+	// LockedUntil is unix milliseconds the account backs off until.
+	LockedUntil int64 `redis:"locked_until"`
+	Locked      bool  `redis:"locked"`
+}
+
+func (r *RedisLoginLimiter) Check(ctx context.Context, ip, email string) (Decision, error) {
+
+	var account redisAccount
+	if err := r.Rdb.HGetAll(ctx, GetAccountKey(email)).Scan(&account); err != nil {
+		return Decision{}, err
+	}
+
+	if account.Locked {
+		return Decision{Locked: true}, nil
+	}
+
+	if wait := time.Until(time.UnixMilli(account.LockedUntil)); wait > 0 {
+		return Decision{RetryAfter: wait}, nil
+	}
+
+	failures, err := r.Rdb.Get(ctx, GetIpKey(ip)).Int()
 	if err == redis.Nil {
-		return true, nil
+		return allowed, nil
 	} else if err != nil {
-		return false, err
+		return Decision{}, err
+	}
+
+	if failures < r.Policy.IpLimit {
+		return allowed, nil
+	}
+
+	wait, err := r.Rdb.PTTL(ctx, GetIpKey(ip)).Result()
+	if err != nil {
+		return Decision{}, err
 	}
 
-	return item.AttemptCount < r.Limit, nil
+	return Decision{RetryAfter: max(wait, 0)}, nil
 }
 
-func (r *RedisLoginLimiter) SetAttemptLogin(ctx context.Context, ip, email string, expireHours int) error {
-	key := GetRedisKey(ip, email)
+// failure counts a failed sign in of the ip and the account in one step,
+// so concurrent failures are not lost and every one of them is backed off.
+var failure = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local accountLimit = tonumber(ARGV[3])
+local backoff = tonumber(ARGV[4])
+local maxBackoff = tonumber(ARGV[5])
+local lockoutThreshold = tonumber(ARGV[6])
+
+if redis.call('INCR', KEYS[1]) == 1 then
+	redis.call('PEXPIRE', KEYS[1], window)
+end
+
+local failures = redis.call('HINCRBY', KEYS[2], 'failures', 1)
 
-	exist, err := r.Rdb.Exists(ctx, key).Result()
+if failures >= accountLimit then
+	local wait = backoff
+	for i = accountLimit + 1, failures do
+		wait = wait * 2
+		if maxBackoff > 0 and wait >= maxBackoff then
+			wait = maxBackoff
+			break
+		end
+	end
+	redis.call('HSET', KEYS[2], 'locked_until', now + wait)
+end
+
+-- a locked account stays locked until an admin unlocks it
+if lockoutThreshold > 0 and failures >= lockoutThreshold then
+	redis.call('HSET', KEYS[2], 'locked', 1)
+	redis.call('PERSIST', KEYS[2])
+else
+	redis.call('HSET', KEYS[2], 'locked', 0)
+	redis.call('PEXPIRE', KEYS[2], window)
+end
+
+return failures
+`)
+
+func (r *RedisLoginLimiter) Fail(ctx context.Context, ip, email string) (Decision, error) {
+
+	err := failure.Run(
+		ctx,
+		r.Rdb,
+		[]string{GetIpKey(ip), GetAccountKey(email)},
+		time.Now().UnixMilli(),
+		r.Policy.Window.Milliseconds(),
+		r.Policy.AccountLimit,
+		r.Policy.Backoff.Milliseconds(),
+		r.Policy.MaxBackoff.Milliseconds(),
+		r.Policy.LockoutThreshold,
+	).Err()
 	if err != nil {
-		return err
+		return Decision{}, err
 	}
 
-	if exist == 0 {
-		fields := map[string]any{
-			"email":        email,
-			"attemptCount": 1,
-		}
-		if err := r.Rdb.HSet(ctx, key, fields).Err(); err != nil {
-			return err
-		}
-		return r.Rdb.Expire(ctx, key, time.Duration(expireHours)*r.Duration).Err()
-	}
+	return r.Check(ctx, ip, email)
+}
 
-	if err := r.Rdb.HIncrBy(ctx, key, "attemptCount", 1).Err(); err != nil {
-		return err
-	}
+// Succeed resets the account, the failures of the ip are kept
+// so an ip trying many accounts is still limited after signing in to its own.
+func (r *RedisLoginLimiter) Succeed(ctx context.Context, ip, email string) error {
+	return r.Rdb.Del(ctx, GetAccountKey(email)).Err()
+}
 
-	return nil
+func (r *RedisLoginLimiter) Unlock(ctx context.Context, email string) error {
+	return r.Rdb.Del(ctx, GetAccountKey(email)).Err()
 }
 
-func GetRedisKey(ip, email string) string {
-	emailHash := sha1.Sum([]byte(email))
+// GetAccountKey is the key of the account, emails are case insensitive.
+func GetAccountKey(email string) string {
+	emailHash := sha1.Sum([]byte(strings.ToLower(email)))
 	key := strings.Builder{}
-	key.WriteString("login:")
-	key.WriteString(ip)
-	key.WriteString(":")
+	key.WriteString("login:account:")
 	key.WriteString(hex.EncodeToString(emailHash[:]))
 
 	return key.String()
 }
+
+func GetIpKey(ip string) string {
+	key := strings.Builder{}
+	key.WriteString("login:ip:")
+	key.WriteString(ip)
+
+	return key.String()
+}
@@ -2,35 +2,119 @@ package loginLimiter
 
 import (
 	"context"
-	"reflect"
+	"strings"
+	"sync"
+	"time"
 )
 
+type accountItem struct {
+	Failures    int
+	LockedUntil time.Time
+	Locked      bool
+	ExpireAt    time.Time
+}
+
+type ipItem struct {
+	Failures int
+	ExpireAt time.Time
+}
+
 type InMemoryLoginLimiter struct {
-	Limit          int
-	LoginItemStore map[string]ItemLoginLimiter
+	Policy   Policy
+	mu       sync.Mutex
+	accounts map[string]accountItem
+	ips      map[string]ipItem
 }
 
-func (ll *InMemoryLoginLimiter) CanLogin(_ context.Context, key, email string) (bool, error) {
-	if reflect.DeepEqual(ll.LoginItemStore[key], ItemLoginLimiter{}) || ll.LoginItemStore[key].Email != email {
-		return true, nil
+func (ll *InMemoryLoginLimiter) Check(_ context.Context, ip, email string) (Decision, error) {
+	ll.mu.Lock()
+	defer ll.mu.Unlock()
+
+	return ll.check(ip, email), nil
+}
+
+// check decides with the lock held.
+func (ll *InMemoryLoginLimiter) check(ip, email string) Decision {
+
+	now := time.Now()
+
+	account := ll.account(email, now)
+	if account.Locked {
+		return Decision{Locked: true}
 	}
 
-	return ll.LoginItemStore[key].AttemptCount < ll.Limit, nil
+	if account.LockedUntil.After(now) {
+		return Decision{RetryAfter: account.LockedUntil.Sub(now)}
+	}
+
+	address := ll.ip(ip, now)
+	if address.Failures >= ll.Policy.IpLimit {
+		return Decision{RetryAfter: address.ExpireAt.Sub(now)}
+	}
+
+	return allowed
 }
 
-func (ll *InMemoryLoginLimiter) SetAttemptLogin(_ context.Context, key, email string, _ int) error {
-	item, exists := ll.LoginItemStore[key]
+func (ll *InMemoryLoginLimiter) Fail(_ context.Context, ip, email string) (Decision, error) {
+	ll.mu.Lock()
+	defer ll.mu.Unlock()
 
-	if exists && item.Email == email {
-		item.AttemptCount++
-		ll.LoginItemStore[key] = item
-		return nil
+	if ll.accounts == nil {
+		ll.accounts = map[string]accountItem{}
+		ll.ips = map[string]ipItem{}
 	}
 
-	ll.LoginItemStore[key] = ItemLoginLimiter{
-		Email:        email,
-		AttemptCount: 1,
+	now := time.Now()
+
+	address := ll.ip(ip, now)
+	if address.Failures == 0 {
+		address.ExpireAt = now.Add(ll.Policy.Window)
+	}
+	address.Failures++
+	ll.ips[ip] = address
+
+	account := ll.account(email, now)
+	account.Failures++
+	account.ExpireAt = now.Add(ll.Policy.Window)
+	account.Locked = ll.Policy.locks(account.Failures)
+
+	if wait := ll.Policy.backoff(account.Failures); wait > 0 {
+		account.LockedUntil = now.Add(wait)
 	}
+	ll.accounts[strings.ToLower(email)] = account
+
+	return ll.check(ip, email), nil
+}
+
+func (ll *InMemoryLoginLimiter) Succeed(ctx context.Context, ip, email string) error {
+	return ll.Unlock(ctx, email)
+}
+
+func (ll *InMemoryLoginLimiter) Unlock(_ context.Context, email string) error {
+	ll.mu.Lock()
+	defer ll.mu.Unlock()
+
+	delete(ll.accounts, strings.ToLower(email))
 
 	return nil
 }
+
+func (ll *InMemoryLoginLimiter) account(email string, now time.Time) accountItem {
+
+	account := ll.accounts[strings.ToLower(email)]
+	if !account.Locked && now.After(account.ExpireAt) {
+		return accountItem{}
+	}
+
+	return account
+}
+
+func (ll *InMemoryLoginLimiter) ip(ip string, now time.Time) ipItem {
+
+	address := ll.ips[ip]
+	if now.After(address.ExpireAt) {
+		return ipItem{}
+	}
+
+	return address
+}
@@ -1,35 +1,41 @@
 package loginLimiter_test
 
 import (
+	"context"
+	"fmt"
+	"sync"
 	"testing"
 
 	loginLimiter "github.com/faizisyellow/indocoffee/internal/limiter/login"
 )
 
 func TestLoginLimiterInMemory(t *testing.T) {
-	loginLimiter.Contract{func() (loginLimiter.LoginLimiter, func()) {
+	loginLimiter.Contract{func(policy loginLimiter.Policy) (loginLimiter.LoginLimiter, func()) {
 		tc := &loginLimiter.InMemoryLoginLimiter{
-			Limit: 3,
-			LoginItemStore: map[string]loginLimiter.ItemLoginLimiter{
-				"u123": {
-					Email:        "alice@example.com",
-					AttemptCount: 2,
-				},
-				"x9ab": {
-					Email:        "bob@example.com",
-					AttemptCount: 1,
-				},
-				"z77y": {
-					Email:        "charlie@example.com",
-					AttemptCount: 3,
-				},
-			},
+			Policy: policy,
 		}
 
-		dropItems := func() {
-			tc.LoginItemStore = nil
-		}
-
-		return tc, dropItems
+		return tc, func() {}
 	}}.Test(t)
 }
+
+func TestLoginLimiterInMemoryConcurrently(t *testing.T) {
+	tc := &loginLimiter.InMemoryLoginLimiter{Policy: loginLimiter.DefaultPolicy}
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			email := fmt.Sprintf("lizzy%d@test.test", i%5)
+			if _, err := tc.Fail(context.Background(), "10.0.0.1", email); err != nil {
+				t.Error(err)
+			}
+			if err := tc.Succeed(context.Background(), "10.0.0.1", email); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
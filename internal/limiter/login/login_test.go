@@ -2,11 +2,9 @@ package loginLimiter_test
 
 import (
 	"context"
-	"fmt"
 	"os"
 	"strconv"
 	"testing"
-	"time"
 
 	loginLimiter "github.com/faizisyellow/indocoffee/internal/limiter/login"
 	"github.com/redis/go-redis/v9"
@@ -18,20 +16,15 @@ func TestRedisLoginLimiter(t *testing.T) {
 	}
 
 	loginLimiter.Contract{
-		NewLoginLimiter: func() (loginLimiter.LoginLimiter, func()) {
+		NewLoginLimiter: func(policy loginLimiter.Policy) (loginLimiter.LoginLimiter, func()) {
 			rdb, err := setupTestRedis(t)
 			if err != nil {
 				t.Fatal(err)
 			}
 
-			if err := setUpInitialData(rdb); err != nil {
-				t.Fatal(err)
-			}
-
 			redisLoginLimiter := loginLimiter.RedisLoginLimiter{
-				Rdb:      rdb,
-				Limit:    3,
-				Duration: time.Second,
+				Rdb:    rdb,
+				Policy: policy,
 			}
 
 			cleanup := func() {
@@ -72,37 +65,6 @@ func setupTestRedis(t *testing.T) (*redis.Client, error) {
 	}), nil
 }
 
-func setUpInitialData(rdb *redis.Client) error {
-	ctx := context.Background()
-
-	initialData := map[string]loginLimiter.ItemLoginLimiter{
-		loginLimiter.GetRedisKey("u123", "alice@example.com"): {
-			Email:        "alice@example.com",
-			AttemptCount: 2,
-		},
-		loginLimiter.GetRedisKey("x9ab", "bob@example.com"): {
-			Email:        "bob@example.com",
-			AttemptCount: 1,
-		},
-		loginLimiter.GetRedisKey("z77y", "charlie@example.com"): {
-			Email:        "charlie@example.com",
-			AttemptCount: 3,
-		},
-	}
-
-	for key, item := range initialData {
-		err := rdb.HSet(ctx, key, map[string]any{
-			"email":        item.Email,
-			"attemptCount": item.AttemptCount,
-		}).Err()
-		if err != nil {
-			return fmt.Errorf("failed to seed key %s: %w", key, err)
-		}
-	}
-
-	return nil
-}
-
 func getEnvironment(t *testing.T) string {
 	t.Helper()
 
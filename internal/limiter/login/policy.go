@@ -0,0 +1,108 @@
+package loginLimiter
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Policy decides how failed sign ins are limited.
+// Failures are counted per account and per ip separately,
+// so neither many ips on one account nor one ip on many accounts get through.
+type Policy struct {
+	// IpLimit is how many failures from one ip are allowed within Window.
+	IpLimit int
+	// AccountLimit is how many failures of one account are allowed before backing off.
+	AccountLimit int
+	// Backoff is the first wait once AccountLimit is reached,
+	// it doubles on every further failure up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// LockoutThreshold locks the account until an admin unlocks it, 0 never locks.
+	LockoutThreshold int
+	// Window is how long failures are remembered.
+	Window time.Duration
+}
+
+var DefaultPolicy = Policy{
+	IpLimit:          20,
+	AccountLimit:     3,
+	Backoff:          30 * time.Second,
+	MaxBackoff:       15 * time.Minute,
+	LockoutThreshold: 10,
+	Window:           12 * time.Hour,
+}
+
+// PolicyFromEnv reads the policy from the LOGIN_* environment variables,
+// the ones that are not set keep the value of DefaultPolicy.
+func PolicyFromEnv() (Policy, error) {
+
+	policy := DefaultPolicy
+
+	ints := map[string]*int{
+		"LOGIN_IP_LIMIT":          &policy.IpLimit,
+		"LOGIN_ACCOUNT_LIMIT":     &policy.AccountLimit,
+		"LOGIN_LOCKOUT_THRESHOLD": &policy.LockoutThreshold,
+	}
+	for name, value := range ints {
+		if env := os.Getenv(name); env != "" {
+			parsed, err := strconv.Atoi(env)
+			if err != nil {
+				return Policy{}, fmt.Errorf("%v: %w", name, err)
+			}
+			*value = parsed
+		}
+	}
+
+	durations := map[string]*time.Duration{
+		"LOGIN_BACKOFF":     &policy.Backoff,
+		"LOGIN_MAX_BACKOFF": &policy.MaxBackoff,
+		"LOGIN_WINDOW":      &policy.Window,
+	}
+	for name, value := range durations {
+		if env := os.Getenv(name); env != "" {
+			parsed, err := time.ParseDuration(env)
+			if err != nil {
+				return Policy{}, fmt.Errorf("%v: %w", name, err)
+			}
+			*value = parsed
+		}
+	}
+
+	return policy, nil
+}
+
+// Decision tells whether a sign in may be tried.
+type Decision struct {
+	Allowed bool
+	// RetryAfter is how long to wait before trying again.
+	RetryAfter time.Duration
+	// Locked is true when only an admin can allow the account again.
+	Locked bool
+}
+
+var allowed = Decision{Allowed: true}
+
+// backoff returns how long the account waits after its nth failure.
+func (p Policy) backoff(failures int) time.Duration {
+
+	if failures < p.AccountLimit {
+		return 0
+	}
+
+	wait := p.Backoff
+	for i := p.AccountLimit; i < failures; i++ {
+		wait *= 2
+		if p.MaxBackoff > 0 && wait >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+
+	return wait
+}
+
+// locks tells whether the nth failure locks the account.
+func (p Policy) locks(failures int) bool {
+	return p.LockoutThreshold > 0 && failures >= p.LockoutThreshold
+}
@@ -0,0 +1,47 @@
+package loginLimiter_test
+
+import (
+	"testing"
+	"time"
+
+	loginLimiter "github.com/faizisyellow/indocoffee/internal/limiter/login"
+)
+
+func TestPolicyFromEnv(t *testing.T) {
+	t.Run("keep the default policy", func(t *testing.T) {
+		policy, err := loginLimiter.PolicyFromEnv()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if policy != loginLimiter.DefaultPolicy {
+			t.Errorf("expected the default policy but got: %+v", policy)
+		}
+	})
+
+	t.Run("override from the environment", func(t *testing.T) {
+		t.Setenv("LOGIN_ACCOUNT_LIMIT", "5")
+		t.Setenv("LOGIN_BACKOFF", "1m")
+
+		policy, err := loginLimiter.PolicyFromEnv()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := loginLimiter.DefaultPolicy
+		expected.AccountLimit = 5
+		expected.Backoff = time.Minute
+
+		if policy != expected {
+			t.Errorf("expected %+v but got: %+v", expected, policy)
+		}
+	})
+
+	t.Run("reject a malformed value", func(t *testing.T) {
+		t.Setenv("LOGIN_WINDOW", "forever")
+
+		if _, err := loginLimiter.PolicyFromEnv(); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
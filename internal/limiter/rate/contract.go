@@ -0,0 +1,121 @@
+package rateLimiter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type RateLimiter interface {
+	// Allow counts a request of the key and tells whether it is within the limit.
+	// Rejected requests are not counted.
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Limit is how many requests are allowed within any sliding Window.
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until a counted request leaves the window.
+	Reset time.Duration
+	// RetryAfter is how long to wait before trying again, 0 when allowed.
+	RetryAfter time.Duration
+}
+
+type Contract struct {
+	NewRateLimiter func() (RateLimiter, func())
+}
+
+func (c Contract) Test(t *testing.T) {
+	limit := Limit{Requests: 3, Window: 300 * time.Millisecond}
+
+	t.Run("allow requests within the limit", func(t *testing.T) {
+		var (
+			ctx                   = context.Background()
+			rateLimiter, teardown = c.NewRateLimiter()
+		)
+		t.Cleanup(teardown)
+
+		for i := range limit.Requests {
+			result, err := rateLimiter.Allow(ctx, "user:1", limit)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !result.Allowed {
+				t.Fatalf("request %v: expected to be allowed", i+1)
+			}
+
+			if result.Limit != limit.Requests || result.Remaining != limit.Requests-i-1 {
+				t.Errorf("request %v: expected %v remaining of %v but got: %+v", i+1, limit.Requests-i-1, limit.Requests, result)
+			}
+
+			if result.Reset <= 0 || result.Reset > limit.Window {
+				t.Errorf("request %v: expected reset within the window but got %v", i+1, result.Reset)
+			}
+		}
+	})
+
+	t.Run("reject requests over the limit until the window slides", func(t *testing.T) {
+		var (
+			ctx                   = context.Background()
+			rateLimiter, teardown = c.NewRateLimiter()
+		)
+		t.Cleanup(teardown)
+
+		for range limit.Requests {
+			if _, err := rateLimiter.Allow(ctx, "user:2", limit); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		result, err := rateLimiter.Allow(ctx, "user:2", limit)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if result.Allowed || result.Remaining != 0 || result.RetryAfter <= 0 || result.RetryAfter > limit.Window {
+			t.Fatalf("expected request to be rejected but got: %+v", result)
+		}
+
+		time.Sleep(result.RetryAfter + 50*time.Millisecond)
+
+		result, err = rateLimiter.Allow(ctx, "user:2", limit)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !result.Allowed {
+			t.Errorf("expected request to be allowed after the window slid but got: %+v", result)
+		}
+	})
+
+	t.Run("count every key apart", func(t *testing.T) {
+		var (
+			ctx                   = context.Background()
+			rateLimiter, teardown = c.NewRateLimiter()
+		)
+		t.Cleanup(teardown)
+
+		for range limit.Requests {
+			if _, err := rateLimiter.Allow(ctx, "user:3", limit); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		result, err := rateLimiter.Allow(ctx, "user:4", limit)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !result.Allowed || result.Remaining != limit.Requests-1 {
+			t.Errorf("expected another key to be allowed but got: %+v", result)
+		}
+	})
+}
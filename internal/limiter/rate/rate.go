@@ -0,0 +1,83 @@
+package rateLimiter
+
+import (
+	"context"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type RedisRateLimiter struct {
+	Rdb *redis.Client
+}
+
+// slidingWindow keeps a sorted set of the request times of a key,
+// counting and adding in one step so replicas share the window.
+var slidingWindow = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+
+local count = redis.call('ZCARD', KEYS[1])
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', KEYS[1], now, ARGV[4])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', KEYS[1], window)
+
+local reset = 0
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+
+return {allowed, count, reset}
+`)
+
+func (r *RedisRateLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+
+	now := time.Now().UnixMilli()
+	// requests of the same millisecond are still counted apart
+	member := strconv.FormatInt(now, 10) + "-" + strconv.FormatUint(rand.Uint64(), 36)
+
+	values, err := slidingWindow.Run(
+		ctx,
+		r.Rdb,
+		[]string{GetRedisKey(key)},
+		now,
+		limit.Window.Milliseconds(),
+		limit.Requests,
+		member,
+	).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+
+	result := Result{
+		Allowed:   values[0] == 1,
+		Limit:     limit.Requests,
+		Remaining: limit.Requests - int(values[1]),
+		Reset:     time.Duration(values[2]) * time.Millisecond,
+	}
+
+	if !result.Allowed {
+		result.RetryAfter = result.Reset
+	}
+
+	return result, nil
+}
+
+func GetRedisKey(key string) string {
+	redisKey := strings.Builder{}
+	redisKey.WriteString("rate:")
+	redisKey.WriteString(key)
+
+	return redisKey.String()
+}
@@ -0,0 +1,52 @@
+package rateLimiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type InMemoryRateLimiter struct {
+	mu       sync.Mutex
+	requests map[string][]time.Time
+}
+
+func (rl *InMemoryRateLimiter) Allow(_ context.Context, key string, limit Limit) (Result, error) {
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if rl.requests == nil {
+		rl.requests = map[string][]time.Time{}
+	}
+
+	now := time.Now()
+
+	// forget the requests that left the window
+	requests := rl.requests[key]
+	for len(requests) > 0 && !requests[0].After(now.Add(-limit.Window)) {
+		requests = requests[1:]
+	}
+
+	allowed := len(requests) < limit.Requests
+	if allowed {
+		requests = append(requests, now)
+	}
+	rl.requests[key] = requests
+
+	result := Result{
+		Allowed:   allowed,
+		Limit:     limit.Requests,
+		Remaining: limit.Requests - len(requests),
+	}
+
+	if len(requests) > 0 {
+		result.Reset = requests[0].Add(limit.Window).Sub(now)
+	}
+
+	if !allowed {
+		result.RetryAfter = result.Reset
+	}
+
+	return result, nil
+}
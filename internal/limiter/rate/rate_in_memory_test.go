@@ -0,0 +1,13 @@
+package rateLimiter_test
+
+import (
+	"testing"
+
+	rateLimiter "github.com/faizisyellow/indocoffee/internal/limiter/rate"
+)
+
+func TestRateLimiterInMemory(t *testing.T) {
+	rateLimiter.Contract{func() (rateLimiter.RateLimiter, func()) {
+		return &rateLimiter.InMemoryRateLimiter{}, func() {}
+	}}.Test(t)
+}
@@ -0,0 +1,71 @@
+package rateLimiter_test
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+
+	rateLimiter "github.com/faizisyellow/indocoffee/internal/limiter/rate"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRedisRateLimiter(t *testing.T) {
+	if getEnvironment(t) != "development" {
+		t.Skip("skipping test: only runs in development environment")
+	}
+
+	rateLimiter.Contract{
+		NewRateLimiter: func() (rateLimiter.RateLimiter, func()) {
+			rdb, err := setupTestRedis(t)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			redisRateLimiter := rateLimiter.RedisRateLimiter{
+				Rdb: rdb,
+			}
+
+			cleanup := func() {
+				ctx := context.Background()
+				defer rdb.Close()
+
+				iter := rdb.Scan(ctx, 0, "rate:*", 0).Iterator()
+				for iter.Next(ctx) {
+					if err := rdb.Del(ctx, iter.Val()).Err(); err != nil {
+						t.Errorf("failed to delete redis key %q: %v", iter.Val(), err)
+					}
+				}
+
+				if err := iter.Err(); err != nil {
+					t.Errorf("redis scan error: %v", err)
+				}
+			}
+
+			return &redisRateLimiter, cleanup
+		},
+	}.Test(t)
+
+}
+
+func setupTestRedis(t *testing.T) (*redis.Client, error) {
+	t.Helper()
+
+	db, err := strconv.Atoi(os.Getenv("REDIS_DB"))
+	if err != nil {
+		return nil, err
+	}
+
+	return redis.NewClient(&redis.Options{
+		Addr:     os.Getenv("REDIS_ADDR"),
+		Username: os.Getenv("REDIS_USERNAME"),
+		Password: os.Getenv("REDIS_PW"),
+		DB:       db,
+	}), nil
+}
+
+func getEnvironment(t *testing.T) string {
+	t.Helper()
+
+	return os.Getenv("ENV")
+}
@@ -0,0 +1,82 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/mailer"
+)
+
+// InMemoryMailer keeps every sent message, it is meant for tests.
+type InMemoryMailer struct {
+	mu       sync.Mutex
+	Messages []mailer.Message
+}
+
+func (m *InMemoryMailer) Send(ctx context.Context, to string, templateFile string, data any) error {
+
+	msg, err := mailer.Render(to, templateFile, data)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Messages = append(m.Messages, msg)
+
+	return nil
+}
+
+// Last returns the last message sent to the recipient.
+func (m *InMemoryMailer) Last(to string) (mailer.Message, bool) {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := len(m.Messages) - 1; i >= 0; i-- {
+		if m.Messages[i].To == to {
+			return m.Messages[i], true
+		}
+	}
+
+	return mailer.Message{}, false
+}
+
+// FileMailer writes every message as a text file to a directory
+// instead of sending it, so emails can be read during development.
+type FileMailer struct {
+	Dir string
+}
+
+func (f *FileMailer) Send(ctx context.Context, to string, templateFile string, data any) error {
+
+	msg, err := mailer.Render(to, templateFile, data)
+	if err != nil {
+		return err
+	}
+
+	saveDir := f.Dir
+	if saveDir == "" {
+		saveDir = "."
+	}
+
+	if err := os.MkdirAll(saveDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	name := fmt.Sprintf("%v_%v_%v.txt", time.Now().UnixNano(), strings.TrimSuffix(templateFile, ".tmpl"), to)
+
+	content := fmt.Sprintf("To: %v\nSubject: %v\n\n%v", msg.To, msg.Subject, msg.PlainBody)
+
+	if err := os.WriteFile(filepath.Join(saveDir, name), []byte(content), 0o644); err != nil {
+		return fmt.Errorf("failed to write mail: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"html/template"
+	"strings"
+	textTemplate "text/template"
+)
+
+const (
+	ACTIVATION_TEMPLATE         = "activation.tmpl"
+	PASSWORD_RESET_TEMPLATE     = "password_reset.tmpl"
+	EMAIL_CHANGE_TEMPLATE       = "email_change.tmpl"
+	ORDER_CONFIRMATION_TEMPLATE = "order_confirmation.tmpl"
+	ORDER_SHIPPED_TEMPLATE      = "order_shipped.tmpl"
+	ORDER_CANCELLED_TEMPLATE    = "order_cancelled.tmpl"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+type Mailer interface {
+	Send(ctx context.Context, to string, templateFile string, data any) error
+}
+
+type Message struct {
+	To        string
+	Subject   string
+	PlainBody string
+	HtmlBody  string
+}
+
+// Render executes the subject, plainBody and htmlBody blocks of a template.
+// Returns the message ready to be sent or an error on failure.
+func Render(to string, templateFile string, data any) (Message, error) {
+
+	path := "templates/" + templateFile
+
+	tmpl, err := textTemplate.New("email").ParseFS(templateFS, path)
+	if err != nil {
+		return Message{}, err
+	}
+
+	subject := new(bytes.Buffer)
+	if err := tmpl.ExecuteTemplate(subject, "subject", data); err != nil {
+		return Message{}, err
+	}
+
+	plainBody := new(bytes.Buffer)
+	if err := tmpl.ExecuteTemplate(plainBody, "plainBody", data); err != nil {
+		return Message{}, err
+	}
+
+	// html body is escaped against the data
+	htmlTmpl, err := template.New("email").ParseFS(templateFS, path)
+	if err != nil {
+		return Message{}, err
+	}
+
+	htmlBody := new(bytes.Buffer)
+	if err := htmlTmpl.ExecuteTemplate(htmlBody, "htmlBody", data); err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		To:        to,
+		Subject:   strings.TrimSpace(subject.String()),
+		PlainBody: plainBody.String(),
+		HtmlBody:  htmlBody.String(),
+	}, nil
+}
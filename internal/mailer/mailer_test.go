@@ -0,0 +1,62 @@
+package mailer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/faizisyellow/indocoffee/internal/mailer"
+	"github.com/faizisyellow/indocoffee/internal/models"
+)
+
+func TestRender(t *testing.T) {
+	order := models.Order{
+		Id:           "ORD-01JORDER",
+		CustomerName: "<lizzy>",
+		Street:       "Jl. Kopi",
+		City:         "Bandung",
+		TotalPrice:   120000,
+		Items: []models.OrderItem{
+			{BeanName: "Arabica", FormName: "Whole Bean", Roasted: "medium", Price: 60000, OrderQuantity: 2},
+		},
+	}
+
+	token := struct {
+		Username string
+		Token    string
+		ExpireIn string
+	}{"<lizzy>", "the-token", "24 hours"}
+
+	tests := []struct {
+		template string
+		data     any
+		expected string
+	}{
+		{mailer.ACTIVATION_TEMPLATE, token, "the-token"},
+		{mailer.PASSWORD_RESET_TEMPLATE, token, "the-token"},
+		{mailer.EMAIL_CHANGE_TEMPLATE, token, "the-token"},
+		{mailer.ORDER_CONFIRMATION_TEMPLATE, order, "120000.00"},
+		{mailer.ORDER_SHIPPED_TEMPLATE, order, "ORD-01JORDER"},
+		{mailer.ORDER_CANCELLED_TEMPLATE, order, "ORD-01JORDER"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			msg, err := mailer.Render("lizzy@test.test", tt.template, tt.data)
+			if err != nil {
+				t.Fatalf("should not be error but got: %v", err)
+			}
+
+			if msg.Subject == "" || strings.Contains(msg.Subject, "\n") {
+				t.Errorf("unexpected subject: %q", msg.Subject)
+			}
+
+			if !strings.Contains(msg.PlainBody, tt.expected) || !strings.Contains(msg.HtmlBody, tt.expected) {
+				t.Errorf("expected both bodies to contain %q", tt.expected)
+			}
+
+			if strings.Contains(msg.HtmlBody, "<lizzy>") {
+				t.Error("expected html body to escape the data")
+			}
+		})
+	}
+}
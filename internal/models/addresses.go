@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+type Address struct {
+	Id            int       `json:"id"`
+	UserId        int       `json:"user_id"`
+	Label         string    `json:"label"`
+	RecipientName string    `json:"recipient_name"`
+	PhoneNumber   string    `json:"phone_number"`
+	Street        string    `json:"street"`
+	City          string    `json:"city"`
+	Province      string    `json:"province"`
+	PostalCode    string    `json:"postal_code"`
+	Notes         *string   `json:"notes"`
+	IsDefault     bool      `json:"is_default"`
+	CreatedAt     time.Time `json:"created_at"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// ApiKey lets an integration call the API as a service principal,
+// it is allowed whatever its role is allowed.
+type ApiKey struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+	// Prefix is the start of the plaintext key, to tell keys apart.
+	Prefix     string      `json:"prefix"`
+	Hash       string      `json:"-"`
+	RoleId     int         `json:"role_id"`
+	CreatedBy  *int        `json:"created_by"`
+	ExpireAt   *time.Time  `json:"expire_at"`
+	LastUsedAt *time.Time  `json:"last_used_at"`
+	RevokedAt  *time.Time  `json:"revoked_at"`
+	CreatedAt  time.Time   `json:"created_at"`
+	Role       *RolesModel `json:"role,omitempty"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditLog records who did a privileged action to what.
+type AuditLog struct {
+	Id int `json:"id"`
+	// ActorId is nil when the action was done with an api key.
+	ActorId    *int   `json:"actor_id"`
+	ActorName  string `json:"actor_name"`
+	ApiKeyId   *int   `json:"api_key_id"`
+	Action     string `json:"action"`
+	TargetType string `json:"target_type"`
+	TargetId   string `json:"target_id"`
+	// Before and After hold only the fields the action changed.
+	Before    json.RawMessage `json:"before" swaggertype:"object"`
+	After     json.RawMessage `json:"after" swaggertype:"object"`
+	RequestId string          `json:"request_id"`
+	CreatedAt time.Time       `json:"created_at"`
+}
@@ -6,7 +6,9 @@ import (
 
 type Cart struct {
 	Id        int       `json:"id"`
+	VariantId int       `json:"variant_id"`
 	ProductId int       `json:"product_id"`
+	Variant   Variant   `json:"variant"`
 	Product   Product   `json:"product"`
 	UserId    int       `json:"user_id"`
 	Quantity  int       `json:"quantity"`
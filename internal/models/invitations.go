@@ -3,7 +3,11 @@ package models
 import "time"
 
 type InvitationModel struct {
-	UserId   int           `json:"user_id"`
-	Token    string        `json:"token"`
-	ExpireAt time.Duration `json:"expire_at"`
+	UserId    int           `json:"user_id"`
+	Token     string        `json:"token"`
+	ExpireAt  time.Duration `json:"expire_at"`
+	CreatedAt time.Time     `json:"created_at"`
+	// Email is the new address of the user waiting to be confirmed,
+	// empty when the invitation activates the account.
+	Email string `json:"email,omitempty"`
 }
@@ -17,12 +17,19 @@ type Order struct {
 	AlternativePhoneNumber *string     `json:"alternative_phone_number"`
 	Street                 string      `json:"street"`
 	City                   string      `json:"city"`
+	Province               string      `json:"province"`
+	PostalCode             string      `json:"postal_code"`
+	DeliveryNotes          *string     `json:"delivery_notes"`
 	CreatedAt              time.Time   `json:"created_at"`
 	CartIds                []int       `json:"order_ids"`
 }
 
 type OrderItem struct {
+	// Id is the id of the product.
 	Id            int     `json:"id"`
+	VariantId     int     `json:"variant_id"`
+	Sku           string  `json:"sku"`
+	Weight        int     `json:"weight"`
 	Image         string  `json:"image"`
 	BeanName      string  `json:"bean_name"`
 	FormName      string  `json:"form_name"`
@@ -1,13 +1,51 @@
 package models
 
 type Product struct {
-	Id         int     `json:"id"`
-	Roasted    string  `json:"roasted"`
-	Price      float64 `json:"price"`
-	Quantity   int     `json:"quantity"`
-	Image      string  `json:"image"`
-	BeanId     int     `json:"bean_id"`
-	FormId     int     `json:"form_id"`
-	BeansModel `json:"bean"`
-	FormsModel `json:"form"`
+	Id          int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Origin      string `json:"origin"`
+	// Process is washed, natural or honey, empty when unknown.
+	Process string `json:"process"`
+	// Altitude is in meters above sea level, 0 when unknown.
+	Altitude     int      `json:"altitude"`
+	TastingNotes []string `json:"tasting_notes"`
+	Roasted      string   `json:"roasted"`
+	// Image is the url of the primary image.
+	Image string `json:"image"`
+	// ImageRenditions are the urls of the resized copies of the primary image.
+	ImageRenditions map[string]string `json:"image_renditions"`
+	BeanId          int               `json:"bean_id"`
+	FormId          int               `json:"form_id"`
+	Variants        []Variant         `json:"variants"`
+	Images          []Image           `json:"images"`
+	BeansModel      `json:"bean"`
+	FormsModel      `json:"form"`
+	// Snippet is the text that matched a search with the matched words in <mark>, empty without a search.
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// Variant is a bag of a product that is sold on its own,
+// with its own price and stock.
+type Variant struct {
+	Id        int    `json:"id"`
+	ProductId int    `json:"product_id"`
+	Sku       string `json:"sku"`
+	// Weight is in grams.
+	Weight   int     `json:"weight"`
+	Price    float64 `json:"price"`
+	Quantity int     `json:"quantity"`
+}
+
+// Image is an image in the gallery of a product.
+type Image struct {
+	Id        int    `json:"id"`
+	ProductId int    `json:"product_id"`
+	Url       string `json:"url"`
+	// Renditions are the urls of the resized copies keyed by rendition name,
+	// Url is the url of the largest one.
+	Renditions map[string]string `json:"renditions"`
+	// Position orders the gallery, lowest first.
+	Position  int  `json:"position"`
+	IsPrimary bool `json:"is_primary"`
 }
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// PasswordReset is a single-use token for a user to set a new password.
+// Only the hash of the token is stored.
+type PasswordReset struct {
+	UserId   int       `json:"user_id"`
+	Token    string    `json:"-"`
+	ExpireAt time.Time `json:"expire_at"`
+}
@@ -1,7 +1,14 @@
 package models
 
 type RolesModel struct {
-	Id    int    `json:"id"`
-	Name  string `json:"name"`
-	Level int    `json:"level"`
+	Id          int      `json:"id"`
+	Name        string   `json:"name"`
+	Level       int      `json:"level"`
+	Permissions []string `json:"permissions"`
+}
+
+type PermissionModel struct {
+	Id          int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
 }
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Session is a sign in of a user, every refresh token
+// rotated from the same sign in belongs to the same session.
+type Session struct {
+	Id        string     `json:"id"`
+	UserId    int        `json:"user_id"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type RefreshToken struct {
+	Token     string     `json:"-"`
+	SessionId string     `json:"session_id"`
+	ExpireAt  time.Time  `json:"expire_at"`
+	UsedAt    *time.Time `json:"used_at"`
+}
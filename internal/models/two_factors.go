@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// TwoFactor is the TOTP enrollment of a user, it only guards
+// the sign in once EnabledAt is set by confirming the first code.
+type TwoFactor struct {
+	UserId int    `json:"user_id"`
+	Secret string `json:"-"`
+	// LastStep is the time step of the last accepted code,
+	// a code can not be used twice.
+	LastStep  int64      `json:"-"`
+	EnabledAt *time.Time `json:"enabled_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TwoFactorChallenge is the pending second step of a sign in.
+type TwoFactorChallenge struct {
+	Token    string    `json:"-"`
+	UserId   int       `json:"user_id"`
+	Attempts int       `json:"attempts"`
+	ExpireAt time.Time `json:"expire_at"`
+}
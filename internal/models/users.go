@@ -17,6 +17,9 @@ type User struct {
 	CreatedAt time.Time   `json:"created_at"`
 	Carts     []Cart      `json:"carts"`
 	Role      *RolesModel `json:"role"`
+	// DeactivatedAt is set when an admin deactivated the account,
+	// such user can not activate it again by itself.
+	DeactivatedAt *time.Time `json:"deactivated_at"`
 }
 
 type Hashed struct {
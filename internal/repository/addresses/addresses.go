@@ -0,0 +1,189 @@
+package addresses
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/faizisyellow/indocoffee/internal/models"
+	"github.com/faizisyellow/indocoffee/internal/repository"
+)
+
+// This is Addresses repository to access users' saved addresses
+// From database.
+type AddressesRepository struct {
+	Db *sql.DB
+}
+
+// Insert inserts new address to database.
+// Returns the address's id and nil on success, or -1 and an error on failure.
+func (a *AddressesRepository) Insert(ctx context.Context, tx *sql.Tx, addr models.Address) (int, error) {
+
+	query := `
+	INSERT INTO addresses(user_id,label,recipient_name,phone_number,street,city,province,postal_code,notes,is_default)
+	VALUES(?,?,?,?,?,?,?,?,?,?)
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	res, err := tx.ExecContext(
+		ctx,
+		query,
+		addr.UserId,
+		addr.Label,
+		addr.RecipientName,
+		addr.PhoneNumber,
+		addr.Street,
+		addr.City,
+		addr.Province,
+		addr.PostalCode,
+		addr.Notes,
+		addr.IsDefault,
+	)
+	if err != nil {
+		return -1, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return -1, err
+	}
+
+	return int(id), nil
+}
+
+// GetById gets an address by its id.
+// Returns sql.ErrNoRows if there is no such address.
+func (a *AddressesRepository) GetById(ctx context.Context, id int) (models.Address, error) {
+
+	query := `
+		SELECT id,user_id,label,recipient_name,phone_number,street,city,province,postal_code,notes,is_default,created_at
+		FROM addresses WHERE id = ?
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	var addr models.Address
+
+	err := a.Db.QueryRowContext(ctx, query, id).Scan(
+		&addr.Id,
+		&addr.UserId,
+		&addr.Label,
+		&addr.RecipientName,
+		&addr.PhoneNumber,
+		&addr.Street,
+		&addr.City,
+		&addr.Province,
+		&addr.PostalCode,
+		&addr.Notes,
+		&addr.IsDefault,
+		&addr.CreatedAt,
+	)
+	if err != nil {
+		return models.Address{}, err
+	}
+
+	return addr, nil
+}
+
+// GetByUserId gets every address of the user, the default address first.
+func (a *AddressesRepository) GetByUserId(ctx context.Context, usrId int) ([]models.Address, error) {
+
+	query := `
+		SELECT id,user_id,label,recipient_name,phone_number,street,city,province,postal_code,notes,is_default,created_at
+		FROM addresses WHERE user_id = ?
+		ORDER BY is_default DESC, id ASC
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	rows, err := a.Db.QueryContext(ctx, query, usrId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var addresses []models.Address
+
+	for rows.Next() {
+		var addr models.Address
+
+		if err := rows.Scan(
+			&addr.Id,
+			&addr.UserId,
+			&addr.Label,
+			&addr.RecipientName,
+			&addr.PhoneNumber,
+			&addr.Street,
+			&addr.City,
+			&addr.Province,
+			&addr.PostalCode,
+			&addr.Notes,
+			&addr.IsDefault,
+			&addr.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		addresses = append(addresses, addr)
+	}
+
+	return addresses, rows.Err()
+}
+
+// Update updates an address, ensure addr has id.
+func (a *AddressesRepository) Update(ctx context.Context, tx *sql.Tx, addr models.Address) error {
+
+	query := `
+		UPDATE addresses
+		SET label = ?, recipient_name = ?, phone_number = ?, street = ?, city = ?, province = ?, postal_code = ?, notes = ?, is_default = ?
+		WHERE id = ?
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	_, err := tx.ExecContext(
+		ctx,
+		query,
+		addr.Label,
+		addr.RecipientName,
+		addr.PhoneNumber,
+		addr.Street,
+		addr.City,
+		addr.Province,
+		addr.PostalCode,
+		addr.Notes,
+		addr.IsDefault,
+		addr.Id,
+	)
+
+	return err
+}
+
+func (a *AddressesRepository) Delete(ctx context.Context, tx *sql.Tx, id int) error {
+
+	query := `DELETE FROM addresses WHERE id = ?`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, id)
+
+	return err
+}
+
+// UnsetDefault makes none of the user's addresses the default one.
+func (a *AddressesRepository) UnsetDefault(ctx context.Context, tx *sql.Tx, usrId int) error {
+
+	query := `UPDATE addresses SET is_default = 0 WHERE user_id = ?`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, usrId)
+
+	return err
+}
@@ -0,0 +1,95 @@
+package addresses
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/models"
+)
+
+type InMemoryAddresses struct {
+	Addresses []models.Address
+}
+
+func (a *InMemoryAddresses) Insert(ctx context.Context, _ *sql.Tx, addr models.Address) (int, error) {
+
+	nextID := 1
+	for _, address := range a.Addresses {
+		if address.Id >= nextID {
+			nextID = address.Id + 1
+		}
+	}
+
+	addr.Id = nextID
+	addr.CreatedAt = time.Now()
+	a.Addresses = append(a.Addresses, addr)
+
+	return addr.Id, nil
+}
+
+func (a *InMemoryAddresses) GetById(ctx context.Context, id int) (models.Address, error) {
+
+	for _, address := range a.Addresses {
+		if address.Id == id {
+			return address, nil
+		}
+	}
+
+	return models.Address{}, sql.ErrNoRows
+}
+
+func (a *InMemoryAddresses) GetByUserId(ctx context.Context, usrId int) ([]models.Address, error) {
+
+	var result []models.Address
+	for _, address := range a.Addresses {
+		if address.UserId == usrId {
+			result = append(result, address)
+		}
+	}
+
+	slices.SortStableFunc(result, func(x, y models.Address) int {
+		switch {
+		case x.IsDefault && !y.IsDefault:
+			return -1
+		case !x.IsDefault && y.IsDefault:
+			return 1
+		default:
+			return x.Id - y.Id
+		}
+	})
+
+	return result, nil
+}
+
+func (a *InMemoryAddresses) Update(ctx context.Context, _ *sql.Tx, addr models.Address) error {
+
+	for i, address := range a.Addresses {
+		if address.Id == addr.Id {
+			a.Addresses[i] = addr
+		}
+	}
+
+	return nil
+}
+
+func (a *InMemoryAddresses) Delete(ctx context.Context, _ *sql.Tx, id int) error {
+
+	a.Addresses = slices.DeleteFunc(a.Addresses, func(address models.Address) bool {
+		return address.Id == id
+	})
+
+	return nil
+}
+
+func (a *InMemoryAddresses) UnsetDefault(ctx context.Context, _ *sql.Tx, usrId int) error {
+
+	for i, address := range a.Addresses {
+		if address.UserId == usrId {
+			a.Addresses[i].IsDefault = false
+		}
+	}
+
+	return nil
+}
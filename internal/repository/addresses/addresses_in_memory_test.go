@@ -0,0 +1,16 @@
+package addresses_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/faizisyellow/indocoffee/internal/repository/addresses"
+)
+
+func TestInMemoryAddresses(t *testing.T) {
+	addresses.Contract{
+		NewAddresses: func() (addresses.Addresses, *sql.Tx, func()) {
+			return &addresses.InMemoryAddresses{}, nil, func() {}
+		},
+	}.Test(t)
+}
@@ -0,0 +1,193 @@
+package addresses
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/faizisyellow/indocoffee/internal/models"
+)
+
+type Addresses interface {
+	Insert(ctx context.Context, tx *sql.Tx, addr models.Address) (int, error)
+	GetById(ctx context.Context, id int) (models.Address, error)
+	GetByUserId(ctx context.Context, usrId int) ([]models.Address, error)
+	Update(ctx context.Context, tx *sql.Tx, addr models.Address) error
+	Delete(ctx context.Context, tx *sql.Tx, id int) error
+	UnsetDefault(ctx context.Context, tx *sql.Tx, usrId int) error
+}
+
+type Contract struct {
+	NewAddresses func() (Addresses, *sql.Tx, func())
+}
+
+func (c Contract) Test(t *testing.T) {
+	newAddress := func(usrId int, label string) models.Address {
+		return models.Address{
+			UserId:        usrId,
+			Label:         label,
+			RecipientName: "lizzy",
+			PhoneNumber:   "+6281234567890",
+			Street:        "Jl. Malioboro No. 52",
+			City:          "Yogyakarta",
+			Province:      "DI Yogyakarta",
+			PostalCode:    "55271",
+		}
+	}
+
+	t.Run("create new address and get it by id", func(t *testing.T) {
+		var (
+			ctx                    = context.Background()
+			addresses, tx, cleanup = c.NewAddresses()
+			initial                = newAddress(1, "home")
+		)
+		t.Cleanup(cleanup)
+
+		id, err := addresses.Insert(ctx, tx, initial)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		result, err := addresses.GetById(ctx, id)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if result.UserId != initial.UserId || result.Street != initial.Street || result.PostalCode != initial.PostalCode {
+			t.Errorf("unexpected address: %+v", result)
+		}
+	})
+
+	t.Run("get address that does not exist", func(t *testing.T) {
+		var (
+			ctx                   = context.Background()
+			addresses, _, cleanup = c.NewAddresses()
+		)
+		t.Cleanup(cleanup)
+
+		if _, err := addresses.GetById(ctx, 404); err != sql.ErrNoRows {
+			t.Errorf("expected sql.ErrNoRows but got: %v", err)
+		}
+	})
+
+	t.Run("get addresses of a user with the default first", func(t *testing.T) {
+		var (
+			ctx                    = context.Background()
+			addresses, tx, cleanup = c.NewAddresses()
+			office                 = newAddress(1, "office")
+		)
+		t.Cleanup(cleanup)
+
+		office.IsDefault = true
+
+		for _, addr := range []models.Address{newAddress(1, "home"), office, newAddress(2, "home")} {
+			if _, err := addresses.Insert(ctx, tx, addr); err != nil {
+				t.Fatalf("should not be error but got: %v", err)
+			}
+		}
+
+		result, err := addresses.GetByUserId(ctx, 1)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if len(result) != 2 {
+			t.Fatalf("expected 2 addresses but got %v", len(result))
+		}
+
+		if result[0].Label != "office" || !result[0].IsDefault {
+			t.Errorf("expected the default address first but got: %+v", result[0])
+		}
+	})
+
+	t.Run("update address", func(t *testing.T) {
+		var (
+			ctx                    = context.Background()
+			addresses, tx, cleanup = c.NewAddresses()
+			notes                  = "leave it at the front desk"
+		)
+		t.Cleanup(cleanup)
+
+		id, err := addresses.Insert(ctx, tx, newAddress(1, "home"))
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		addr, err := addresses.GetById(ctx, id)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		addr.City = "Sleman"
+		addr.Notes = &notes
+
+		if err := addresses.Update(ctx, tx, addr); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		result, err := addresses.GetById(ctx, id)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if result.City != "Sleman" || result.Notes == nil || *result.Notes != notes {
+			t.Errorf("unexpected updated address: %+v", result)
+		}
+	})
+
+	t.Run("unset default addresses of a user", func(t *testing.T) {
+		var (
+			ctx                    = context.Background()
+			addresses, tx, cleanup = c.NewAddresses()
+			mine                   = newAddress(1, "home")
+			other                  = newAddress(2, "home")
+		)
+		t.Cleanup(cleanup)
+
+		mine.IsDefault = true
+		other.IsDefault = true
+
+		mineId, err := addresses.Insert(ctx, tx, mine)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		otherId, err := addresses.Insert(ctx, tx, other)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if err := addresses.UnsetDefault(ctx, tx, 1); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if result, _ := addresses.GetById(ctx, mineId); result.IsDefault {
+			t.Error("expected the user's address not to be default")
+		}
+
+		if result, _ := addresses.GetById(ctx, otherId); !result.IsDefault {
+			t.Error("expected other user's address to stay default")
+		}
+	})
+
+	t.Run("delete address", func(t *testing.T) {
+		var (
+			ctx                    = context.Background()
+			addresses, tx, cleanup = c.NewAddresses()
+		)
+		t.Cleanup(cleanup)
+
+		id, err := addresses.Insert(ctx, tx, newAddress(1, "home"))
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if err := addresses.Delete(ctx, tx, id); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if _, err := addresses.GetById(ctx, id); err != sql.ErrNoRows {
+			t.Errorf("expected sql.ErrNoRows but got: %v", err)
+		}
+	})
+}
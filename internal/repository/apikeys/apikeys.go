@@ -0,0 +1,137 @@
+package apikeys
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/models"
+	"github.com/faizisyellow/indocoffee/internal/repository"
+)
+
+type ApiKeysRepository struct {
+	Db *sql.DB
+}
+
+const apiKeyColumns = `id,name,prefix,hash,role_id,created_by,expire_at,last_used_at,revoked_at,created_at`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanApiKey(row scanner) (models.ApiKey, error) {
+
+	var key models.ApiKey
+
+	err := row.Scan(
+		&key.Id,
+		&key.Name,
+		&key.Prefix,
+		&key.Hash,
+		&key.RoleId,
+		&key.CreatedBy,
+		&key.ExpireAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+		&key.CreatedAt,
+	)
+
+	return key, err
+}
+
+// Insert inserts new api key to database.
+// Returns the api key's id and nil on success, or -1 and an error on failure.
+func (a *ApiKeysRepository) Insert(ctx context.Context, tx *sql.Tx, key models.ApiKey) (int, error) {
+
+	query := `INSERT INTO api_keys(name,prefix,hash,role_id,created_by,expire_at) VALUES(?,?,?,?,?,?)`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	res, err := tx.ExecContext(ctx, query, key.Name, key.Prefix, key.Hash, key.RoleId, key.CreatedBy, key.ExpireAt)
+	if err != nil {
+		return -1, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return -1, err
+	}
+
+	return int(id), nil
+}
+
+// GetById gets an api key by its id.
+// Returns sql.ErrNoRows if there is no such api key.
+func (a *ApiKeysRepository) GetById(ctx context.Context, id int) (models.ApiKey, error) {
+
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE id = ?`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	return scanApiKey(a.Db.QueryRowContext(ctx, query, id))
+}
+
+// GetByHash gets an api key by the hash of the plaintext key.
+// Returns sql.ErrNoRows if there is no such api key.
+func (a *ApiKeysRepository) GetByHash(ctx context.Context, hash string) (models.ApiKey, error) {
+
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE hash = ?`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	return scanApiKey(a.Db.QueryRowContext(ctx, query, hash))
+}
+
+// GetAll gets every api key, revoked and expired ones included, in creation order.
+func (a *ApiKeysRepository) GetAll(ctx context.Context) ([]models.ApiKey, error) {
+
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY id`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	rows, err := a.Db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []models.ApiKey{}
+	for rows.Next() {
+		key, err := scanApiKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+func (a *ApiKeysRepository) Revoke(ctx context.Context, tx *sql.Tx, id int) error {
+
+	query := `UPDATE api_keys SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, time.Now(), id)
+
+	return err
+}
+
+// Touch records when the api key was last used.
+func (a *ApiKeysRepository) Touch(ctx context.Context, id int, at time.Time) error {
+
+	query := `UPDATE api_keys SET last_used_at = ? WHERE id = ?`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	_, err := a.Db.ExecContext(ctx, query, at, id)
+
+	return err
+}
@@ -0,0 +1,72 @@
+package apikeys
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/models"
+)
+
+type InMemoryApiKeys struct {
+	ApiKeys []models.ApiKey
+}
+
+func (a *InMemoryApiKeys) Insert(ctx context.Context, _ *sql.Tx, key models.ApiKey) (int, error) {
+
+	key.Id = len(a.ApiKeys) + 1
+	key.CreatedAt = time.Now()
+	a.ApiKeys = append(a.ApiKeys, key)
+
+	return key.Id, nil
+}
+
+func (a *InMemoryApiKeys) GetById(ctx context.Context, id int) (models.ApiKey, error) {
+
+	for _, key := range a.ApiKeys {
+		if key.Id == id {
+			return key, nil
+		}
+	}
+
+	return models.ApiKey{}, sql.ErrNoRows
+}
+
+func (a *InMemoryApiKeys) GetByHash(ctx context.Context, hash string) (models.ApiKey, error) {
+
+	for _, key := range a.ApiKeys {
+		if key.Hash == hash {
+			return key, nil
+		}
+	}
+
+	return models.ApiKey{}, sql.ErrNoRows
+}
+
+func (a *InMemoryApiKeys) GetAll(ctx context.Context) ([]models.ApiKey, error) {
+
+	return append([]models.ApiKey(nil), a.ApiKeys...), nil
+}
+
+func (a *InMemoryApiKeys) Revoke(ctx context.Context, _ *sql.Tx, id int) error {
+
+	now := time.Now()
+	for i := range a.ApiKeys {
+		if a.ApiKeys[i].Id == id && a.ApiKeys[i].RevokedAt == nil {
+			a.ApiKeys[i].RevokedAt = &now
+		}
+	}
+
+	return nil
+}
+
+func (a *InMemoryApiKeys) Touch(ctx context.Context, id int, at time.Time) error {
+
+	for i := range a.ApiKeys {
+		if a.ApiKeys[i].Id == id {
+			a.ApiKeys[i].LastUsedAt = &at
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,16 @@
+package apikeys_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/faizisyellow/indocoffee/internal/repository/apikeys"
+)
+
+func TestInMemoryApiKeys(t *testing.T) {
+	apikeys.Contract{
+		NewApiKeys: func() (apikeys.ApiKeys, *sql.Tx, func()) {
+			return &apikeys.InMemoryApiKeys{}, nil, func() {}
+		},
+	}.Test(t)
+}
@@ -0,0 +1,119 @@
+package apikeys
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/models"
+)
+
+type ApiKeys interface {
+	Insert(ctx context.Context, tx *sql.Tx, key models.ApiKey) (int, error)
+	GetById(ctx context.Context, id int) (models.ApiKey, error)
+	GetByHash(ctx context.Context, hash string) (models.ApiKey, error)
+	GetAll(ctx context.Context) ([]models.ApiKey, error)
+	Revoke(ctx context.Context, tx *sql.Tx, id int) error
+	Touch(ctx context.Context, id int, at time.Time) error
+}
+
+type Contract struct {
+	NewApiKeys func() (ApiKeys, *sql.Tx, func())
+}
+
+func (c Contract) Test(t *testing.T) {
+	t.Run("create api key and get it by hash", func(t *testing.T) {
+		var (
+			ctx                  = context.Background()
+			apiKeys, tx, cleanup = c.NewApiKeys()
+			expire               = time.Now().Add(time.Hour).Truncate(time.Second)
+			key                  = models.ApiKey{Name: "warehouse scanner", Prefix: "ick_0a1b2c3d", Hash: "hashed-key", RoleId: 1, ExpireAt: &expire}
+		)
+		t.Cleanup(cleanup)
+
+		id, err := apiKeys.Insert(ctx, tx, key)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		result, err := apiKeys.GetByHash(ctx, key.Hash)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if result.Id != id || result.Name != key.Name || result.RoleId != key.RoleId || result.Prefix != key.Prefix {
+			t.Errorf("expected %+v but got %+v", key, result)
+		}
+
+		if result.ExpireAt == nil || !result.ExpireAt.Equal(expire) {
+			t.Errorf("expected expire at %v but got %v", expire, result.ExpireAt)
+		}
+
+		if _, err := apiKeys.GetByHash(ctx, "unknown"); err != sql.ErrNoRows {
+			t.Errorf("expected sql.ErrNoRows but got: %v", err)
+		}
+	})
+
+	t.Run("list api keys", func(t *testing.T) {
+		var (
+			ctx                  = context.Background()
+			apiKeys, tx, cleanup = c.NewApiKeys()
+		)
+		t.Cleanup(cleanup)
+
+		for _, name := range []string{"warehouse scanner", "accounting"} {
+			if _, err := apiKeys.Insert(ctx, tx, models.ApiKey{Name: name, Prefix: name, Hash: name, RoleId: 1}); err != nil {
+				t.Fatalf("should not be error but got: %v", err)
+			}
+		}
+
+		result, err := apiKeys.GetAll(ctx)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if len(result) != 2 || result[0].Name != "warehouse scanner" || result[1].Name != "accounting" {
+			t.Errorf("expected both keys in creation order but got: %+v", result)
+		}
+	})
+
+	t.Run("revoke api key and track its use", func(t *testing.T) {
+		var (
+			ctx                  = context.Background()
+			apiKeys, tx, cleanup = c.NewApiKeys()
+			usedAt               = time.Now().Truncate(time.Second)
+		)
+		t.Cleanup(cleanup)
+
+		id, err := apiKeys.Insert(ctx, tx, models.ApiKey{Name: "accounting", Prefix: "ick_1", Hash: "hashed", RoleId: 1})
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if err := apiKeys.Touch(ctx, id, usedAt); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if err := apiKeys.Revoke(ctx, tx, id); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		result, err := apiKeys.GetById(ctx, id)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if result.RevokedAt == nil {
+			t.Error("expected api key to be revoked")
+		}
+
+		if result.LastUsedAt == nil || !result.LastUsedAt.Equal(usedAt) {
+			t.Errorf("expected last used at %v but got %v", usedAt, result.LastUsedAt)
+		}
+
+		if _, err := apiKeys.GetById(ctx, id+1); err != sql.ErrNoRows {
+			t.Errorf("expected sql.ErrNoRows but got: %v", err)
+		}
+	})
+}
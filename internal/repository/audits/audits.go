@@ -0,0 +1,144 @@
+package audits
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/faizisyellow/indocoffee/internal/models"
+	"github.com/faizisyellow/indocoffee/internal/repository"
+)
+
+type AuditsRepository struct {
+	Db *sql.DB
+}
+
+// Insert appends a log to the audit log in the transaction of the action,
+// so the action and its log are saved together or not at all.
+func (a *AuditsRepository) Insert(ctx context.Context, tx *sql.Tx, log models.AuditLog) error {
+
+	query := `INSERT INTO audit_logs(actor_id,actor_name,api_key_id,action,target_type,target_id,before_data,after_data,request_id)
+	VALUES(?,?,?,?,?,?,?,?,?)`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	_, err := repository.Execer(a.Db, tx).ExecContext(
+		ctx,
+		query,
+		log.ActorId,
+		log.ActorName,
+		log.ApiKeyId,
+		log.Action,
+		log.TargetType,
+		log.TargetId,
+		nullJson(log.Before),
+		nullJson(log.After),
+		log.RequestId,
+	)
+
+	return err
+}
+
+// Find gets the logs filtered by actor, target and time range, newest first.
+func (a *AuditsRepository) Find(ctx context.Context, qry repository.PaginatedAuditQuery) ([]models.AuditLog, error) {
+
+	var (
+		conditions []string
+		args       []any
+	)
+
+	if qry.ActorId != 0 {
+		conditions = append(conditions, "actor_id = ?")
+		args = append(args, qry.ActorId)
+	}
+
+	if qry.TargetType != "" {
+		conditions = append(conditions, "target_type = ?")
+		args = append(args, qry.TargetType)
+	}
+
+	if qry.TargetId != "" {
+		conditions = append(conditions, "target_id = ?")
+		args = append(args, qry.TargetId)
+	}
+
+	if qry.From != nil {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, *qry.From)
+	}
+
+	if qry.To != nil {
+		conditions = append(conditions, "created_at <= ?")
+		args = append(args, *qry.To)
+	}
+
+	query := `SELECT id,actor_id,actor_name,api_key_id,action,target_type,target_id,before_data,after_data,request_id,created_at FROM audit_logs`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?"
+	args = append(args, qry.Limit, qry.Offset)
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	rows, err := a.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	logs := []models.AuditLog{}
+	for rows.Next() {
+		var (
+			log      models.AuditLog
+			actorId  sql.NullInt64
+			apiKeyId sql.NullInt64
+			before   []byte
+			after    []byte
+		)
+
+		err := rows.Scan(
+			&log.Id,
+			&actorId,
+			&log.ActorName,
+			&apiKeyId,
+			&log.Action,
+			&log.TargetType,
+			&log.TargetId,
+			&before,
+			&after,
+			&log.RequestId,
+			&log.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if actorId.Valid {
+			id := int(actorId.Int64)
+			log.ActorId = &id
+		}
+
+		if apiKeyId.Valid {
+			id := int(apiKeyId.Int64)
+			log.ApiKeyId = &id
+		}
+
+		log.Before = before
+		log.After = after
+
+		logs = append(logs, log)
+	}
+
+	return logs, rows.Err()
+}
+
+func nullJson(data []byte) any {
+	if len(data) == 0 {
+		return nil
+	}
+
+	return string(data)
+}
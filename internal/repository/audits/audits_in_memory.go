@@ -0,0 +1,64 @@
+package audits
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/models"
+	"github.com/faizisyellow/indocoffee/internal/repository"
+)
+
+type InMemoryAudits struct {
+	Logs []models.AuditLog
+}
+
+func (a *InMemoryAudits) Insert(ctx context.Context, _ *sql.Tx, log models.AuditLog) error {
+
+	log.Id = len(a.Logs) + 1
+	log.CreatedAt = time.Now()
+	a.Logs = append(a.Logs, log)
+
+	return nil
+}
+
+func (a *InMemoryAudits) Find(ctx context.Context, qry repository.PaginatedAuditQuery) ([]models.AuditLog, error) {
+
+	logs := []models.AuditLog{}
+	for i := len(a.Logs) - 1; i >= 0; i-- {
+		log := a.Logs[i]
+
+		if qry.ActorId != 0 && (log.ActorId == nil || *log.ActorId != qry.ActorId) {
+			continue
+		}
+
+		if qry.TargetType != "" && log.TargetType != qry.TargetType {
+			continue
+		}
+
+		if qry.TargetId != "" && log.TargetId != qry.TargetId {
+			continue
+		}
+
+		if qry.From != nil && log.CreatedAt.Before(*qry.From) {
+			continue
+		}
+
+		if qry.To != nil && log.CreatedAt.After(*qry.To) {
+			continue
+		}
+
+		logs = append(logs, log)
+	}
+
+	if qry.Offset >= len(logs) {
+		return []models.AuditLog{}, nil
+	}
+	logs = logs[qry.Offset:]
+
+	if qry.Limit < len(logs) {
+		logs = logs[:qry.Limit]
+	}
+
+	return logs, nil
+}
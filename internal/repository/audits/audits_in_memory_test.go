@@ -0,0 +1,15 @@
+package audits_test
+
+import (
+	"testing"
+
+	"github.com/faizisyellow/indocoffee/internal/repository/audits"
+)
+
+func TestInMemoryAudits(t *testing.T) {
+	audits.Contract{
+		NewAudits: func() (audits.Audits, func()) {
+			return &audits.InMemoryAudits{}, func() {}
+		},
+	}.Test(t)
+}
@@ -0,0 +1,127 @@
+package audits
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/models"
+	"github.com/faizisyellow/indocoffee/internal/repository"
+)
+
+type Audits interface {
+	Insert(ctx context.Context, tx *sql.Tx, log models.AuditLog) error
+	// Find gets the logs matching the query, newest first.
+	Find(ctx context.Context, qry repository.PaginatedAuditQuery) ([]models.AuditLog, error)
+}
+
+type Contract struct {
+	NewAudits func() (Audits, func())
+}
+
+func (c Contract) Test(t *testing.T) {
+	newLog := func(actorId int, targetType, targetId string) models.AuditLog {
+		return models.AuditLog{
+			ActorId:    &actorId,
+			ActorName:  "lizzy",
+			Action:     targetType + "s.update",
+			TargetType: targetType,
+			TargetId:   targetId,
+			Before:     []byte(`{"price":10}`),
+			After:      []byte(`{"price":12}`),
+			RequestId:  "host/abc-000001",
+		}
+	}
+
+	seed := func(t *testing.T, audits Audits, logs ...models.AuditLog) {
+		t.Helper()
+
+		for _, log := range logs {
+			if err := audits.Insert(context.Background(), nil, log); err != nil {
+				t.Fatalf("should not be error but got: %v", err)
+			}
+		}
+	}
+
+	t.Run("find logs newest first", func(t *testing.T) {
+		var (
+			ctx             = context.Background()
+			audits, cleanup = c.NewAudits()
+		)
+		t.Cleanup(cleanup)
+
+		seed(t, audits, newLog(1, "product", "1"), newLog(1, "product", "2"))
+
+		result, err := audits.Find(ctx, repository.PaginatedAuditQuery{Limit: 10})
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if len(result) != 2 {
+			t.Fatalf("expected 2 logs but got %v", len(result))
+		}
+
+		if result[0].TargetId != "2" || result[0].RequestId != "host/abc-000001" || string(result[0].After) != `{"price":12}` {
+			t.Errorf("expected the newest log first but got: %+v", result[0])
+		}
+	})
+
+	t.Run("filter logs by actor and target", func(t *testing.T) {
+		var (
+			ctx             = context.Background()
+			audits, cleanup = c.NewAudits()
+		)
+		t.Cleanup(cleanup)
+
+		seed(t, audits, newLog(1, "product", "1"), newLog(2, "product", "1"), newLog(1, "role", "1"))
+
+		result, err := audits.Find(ctx, repository.PaginatedAuditQuery{Limit: 10, ActorId: 1})
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if len(result) != 2 {
+			t.Errorf("expected 2 logs of the actor but got %v", len(result))
+		}
+
+		result, err = audits.Find(ctx, repository.PaginatedAuditQuery{Limit: 10, TargetType: "product", TargetId: "1"})
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if len(result) != 2 {
+			t.Errorf("expected 2 logs of the target but got %v", len(result))
+		}
+	})
+
+	t.Run("filter logs by time range", func(t *testing.T) {
+		var (
+			ctx             = context.Background()
+			audits, cleanup = c.NewAudits()
+			past            = time.Now().Add(-time.Hour)
+			future          = time.Now().Add(time.Hour)
+		)
+		t.Cleanup(cleanup)
+
+		seed(t, audits, newLog(1, "product", "1"))
+
+		result, err := audits.Find(ctx, repository.PaginatedAuditQuery{Limit: 10, From: &past, To: &future})
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if len(result) != 1 {
+			t.Errorf("expected the log within the range but got %v", len(result))
+		}
+
+		result, err = audits.Find(ctx, repository.PaginatedAuditQuery{Limit: 10, From: &future})
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if len(result) != 0 {
+			t.Errorf("expected no log after the range but got %v", len(result))
+		}
+	})
+}
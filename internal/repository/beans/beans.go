@@ -3,6 +3,7 @@ package beans
 import (
 	"context"
 	"database/sql"
+	"strings"
 
 	"github.com/faizisyellow/indocoffee/internal/models"
 	"github.com/faizisyellow/indocoffee/internal/repository"
@@ -12,19 +13,25 @@ type BeansRepository struct {
 	Db *sql.DB
 }
 
-func (Beans *BeansRepository) Insert(ctx context.Context, nw models.BeansModel) error {
+// Insert inserts new bean and returns its id.
+func (Beans *BeansRepository) Insert(ctx context.Context, tx *sql.Tx, nw models.BeansModel) (int, error) {
 
 	query := `INSERT INTO beans(name) VALUES(?)`
 
 	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
 	defer cancel()
 
-	_, err := Beans.Db.ExecContext(ctx, query, nw.Name)
+	result, err := repository.Execer(Beans.Db, tx).ExecContext(ctx, query, nw.Name)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
 }
 
 func (Beans *BeansRepository) GetAll(ctx context.Context) ([]models.BeansModel, error) {
@@ -74,14 +81,14 @@ func (Beans *BeansRepository) GetById(ctx context.Context, id int) (models.Beans
 	return bean, nil
 }
 
-func (Beans *BeansRepository) Update(ctx context.Context, nw models.BeansModel) error {
+func (Beans *BeansRepository) Update(ctx context.Context, tx *sql.Tx, nw models.BeansModel) error {
 
 	query := `UPDATE beans SET name = ?, is_delete = ? WHERE id = ?`
 
 	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
 	defer cancel()
 
-	_, err := Beans.Db.ExecContext(ctx, query, nw.Name, nw.IsDelete, nw.Id)
+	_, err := repository.Execer(Beans.Db, tx).ExecContext(ctx, query, nw.Name, nw.IsDelete, nw.Id)
 	if err != nil {
 		return err
 	}
@@ -89,14 +96,14 @@ func (Beans *BeansRepository) Update(ctx context.Context, nw models.BeansModel)
 	return nil
 }
 
-func (Beans *BeansRepository) Delete(ctx context.Context, id int) error {
+func (Beans *BeansRepository) Delete(ctx context.Context, tx *sql.Tx, id int) error {
 
 	query := `UPDATE beans SET is_delete = TRUE WHERE id = ? AND is_delete = FALSE`
 
 	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
 	defer cancel()
 
-	result, err := Beans.Db.ExecContext(ctx, query, id)
+	result, err := repository.Execer(Beans.Db, tx).ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -113,17 +120,46 @@ func (Beans *BeansRepository) Delete(ctx context.Context, id int) error {
 	return nil
 }
 
-func (Beans *BeansRepository) DestroyMany(ctx context.Context) error {
-
-	query := `DELETE FROM beans WHERE is_delete = TRUE`
+// DestroyMany deletes the beans in the trash for good.
+// Returns the ids of the deleted beans.
+func (Beans *BeansRepository) DestroyMany(ctx context.Context, tx *sql.Tx) ([]int, error) {
 
 	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
 	defer cancel()
 
-	_, err := Beans.Db.ExecContext(ctx, query)
+	rows, err := repository.Execer(Beans.Db, tx).QueryContext(ctx, `SELECT id FROM beans WHERE is_delete = TRUE FOR UPDATE`)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer rows.Close()
 
-	return nil
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return ids, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	_, err = repository.Execer(Beans.Db, tx).ExecContext(ctx, `DELETE FROM beans WHERE id IN (`+placeholders+`)`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
 }
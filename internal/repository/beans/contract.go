@@ -2,18 +2,19 @@ package beans
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 
 	"github.com/faizisyellow/indocoffee/internal/models"
 )
 
 type Beans interface {
-	Insert(ctx context.Context, nw models.BeansModel) error
+	Insert(ctx context.Context, tx *sql.Tx, nw models.BeansModel) (int, error)
 	GetAll(ctx context.Context) ([]models.BeansModel, error)
 	GetById(ctx context.Context, id int) (models.BeansModel, error)
-	Update(ctx context.Context, nw models.BeansModel) error
-	Delete(ctx context.Context, id int) error
-	DestroyMany(ctx context.Context) error
+	Update(ctx context.Context, tx *sql.Tx, nw models.BeansModel) error
+	Delete(ctx context.Context, tx *sql.Tx, id int) error
+	DestroyMany(ctx context.Context, tx *sql.Tx) ([]int, error)
 }
 
 type Contract struct {
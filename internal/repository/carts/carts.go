@@ -24,18 +24,22 @@ type CartsRepository struct {
 }
 
 func (c *CartsRepository) Insert(ctx context.Context, cart models.Cart) error {
-	query := `INSERT INTO cart_items(product_id,user_id) VALUES(?,?)`
+	query := `INSERT INTO cart_items(variant_id,user_id) VALUES(?,?)`
 
 	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
 	defer cancel()
 
-	_, err := c.Db.ExecContext(ctx, query, cart.ProductId, cart.UserId)
+	_, err := c.Db.ExecContext(ctx, query, cart.VariantId, cart.UserId)
 
 	return err
 }
 
 func (c *CartsRepository) GetById(ctx context.Context, cartid int) (models.Cart, error) {
-	query := `SELECT id,user_id,product_id,quantity,created_at FROM cart_items WHERE id = ? AND status = "open"`
+	query := `
+	SELECT cart_items.id,cart_items.user_id,cart_items.variant_id,product_variants.product_id,cart_items.quantity,cart_items.created_at
+	FROM cart_items
+	JOIN product_variants ON product_variants.id = cart_items.variant_id
+	WHERE cart_items.id = ? AND cart_items.status = "open"`
 
 	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
 	defer cancel()
@@ -45,6 +49,7 @@ func (c *CartsRepository) GetById(ctx context.Context, cartid int) (models.Cart,
 	return cart, c.Db.QueryRowContext(ctx, query, cartid).Scan(
 		&cart.Id,
 		&cart.UserId,
+		&cart.VariantId,
 		&cart.ProductId,
 		&cart.Quantity,
 		&cart.CreatedAt,
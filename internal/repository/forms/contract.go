@@ -2,18 +2,19 @@ package forms
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 
 	"github.com/faizisyellow/indocoffee/internal/models"
 )
 
 type Forms interface {
-	Insert(ctx context.Context, nw models.FormsModel) error
+	Insert(ctx context.Context, tx *sql.Tx, nw models.FormsModel) (int, error)
 	GetAll(ctx context.Context) ([]models.FormsModel, error)
 	GetById(ctx context.Context, id int) (models.FormsModel, error)
-	Update(ctx context.Context, nw models.FormsModel) error
-	Delete(ctx context.Context, id int) error
-	DestroyMany(ctx context.Context) error
+	Update(ctx context.Context, tx *sql.Tx, nw models.FormsModel) error
+	Delete(ctx context.Context, tx *sql.Tx, id int) error
+	DestroyMany(ctx context.Context, tx *sql.Tx) ([]int, error)
 }
 
 type Contract struct {
@@ -3,6 +3,7 @@ package forms
 import (
 	"context"
 	"database/sql"
+	"strings"
 
 	"github.com/faizisyellow/indocoffee/internal/models"
 	"github.com/faizisyellow/indocoffee/internal/repository"
@@ -12,19 +13,25 @@ type FormsRepository struct {
 	Db *sql.DB
 }
 
-func (Forms *FormsRepository) Insert(ctx context.Context, nw models.FormsModel) error {
+// Insert inserts new form and returns its id.
+func (Forms *FormsRepository) Insert(ctx context.Context, tx *sql.Tx, nw models.FormsModel) (int, error) {
 
 	qry := `INSERT INTO forms (name) VALUES(?)`
 
 	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
 	defer cancel()
 
-	_, err := Forms.Db.ExecContext(ctx, qry, nw.Name)
+	result, err := repository.Execer(Forms.Db, tx).ExecContext(ctx, qry, nw.Name)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
 }
 
 func (Forms *FormsRepository) GetAll(ctx context.Context) ([]models.FormsModel, error) {
@@ -75,14 +82,14 @@ func (Forms *FormsRepository) GetById(ctx context.Context, id int) (models.Forms
 	return form, result.Err()
 }
 
-func (Forms *FormsRepository) Update(ctx context.Context, nw models.FormsModel) error {
+func (Forms *FormsRepository) Update(ctx context.Context, tx *sql.Tx, nw models.FormsModel) error {
 
 	qry := `UPDATE forms SET name = ? WHERE id = ? AND is_delete = FALSE`
 
 	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
 	defer cancel()
 
-	_, err := Forms.Db.ExecContext(ctx, qry, nw.Name, nw.Id)
+	_, err := repository.Execer(Forms.Db, tx).ExecContext(ctx, qry, nw.Name, nw.Id)
 	if err != nil {
 		return err
 	}
@@ -90,14 +97,14 @@ func (Forms *FormsRepository) Update(ctx context.Context, nw models.FormsModel)
 	return nil
 }
 
-func (Forms *FormsRepository) Delete(ctx context.Context, id int) error {
+func (Forms *FormsRepository) Delete(ctx context.Context, tx *sql.Tx, id int) error {
 
 	qry := `UPDATE forms SET is_delete = TRUE WHERE id = ? AND is_delete = FALSE`
 
 	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
 	defer cancel()
 
-	_, err := Forms.Db.ExecContext(ctx, qry, id)
+	_, err := repository.Execer(Forms.Db, tx).ExecContext(ctx, qry, id)
 	if err != nil {
 		return err
 	}
@@ -105,17 +112,46 @@ func (Forms *FormsRepository) Delete(ctx context.Context, id int) error {
 	return nil
 }
 
-func (Forms *FormsRepository) DestroyMany(ctx context.Context) error {
-
-	qry := `DELETE FROM forms WHERE is_delete = TRUE `
+// DestroyMany deletes the forms in the trash for good.
+// Returns the ids of the deleted forms.
+func (Forms *FormsRepository) DestroyMany(ctx context.Context, tx *sql.Tx) ([]int, error) {
 
 	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
 	defer cancel()
 
-	_, err := Forms.Db.ExecContext(ctx, qry)
+	rows, err := repository.Execer(Forms.Db, tx).QueryContext(ctx, `SELECT id FROM forms WHERE is_delete = TRUE FOR UPDATE`)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer rows.Close()
 
-	return nil
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return ids, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	_, err = repository.Execer(Forms.Db, tx).ExecContext(ctx, `DELETE FROM forms WHERE id IN (`+placeholders+`)`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
 }
@@ -13,7 +13,10 @@ import (
 type Invitations interface {
 	Insert(ctx context.Context, tx *sql.Tx, invt models.InvitationModel) error
 	Get(ctx context.Context, tx *sql.Tx, token string) (int, error)
+	GetEmailChange(ctx context.Context, tx *sql.Tx, token string) (models.InvitationModel, error)
+	GetLatestByUserId(ctx context.Context, tx *sql.Tx, usrId int) (models.InvitationModel, error)
 	DeleteByUserId(ctx context.Context, tx *sql.Tx, usrId int) error
+	DeleteEmailChangeByUserId(ctx context.Context, tx *sql.Tx, usrId int) error
 }
 
 type Contract struct {
@@ -66,14 +69,170 @@ func (u Contract) Test(t *testing.T) {
 		}
 	})
 
+	t.Run("get invitation that does not exist", func(t *testing.T) {
+		var (
+			ctx                      = context.Background()
+			invitations, tx, cleanup = u.NewInvitations()
+		)
+		t.Cleanup(cleanup)
+
+		if _, err := invitations.Get(ctx, tx, utils.UUID{}.Generate()); err != sql.ErrNoRows {
+			t.Errorf("expected sql.ErrNoRows but got: %v", err)
+		}
+	})
+
+	t.Run("get expired invitation", func(t *testing.T) {
+		var (
+			ctx                      = context.Background()
+			invitations, tx, cleanup = u.NewInvitations()
+			initial                  = models.InvitationModel{
+				UserId:   1,
+				Token:    utils.UUID{}.Generate(),
+				ExpireAt: -time.Minute,
+			}
+		)
+		t.Cleanup(cleanup)
+
+		if err := invitations.Insert(ctx, tx, initial); err != nil {
+			t.Fatal("should not be error")
+		}
+
+		if _, err := invitations.Get(ctx, tx, initial.Token); err != sql.ErrNoRows {
+			t.Errorf("expected sql.ErrNoRows but got: %v", err)
+		}
+	})
+
+	t.Run("get latest invitation of a user", func(t *testing.T) {
+		var (
+			ctx                      = context.Background()
+			invitations, tx, cleanup = u.NewInvitations()
+			initial                  = models.InvitationModel{
+				UserId:   1,
+				Token:    utils.UUID{}.Generate(),
+				ExpireAt: time.Hour * 24,
+			}
+		)
+		t.Cleanup(cleanup)
+
+		if _, err := invitations.GetLatestByUserId(ctx, tx, 1); err != sql.ErrNoRows {
+			t.Errorf("expected sql.ErrNoRows but got: %v", err)
+		}
+
+		if err := invitations.Insert(ctx, tx, initial); err != nil {
+			t.Fatal("should not be error")
+		}
+
+		latest, err := invitations.GetLatestByUserId(ctx, tx, 1)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if latest.Token != initial.Token || latest.CreatedAt.IsZero() {
+			t.Errorf("unexpected latest invitation: %+v", latest)
+		}
+	})
+
 	t.Run("delete invitations", func(t *testing.T) {
 		var (
 			ctx                      = context.Background()
 			invitations, tx, cleanup = u.NewInvitations()
+			initial                  = models.InvitationModel{
+				UserId:   1,
+				Token:    utils.UUID{}.Generate(),
+				ExpireAt: time.Hour * 24,
+			}
 		)
 		t.Cleanup(cleanup)
+
+		if err := invitations.Insert(ctx, tx, initial); err != nil {
+			t.Fatal("should not be error")
+		}
+
 		if err := invitations.DeleteByUserId(ctx, tx, 1); err != nil {
 			t.Error("should not be error")
 		}
+
+		if _, err := invitations.Get(ctx, tx, initial.Token); err != sql.ErrNoRows {
+			t.Errorf("expected deleted invitation to be gone but got: %v", err)
+		}
+	})
+
+	t.Run("delete only email change invitations", func(t *testing.T) {
+		var (
+			ctx                      = context.Background()
+			invitations, tx, cleanup = u.NewInvitations()
+			activation               = models.InvitationModel{
+				UserId:   1,
+				Token:    utils.UUID{}.Generate(),
+				ExpireAt: time.Hour * 24,
+			}
+			emailChange = models.InvitationModel{
+				UserId:   1,
+				Token:    utils.UUID{}.Generate(),
+				ExpireAt: time.Hour * 24,
+				Email:    "new@test.test",
+			}
+		)
+		t.Cleanup(cleanup)
+
+		for _, invt := range []models.InvitationModel{activation, emailChange} {
+			if err := invitations.Insert(ctx, tx, invt); err != nil {
+				t.Fatal("should not be error")
+			}
+		}
+
+		if err := invitations.DeleteEmailChangeByUserId(ctx, tx, 1); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if _, err := invitations.GetEmailChange(ctx, tx, emailChange.Token); err != sql.ErrNoRows {
+			t.Errorf("expected email change invitation to be gone but got: %v", err)
+		}
+
+		if _, err := invitations.Get(ctx, tx, activation.Token); err != nil {
+			t.Errorf("expected activation invitation to be kept but got: %v", err)
+		}
+	})
+
+	t.Run("get email change invitation by token", func(t *testing.T) {
+		var (
+			ctx                      = context.Background()
+			invitations, tx, cleanup = u.NewInvitations()
+			activation               = models.InvitationModel{
+				UserId:   1,
+				Token:    utils.UUID{}.Generate(),
+				ExpireAt: time.Hour * 24,
+			}
+			emailChange = models.InvitationModel{
+				UserId:   2,
+				Token:    utils.UUID{}.Generate(),
+				ExpireAt: time.Hour * 24,
+				Email:    "new@test.test",
+			}
+		)
+		t.Cleanup(cleanup)
+
+		for _, invt := range []models.InvitationModel{activation, emailChange} {
+			if err := invitations.Insert(ctx, tx, invt); err != nil {
+				t.Fatal("should not be error")
+			}
+		}
+
+		result, err := invitations.GetEmailChange(ctx, tx, emailChange.Token)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if result.UserId != emailChange.UserId || result.Email != emailChange.Email {
+			t.Errorf("unexpected email change invitation: %+v", result)
+		}
+
+		if _, err := invitations.Get(ctx, tx, emailChange.Token); err != sql.ErrNoRows {
+			t.Errorf("expected email change token not to activate account but got: %v", err)
+		}
+
+		if _, err := invitations.GetEmailChange(ctx, tx, activation.Token); err != sql.ErrNoRows {
+			t.Errorf("expected activation token not to change email but got: %v", err)
+		}
 	})
 }
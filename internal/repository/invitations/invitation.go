@@ -17,14 +17,14 @@ type InvitationRepository struct {
 // Returns  nil on success or an error on failure.
 func (ir *InvitationRepository) Insert(ctx context.Context, tx *sql.Tx, invt models.InvitationModel) error {
 
-	query := `INSERT INTO invitations(user_id,token,expire_at)
-	VALUES(?,?,?)
+	query := `INSERT INTO invitations(user_id,token,expire_at,email)
+	VALUES(?,?,?,NULLIF(?,''))
 	`
 
 	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
 	defer cancel()
 
-	_, err := tx.ExecContext(ctx, query, invt.UserId, invt.Token, time.Now().Add(invt.ExpireAt))
+	_, err := tx.ExecContext(ctx, query, invt.UserId, invt.Token, time.Now().Add(invt.ExpireAt), invt.Email)
 	if err != nil {
 		return err
 	}
@@ -32,11 +32,11 @@ func (ir *InvitationRepository) Insert(ctx context.Context, tx *sql.Tx, invt mod
 	return nil
 }
 
-// Get gets an invitation if the invitation is not expired
+// Get gets an activation invitation if the invitation is not expired
 // Returns a the user's id on success or an error on failure.
 func (ir *InvitationRepository) Get(ctx context.Context, tx *sql.Tx, token string) (int, error) {
 
-	query := `SELECT user_id FROM invitations WHERE token = ? AND expire_at > ?;`
+	query := `SELECT user_id FROM invitations WHERE token = ? AND email IS NULL AND expire_at > ?;`
 
 	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
 	defer cancel()
@@ -53,6 +53,44 @@ func (ir *InvitationRepository) Get(ctx context.Context, tx *sql.Tx, token strin
 	return userId, nil
 }
 
+// GetEmailChange gets an email change invitation if the invitation is not expired.
+// Returns sql.ErrNoRows if there is no such invitation.
+func (ir *InvitationRepository) GetEmailChange(ctx context.Context, tx *sql.Tx, token string) (models.InvitationModel, error) {
+
+	query := `SELECT user_id,token,email,created_at FROM invitations WHERE token = ? AND email IS NOT NULL AND expire_at > ?`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	var invt models.InvitationModel
+
+	err := tx.QueryRowContext(ctx, query, token, time.Now()).Scan(&invt.UserId, &invt.Token, &invt.Email, &invt.CreatedAt)
+	if err != nil {
+		return models.InvitationModel{}, err
+	}
+
+	return invt, nil
+}
+
+// GetLatestByUserId gets the last invitation sent to a user, expired or not.
+// Returns sql.ErrNoRows if the user has no invitation.
+func (ir *InvitationRepository) GetLatestByUserId(ctx context.Context, tx *sql.Tx, usrId int) (models.InvitationModel, error) {
+
+	query := `SELECT user_id,token,created_at FROM invitations WHERE user_id = ? ORDER BY created_at DESC LIMIT 1 FOR UPDATE`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	var invt models.InvitationModel
+
+	err := tx.QueryRowContext(ctx, query, usrId).Scan(&invt.UserId, &invt.Token, &invt.CreatedAt)
+	if err != nil {
+		return models.InvitationModel{}, err
+	}
+
+	return invt, nil
+}
+
 // DeleteByUserId Deletes an invitation by user's id.
 func (ir *InvitationRepository) DeleteByUserId(ctx context.Context, tx *sql.Tx, usrId int) error {
 
@@ -68,3 +106,20 @@ func (ir *InvitationRepository) DeleteByUserId(ctx context.Context, tx *sql.Tx,
 
 	return nil
 }
+
+// DeleteEmailChangeByUserId deletes the email change invitations of a user,
+// the user's activation invitation is kept.
+func (ir *InvitationRepository) DeleteEmailChangeByUserId(ctx context.Context, tx *sql.Tx, usrId int) error {
+
+	query := `DELETE FROM invitations WHERE user_id = ? AND email IS NOT NULL`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, usrId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package invitations
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/faizisyellow/indocoffee/internal/models"
 )
@@ -13,29 +14,79 @@ type InMemoryInvitations struct {
 
 func (i *InMemoryInvitations) Insert(ctx context.Context, _ *sql.Tx, invt models.InvitationModel) error {
 
+	if invt.CreatedAt.IsZero() {
+		invt.CreatedAt = time.Now()
+	}
+
 	i.Invitation = append(i.Invitation, invt)
 	return nil
 }
 
 func (i *InMemoryInvitations) Get(ctx context.Context, _ *sql.Tx, token string) (int, error) {
-	inv := models.InvitationModel{}
 
 	for _, invitation := range i.Invitation {
-		if invitation.Token == token {
-			inv = invitation
+		if invitation.Token == token && invitation.Email == "" && invitation.CreatedAt.Add(invitation.ExpireAt).After(time.Now()) {
+			return invitation.UserId, nil
+		}
+	}
+
+	return 0, sql.ErrNoRows
+}
+
+func (i *InMemoryInvitations) GetEmailChange(ctx context.Context, _ *sql.Tx, token string) (models.InvitationModel, error) {
+
+	for _, invitation := range i.Invitation {
+		if invitation.Token == token && invitation.Email != "" && invitation.CreatedAt.Add(invitation.ExpireAt).After(time.Now()) {
+			return invitation, nil
 		}
 	}
 
-	return inv.UserId, nil
+	return models.InvitationModel{}, sql.ErrNoRows
+}
+
+func (i *InMemoryInvitations) GetLatestByUserId(ctx context.Context, _ *sql.Tx, usrId int) (models.InvitationModel, error) {
+
+	var (
+		latest models.InvitationModel
+		found  bool
+	)
+
+	for _, invitation := range i.Invitation {
+		if invitation.UserId == usrId && !invitation.CreatedAt.Before(latest.CreatedAt) {
+			latest = invitation
+			found = true
+		}
+	}
+
+	if !found {
+		return models.InvitationModel{}, sql.ErrNoRows
+	}
+
+	return latest, nil
 }
 
 func (in *InMemoryInvitations) DeleteByUserId(ctx context.Context, _ *sql.Tx, usrId int) error {
-	for i, invitation := range in.Invitation {
-		if invitation.UserId == usrId {
-			in.Invitation = append(in.Invitation[:i], in.Invitation[i+1:]...)
-			break
+
+	remaining := in.Invitation[:0]
+	for _, invitation := range in.Invitation {
+		if invitation.UserId != usrId {
+			remaining = append(remaining, invitation)
+		}
+	}
+	in.Invitation = remaining
+
+	return nil
+}
+
+func (in *InMemoryInvitations) DeleteEmailChangeByUserId(ctx context.Context, _ *sql.Tx, usrId int) error {
+
+	remaining := in.Invitation[:0]
+	for _, invitation := range in.Invitation {
+		if invitation.UserId != usrId || invitation.Email == "" {
+			remaining = append(remaining, invitation)
 		}
 	}
+	in.Invitation = remaining
 
 	return nil
 }
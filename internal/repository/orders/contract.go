@@ -17,12 +17,137 @@ type Orders interface {
 	GetOrderStatusById(ctx context.Context, orderId string) (string, error)
 	GetOrderById(ctx context.Context, orderId string) (models.Order, error)
 	GetOrders(ctx context.Context, r repository.PaginatedOrdersQuery) ([]models.Order, error)
+	GetByCustomerId(ctx context.Context, customerId int) ([]models.Order, error)
+	GetStatusesByCustomerId(ctx context.Context, tx *sql.Tx, customerId int) ([]string, error)
+	AnonymizeByCustomerId(ctx context.Context, tx *sql.Tx, customerId int) error
 }
 
 type Contract struct {
-	NewOrder Orders
+	NewOrders func() (Orders, *sql.Tx, func())
 }
 
-func (c *Contract) Test(t *testing.T) {
+func (c Contract) Test(t *testing.T) {
+	newOrder := func(id string, customerId int) models.Order {
+		return models.Order{
+			Id:             id,
+			IdempotencyKey: "key-" + id,
+			CustomerId:     customerId,
+			CustomerName:   "lizzy",
+			CustomerEmail:  "lizzy@test.test",
+			Items:          []models.OrderItem{{Id: 1, BeanName: "arabica", Price: 12.5, OrderQuantity: 2}},
+			TotalPrice:     25,
+			PhoneNumber:    "+6281234567890",
+			Street:         "Jl. Malioboro No. 52",
+			City:           "Yogyakarta",
+			Province:       "DI Yogyakarta",
+			PostalCode:     "55271",
+			CartIds:        []int{1},
+		}
+	}
 
+	t.Run("get every order of the customer", func(t *testing.T) {
+		var (
+			ctx                 = context.Background()
+			orders, tx, cleanup = c.NewOrders()
+		)
+		t.Cleanup(cleanup)
+
+		for _, order := range []models.Order{newOrder("01JORDER1", 1), newOrder("01JORDER2", 1), newOrder("01JORDER3", 2)} {
+			if _, err := orders.Create(ctx, tx, order); err != nil {
+				t.Fatalf("should not be error but got: %v", err)
+			}
+		}
+
+		result, err := orders.GetByCustomerId(ctx, 1)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if len(result) != 2 {
+			t.Errorf("expected 2 orders but got %v", len(result))
+		}
+
+		for _, order := range result {
+			if order.CustomerId != 1 {
+				t.Errorf("expected only orders of the customer but got: %+v", order)
+			}
+		}
+	})
+
+	t.Run("anonymize orders of the customer", func(t *testing.T) {
+		var (
+			ctx                 = context.Background()
+			orders, tx, cleanup = c.NewOrders()
+		)
+		t.Cleanup(cleanup)
+
+		for _, order := range []models.Order{newOrder("01JORDER1", 1), newOrder("01JORDER2", 2)} {
+			if _, err := orders.Create(ctx, tx, order); err != nil {
+				t.Fatalf("should not be error but got: %v", err)
+			}
+		}
+
+		if err := orders.AnonymizeByCustomerId(ctx, tx, 1); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		anonymized, err := orders.GetOrderById(ctx, "01JORDER1")
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if anonymized.CustomerId != 0 || anonymized.CustomerName != AnonymousCustomer || anonymized.CustomerEmail != "" ||
+			anonymized.PhoneNumber != "" || anonymized.Street != "" || anonymized.PostalCode != "" {
+			t.Errorf("expected personal data to be removed but got: %+v", anonymized)
+		}
+
+		if anonymized.TotalPrice != 25 || len(anonymized.Items) != 1 {
+			t.Errorf("expected items and totals to be kept but got: %+v", anonymized)
+		}
+
+		kept, err := orders.GetOrderById(ctx, "01JORDER2")
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if kept.CustomerId != 2 || kept.CustomerEmail != "lizzy@test.test" {
+			t.Errorf("expected orders of other customers to be kept but got: %+v", kept)
+		}
+
+		result, err := orders.GetByCustomerId(ctx, 1)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if len(result) != 0 {
+			t.Errorf("expected anonymized orders to be detached but got %v", len(result))
+		}
+	})
+
+	t.Run("get statuses of the customer's orders", func(t *testing.T) {
+		var (
+			ctx                 = context.Background()
+			orders, tx, cleanup = c.NewOrders()
+		)
+		t.Cleanup(cleanup)
+
+		for _, order := range []models.Order{newOrder("01JORDER1", 1), newOrder("01JORDER2", 2)} {
+			if _, err := orders.Create(ctx, tx, order); err != nil {
+				t.Fatalf("should not be error but got: %v", err)
+			}
+		}
+
+		if err := orders.UpdateOrdersStatusWithTx(ctx, tx, "01JORDER1", Cancelled); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		statuses, err := orders.GetStatusesByCustomerId(ctx, tx, 1)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if len(statuses) != 1 || statuses[0] != Cancelled.String() {
+			t.Errorf("expected the status of the customer's order only but got: %v", statuses)
+		}
+	})
 }
@@ -21,6 +21,9 @@ const (
 	Cancelled
 )
 
+// AnonymousCustomer replaces the name of the customer on orders of deleted accounts.
+const AnonymousCustomer = "deleted customer"
+
 func (o OrderStatus) String() string {
 
 	return []string{"confirm", "roasting", "shipped", "complete", "cancelled"}[o]
@@ -44,9 +47,12 @@ func (o *OrdersRepository) Create(ctx context.Context, tx *sql.Tx, newOrder mode
 			alternative_phone_number,
 			street,
 			city,
+			province,
+			postal_code,
+			delivery_notes,
 			cart_ids,
 			created_at
-		) VALUES(?,?,?,?,?,?,CAST(? AS JSON),?,?,?,?,?,?)
+		) VALUES(?,?,?,?,?,?,CAST(? AS JSON),?,?,?,?,?,?,?,?,?)
 	`
 
 	itemsJSON, err := json.Marshal(newOrder.Items)
@@ -76,6 +82,9 @@ func (o *OrdersRepository) Create(ctx context.Context, tx *sql.Tx, newOrder mode
 		newOrder.AlternativePhoneNumber,
 		newOrder.Street,
 		newOrder.City,
+		newOrder.Province,
+		newOrder.PostalCode,
+		newOrder.DeliveryNotes,
 		string(cartIdsJSON),
 		time.Now().UTC(),
 	)
@@ -146,6 +155,9 @@ func (o *OrdersRepository) GetOrderById(ctx context.Context, orderId string) (mo
 			alternative_phone_number,
 			street,
 			city,
+			province,
+			postal_code,
+			delivery_notes,
 			created_at,
 			items,
 			cart_ids
@@ -158,6 +170,7 @@ func (o *OrdersRepository) GetOrderById(ctx context.Context, orderId string) (mo
 
 	var (
 		order       models.Order
+		customerId  sql.NullInt64
 		itemsJSON   sql.NullString
 		cartIdsJSON sql.NullString
 	)
@@ -165,7 +178,7 @@ func (o *OrdersRepository) GetOrderById(ctx context.Context, orderId string) (mo
 	err := o.Db.QueryRowContext(ctx, query, orderId).Scan(
 		&order.Id,
 		&order.IdempotencyKey,
-		&order.CustomerId,
+		&customerId,
 		&order.CustomerName,
 		&order.CustomerEmail,
 		&order.Status,
@@ -174,6 +187,9 @@ func (o *OrdersRepository) GetOrderById(ctx context.Context, orderId string) (mo
 		&order.AlternativePhoneNumber,
 		&order.Street,
 		&order.City,
+		&order.Province,
+		&order.PostalCode,
+		&order.DeliveryNotes,
 		&order.CreatedAt,
 		&itemsJSON,
 		&cartIdsJSON,
@@ -183,6 +199,11 @@ func (o *OrdersRepository) GetOrderById(ctx context.Context, orderId string) (mo
 		return order, err
 	}
 
+	// an anonymized order has no customer, its id is left zero
+	if customerId.Valid {
+		order.CustomerId = int(customerId.Int64)
+	}
+
 	// Unmarshal only if not NULL
 	if itemsJSON.Valid && itemsJSON.String != "" {
 		err = json.Unmarshal([]byte(itemsJSON.String), &order.Items)
@@ -215,6 +236,9 @@ func (o *OrdersRepository) GetOrders(ctx context.Context, qry repository.Paginat
 			alternative_phone_number,
 			street,
 			city,
+			province,
+			postal_code,
+			delivery_notes,
 			created_at,
 			items,
 			cart_ids
@@ -231,6 +255,9 @@ func (o *OrdersRepository) GetOrders(ctx context.Context, qry repository.Paginat
 				alternative_phone_number,
 				street,
 				city,
+				province,
+				postal_code,
+				delivery_notes,
 				created_at,
 				items,
 				cart_ids
@@ -256,6 +283,7 @@ func (o *OrdersRepository) GetOrders(ctx context.Context, qry repository.Paginat
 	for rowsResult.Next() {
 		var (
 			order       models.Order
+			customerId  sql.NullInt64
 			itemsJSON   sql.NullString
 			cartIdsJSON sql.NullString
 		)
@@ -263,7 +291,7 @@ func (o *OrdersRepository) GetOrders(ctx context.Context, qry repository.Paginat
 		if err := rowsResult.Scan(
 			&order.Id,
 			&order.IdempotencyKey,
-			&order.CustomerId,
+			&customerId,
 			&order.CustomerName,
 			&order.CustomerEmail,
 			&order.Status,
@@ -272,6 +300,9 @@ func (o *OrdersRepository) GetOrders(ctx context.Context, qry repository.Paginat
 			&order.AlternativePhoneNumber,
 			&order.Street,
 			&order.City,
+			&order.Province,
+			&order.PostalCode,
+			&order.DeliveryNotes,
 			&order.CreatedAt,
 			&itemsJSON,
 			&cartIdsJSON,
@@ -279,6 +310,10 @@ func (o *OrdersRepository) GetOrders(ctx context.Context, qry repository.Paginat
 			return nil, err
 		}
 
+		if customerId.Valid {
+			order.CustomerId = int(customerId.Int64)
+		}
+
 		// Unmarshal only if not NULL
 		if itemsJSON.Valid && itemsJSON.String != "" {
 			err = json.Unmarshal([]byte(itemsJSON.String), &order.Items)
@@ -299,3 +334,136 @@ func (o *OrdersRepository) GetOrders(ctx context.Context, qry repository.Paginat
 
 	return orders, rowsResult.Err()
 }
+
+// GetByCustomerId gets every order of the customer, newest first.
+func (o *OrdersRepository) GetByCustomerId(ctx context.Context, customerId int) ([]models.Order, error) {
+	query := `
+		SELECT
+			id,
+			idempotency_key,
+			customer_id,
+			customer_name,
+			customer_email,
+			status,
+			total_price,
+			phone_number,
+			alternative_phone_number,
+			street,
+			city,
+			province,
+			postal_code,
+			delivery_notes,
+			created_at,
+			items,
+			cart_ids
+		FROM orders
+		WHERE customer_id = ?
+		ORDER BY created_at DESC
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	rowsResult, err := o.Db.QueryContext(ctx, query, customerId)
+	if err != nil {
+		return nil, err
+	}
+	defer rowsResult.Close()
+
+	orders := []models.Order{}
+
+	for rowsResult.Next() {
+		var (
+			order       models.Order
+			itemsJSON   sql.NullString
+			cartIdsJSON sql.NullString
+		)
+
+		if err := rowsResult.Scan(
+			&order.Id,
+			&order.IdempotencyKey,
+			&order.CustomerId,
+			&order.CustomerName,
+			&order.CustomerEmail,
+			&order.Status,
+			&order.TotalPrice,
+			&order.PhoneNumber,
+			&order.AlternativePhoneNumber,
+			&order.Street,
+			&order.City,
+			&order.Province,
+			&order.PostalCode,
+			&order.DeliveryNotes,
+			&order.CreatedAt,
+			&itemsJSON,
+			&cartIdsJSON,
+		); err != nil {
+			return nil, err
+		}
+
+		if itemsJSON.Valid && itemsJSON.String != "" {
+			if err := json.Unmarshal([]byte(itemsJSON.String), &order.Items); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal items: %w", err)
+			}
+		}
+
+		if cartIdsJSON.Valid && cartIdsJSON.String != "" {
+			if err := json.Unmarshal([]byte(cartIdsJSON.String), &order.CartIds); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal cart ids: %w", err)
+			}
+		}
+
+		orders = append(orders, order)
+	}
+
+	return orders, rowsResult.Err()
+}
+
+// GetStatusesByCustomerId gets the status of every order of the customer,
+// the orders are locked until the transaction ends.
+func (o *OrdersRepository) GetStatusesByCustomerId(ctx context.Context, tx *sql.Tx, customerId int) ([]string, error) {
+	query := `SELECT status FROM orders WHERE customer_id = ? FOR UPDATE`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	rows, err := tx.QueryContext(ctx, query, customerId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	statuses := []string{}
+	for rows.Next() {
+		var status string
+		if err := rows.Scan(&status); err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, rows.Err()
+}
+
+// AnonymizeByCustomerId removes the personal data of the customer from its orders
+// and detaches them from the customer, the items and totals are kept for the books.
+func (o *OrdersRepository) AnonymizeByCustomerId(ctx context.Context, tx *sql.Tx, customerId int) error {
+	query := `
+		UPDATE orders SET
+			customer_id = NULL,
+			customer_name = ?,
+			customer_email = '',
+			phone_number = '',
+			alternative_phone_number = NULL,
+			street = '',
+			postal_code = '',
+			delivery_notes = NULL
+		WHERE customer_id = ?
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, AnonymousCustomer, customerId)
+	return err
+}
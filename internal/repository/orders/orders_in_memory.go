@@ -0,0 +1,140 @@
+package orders
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/models"
+	"github.com/faizisyellow/indocoffee/internal/repository"
+)
+
+type InMemoryOrders struct {
+	Orders []models.Order
+}
+
+func (o *InMemoryOrders) Create(ctx context.Context, _ *sql.Tx, nw models.Order) (string, error) {
+
+	nw.CreatedAt = time.Now()
+	if nw.Status == "" {
+		nw.Status = Confirm.String()
+	}
+	o.Orders = append(o.Orders, nw)
+
+	return nw.Id, nil
+}
+
+func (o *InMemoryOrders) GetIdempotencyKey(ctx context.Context, idemKey string) (string, error) {
+
+	for _, order := range o.Orders {
+		if order.IdempotencyKey == idemKey {
+			return order.IdempotencyKey, nil
+		}
+	}
+
+	return "", sql.ErrNoRows
+}
+
+func (o *InMemoryOrders) UpdateOrdersStatus(ctx context.Context, orderId string, status OrderStatus) error {
+
+	for i, order := range o.Orders {
+		if order.Id == orderId {
+			o.Orders[i].Status = status.String()
+		}
+	}
+
+	return nil
+}
+
+func (o *InMemoryOrders) UpdateOrdersStatusWithTx(ctx context.Context, _ *sql.Tx, orderId string, status OrderStatus) error {
+	return o.UpdateOrdersStatus(ctx, orderId, status)
+}
+
+func (o *InMemoryOrders) GetOrderStatusById(ctx context.Context, orderId string) (string, error) {
+
+	order, err := o.GetOrderById(ctx, orderId)
+	if err != nil {
+		return "", err
+	}
+
+	return order.Status, nil
+}
+
+func (o *InMemoryOrders) GetOrderById(ctx context.Context, orderId string) (models.Order, error) {
+
+	for _, order := range o.Orders {
+		if order.Id == orderId {
+			return order, nil
+		}
+	}
+
+	return models.Order{}, sql.ErrNoRows
+}
+
+func (o *InMemoryOrders) GetOrders(ctx context.Context, r repository.PaginatedOrdersQuery) ([]models.Order, error) {
+
+	var result []models.Order
+	for _, order := range o.Orders {
+		if strings.Contains(order.Status, r.Status) {
+			result = append(result, order)
+		}
+	}
+
+	if r.Sort == "desc" {
+		slices.Reverse(result)
+	}
+
+	start := min(r.Offset, len(result))
+	end := len(result)
+	if r.Limit > 0 {
+		end = min(start+r.Limit, len(result))
+	}
+
+	return result[start:end], nil
+}
+
+func (o *InMemoryOrders) GetByCustomerId(ctx context.Context, customerId int) ([]models.Order, error) {
+
+	result := []models.Order{}
+	for _, order := range slices.Backward(o.Orders) {
+		if order.CustomerId == customerId {
+			result = append(result, order)
+		}
+	}
+
+	return result, nil
+}
+
+func (o *InMemoryOrders) GetStatusesByCustomerId(ctx context.Context, _ *sql.Tx, customerId int) ([]string, error) {
+
+	statuses := []string{}
+	for _, order := range o.Orders {
+		if order.CustomerId == customerId {
+			statuses = append(statuses, order.Status)
+		}
+	}
+
+	return statuses, nil
+}
+
+func (o *InMemoryOrders) AnonymizeByCustomerId(ctx context.Context, _ *sql.Tx, customerId int) error {
+
+	for i, order := range o.Orders {
+		if order.CustomerId != customerId {
+			continue
+		}
+
+		o.Orders[i].CustomerId = 0
+		o.Orders[i].CustomerName = AnonymousCustomer
+		o.Orders[i].CustomerEmail = ""
+		o.Orders[i].PhoneNumber = ""
+		o.Orders[i].AlternativePhoneNumber = nil
+		o.Orders[i].Street = ""
+		o.Orders[i].PostalCode = ""
+		o.Orders[i].DeliveryNotes = nil
+	}
+
+	return nil
+}
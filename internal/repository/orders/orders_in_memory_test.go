@@ -0,0 +1,16 @@
+package orders_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/faizisyellow/indocoffee/internal/repository/orders"
+)
+
+func TestInMemoryOrders(t *testing.T) {
+	orders.Contract{
+		NewOrders: func() (orders.Orders, *sql.Tx, func()) {
+			return &orders.InMemoryOrders{}, nil, func() {}
+		},
+	}.Test(t)
+}
@@ -2,15 +2,20 @@ package repository
 
 import (
 	"strconv"
+	"strings"
+	"time"
 )
 
 type PaginatedProductsQuery struct {
 	Limit  int    `json:"limit" validate:"gte=1,lte=20"`
 	Offset int    `json:"offset" validate:"gte=0,lte=100"`
 	Sort   string `json:"sort" validate:"oneof=asc desc"`
-	Roast  string `json:"roast"`
+	Roast  string `json:"roast" validate:"omitempty,oneof=light medium dark"`
 	Form   int    `json:"form"`
 	Bean   int    `json:"bean"`
+	// Q searches the name, description, tasting notes, bean and form of the products,
+	// the most relevant first.
+	Q string `json:"q" validate:"omitempty,max=100"`
 }
 
 type QueryProducts struct {
@@ -20,6 +25,7 @@ type QueryProducts struct {
 	Roast  string
 	Form   string
 	Bean   string
+	Q      string
 }
 
 func (p PaginatedProductsQuery) Parse(r QueryProducts) (PaginatedProductsQuery, error) {
@@ -69,6 +75,11 @@ func (p PaginatedProductsQuery) Parse(r QueryProducts) (PaginatedProductsQuery,
 		p.Bean = b
 	}
 
+	q := strings.TrimSpace(r.Q)
+	if q != "" {
+		p.Q = q
+	}
+
 	return p, nil
 }
 
@@ -116,3 +127,145 @@ func (p PaginatedOrdersQuery) Parse(r QueryOrders) (PaginatedOrdersQuery, error)
 
 	return p, nil
 }
+
+type PaginatedUsersQuery struct {
+	Limit  int    `json:"limit" validate:"gte=1,lte=20"`
+	Offset int    `json:"offset" validate:"gte=0"`
+	Sort   string `json:"sort" validate:"oneof=asc desc"`
+	Role   int    `json:"role"`
+	Active *bool  `json:"active"`
+	Search string `json:"search" validate:"max=32"`
+}
+
+type QueryUsers struct {
+	Limit  string
+	Offset string
+	Sort   string
+	Role   string
+	Active string
+	Search string
+}
+
+func (p PaginatedUsersQuery) Parse(r QueryUsers) (PaginatedUsersQuery, error) {
+	limit := r.Limit
+	if limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil {
+			return p, err
+		}
+		p.Limit = l
+	}
+
+	offset := r.Offset
+	if offset != "" {
+		o, err := strconv.Atoi(offset)
+		if err != nil {
+			return p, err
+		}
+		p.Offset = o
+	}
+
+	if r.Sort != "" {
+		p.Sort = r.Sort
+	}
+
+	role := r.Role
+	if role != "" {
+		rl, err := strconv.Atoi(role)
+		if err != nil {
+			return p, err
+		}
+		p.Role = rl
+	}
+
+	active := r.Active
+	if active != "" {
+		a, err := strconv.ParseBool(active)
+		if err != nil {
+			return p, err
+		}
+		p.Active = &a
+	}
+
+	if r.Search != "" {
+		p.Search = r.Search
+	}
+
+	return p, nil
+}
+
+type PaginatedAuditQuery struct {
+	Limit      int        `json:"limit" validate:"gte=1,lte=100"`
+	Offset     int        `json:"offset" validate:"gte=0"`
+	ActorId    int        `json:"actor_id"`
+	TargetType string     `json:"target_type" validate:"max=32"`
+	TargetId   string     `json:"target_id" validate:"max=64"`
+	From       *time.Time `json:"from"`
+	To         *time.Time `json:"to"`
+}
+
+type QueryAudit struct {
+	Limit      string
+	Offset     string
+	ActorId    string
+	TargetType string
+	TargetId   string
+	From       string
+	To         string
+}
+
+// Parse reads the query, From and To are RFC 3339 times.
+func (p PaginatedAuditQuery) Parse(r QueryAudit) (PaginatedAuditQuery, error) {
+	limit := r.Limit
+	if limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil {
+			return p, err
+		}
+		p.Limit = l
+	}
+
+	offset := r.Offset
+	if offset != "" {
+		o, err := strconv.Atoi(offset)
+		if err != nil {
+			return p, err
+		}
+		p.Offset = o
+	}
+
+	actor := r.ActorId
+	if actor != "" {
+		a, err := strconv.Atoi(actor)
+		if err != nil {
+			return p, err
+		}
+		p.ActorId = a
+	}
+
+	if r.TargetType != "" {
+		p.TargetType = r.TargetType
+	}
+
+	if r.TargetId != "" {
+		p.TargetId = r.TargetId
+	}
+
+	if r.From != "" {
+		from, err := time.Parse(time.RFC3339, r.From)
+		if err != nil {
+			return p, err
+		}
+		p.From = &from
+	}
+
+	if r.To != "" {
+		to, err := time.Parse(time.RFC3339, r.To)
+		if err != nil {
+			return p, err
+		}
+		p.To = &to
+	}
+
+	return p, nil
+}
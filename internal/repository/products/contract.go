@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"slices"
 	"testing"
 
 	"github.com/faizisyellow/indocoffee/internal/models"
@@ -13,14 +14,27 @@ import (
 )
 
 type Products interface {
-	Insert(ctx context.Context, newProduct models.Product) error
+	Insert(ctx context.Context, tx *sql.Tx, newProduct models.Product) (int, error)
 	GetById(ctx context.Context, id int) (models.Product, error)
 	GetAll(ctx context.Context, r repository.PaginatedProductsQuery) ([]models.Product, error)
-	Update(ctx context.Context, product models.Product) error
-	DecrementQuantity(ctx context.Context, tx *sql.Tx, productId, quantity int) error
-	IncrementQuantity(ctx context.Context, tx *sql.Tx, productId, quantity int) error
+	Update(ctx context.Context, tx *sql.Tx, product models.Product) error
+	DecrementQuantity(ctx context.Context, tx *sql.Tx, variantId, quantity int) error
+	IncrementQuantity(ctx context.Context, tx *sql.Tx, variantId, quantity int) error
 	DeleteMany(ctx context.Context) error
-	Delete(ctx context.Context, id int) error
+	Delete(ctx context.Context, tx *sql.Tx, id int) error
+	InsertVariant(ctx context.Context, tx *sql.Tx, variant models.Variant) (int, error)
+	GetVariantById(ctx context.Context, id int) (models.Variant, error)
+	UpdateVariant(ctx context.Context, tx *sql.Tx, variant models.Variant) error
+	CountVariants(ctx context.Context, tx *sql.Tx, productId int) (int, error)
+	DeleteVariant(ctx context.Context, tx *sql.Tx, id int) error
+	CountImages(ctx context.Context, tx *sql.Tx, productId int) (int, error)
+	InsertImage(ctx context.Context, tx *sql.Tx, image models.Image) (int, error)
+	GetImages(ctx context.Context, productId int) ([]models.Image, error)
+	GetImageById(ctx context.Context, id int) (models.Image, error)
+	ReorderImages(ctx context.Context, tx *sql.Tx, productId int, imageIds []int) error
+	SetPrimaryImage(ctx context.Context, tx *sql.Tx, productId, imageId int) error
+	DeleteImage(ctx context.Context, tx *sql.Tx, id int) error
+	GetImageUrls(ctx context.Context) ([]string, error)
 }
 
 type Contract struct {
@@ -41,15 +55,18 @@ func (u Contract) Test(t *testing.T) {
 			})
 
 			newProduct := models.Product{
-				Roasted:  "light",
-				Price:    10.5,
-				Quantity: 50,
-				Image:    "light_arabica_grounded.jpeg",
-				BeanId:   1,
-				FormId:   1,
+				Roasted: "light",
+				Image:   "light_arabica_grounded.jpeg",
+				Name:    "light arabica grounded",
+				Variants: []models.Variant{
+					{Sku: "LIGHT-ARABICA-GROUNDED-250", Weight: 250, Price: 10.5, Quantity: 50},
+					{Sku: "LIGHT-ARABICA-GROUNDED-1000", Weight: 1000, Price: 36, Quantity: 20},
+				},
+				BeanId: 1,
+				FormId: 1,
 			}
 
-			err := product.Insert(ctx, newProduct)
+			_, err := product.Insert(ctx, nil, newProduct)
 			if err != nil {
 				t.Errorf("expected to be success but got error: %v", err.Error())
 				return
@@ -69,17 +86,17 @@ func (u Contract) Test(t *testing.T) {
 
 			newProduct := models.Product{
 				Roasted:  "light",
-				Price:    15.7,
-				Quantity: 50,
 				Image:    "light_arabica_grounded.jpeg",
+				Name:     "light arabica grounded",
+				Variants: []models.Variant{{Sku: "LIGHT-ARABICA-GROUNDED-250", Weight: 250, Price: 15.7, Quantity: 50}},
 				BeanId:   1,
 				FormId:   1,
 			}
 
-			product.Insert(ctx, newProduct)
+			product.Insert(ctx, nil, newProduct)
 
 			// recreate again
-			err := product.Insert(ctx, newProduct)
+			_, err := product.Insert(ctx, nil, newProduct)
 			if err == nil {
 				t.Error("expected to be error but got success")
 				return
@@ -87,6 +104,214 @@ func (u Contract) Test(t *testing.T) {
 		})
 	})
 
+	t.Run("get product by id with its attributes", func(t *testing.T) {
+		var (
+			ctx               = context.Background()
+			product, teardown = u.NewProducts()
+		)
+		t.Cleanup(func() {
+			product.DeleteMany(ctx)
+			teardown()
+		})
+
+		newProduct := models.Product{
+			Name:         "Gayo Wine",
+			Description:  "Aceh highland arabica",
+			Origin:       "Aceh",
+			Process:      "natural",
+			Altitude:     1400,
+			TastingNotes: []string{"red wine", "dark chocolate"},
+			Roasted:      "medium",
+			Image:        "gayo_wine.jpeg",
+			Variants: []models.Variant{
+				{Sku: "GAYO-WINE-200", Weight: 200, Price: 12.5, Quantity: 40},
+				{Sku: "GAYO-WINE-500", Weight: 500, Price: 28, Quantity: 15},
+			},
+			BeanId: 1,
+			FormId: 2,
+		}
+
+		if _, err := product.Insert(ctx, nil, newProduct); err != nil {
+			t.Fatalf("expected to be success but got error: %v", err.Error())
+		}
+
+		products, err := product.GetAll(ctx, repository.PaginatedProductsQuery{Sort: "asc"})
+		if err != nil || len(products) != 1 {
+			t.Fatalf("expected the new product but got %v products and error: %v", len(products), err)
+		}
+
+		result, err := product.GetById(ctx, products[0].Id)
+		if err != nil {
+			t.Fatalf("expected to be success but got error: %v", err.Error())
+		}
+
+		expected := newProduct
+		expected.Images = []models.Image{{Url: "gayo_wine.jpeg", Position: 0, IsPrimary: true}}
+		expected.BeansModel = models.BeansModel{Name: "arabica"}
+		expected.FormsModel = models.FormsModel{Name: "whole coffee beans"}
+
+		if diff := cmp.Diff(expected, result, cmpopts.IgnoreFields(models.Product{}, "Id"), cmpopts.IgnoreFields(models.Variant{}, "Id", "ProductId"), cmpopts.IgnoreFields(models.Image{}, "Id", "ProductId")); diff != "" {
+			t.Errorf("mismatch (-expected +got):\n%s", diff)
+		}
+	})
+
+	t.Run("manage variants of a product", func(t *testing.T) {
+		var (
+			ctx               = context.Background()
+			product, teardown = u.NewProducts()
+		)
+		t.Cleanup(func() {
+			product.DeleteMany(ctx)
+			teardown()
+		})
+
+		if err := createTestProduct(t, product); err != nil {
+			t.Fatal(err)
+		}
+
+		products, err := product.GetAll(ctx, repository.PaginatedProductsQuery{Sort: "asc"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		productId := products[0].Id
+
+		id, err := product.InsertVariant(ctx, nil, models.Variant{ProductId: productId, Sku: "LIGHT-ARABICA-GROUNDED-1000", Weight: 1000, Price: 36, Quantity: 5})
+		if err != nil {
+			t.Fatalf("expected to be success but got error: %v", err)
+		}
+
+		if _, err := product.InsertVariant(ctx, nil, models.Variant{ProductId: productId, Sku: "LIGHT-ARABICA-GROUNDED-1000", Weight: 500, Price: 20, Quantity: 5}); err == nil {
+			t.Error("expected a duplicate sku to be error but got success")
+		}
+
+		variant, err := product.GetVariantById(ctx, id)
+		if err != nil {
+			t.Fatalf("expected to be success but got error: %v", err)
+		}
+
+		if variant.ProductId != productId || variant.Weight != 1000 || variant.Quantity != 5 {
+			t.Errorf("expected the new variant but got: %+v", variant)
+		}
+
+		variant.Price = 34
+		if err := product.UpdateVariant(ctx, nil, variant); err != nil {
+			t.Fatalf("expected to be success but got error: %v", err)
+		}
+
+		result, err := product.GetById(ctx, productId)
+		if err != nil {
+			t.Fatalf("expected to be success but got error: %v", err)
+		}
+
+		if len(result.Variants) != 2 || result.Variants[1].Price != 34 {
+			t.Errorf("expected the updated variant to be listed last but got: %+v", result.Variants)
+		}
+
+		if err := product.DeleteVariant(ctx, nil, id); err != nil {
+			t.Fatalf("expected to be success but got error: %v", err)
+		}
+
+		if _, err := product.GetVariantById(ctx, id); err != sql.ErrNoRows {
+			t.Errorf("expected sql.ErrNoRows but got: %v", err)
+		}
+
+		count, err := product.CountVariants(ctx, nil, productId)
+		if err != nil {
+			t.Fatalf("expected to be success but got error: %v", err)
+		}
+
+		if count != 1 {
+			t.Errorf("expected 1 variant left but got: %v", count)
+		}
+	})
+
+	t.Run("manage the gallery of a product", func(t *testing.T) {
+		var (
+			ctx               = context.Background()
+			product, teardown = u.NewProducts()
+		)
+		t.Cleanup(func() {
+			product.DeleteMany(ctx)
+			teardown()
+		})
+
+		if err := createTestProduct(t, product); err != nil {
+			t.Fatal(err)
+		}
+
+		products, err := product.GetAll(ctx, repository.PaginatedProductsQuery{Sort: "asc"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		productId := products[0].Id
+
+		secondId, err := product.InsertImage(ctx, nil, models.Image{ProductId: productId, Url: "second.jpeg"})
+		if err != nil {
+			t.Fatalf("expected to be success but got error: %v", err)
+		}
+
+		thirdId, err := product.InsertImage(ctx, nil, models.Image{ProductId: productId, Url: "third.jpeg"})
+		if err != nil {
+			t.Fatalf("expected to be success but got error: %v", err)
+		}
+
+		images, err := product.GetImages(ctx, productId)
+		if err != nil {
+			t.Fatalf("expected to be success but got error: %v", err)
+		}
+
+		if len(images) != 3 || !images[0].IsPrimary || images[1].Id != secondId || images[2].Id != thirdId || images[2].Position != 2 {
+			t.Fatalf("expected new images to be appended to the gallery but got: %+v", images)
+		}
+
+		count, err := product.CountImages(ctx, nil, productId)
+		if err != nil {
+			t.Fatalf("expected to be success but got error: %v", err)
+		}
+
+		if count != 3 {
+			t.Errorf("expected 3 images but got: %v", count)
+		}
+
+		if err := product.ReorderImages(ctx, nil, productId, []int{thirdId, images[0].Id, secondId}); err != nil {
+			t.Fatalf("expected to be success but got error: %v", err)
+		}
+
+		if err := product.SetPrimaryImage(ctx, nil, productId, thirdId); err != nil {
+			t.Fatalf("expected to be success but got error: %v", err)
+		}
+
+		result, err := product.GetById(ctx, productId)
+		if err != nil {
+			t.Fatalf("expected to be success but got error: %v", err)
+		}
+
+		if result.Image != "third.jpeg" || result.Images[0].Id != thirdId || result.Images[2].Id != secondId {
+			t.Errorf("expected the third image to be first and primary but got: %+v", result.Images)
+		}
+
+		if result.Images[1].IsPrimary {
+			t.Error("expected the previous primary image to be unset")
+		}
+
+		if err := product.DeleteImage(ctx, nil, secondId); err != nil {
+			t.Fatalf("expected to be success but got error: %v", err)
+		}
+
+		if _, err := product.GetImageById(ctx, secondId); err != sql.ErrNoRows {
+			t.Errorf("expected sql.ErrNoRows but got: %v", err)
+		}
+
+		urls, err := product.GetImageUrls(ctx)
+		if err != nil {
+			t.Fatalf("expected to be success but got error: %v", err)
+		}
+
+		if !slices.Contains(urls, "third.jpeg") || slices.Contains(urls, "second.jpeg") {
+			t.Errorf("expected the urls of the remaining images but got: %v", urls)
+		}
+	})
+
 	t.Run("get all products", func(t *testing.T) {
 		tests := []struct {
 			name     string
@@ -99,21 +324,29 @@ func (u Contract) Test(t *testing.T) {
 					Sort: "asc",
 				},
 				expected: []models.Product{
-					{Roasted: "light", Price: 10.5, Quantity: 50, Image: "light_arabica_grounded.jpeg", BeanId: 1, FormId: 1,
+					{Roasted: "light", Image: "light_arabica_grounded.jpeg", Name: "light arabica grounded",
+						Variants: []models.Variant{{Sku: "LIGHT-ARABICA-GROUNDED", Weight: 250, Price: 10.5, Quantity: 50}}, BeanId: 1, FormId: 1,
 						BeansModel: models.BeansModel{Name: "arabica"}, FormsModel: models.FormsModel{Name: "grounded"}},
-					{Roasted: "medium", Price: 12.0, Quantity: 70, Image: "medium_arabica_grounded.jpeg", BeanId: 1, FormId: 1,
+					{Roasted: "medium", Image: "medium_arabica_grounded.jpeg", Name: "medium arabica grounded",
+						Variants: []models.Variant{{Sku: "MEDIUM-ARABICA-GROUNDED", Weight: 250, Price: 12.0, Quantity: 70}}, BeanId: 1, FormId: 1,
 						BeansModel: models.BeansModel{Name: "arabica"}, FormsModel: models.FormsModel{Name: "grounded"}},
-					{Roasted: "dark", Price: 14.8, Quantity: 30, Image: "dark_arabica_whole.jpeg", BeanId: 1, FormId: 2,
+					{Roasted: "dark", Image: "dark_arabica_whole.jpeg", Name: "dark arabica whole",
+						Variants: []models.Variant{{Sku: "DARK-ARABICA-WHOLE", Weight: 250, Price: 14.8, Quantity: 30}}, BeanId: 1, FormId: 2,
 						BeansModel: models.BeansModel{Name: "arabica"}, FormsModel: models.FormsModel{Name: "whole coffee beans"}},
-					{Roasted: "light", Price: 15.2, Quantity: 120, Image: "light_robusta_grounded.jpeg", BeanId: 2, FormId: 1,
+					{Roasted: "light", Image: "light_robusta_grounded.jpeg", Name: "light robusta grounded",
+						Variants: []models.Variant{{Sku: "LIGHT-ROBUSTA-GROUNDED", Weight: 250, Price: 15.2, Quantity: 120}}, BeanId: 2, FormId: 1,
 						BeansModel: models.BeansModel{Name: "robusta"}, FormsModel: models.FormsModel{Name: "grounded"}},
-					{Roasted: "medium", Price: 18.0, Quantity: 90, Image: "medium_robusta_grounded.jpeg", BeanId: 2, FormId: 1,
+					{Roasted: "medium", Image: "medium_robusta_grounded.jpeg", Name: "medium robusta grounded",
+						Variants: []models.Variant{{Sku: "MEDIUM-ROBUSTA-GROUNDED", Weight: 250, Price: 18.0, Quantity: 90}}, BeanId: 2, FormId: 1,
 						BeansModel: models.BeansModel{Name: "robusta"}, FormsModel: models.FormsModel{Name: "grounded"}},
-					{Roasted: "dark", Price: 20.0, Quantity: 40, Image: "dark_robusta_whole.jpeg", BeanId: 2, FormId: 2,
+					{Roasted: "dark", Image: "dark_robusta_whole.jpeg", Name: "dark robusta whole",
+						Variants: []models.Variant{{Sku: "DARK-ROBUSTA-WHOLE", Weight: 250, Price: 20.0, Quantity: 40}}, BeanId: 2, FormId: 2,
 						BeansModel: models.BeansModel{Name: "robusta"}, FormsModel: models.FormsModel{Name: "whole coffee beans"}},
-					{Roasted: "light", Price: 25.5, Quantity: 200, Image: "light_arabica_whole_premium.jpeg", BeanId: 1, FormId: 2,
+					{Roasted: "light", Image: "light_arabica_whole_premium.jpeg", Name: "light arabica whole premium",
+						Variants: []models.Variant{{Sku: "LIGHT-ARABICA-WHOLE-PREMIUM", Weight: 250, Price: 25.5, Quantity: 200}}, BeanId: 1, FormId: 2,
 						BeansModel: models.BeansModel{Name: "arabica"}, FormsModel: models.FormsModel{Name: "whole coffee beans"}},
-					{Roasted: "dark", Price: 30.0, Quantity: 10, Image: "dark_robusta_grounded_limited.jpeg", BeanId: 2, FormId: 1,
+					{Roasted: "dark", Image: "dark_robusta_grounded_limited.jpeg", Name: "dark robusta grounded limited",
+						Variants: []models.Variant{{Sku: "DARK-ROBUSTA-GROUNDED-LIMITED", Weight: 250, Price: 30.0, Quantity: 10}}, BeanId: 2, FormId: 1,
 						BeansModel: models.BeansModel{Name: "robusta"}, FormsModel: models.FormsModel{Name: "grounded"}},
 				},
 			},
@@ -124,9 +357,11 @@ func (u Contract) Test(t *testing.T) {
 					Roast: "medium",
 				},
 				expected: []models.Product{
-					{Roasted: "medium", Price: 12.0, Quantity: 70, Image: "medium_arabica_grounded.jpeg", BeanId: 1, FormId: 1,
+					{Roasted: "medium", Image: "medium_arabica_grounded.jpeg", Name: "medium arabica grounded",
+						Variants: []models.Variant{{Sku: "MEDIUM-ARABICA-GROUNDED", Weight: 250, Price: 12.0, Quantity: 70}}, BeanId: 1, FormId: 1,
 						BeansModel: models.BeansModel{Name: "arabica"}, FormsModel: models.FormsModel{Name: "grounded"}},
-					{Roasted: "medium", Price: 18.0, Quantity: 90, Image: "medium_robusta_grounded.jpeg", BeanId: 2, FormId: 1,
+					{Roasted: "medium", Image: "medium_robusta_grounded.jpeg", Name: "medium robusta grounded",
+						Variants: []models.Variant{{Sku: "MEDIUM-ROBUSTA-GROUNDED", Weight: 250, Price: 18.0, Quantity: 90}}, BeanId: 2, FormId: 1,
 						BeansModel: models.BeansModel{Name: "robusta"}, FormsModel: models.FormsModel{Name: "grounded"}},
 				},
 			},
@@ -138,9 +373,11 @@ func (u Contract) Test(t *testing.T) {
 					Form:  1,
 				},
 				expected: []models.Product{
-					{Roasted: "light", Price: 10.5, Quantity: 50, Image: "light_arabica_grounded.jpeg", BeanId: 1, FormId: 1,
+					{Roasted: "light", Image: "light_arabica_grounded.jpeg", Name: "light arabica grounded",
+						Variants: []models.Variant{{Sku: "LIGHT-ARABICA-GROUNDED", Weight: 250, Price: 10.5, Quantity: 50}}, BeanId: 1, FormId: 1,
 						BeansModel: models.BeansModel{Name: "arabica"}, FormsModel: models.FormsModel{Name: "grounded"}},
-					{Roasted: "light", Price: 15.2, Quantity: 120, Image: "light_robusta_grounded.jpeg", BeanId: 2, FormId: 1,
+					{Roasted: "light", Image: "light_robusta_grounded.jpeg", Name: "light robusta grounded",
+						Variants: []models.Variant{{Sku: "LIGHT-ROBUSTA-GROUNDED", Weight: 250, Price: 15.2, Quantity: 120}}, BeanId: 2, FormId: 1,
 						BeansModel: models.BeansModel{Name: "robusta"}, FormsModel: models.FormsModel{Name: "grounded"}},
 				},
 			},
@@ -152,11 +389,14 @@ func (u Contract) Test(t *testing.T) {
 					Offset: 0,
 				},
 				expected: []models.Product{
-					{Roasted: "light", Price: 10.5, Quantity: 50, Image: "light_arabica_grounded.jpeg", BeanId: 1, FormId: 1,
+					{Roasted: "light", Image: "light_arabica_grounded.jpeg", Name: "light arabica grounded",
+						Variants: []models.Variant{{Sku: "LIGHT-ARABICA-GROUNDED", Weight: 250, Price: 10.5, Quantity: 50}}, BeanId: 1, FormId: 1,
 						BeansModel: models.BeansModel{Name: "arabica"}, FormsModel: models.FormsModel{Name: "grounded"}},
-					{Roasted: "medium", Price: 12.0, Quantity: 70, Image: "medium_arabica_grounded.jpeg", BeanId: 1, FormId: 1,
+					{Roasted: "medium", Image: "medium_arabica_grounded.jpeg", Name: "medium arabica grounded",
+						Variants: []models.Variant{{Sku: "MEDIUM-ARABICA-GROUNDED", Weight: 250, Price: 12.0, Quantity: 70}}, BeanId: 1, FormId: 1,
 						BeansModel: models.BeansModel{Name: "arabica"}, FormsModel: models.FormsModel{Name: "grounded"}},
-					{Roasted: "dark", Price: 14.8, Quantity: 30, Image: "dark_arabica_whole.jpeg", BeanId: 1, FormId: 2,
+					{Roasted: "dark", Image: "dark_arabica_whole.jpeg", Name: "dark arabica whole",
+						Variants: []models.Variant{{Sku: "DARK-ARABICA-WHOLE", Weight: 250, Price: 14.8, Quantity: 30}}, BeanId: 1, FormId: 2,
 						BeansModel: models.BeansModel{Name: "arabica"}, FormsModel: models.FormsModel{Name: "whole coffee beans"}},
 				},
 			},
@@ -169,9 +409,11 @@ func (u Contract) Test(t *testing.T) {
 					Roast:  "medium",
 				},
 				expected: []models.Product{
-					{Roasted: "medium", Price: 12.0, Quantity: 70, Image: "medium_arabica_grounded.jpeg", BeanId: 1, FormId: 1,
+					{Roasted: "medium", Image: "medium_arabica_grounded.jpeg", Name: "medium arabica grounded",
+						Variants: []models.Variant{{Sku: "MEDIUM-ARABICA-GROUNDED", Weight: 250, Price: 12.0, Quantity: 70}}, BeanId: 1, FormId: 1,
 						BeansModel: models.BeansModel{Name: "arabica"}, FormsModel: models.FormsModel{Name: "grounded"}},
-					{Roasted: "medium", Price: 18.0, Quantity: 90, Image: "medium_robusta_grounded.jpeg", BeanId: 2, FormId: 1,
+					{Roasted: "medium", Image: "medium_robusta_grounded.jpeg", Name: "medium robusta grounded",
+						Variants: []models.Variant{{Sku: "MEDIUM-ROBUSTA-GROUNDED", Weight: 250, Price: 18.0, Quantity: 90}}, BeanId: 2, FormId: 1,
 						BeansModel: models.BeansModel{Name: "robusta"}, FormsModel: models.FormsModel{Name: "grounded"}},
 				},
 			},
@@ -182,13 +424,17 @@ func (u Contract) Test(t *testing.T) {
 					Bean: 1,
 				},
 				expected: []models.Product{
-					{Roasted: "light", Price: 10.5, Quantity: 50, Image: "light_arabica_grounded.jpeg", BeanId: 1, FormId: 1,
+					{Roasted: "light", Image: "light_arabica_grounded.jpeg", Name: "light arabica grounded",
+						Variants: []models.Variant{{Sku: "LIGHT-ARABICA-GROUNDED", Weight: 250, Price: 10.5, Quantity: 50}}, BeanId: 1, FormId: 1,
 						BeansModel: models.BeansModel{Name: "arabica"}, FormsModel: models.FormsModel{Name: "grounded"}},
-					{Roasted: "medium", Price: 12.0, Quantity: 70, Image: "medium_arabica_grounded.jpeg", BeanId: 1, FormId: 1,
+					{Roasted: "medium", Image: "medium_arabica_grounded.jpeg", Name: "medium arabica grounded",
+						Variants: []models.Variant{{Sku: "MEDIUM-ARABICA-GROUNDED", Weight: 250, Price: 12.0, Quantity: 70}}, BeanId: 1, FormId: 1,
 						BeansModel: models.BeansModel{Name: "arabica"}, FormsModel: models.FormsModel{Name: "grounded"}},
-					{Roasted: "dark", Price: 14.8, Quantity: 30, Image: "dark_arabica_whole.jpeg", BeanId: 1, FormId: 2,
+					{Roasted: "dark", Image: "dark_arabica_whole.jpeg", Name: "dark arabica whole",
+						Variants: []models.Variant{{Sku: "DARK-ARABICA-WHOLE", Weight: 250, Price: 14.8, Quantity: 30}}, BeanId: 1, FormId: 2,
 						BeansModel: models.BeansModel{Name: "arabica"}, FormsModel: models.FormsModel{Name: "whole coffee beans"}},
-					{Roasted: "light", Price: 25.5, Quantity: 200, Image: "light_arabica_whole_premium.jpeg", BeanId: 1, FormId: 2,
+					{Roasted: "light", Image: "light_arabica_whole_premium.jpeg", Name: "light arabica whole premium",
+						Variants: []models.Variant{{Sku: "LIGHT-ARABICA-WHOLE-PREMIUM", Weight: 250, Price: 25.5, Quantity: 200}}, BeanId: 1, FormId: 2,
 						BeansModel: models.BeansModel{Name: "arabica"}, FormsModel: models.FormsModel{Name: "whole coffee beans"}},
 				},
 			},
@@ -212,12 +458,111 @@ func (u Contract) Test(t *testing.T) {
 					t.Fatalf("unexpected error: %v", err)
 				}
 
-				if diff := cmp.Diff(tc.expected, products, cmpopts.IgnoreFields(models.Product{}, "Id")); diff != "" {
+				if diff := cmp.Diff(tc.expected, products, cmpopts.IgnoreFields(models.Product{}, "Id"), cmpopts.IgnoreFields(models.Variant{}, "Id", "ProductId")); diff != "" {
 					t.Errorf("mismatch (-expected +got):\n%s", diff)
 				}
 			})
 		}
 	})
+
+	t.Run("search products", func(t *testing.T) {
+		newProducts := []models.Product{
+			{Name: "Toraja Sapan", Description: "Toraja highland arabica, natural process", TastingNotes: []string{"jackfruit", "cacao"},
+				Roasted: "light", Image: "toraja_sapan.jpeg", Variants: []models.Variant{{Sku: "TORAJA-SAPAN", Weight: 250, Price: 18, Quantity: 10}}, BeanId: 1, FormId: 2},
+			{Name: "Toraja Kalosi", Description: "Washed toraja from Kalosi", TastingNotes: []string{"spice", "brown sugar"},
+				Roasted: "medium", Image: "toraja_kalosi.jpeg", Variants: []models.Variant{{Sku: "TORAJA-KALOSI", Weight: 250, Price: 15, Quantity: 10}}, BeanId: 1, FormId: 1},
+			{Name: "Gayo", Description: "Aceh beans dried natural", TastingNotes: []string{"strawberry"},
+				Roasted: "dark", Image: "gayo.jpeg", Variants: []models.Variant{{Sku: "GAYO", Weight: 250, Price: 12, Quantity: 10}}, BeanId: 2, FormId: 1},
+			{Name: "Flores Bajawa", Description: "Volcanic soil", TastingNotes: []string{"dark chocolate", "tobacco"},
+				Roasted: "dark", Image: "flores_bajawa.jpeg", Variants: []models.Variant{{Sku: "FLORES-BAJAWA", Weight: 250, Price: 20, Quantity: 10}}, BeanId: 1, FormId: 2},
+		}
+
+		tests := []struct {
+			name     string
+			query    repository.PaginatedProductsQuery
+			expected []string
+			// first is the product expected to be the most relevant and its snippet
+			first   string
+			snippet string
+		}{
+			{
+				name:     "products matching any word, the one matching all first",
+				query:    repository.PaginatedProductsQuery{Sort: "asc", Q: "toraja natural"},
+				expected: []string{"Toraja Sapan", "Toraja Kalosi", "Gayo"},
+				first:    "Toraja Sapan",
+				snippet:  "<mark>Toraja</mark> highland arabica, <mark>natural</mark> process",
+			},
+			{
+				name:     "search the tasting notes",
+				query:    repository.PaginatedProductsQuery{Sort: "asc", Q: "Jackfruit"},
+				expected: []string{"Toraja Sapan"},
+				first:    "Toraja Sapan",
+				snippet:  "<mark>jackfruit</mark>, cacao",
+			},
+			{
+				name:     "search the bean name",
+				query:    repository.PaginatedProductsQuery{Sort: "asc", Q: "robusta"},
+				expected: []string{"Gayo"},
+				first:    "Gayo",
+				snippet:  "<mark>robusta</mark>",
+			},
+			{
+				name:     "search with filters",
+				query:    repository.PaginatedProductsQuery{Sort: "asc", Q: "toraja", Roast: "medium"},
+				expected: []string{"Toraja Kalosi"},
+				first:    "Toraja Kalosi",
+				snippet:  "Washed <mark>toraja</mark> from Kalosi",
+			},
+			{
+				name:     "words too short or too common match nothing",
+				query:    repository.PaginatedProductsQuery{Sort: "asc", Q: "the of"},
+				expected: []string{},
+			},
+		}
+
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				ctx := context.Background()
+				product, teardown := u.NewProducts()
+				t.Cleanup(func() {
+					product.DeleteMany(ctx)
+					teardown()
+				})
+
+				for _, newProduct := range newProducts {
+					if _, err := product.Insert(ctx, nil, newProduct); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				products, err := product.GetAll(ctx, tc.query)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				names := []string{}
+				for _, found := range products {
+					names = append(names, found.Name)
+				}
+
+				if diff := cmp.Diff(tc.expected, names, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+					t.Errorf("mismatch (-expected +got):\n%s", diff)
+				}
+
+				if tc.first == "" {
+					return
+				}
+
+				if len(products) == 0 || products[0].Name != tc.first {
+					t.Fatalf("expected %v to be the most relevant but got: %v", tc.first, names)
+				}
+
+				if products[0].Snippet != tc.snippet {
+					t.Errorf("expected snippet %q but got %q", tc.snippet, products[0].Snippet)
+				}
+			})
+		}
+	})
 }
 
 func createTestProduct(t *testing.T, p Products) error {
@@ -228,27 +573,35 @@ func createTestProduct(t *testing.T, p Products) error {
 	}{
 		input: []models.Product{
 			// Arabica, grounded
-			{Roasted: "light", Price: 10.5, Quantity: 50, Image: "light_arabica_grounded.jpeg", BeanId: 1, FormId: 1},
-			{Roasted: "medium", Price: 12.0, Quantity: 70, Image: "medium_arabica_grounded.jpeg", BeanId: 1, FormId: 1},
+			{Roasted: "light", Image: "light_arabica_grounded.jpeg", Name: "light arabica grounded",
+				Variants: []models.Variant{{Sku: "LIGHT-ARABICA-GROUNDED", Weight: 250, Price: 10.5, Quantity: 50}}, BeanId: 1, FormId: 1},
+			{Roasted: "medium", Image: "medium_arabica_grounded.jpeg", Name: "medium arabica grounded",
+				Variants: []models.Variant{{Sku: "MEDIUM-ARABICA-GROUNDED", Weight: 250, Price: 12.0, Quantity: 70}}, BeanId: 1, FormId: 1},
 
 			// Arabica, whole beans
-			{Roasted: "dark", Price: 14.8, Quantity: 30, Image: "dark_arabica_whole.jpeg", BeanId: 1, FormId: 2},
+			{Roasted: "dark", Image: "dark_arabica_whole.jpeg", Name: "dark arabica whole",
+				Variants: []models.Variant{{Sku: "DARK-ARABICA-WHOLE", Weight: 250, Price: 14.8, Quantity: 30}}, BeanId: 1, FormId: 2},
 
 			// Robusta, grounded
-			{Roasted: "light", Price: 15.2, Quantity: 120, Image: "light_robusta_grounded.jpeg", BeanId: 2, FormId: 1},
-			{Roasted: "medium", Price: 18.0, Quantity: 90, Image: "medium_robusta_grounded.jpeg", BeanId: 2, FormId: 1},
+			{Roasted: "light", Image: "light_robusta_grounded.jpeg", Name: "light robusta grounded",
+				Variants: []models.Variant{{Sku: "LIGHT-ROBUSTA-GROUNDED", Weight: 250, Price: 15.2, Quantity: 120}}, BeanId: 2, FormId: 1},
+			{Roasted: "medium", Image: "medium_robusta_grounded.jpeg", Name: "medium robusta grounded",
+				Variants: []models.Variant{{Sku: "MEDIUM-ROBUSTA-GROUNDED", Weight: 250, Price: 18.0, Quantity: 90}}, BeanId: 2, FormId: 1},
 
 			// Robusta, whole beans
-			{Roasted: "dark", Price: 20.0, Quantity: 40, Image: "dark_robusta_whole.jpeg", BeanId: 2, FormId: 2},
+			{Roasted: "dark", Image: "dark_robusta_whole.jpeg", Name: "dark robusta whole",
+				Variants: []models.Variant{{Sku: "DARK-ROBUSTA-WHOLE", Weight: 250, Price: 20.0, Quantity: 40}}, BeanId: 2, FormId: 2},
 
 			// Extra variations for testing price/quantity ranges
-			{Roasted: "light", Price: 25.5, Quantity: 200, Image: "light_arabica_whole_premium.jpeg", BeanId: 1, FormId: 2},
-			{Roasted: "dark", Price: 30.0, Quantity: 10, Image: "dark_robusta_grounded_limited.jpeg", BeanId: 2, FormId: 1},
+			{Roasted: "light", Image: "light_arabica_whole_premium.jpeg", Name: "light arabica whole premium",
+				Variants: []models.Variant{{Sku: "LIGHT-ARABICA-WHOLE-PREMIUM", Weight: 250, Price: 25.5, Quantity: 200}}, BeanId: 1, FormId: 2},
+			{Roasted: "dark", Image: "dark_robusta_grounded_limited.jpeg", Name: "dark robusta grounded limited",
+				Variants: []models.Variant{{Sku: "DARK-ROBUSTA-GROUNDED-LIMITED", Weight: 250, Price: 30.0, Quantity: 10}}, BeanId: 2, FormId: 1},
 		},
 	}
 
 	for _, nw := range newProducts.input {
-		err := p.Insert(context.Background(), nw)
+		_, err := p.Insert(context.Background(), nil, nw)
 		if err != nil {
 			return fmt.Errorf("failed inserting product %+v: %w", nw, err)
 		}
@@ -3,6 +3,8 @@ package products
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"strings"
 
 	"github.com/faizisyellow/indocoffee/internal/models"
 	"github.com/faizisyellow/indocoffee/internal/repository"
@@ -12,35 +14,101 @@ type ProductRepository struct {
 	Db *sql.DB
 }
 
-func (p *ProductRepository) Insert(ctx context.Context, newProduct models.Product) error {
+// inTx runs fn in tx, or in a transaction of its own
+// when the caller does not have one.
+func (p *ProductRepository) inTx(ctx context.Context, tx *sql.Tx, fn func(tx *sql.Tx) error) error {
+	if tx != nil {
+		return fn(tx)
+	}
+
+	tx, err := p.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Insert inserts new product together with its variants and its image as the primary image,
+// either all of them are saved or none. Returns the id of the new product.
+func (p *ProductRepository) Insert(ctx context.Context, tx *sql.Tx, newProduct models.Product) (int, error) {
+
+	qry := `INSERT INTO products(name,description,origin,process,altitude,tasting_notes,roasted,bean_id,form_id)
+	VALUE(?,?,?,?,?,?,?,?,?)`
 
-	qry := `INSERT INTO products(roasted,price,quantity,image,bean_id,form_id) VALUE(?,?,?,?,?,?)`
+	tastingNotes, err := encodeTastingNotes(newProduct.TastingNotes)
+	if err != nil {
+		return 0, err
+	}
 
 	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
 	defer cancel()
 
-	_, err := p.Db.ExecContext(
-		ctx,
-		qry,
-		newProduct.Roasted,
-		newProduct.Price,
-		newProduct.Quantity,
-		newProduct.Image,
-		newProduct.BeanId,
-		newProduct.FormId,
-	)
+	var productId int64
+	err = p.inTx(ctx, tx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(
+			ctx,
+			qry,
+			newProduct.Name,
+			nullString(newProduct.Description),
+			newProduct.Origin,
+			nullString(newProduct.Process),
+			newProduct.Altitude,
+			tastingNotes,
+			newProduct.Roasted,
+			newProduct.BeanId,
+			newProduct.FormId,
+		)
+		if err != nil {
+			return err
+		}
 
-	return err
+		productId, err = result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		for _, variant := range newProduct.Variants {
+			variant.ProductId = int(productId)
+			if err := insertVariant(ctx, tx, variant); err != nil {
+				return err
+			}
+		}
+
+		if newProduct.Image != "" {
+			if err := savePrimaryImage(ctx, tx, int(productId), newProduct.Image, newProduct.ImageRenditions); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(productId), nil
 }
 
 func (p *ProductRepository) GetById(ctx context.Context, id int) (models.Product, error) {
 	qry := `
 	SELECT
        products.id,
+       products.name,
+       products.description,
+       products.origin,
+       products.process,
+       products.altitude,
+       products.tasting_notes,
        products.roasted,
-       products.price,
-       products.quantity,
-       products.image,
+       COALESCE(product_images.url, '') AS image,
+       product_images.renditions,
        products.bean_id,
        products.form_id,
        beans.name  AS bean_name,
@@ -48,19 +116,31 @@ func (p *ProductRepository) GetById(ctx context.Context, id int) (models.Product
     FROM products
     JOIN beans ON beans.id = products.bean_id
     JOIN forms ON forms.id = products.form_id
+    LEFT JOIN product_images ON product_images.product_id = products.id AND product_images.is_primary = TRUE
     WHERE products.id = ?;
 	`
 
 	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
 	defer cancel()
 
-	product := models.Product{}
+	var (
+		product      models.Product
+		description  sql.NullString
+		process      sql.NullString
+		tastingNotes []byte
+		renditions   []byte
+	)
 	if err := p.Db.QueryRowContext(ctx, qry, id).Scan(
 		&product.Id,
+		&product.Name,
+		&description,
+		&product.Origin,
+		&process,
+		&product.Altitude,
+		&tastingNotes,
 		&product.Roasted,
-		&product.Price,
-		&product.Quantity,
 		&product.Image,
+		&renditions,
 		&product.BeanId,
 		&product.FormId,
 		&product.BeansModel.Name,
@@ -69,17 +149,59 @@ func (p *ProductRepository) GetById(ctx context.Context, id int) (models.Product
 		return models.Product{}, err
 	}
 
+	if err := setAttributes(&product, description, process, tastingNotes); err != nil {
+		return models.Product{}, err
+	}
+
+	if err := decodeRenditions(renditions, &product.ImageRenditions); err != nil {
+		return models.Product{}, err
+	}
+
+	variants, err := p.getVariants(ctx, []int{product.Id})
+	if err != nil {
+		return models.Product{}, err
+	}
+	product.Variants = variants[product.Id]
+
+	product.Images, err = p.GetImages(ctx, product.Id)
+	if err != nil {
+		return models.Product{}, err
+	}
+
 	return product, nil
 }
 
+// GetAll gets products with their variants and primary image, sorted by the price of their cheapest variant.
+// A search only gets the products matching it, the most relevant first.
 func (p *ProductRepository) GetAll(ctx context.Context, qry repository.PaginatedProductsQuery) ([]models.Product, error) {
+	var (
+		terms     []string
+		relevance = "0"
+		args      = []any{}
+	)
+
+	if qry.Q != "" {
+		terms = searchTerms(qry.Q)
+		search := strings.Join(terms, " ")
+
+		relevance = `MATCH(products.name, products.description, products.tasting_notes_text) AGAINST(? IN NATURAL LANGUAGE MODE)
+					+ MATCH(beans.name) AGAINST(? IN NATURAL LANGUAGE MODE)
+					+ MATCH(forms.name) AGAINST(? IN NATURAL LANGUAGE MODE)`
+		args = append(args, search, search, search)
+	}
+
 	query := `
 		SELECT
 			p.id,
+			p.name,
+			p.description,
+			p.origin,
+			p.process,
+			p.altitude,
+			p.tasting_notes,
 			p.roasted,
-			p.price,
-			p.quantity,
 			p.image,
+			p.renditions,
 			p.bean_id,
 			p.form_id,
 			p.bean_name,
@@ -87,25 +209,33 @@ func (p *ProductRepository) GetAll(ctx context.Context, qry repository.Paginated
 		FROM (
 			SELECT
 				products.id,
+				products.name,
+				products.description,
+				products.origin,
+				products.process,
+				products.altitude,
+				products.tasting_notes,
 				products.roasted,
-				products.price,
-				products.quantity,
-				products.image,
+				COALESCE(product_images.url, '') AS image,
+				product_images.renditions,
 				products.bean_id,
 				products.form_id,
 				beans.name AS bean_name,
-				forms.name AS form_name
+				forms.name AS form_name,
+				` + relevance + ` AS relevance
 			FROM products
 			JOIN beans ON beans.id = products.bean_id
 			JOIN forms ON forms.id = products.form_id
+			JOIN (
+				SELECT product_id, MIN(price) AS price FROM product_variants GROUP BY product_id
+			) AS prices ON prices.product_id = products.id
+			LEFT JOIN product_images ON product_images.product_id = products.id AND product_images.is_primary = TRUE
 			WHERE 1=1
 `
 
-	args := []any{}
-
 	// add filters if present
 	if qry.Roast != "" {
-		query += " AND products.roasted LIKE CONCAT('%', ?, '%')"
+		query += " AND products.roasted = ?"
 		args = append(args, qry.Roast)
 	}
 
@@ -119,11 +249,19 @@ func (p *ProductRepository) GetAll(ctx context.Context, qry repository.Paginated
 		args = append(args, qry.Bean)
 	}
 
-	// close subquery
-	query += `
-			ORDER BY products.price ` + qry.Sort + `
+	// close subquery, a search keeps the products it matches
+	if qry.Q != "" {
+		query += `
+			HAVING relevance > 0
+			ORDER BY relevance DESC, prices.price ` + qry.Sort + `, products.id
 		) AS p
 	`
+	} else {
+		query += `
+			ORDER BY prices.price ` + qry.Sort + `, products.id
+		) AS p
+	`
+	}
 
 	// add pagination only if limit > 0
 	if qry.Limit > 0 {
@@ -144,13 +282,24 @@ func (p *ProductRepository) GetAll(ctx context.Context, qry repository.Paginated
 	products := make([]models.Product, 0)
 
 	for rows.Next() {
-		var product models.Product
+		var (
+			product      models.Product
+			description  sql.NullString
+			process      sql.NullString
+			tastingNotes []byte
+			renditions   []byte
+		)
 		if err := rows.Scan(
 			&product.Id,
+			&product.Name,
+			&description,
+			&product.Origin,
+			&process,
+			&product.Altitude,
+			&tastingNotes,
 			&product.Roasted,
-			&product.Price,
-			&product.Quantity,
 			&product.Image,
+			&renditions,
 			&product.BeanId,
 			&product.FormId,
 			&product.BeansModel.Name,
@@ -158,58 +307,116 @@ func (p *ProductRepository) GetAll(ctx context.Context, qry repository.Paginated
 		); err != nil {
 			return nil, err
 		}
+
+		if err := setAttributes(&product, description, process, tastingNotes); err != nil {
+			return nil, err
+		}
+
+		if err := decodeRenditions(renditions, &product.ImageRenditions); err != nil {
+			return nil, err
+		}
+
+		if qry.Q != "" {
+			product.Snippet = snippet(product, terms)
+		}
+
 		products = append(products, product)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(products) == 0 {
+		return products, nil
+	}
+
+	ids := make([]int, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.Id)
+	}
+
+	variants, err := p.getVariants(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range products {
+		products[i].Variants = variants[products[i].Id]
+	}
+
 	return products, nil
 }
 
-func (p *ProductRepository) Update(ctx context.Context, product models.Product) error {
+// Update updates the product and the url of its primary image, without its variants.
+func (p *ProductRepository) Update(ctx context.Context, tx *sql.Tx, product models.Product) error {
 	query := `UPDATE products SET
+		name = ?,
+		description = ?,
+		origin = ?,
+		process = ?,
+		altitude = ?,
+		tasting_notes = ?,
 		roasted = ?,
-		price = ?,
-		quantity = ?,
-		image = ?,
 		bean_id = ?,
 		form_id = ?
 		WHERE id = ?;
 	`
 
+	tastingNotes, err := encodeTastingNotes(product.TastingNotes)
+	if err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
 	defer cancel()
 
-	_, err := p.Db.ExecContext(
-		ctx,
-		query,
-		product.Roasted,
-		product.Price,
-		product.Quantity,
-		product.Image,
-		product.BeanId,
-		product.FormId,
-		product.Id,
-	)
+	return p.inTx(ctx, tx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(
+			ctx,
+			query,
+			product.Name,
+			nullString(product.Description),
+			product.Origin,
+			nullString(product.Process),
+			product.Altitude,
+			tastingNotes,
+			product.Roasted,
+			product.BeanId,
+			product.FormId,
+			product.Id,
+		)
+		if err != nil {
+			return err
+		}
 
-	return err
+		if product.Image != "" {
+			if err := savePrimaryImage(ctx, tx, product.Id, product.Image, product.ImageRenditions); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 }
 
-func (p *ProductRepository) DecrementQuantity(ctx context.Context, tx *sql.Tx, productId, quantity int) error {
-	query := `UPDATE products SET quantity = quantity - ? WHERE id = ?`
+func (p *ProductRepository) DecrementQuantity(ctx context.Context, tx *sql.Tx, variantId, quantity int) error {
+	query := `UPDATE product_variants SET quantity = quantity - ? WHERE id = ?`
 
 	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
 	defer cancel()
 
-	_, err := tx.ExecContext(ctx, query, quantity, productId)
+	_, err := tx.ExecContext(ctx, query, quantity, variantId)
 	return err
 }
 
-func (p *ProductRepository) IncrementQuantity(ctx context.Context, tx *sql.Tx, productId, quantity int) error {
-	query := `UPDATE products SET quantity = quantity + ? WHERE id = ?`
+func (p *ProductRepository) IncrementQuantity(ctx context.Context, tx *sql.Tx, variantId, quantity int) error {
+	query := `UPDATE product_variants SET quantity = quantity + ? WHERE id = ?`
 
 	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
 	defer cancel()
 
-	_, err := tx.ExecContext(ctx, query, quantity, productId)
+	_, err := tx.ExecContext(ctx, query, quantity, variantId)
 	return err
 }
 
@@ -227,13 +434,411 @@ func (p *ProductRepository) DeleteMany(ctx context.Context) error {
 	return nil
 }
 
-func (p *ProductRepository) Delete(ctx context.Context, id int) error {
+// Delete deletes the product, its variants and images are deleted with it.
+func (p *ProductRepository) Delete(ctx context.Context, tx *sql.Tx, id int) error {
 	query := `DELETE FROM products WHERE id = ?`
 
 	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
 	defer cancel()
 
-	_, err := p.Db.ExecContext(ctx, query, id)
+	_, err := repository.Execer(p.Db, tx).ExecContext(ctx, query, id)
+
+	return err
+}
+
+// InsertVariant adds a variant to an existing product.
+// Returns the id of the new variant.
+func (p *ProductRepository) InsertVariant(ctx context.Context, tx *sql.Tx, variant models.Variant) (int, error) {
+
+	query := `INSERT INTO product_variants(product_id,sku,weight,price,quantity) VALUES(?,?,?,?,?)`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	result, err := repository.Execer(p.Db, tx).ExecContext(ctx, query, variant.ProductId, variant.Sku, variant.Weight, variant.Price, variant.Quantity)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+
+	return int(id), err
+}
+
+// GetVariantById gets a variant by its id.
+// Returns sql.ErrNoRows if the variant does not exist.
+func (p *ProductRepository) GetVariantById(ctx context.Context, id int) (models.Variant, error) {
+
+	query := `SELECT id,product_id,sku,weight,price,quantity FROM product_variants WHERE id = ?`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	var variant models.Variant
+	err := p.Db.QueryRowContext(ctx, query, id).Scan(
+		&variant.Id,
+		&variant.ProductId,
+		&variant.Sku,
+		&variant.Weight,
+		&variant.Price,
+		&variant.Quantity,
+	)
+	if err != nil {
+		return models.Variant{}, err
+	}
+
+	return variant, nil
+}
+
+func (p *ProductRepository) UpdateVariant(ctx context.Context, tx *sql.Tx, variant models.Variant) error {
+
+	query := `UPDATE product_variants SET sku = ?, weight = ?, price = ?, quantity = ? WHERE id = ?`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	_, err := repository.Execer(p.Db, tx).ExecContext(ctx, query, variant.Sku, variant.Weight, variant.Price, variant.Quantity, variant.Id)
+
+	return err
+}
+
+// CountVariants counts the variants of the product and locks them until tx ends,
+// so they can not be deleted from under the caller.
+func (p *ProductRepository) CountVariants(ctx context.Context, tx *sql.Tx, productId int) (int, error) {
+
+	query := `SELECT COUNT(*) FROM product_variants WHERE product_id = ? FOR UPDATE`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	var count int
+	err := repository.Execer(p.Db, tx).QueryRowContext(ctx, query, productId).Scan(&count)
+
+	return count, err
+}
+
+// CountImages counts the images of the product and locks the product until tx ends,
+// so images added at the same time are counted one after another.
+func (p *ProductRepository) CountImages(ctx context.Context, tx *sql.Tx, productId int) (int, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	db := repository.Execer(p.Db, tx)
+
+	var id int
+	if err := db.QueryRowContext(ctx, `SELECT id FROM products WHERE id = ? FOR UPDATE`, productId).Scan(&id); err != nil {
+		return 0, err
+	}
+
+	var count int
+	err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM product_images WHERE product_id = ?`, productId).Scan(&count)
+
+	return count, err
+}
+
+func (p *ProductRepository) DeleteVariant(ctx context.Context, tx *sql.Tx, id int) error {
+
+	query := `DELETE FROM product_variants WHERE id = ?`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	_, err := repository.Execer(p.Db, tx).ExecContext(ctx, query, id)
 
 	return err
 }
+
+// InsertImage appends an image to the gallery of a product,
+// the first image of a product becomes its primary image.
+// Returns the id of the new image.
+func (p *ProductRepository) InsertImage(ctx context.Context, tx *sql.Tx, image models.Image) (int, error) {
+
+	query := `INSERT INTO product_images(product_id,url,renditions,position,is_primary)
+	SELECT ?, ?, ?, COALESCE(MAX(position) + 1, 0), COUNT(*) = 0 FROM product_images WHERE product_id = ?`
+
+	renditions, err := encodeRenditions(image.Renditions)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	result, err := repository.Execer(p.Db, tx).ExecContext(ctx, query, image.ProductId, image.Url, renditions, image.ProductId)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+
+	return int(id), err
+}
+
+// GetImages gets the gallery of a product in order.
+func (p *ProductRepository) GetImages(ctx context.Context, productId int) ([]models.Image, error) {
+
+	query := `SELECT id,product_id,url,renditions,position,is_primary FROM product_images
+	WHERE product_id = ? ORDER BY position, id`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	rows, err := p.Db.QueryContext(ctx, query, productId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var images []models.Image
+	for rows.Next() {
+		var (
+			image      models.Image
+			renditions []byte
+		)
+		if err := rows.Scan(&image.Id, &image.ProductId, &image.Url, &renditions, &image.Position, &image.IsPrimary); err != nil {
+			return nil, err
+		}
+
+		if err := decodeRenditions(renditions, &image.Renditions); err != nil {
+			return nil, err
+		}
+
+		images = append(images, image)
+	}
+
+	return images, rows.Err()
+}
+
+// GetImageUrls gets the url and the renditions of every image of every product,
+// anything else in the storage is not referenced.
+func (p *ProductRepository) GetImageUrls(ctx context.Context) ([]string, error) {
+
+	query := `SELECT url,renditions FROM product_images`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	rows, err := p.Db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var urls []string
+	for rows.Next() {
+		var (
+			url        string
+			renditions []byte
+			decoded    map[string]string
+		)
+		if err := rows.Scan(&url, &renditions); err != nil {
+			return nil, err
+		}
+
+		if err := decodeRenditions(renditions, &decoded); err != nil {
+			return nil, err
+		}
+
+		urls = append(urls, url)
+		for _, rendition := range decoded {
+			urls = append(urls, rendition)
+		}
+	}
+
+	return urls, rows.Err()
+}
+
+// GetImageById gets an image by its id.
+// Returns sql.ErrNoRows if the image does not exist.
+func (p *ProductRepository) GetImageById(ctx context.Context, id int) (models.Image, error) {
+
+	query := `SELECT id,product_id,url,renditions,position,is_primary FROM product_images WHERE id = ?`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	var (
+		image      models.Image
+		renditions []byte
+	)
+	err := p.Db.QueryRowContext(ctx, query, id).Scan(&image.Id, &image.ProductId, &image.Url, &renditions, &image.Position, &image.IsPrimary)
+	if err != nil {
+		return models.Image{}, err
+	}
+
+	if err := decodeRenditions(renditions, &image.Renditions); err != nil {
+		return models.Image{}, err
+	}
+
+	return image, nil
+}
+
+// ReorderImages sets the position of every image to its index in imageIds.
+func (p *ProductRepository) ReorderImages(ctx context.Context, tx *sql.Tx, productId int, imageIds []int) error {
+
+	query := `UPDATE product_images SET position = ? WHERE id = ? AND product_id = ?`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	return p.inTx(ctx, tx, func(tx *sql.Tx) error {
+		for position, id := range imageIds {
+			if _, err := tx.ExecContext(ctx, query, position, id, productId); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// SetPrimaryImage makes the image the only primary image of the product.
+func (p *ProductRepository) SetPrimaryImage(ctx context.Context, tx *sql.Tx, productId, imageId int) error {
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	return p.inTx(ctx, tx, func(tx *sql.Tx) error {
+		// unset first, a product can only have one primary image at a time
+		if _, err := tx.ExecContext(ctx, `UPDATE product_images SET is_primary = FALSE WHERE product_id = ?`, productId); err != nil {
+			return err
+		}
+
+		_, err := tx.ExecContext(ctx, `UPDATE product_images SET is_primary = TRUE WHERE id = ? AND product_id = ?`, imageId, productId)
+
+		return err
+	})
+}
+
+func (p *ProductRepository) DeleteImage(ctx context.Context, tx *sql.Tx, id int) error {
+
+	query := `DELETE FROM product_images WHERE id = ?`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	_, err := repository.Execer(p.Db, tx).ExecContext(ctx, query, id)
+
+	return err
+}
+
+// getVariants gets the variants of the products lightest first, keyed by product id.
+func (p *ProductRepository) getVariants(ctx context.Context, productIds []int) (map[int][]models.Variant, error) {
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(productIds)), ",")
+	query := `SELECT id,product_id,sku,weight,price,quantity FROM product_variants
+	WHERE product_id IN (` + placeholders + `) ORDER BY weight`
+
+	args := make([]any, 0, len(productIds))
+	for _, id := range productIds {
+		args = append(args, id)
+	}
+
+	rows, err := p.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	variants := make(map[int][]models.Variant)
+	for rows.Next() {
+		var variant models.Variant
+		if err := rows.Scan(
+			&variant.Id,
+			&variant.ProductId,
+			&variant.Sku,
+			&variant.Weight,
+			&variant.Price,
+			&variant.Quantity,
+		); err != nil {
+			return nil, err
+		}
+
+		variants[variant.ProductId] = append(variants[variant.ProductId], variant)
+	}
+
+	return variants, rows.Err()
+}
+
+func insertVariant(ctx context.Context, tx *sql.Tx, variant models.Variant) error {
+
+	query := `INSERT INTO product_variants(product_id,sku,weight,price,quantity) VALUES(?,?,?,?,?)`
+
+	_, err := tx.ExecContext(ctx, query, variant.ProductId, variant.Sku, variant.Weight, variant.Price, variant.Quantity)
+
+	return err
+}
+
+// savePrimaryImage replaces the url of the primary image of the product,
+// or adds it first in the gallery when the product has none.
+func savePrimaryImage(ctx context.Context, tx *sql.Tx, productId int, url string, renditions map[string]string) error {
+
+	query := `INSERT INTO product_images(product_id,url,renditions,position,is_primary) VALUES(?,?,?,0,TRUE)
+	ON DUPLICATE KEY UPDATE url = VALUES(url), renditions = VALUES(renditions)`
+
+	encoded, err := encodeRenditions(renditions)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, query, productId, url, encoded)
+
+	return err
+}
+
+// encodeRenditions encodes the renditions to json, no renditions are stored as NULL.
+func encodeRenditions(renditions map[string]string) (any, error) {
+	if len(renditions) == 0 {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(renditions)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+func decodeRenditions(data []byte, renditions *map[string]string) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(data, renditions)
+}
+
+// setAttributes sets the nullable attributes scanned from a product row.
+func setAttributes(product *models.Product, description, process sql.NullString, tastingNotes []byte) error {
+
+	product.Description = description.String
+	product.Process = process.String
+
+	if len(tastingNotes) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(tastingNotes, &product.TastingNotes)
+}
+
+// encodeTastingNotes encodes the notes to json, no notes are stored as NULL.
+func encodeTastingNotes(notes []string) (any, error) {
+	if len(notes) == 0 {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(notes)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"slices"
 
 	"github.com/faizisyellow/indocoffee/internal/models"
 	"github.com/faizisyellow/indocoffee/internal/repository"
@@ -11,53 +12,156 @@ import (
 
 type InMemoryProducts struct {
 	Products []models.Product
+	// Beans and Forms are the names of the beans and forms by id, products are joined with them.
+	Beans map[int]string
+	Forms map[int]string
+	// variantId is the id of the last variant inserted.
+	variantId int
+	// imageId is the id of the last image inserted.
+	imageId int
+	// productId is the id of the last product inserted.
+	productId int
 }
 
-func (p *InMemoryProducts) Insert(ctx context.Context, newProduct models.Product) error {
+func (p *InMemoryProducts) Insert(ctx context.Context, _ *sql.Tx, newProduct models.Product) (int, error) {
 
 	if newProduct.Image == "" {
-		return errors.New("errors image url is empty")
+		return 0, errors.New("errors image url is empty")
 	}
 
 	for _, product := range p.Products {
 		if product.Roasted == newProduct.Roasted && product.FormId == newProduct.FormId && product.BeanId == newProduct.BeanId {
-			return errors.New("product already exist")
+			return 0, errors.New("product already exist")
 		}
 	}
 
+	p.productId++
 	np := models.Product{
-		Id:       newProduct.Id,
-		Roasted:  newProduct.Roasted,
-		Price:    newProduct.Price,
-		Quantity: newProduct.Quantity,
-		Image:    newProduct.Image,
-		BeanId:   newProduct.BeanId,
-		FormId:   newProduct.FormId,
+		Id:           p.productId,
+		Name:         newProduct.Name,
+		Description:  newProduct.Description,
+		Origin:       newProduct.Origin,
+		Process:      newProduct.Process,
+		Altitude:     newProduct.Altitude,
+		TastingNotes: newProduct.TastingNotes,
+		Roasted:      newProduct.Roasted,
+		Image:        newProduct.Image,
+		BeanId:       newProduct.BeanId,
+		FormId:       newProduct.FormId,
 	}
 
+	for _, variant := range newProduct.Variants {
+		p.variantId++
+		variant.Id = p.variantId
+		variant.ProductId = np.Id
+		np.Variants = append(np.Variants, variant)
+	}
+
+	p.imageId++
+	np.ImageRenditions = newProduct.ImageRenditions
+	np.Images = []models.Image{{Id: p.imageId, ProductId: np.Id, Url: newProduct.Image, Renditions: newProduct.ImageRenditions, IsPrimary: true}}
+
 	p.Products = append(p.Products, np)
 
-	return nil
+	return np.Id, nil
 }
 
 func (p *InMemoryProducts) GetById(ctx context.Context, id int) (models.Product, error) {
-	return models.Product{}, nil
+	for _, product := range p.Products {
+		if product.Id == id {
+			return p.join(product), nil
+		}
+	}
+
+	return models.Product{}, sql.ErrNoRows
 }
 
+// GetAll filters the products like the database does, a search matches the words
+// of the query and orders by how many words of a product match.
 func (p *InMemoryProducts) GetAll(ctx context.Context, qry repository.PaginatedProductsQuery) ([]models.Product, error) {
-	return nil, nil
+	var terms []string
+	if qry.Q != "" {
+		terms = searchTerms(qry.Q)
+	}
+
+	type result struct {
+		product   models.Product
+		price     float64
+		relevance int
+	}
+
+	var results []result
+	for _, product := range p.Products {
+		if len(product.Variants) == 0 ||
+			qry.Roast != "" && product.Roasted != qry.Roast ||
+			qry.Form > 0 && product.FormId != qry.Form ||
+			qry.Bean > 0 && product.BeanId != qry.Bean {
+			continue
+		}
+
+		product = p.join(product)
+		product.Images = nil
+
+		found := result{product: product, price: product.Variants[0].Price}
+		for _, variant := range product.Variants {
+			found.price = min(found.price, variant.Price)
+		}
+
+		if qry.Q != "" {
+			found.relevance = relevance(product, terms)
+			if found.relevance == 0 {
+				continue
+			}
+			found.product.Snippet = snippet(product, terms)
+		}
+
+		results = append(results, found)
+	}
+
+	slices.SortStableFunc(results, func(a, b result) int {
+		if a.relevance != b.relevance {
+			return b.relevance - a.relevance
+		}
+
+		if a.price != b.price {
+			if (a.price < b.price) == (qry.Sort == "desc") {
+				return 1
+			}
+			return -1
+		}
+
+		return a.product.Id - b.product.Id
+	})
+
+	if qry.Limit > 0 {
+		results = results[min(qry.Offset, len(results)):min(qry.Offset+qry.Limit, len(results))]
+	}
+
+	products := make([]models.Product, 0, len(results))
+	for _, found := range results {
+		products = append(products, found.product)
+	}
+
+	return products, nil
 }
 
-func (p *InMemoryProducts) Update(ctx context.Context, product models.Product) error {
-	return nil
+// join sets the names of the bean and form of the product.
+func (p *InMemoryProducts) join(product models.Product) models.Product {
+	product.BeansModel.Name = p.Beans[product.BeanId]
+	product.FormsModel.Name = p.Forms[product.FormId]
+	return product
 }
 
-func (p *InMemoryProducts) DecrementQuantity(ctx context.Context, tx *sql.Tx, productId, quantity int) error {
+func (p *InMemoryProducts) Update(ctx context.Context, _ *sql.Tx, product models.Product) error {
 	return nil
 }
 
-func (p *InMemoryProducts) IncrementQuantity(ctx context.Context, tx *sql.Tx, productId, quantity int) error {
-	return nil
+func (p *InMemoryProducts) DecrementQuantity(ctx context.Context, tx *sql.Tx, variantId, quantity int) error {
+	return p.changeQuantity(variantId, -quantity)
+}
+
+func (p *InMemoryProducts) IncrementQuantity(ctx context.Context, tx *sql.Tx, variantId, quantity int) error {
+	return p.changeQuantity(variantId, quantity)
 }
 
 func (p *InMemoryProducts) DeleteMany(ctx context.Context) error {
@@ -65,7 +169,211 @@ func (p *InMemoryProducts) DeleteMany(ctx context.Context) error {
 	return nil
 }
 
-func (p *InMemoryProducts) Delete(ctx context.Context, id int) error {
+func (p *InMemoryProducts) Delete(ctx context.Context, _ *sql.Tx, id int) error {
+
+	return nil
+}
+
+func (p *InMemoryProducts) InsertVariant(ctx context.Context, _ *sql.Tx, variant models.Variant) (int, error) {
+	for _, product := range p.Products {
+		for _, other := range product.Variants {
+			if other.Sku == variant.Sku {
+				return 0, errors.New("sku already exist")
+			}
+		}
+	}
+
+	for i, product := range p.Products {
+		if product.Id != variant.ProductId {
+			continue
+		}
+
+		p.variantId++
+		variant.Id = p.variantId
+		p.Products[i].Variants = append(p.Products[i].Variants, variant)
+
+		return variant.Id, nil
+	}
+
+	return 0, errors.New("product does not exist")
+}
+
+func (p *InMemoryProducts) GetVariantById(ctx context.Context, id int) (models.Variant, error) {
+	for _, product := range p.Products {
+		for _, variant := range product.Variants {
+			if variant.Id == id {
+				return variant, nil
+			}
+		}
+	}
+
+	return models.Variant{}, sql.ErrNoRows
+}
+
+func (p *InMemoryProducts) UpdateVariant(ctx context.Context, _ *sql.Tx, variant models.Variant) error {
+	for i, product := range p.Products {
+		for j := range product.Variants {
+			if product.Variants[j].Id == variant.Id {
+				p.Products[i].Variants[j] = variant
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *InMemoryProducts) CountVariants(ctx context.Context, _ *sql.Tx, productId int) (int, error) {
+	for _, product := range p.Products {
+		if product.Id == productId {
+			return len(product.Variants), nil
+		}
+	}
+
+	return 0, nil
+}
+
+func (p *InMemoryProducts) DeleteVariant(ctx context.Context, _ *sql.Tx, id int) error {
+	for i, product := range p.Products {
+		for j := range product.Variants {
+			if product.Variants[j].Id == id {
+				p.Products[i].Variants = append(product.Variants[:j], product.Variants[j+1:]...)
+				return nil
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *InMemoryProducts) CountImages(ctx context.Context, _ *sql.Tx, productId int) (int, error) {
+	for _, product := range p.Products {
+		if product.Id == productId {
+			return len(product.Images), nil
+		}
+	}
+
+	return 0, sql.ErrNoRows
+}
+
+func (p *InMemoryProducts) InsertImage(ctx context.Context, _ *sql.Tx, image models.Image) (int, error) {
+	for i, product := range p.Products {
+		if product.Id != image.ProductId {
+			continue
+		}
+
+		p.imageId++
+		image.Id = p.imageId
+		image.Position = len(product.Images)
+		image.IsPrimary = len(product.Images) == 0
+		p.Products[i].Images = append(p.Products[i].Images, image)
+		if image.IsPrimary {
+			p.Products[i].Image = image.Url
+			p.Products[i].ImageRenditions = image.Renditions
+		}
+
+		return image.Id, nil
+	}
+
+	return 0, errors.New("product does not exist")
+}
+
+func (p *InMemoryProducts) GetImages(ctx context.Context, productId int) ([]models.Image, error) {
+	for _, product := range p.Products {
+		if product.Id == productId {
+			images := slices.Clone(product.Images)
+			slices.SortStableFunc(images, func(a, b models.Image) int { return a.Position - b.Position })
+			return images, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (p *InMemoryProducts) GetImageUrls(ctx context.Context) ([]string, error) {
+	var urls []string
+	for _, product := range p.Products {
+		for _, image := range product.Images {
+			urls = append(urls, image.Url)
+			for _, rendition := range image.Renditions {
+				urls = append(urls, rendition)
+			}
+		}
+	}
+
+	return urls, nil
+}
+
+func (p *InMemoryProducts) GetImageById(ctx context.Context, id int) (models.Image, error) {
+	for _, product := range p.Products {
+		for _, image := range product.Images {
+			if image.Id == id {
+				return image, nil
+			}
+		}
+	}
+
+	return models.Image{}, sql.ErrNoRows
+}
+
+func (p *InMemoryProducts) ReorderImages(ctx context.Context, _ *sql.Tx, productId int, imageIds []int) error {
+	for i, product := range p.Products {
+		if product.Id != productId {
+			continue
+		}
+
+		for position, id := range imageIds {
+			for j := range product.Images {
+				if product.Images[j].Id == id {
+					p.Products[i].Images[j].Position = position
+				}
+			}
+		}
+
+		slices.SortStableFunc(p.Products[i].Images, func(a, b models.Image) int { return a.Position - b.Position })
+	}
+
+	return nil
+}
+
+func (p *InMemoryProducts) SetPrimaryImage(ctx context.Context, _ *sql.Tx, productId, imageId int) error {
+	for i, product := range p.Products {
+		if product.Id != productId {
+			continue
+		}
+
+		for j, image := range product.Images {
+			p.Products[i].Images[j].IsPrimary = image.Id == imageId
+			if image.Id == imageId {
+				p.Products[i].Image = image.Url
+				p.Products[i].ImageRenditions = image.Renditions
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *InMemoryProducts) DeleteImage(ctx context.Context, _ *sql.Tx, id int) error {
+	for i, product := range p.Products {
+		p.Products[i].Images = slices.DeleteFunc(product.Images, func(image models.Image) bool { return image.Id == id })
+	}
+
+	return nil
+}
+
+func (p *InMemoryProducts) changeQuantity(variantId, quantity int) error {
+	for i, product := range p.Products {
+		for j := range product.Variants {
+			if product.Variants[j].Id != variantId {
+				continue
+			}
+
+			if product.Variants[j].Quantity+quantity < 0 {
+				return errors.New("quantity_non_negative")
+			}
+			p.Products[i].Variants[j].Quantity += quantity
+		}
+	}
 
 	return nil
 }
@@ -27,6 +27,15 @@ func TestProductsWithRealDB(t *testing.T) {
 	}}.Test(t)
 }
 
+func TestProductsInMemory(t *testing.T) {
+	products.Contract{func() (products.Products, func()) {
+		return &products.InMemoryProducts{
+			Beans: map[int]string{1: "arabica", 2: "robusta"},
+			Forms: map[int]string{1: "grounded", 2: "whole coffee beans"},
+		}, func() {}
+	}}.Test(t)
+}
+
 func setupTestDB(t *testing.T) (*sql.DB, error) {
 	t.Helper()
 
@@ -0,0 +1,137 @@
+package products
+
+import (
+	"html"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/faizisyellow/indocoffee/internal/models"
+)
+
+const (
+	// minSearchTermLength is the shortest word indexed by MySQL full-text search.
+	minSearchTermLength = 3
+	// snippetWords is how many words a snippet shows at most.
+	snippetWords = 24
+)
+
+// stopwords are the default stopwords of InnoDB full-text search, they never match.
+var stopwords = []string{
+	"about", "are", "com", "for", "from", "how", "that", "the",
+	"this", "was", "what", "when", "where", "who", "will", "with", "und", "www",
+}
+
+// word is a word of a text and where it is.
+type word struct {
+	start, end int
+}
+
+// words splits the text into words of letters and digits.
+func words(text string) []word {
+	var (
+		found []word
+		start = -1
+	)
+
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			found = append(found, word{start, i})
+			start = -1
+		}
+	}
+
+	if start >= 0 {
+		found = append(found, word{start, len(text)})
+	}
+
+	return found
+}
+
+// searchTerms are the lowercase words of the query that can match,
+// words too short or too common to be indexed are left out.
+func searchTerms(q string) []string {
+	var terms []string
+	for _, w := range words(q) {
+		term := strings.ToLower(q[w.start:w.end])
+		if len([]rune(term)) < minSearchTermLength || slices.Contains(stopwords, term) || slices.Contains(terms, term) {
+			continue
+		}
+		terms = append(terms, term)
+	}
+
+	return terms
+}
+
+// searchFields are the texts of a product that are searched, in the order a snippet is taken from them.
+func searchFields(product models.Product) []string {
+	return []string{
+		product.Description,
+		strings.Join(product.TastingNotes, ", "),
+		product.Name,
+		product.BeansModel.Name,
+		product.FormsModel.Name,
+	}
+}
+
+// relevance counts the words of the product that match a term.
+func relevance(product models.Product, terms []string) int {
+	count := 0
+	for _, field := range searchFields(product) {
+		for _, w := range words(field) {
+			if slices.Contains(terms, strings.ToLower(field[w.start:w.end])) {
+				count++
+			}
+		}
+	}
+
+	return count
+}
+
+// snippet is the text around the first term found in the fields of the product, with the
+// terms wrapped in <mark>. The rest of the text is html escaped so the snippet can be shown as html.
+func snippet(product models.Product, terms []string) string {
+	for _, field := range searchFields(product) {
+		found := words(field)
+
+		first := slices.IndexFunc(found, func(w word) bool {
+			return slices.Contains(terms, strings.ToLower(field[w.start:w.end]))
+		})
+		if first < 0 {
+			continue
+		}
+
+		start := max(first-snippetWords/4, 0)
+		end := min(start+snippetWords, len(found))
+
+		var text strings.Builder
+		if start > 0 {
+			text.WriteString("…")
+		}
+
+		position := found[start].start
+		for _, w := range found[start:end] {
+			text.WriteString(html.EscapeString(field[position:w.start]))
+
+			value := html.EscapeString(field[w.start:w.end])
+			if slices.Contains(terms, strings.ToLower(field[w.start:w.end])) {
+				value = "<mark>" + value + "</mark>"
+			}
+			text.WriteString(value)
+
+			position = w.end
+		}
+
+		if end < len(found) {
+			text.WriteString("…")
+		}
+
+		return text.String()
+	}
+
+	return ""
+}
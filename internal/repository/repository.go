@@ -1,5 +1,26 @@
 package repository
 
-import "time"
+import (
+	"context"
+	"database/sql"
+	"time"
+)
 
 const QueryTimeout = time.Second * 5
+
+// DBTX runs statements, it is either a transaction or the database.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Execer runs the statements in tx, or straight to the database
+// when the caller does not need a transaction.
+func Execer(db *sql.DB, tx *sql.Tx) DBTX {
+	if tx == nil {
+		return db
+	}
+
+	return tx
+}
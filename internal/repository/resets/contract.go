@@ -0,0 +1,100 @@
+package resets
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/models"
+	"github.com/faizisyellow/indocoffee/internal/utils"
+)
+
+type Resets interface {
+	Insert(ctx context.Context, tx *sql.Tx, reset models.PasswordReset) error
+	Get(ctx context.Context, tx *sql.Tx, token string) (int, error)
+	DeleteByUserId(ctx context.Context, tx *sql.Tx, usrId int) error
+}
+
+type Contract struct {
+	NewResets func() (Resets, *sql.Tx, func())
+}
+
+func (c Contract) Test(t *testing.T) {
+	t.Run("get password reset by token and return user's id", func(t *testing.T) {
+		var (
+			ctx                 = context.Background()
+			resets, tx, cleanup = c.NewResets()
+			initial             = models.PasswordReset{
+				UserId:   1,
+				Token:    utils.HashToken("reset-token"),
+				ExpireAt: time.Now().Add(time.Hour),
+			}
+		)
+		t.Cleanup(cleanup)
+
+		if err := resets.Insert(ctx, tx, initial); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		id, err := resets.Get(ctx, tx, initial.Token)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if id != initial.UserId {
+			t.Errorf("expected user id %v but got %v", initial.UserId, id)
+		}
+	})
+
+	t.Run("expired password reset is not found", func(t *testing.T) {
+		var (
+			ctx                 = context.Background()
+			resets, tx, cleanup = c.NewResets()
+			initial             = models.PasswordReset{
+				UserId:   1,
+				Token:    utils.HashToken("expired-token"),
+				ExpireAt: time.Now().Add(-time.Minute),
+			}
+		)
+		t.Cleanup(cleanup)
+
+		if err := resets.Insert(ctx, tx, initial); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if _, err := resets.Get(ctx, tx, initial.Token); err != sql.ErrNoRows {
+			t.Errorf("expected sql.ErrNoRows but got: %v", err)
+		}
+	})
+
+	t.Run("delete every password reset of a user", func(t *testing.T) {
+		var (
+			ctx                 = context.Background()
+			resets, tx, cleanup = c.NewResets()
+			initial             = []models.PasswordReset{
+				{UserId: 1, Token: utils.HashToken("first"), ExpireAt: time.Now().Add(time.Hour)},
+				{UserId: 1, Token: utils.HashToken("second"), ExpireAt: time.Now().Add(time.Hour)},
+				{UserId: 2, Token: utils.HashToken("third"), ExpireAt: time.Now().Add(time.Hour)},
+			}
+		)
+		t.Cleanup(cleanup)
+
+		for _, reset := range initial {
+			if err := resets.Insert(ctx, tx, reset); err != nil {
+				t.Fatalf("should not be error but got: %v", err)
+			}
+		}
+
+		if err := resets.DeleteByUserId(ctx, tx, 1); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		for _, reset := range initial {
+			_, err := resets.Get(ctx, tx, reset.Token)
+			if (err == sql.ErrNoRows) != (reset.UserId == 1) {
+				t.Errorf("user %v: unexpected result after delete: %v", reset.UserId, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package resets
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/models"
+	"github.com/faizisyellow/indocoffee/internal/repository"
+)
+
+type ResetsRepository struct {
+	Db *sql.DB
+}
+
+// Insert inserts new password reset to database.
+// Returns nil on success or an error on failure.
+func (r *ResetsRepository) Insert(ctx context.Context, tx *sql.Tx, reset models.PasswordReset) error {
+
+	query := `INSERT INTO password_resets(token,user_id,expire_at) VALUES(?,?,?)`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, reset.Token, reset.UserId, reset.ExpireAt)
+
+	return err
+}
+
+// Get gets a password reset if the password reset is not expired.
+// Returns the user's id on success or sql.ErrNoRows if there is none.
+func (r *ResetsRepository) Get(ctx context.Context, tx *sql.Tx, token string) (int, error) {
+
+	query := `SELECT user_id FROM password_resets WHERE token = ? AND expire_at > ? FOR UPDATE`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	userId := 0
+
+	err := tx.QueryRowContext(ctx, query, token, time.Now()).Scan(&userId)
+	if err != nil {
+		return 0, err
+	}
+
+	return userId, nil
+}
+
+// DeleteByUserId deletes every password reset of a user.
+func (r *ResetsRepository) DeleteByUserId(ctx context.Context, tx *sql.Tx, usrId int) error {
+
+	query := `DELETE FROM password_resets WHERE user_id = ?`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, usrId)
+
+	return err
+}
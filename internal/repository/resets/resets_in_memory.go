@@ -0,0 +1,43 @@
+package resets
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/models"
+)
+
+type InMemoryResets struct {
+	Resets []models.PasswordReset
+}
+
+func (r *InMemoryResets) Insert(ctx context.Context, _ *sql.Tx, reset models.PasswordReset) error {
+
+	r.Resets = append(r.Resets, reset)
+	return nil
+}
+
+func (r *InMemoryResets) Get(ctx context.Context, _ *sql.Tx, token string) (int, error) {
+
+	for _, reset := range r.Resets {
+		if reset.Token == token && reset.ExpireAt.After(time.Now()) {
+			return reset.UserId, nil
+		}
+	}
+
+	return 0, sql.ErrNoRows
+}
+
+func (r *InMemoryResets) DeleteByUserId(ctx context.Context, _ *sql.Tx, usrId int) error {
+
+	remaining := r.Resets[:0]
+	for _, reset := range r.Resets {
+		if reset.UserId != usrId {
+			remaining = append(remaining, reset)
+		}
+	}
+	r.Resets = remaining
+
+	return nil
+}
@@ -0,0 +1,16 @@
+package resets_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/faizisyellow/indocoffee/internal/repository/resets"
+)
+
+func TestInMemoryResets(t *testing.T) {
+	resets.Contract{
+		NewResets: func() (resets.Resets, *sql.Tx, func()) {
+			return &resets.InMemoryResets{}, nil, func() {}
+		},
+	}.Test(t)
+}
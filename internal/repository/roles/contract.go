@@ -2,25 +2,181 @@ package roles
 
 import (
 	"context"
+	"database/sql"
+	"slices"
 	"testing"
 
 	"github.com/faizisyellow/indocoffee/internal/models"
 )
 
 type Roles interface {
-	Insert(ctx context.Context, nw models.RolesModel) error
+	Insert(ctx context.Context, tx *sql.Tx, nw models.RolesModel) (int, error)
 	GetAll(ctx context.Context) ([]models.RolesModel, error)
 	GetById(ctx context.Context, id int) (models.RolesModel, error)
 	GetByName(ctx context.Context, rolename string) (models.RolesModel, error)
-	Update(ctx context.Context, nw models.RolesModel) error
-	Delete(ctx context.Context, id int) error
-	DestroyMany(ctx context.Context) error
+	Update(ctx context.Context, tx *sql.Tx, nw models.RolesModel) error
+	Delete(ctx context.Context, tx *sql.Tx, id int) error
+	DestroyMany(ctx context.Context, tx *sql.Tx) ([]int, error)
+	GetPermissions(ctx context.Context, roleId int) ([]string, error)
+	SetPermissions(ctx context.Context, tx *sql.Tx, roleId int, permissions []string) error
+	GetAllPermissions(ctx context.Context) ([]models.PermissionModel, error)
 }
 
+// Contract expects the store to know at least
+// the "orders:read" and "orders:ship" permissions.
 type Contract struct {
-	NewRoles Roles
+	NewRoles func() (Roles, *sql.Tx, func())
 }
 
-func (c *Contract) Test(t *testing.T) {
+func (c Contract) Test(t *testing.T) {
+	t.Run("create new role and get it by name", func(t *testing.T) {
+		var (
+			ctx                = context.Background()
+			roles, tx, cleanup = c.NewRoles()
+		)
+		t.Cleanup(cleanup)
 
+		id, err := roles.Insert(ctx, tx, models.RolesModel{Name: "roaster", Level: 2})
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		role, err := roles.GetByName(ctx, "roaster")
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if role.Id != id || role.Level != 2 {
+			t.Errorf("unexpected role: %+v", role)
+		}
+	})
+
+	t.Run("get role that does not exist", func(t *testing.T) {
+		var (
+			ctx               = context.Background()
+			roles, _, cleanup = c.NewRoles()
+		)
+		t.Cleanup(cleanup)
+
+		if _, err := roles.GetByName(ctx, "nobody"); err != sql.ErrNoRows {
+			t.Errorf("expected sql.ErrNoRows but got: %v", err)
+		}
+	})
+
+	t.Run("grant permissions to role", func(t *testing.T) {
+		var (
+			ctx                = context.Background()
+			roles, tx, cleanup = c.NewRoles()
+		)
+		t.Cleanup(cleanup)
+
+		if _, err := roles.Insert(ctx, tx, models.RolesModel{Name: "warehouse", Level: 2}); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		role, err := roles.GetByName(ctx, "warehouse")
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if err := roles.SetPermissions(ctx, tx, role.Id, []string{"orders:read", "orders:ship"}); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		permissions, err := roles.GetPermissions(ctx, role.Id)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if !slices.Equal(permissions, []string{"orders:read", "orders:ship"}) {
+			t.Errorf("unexpected permissions: %v", permissions)
+		}
+
+		// replacing the permissions revokes the rest
+		if err := roles.SetPermissions(ctx, tx, role.Id, []string{"orders:read"}); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		permissions, err = roles.GetPermissions(ctx, role.Id)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if !slices.Equal(permissions, []string{"orders:read"}) {
+			t.Errorf("expected orders:ship to be revoked but got: %v", permissions)
+		}
+	})
+
+	t.Run("deleted role can not be found", func(t *testing.T) {
+		var (
+			ctx                = context.Background()
+			roles, tx, cleanup = c.NewRoles()
+		)
+		t.Cleanup(cleanup)
+
+		if _, err := roles.Insert(ctx, tx, models.RolesModel{Name: "intern", Level: 1}); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		role, err := roles.GetByName(ctx, "intern")
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if err := roles.Delete(ctx, tx, role.Id); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if _, err := roles.GetById(ctx, role.Id); err != sql.ErrNoRows {
+			t.Errorf("expected sql.ErrNoRows but got: %v", err)
+		}
+	})
+
+	t.Run("destroy the deleted roles and return their ids", func(t *testing.T) {
+		var (
+			ctx                = context.Background()
+			roles, tx, cleanup = c.NewRoles()
+		)
+		t.Cleanup(cleanup)
+
+		id, err := roles.Insert(ctx, tx, models.RolesModel{Name: "temporary", Level: 1})
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if err := roles.Delete(ctx, tx, id); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		ids, err := roles.DestroyMany(ctx, tx)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if !slices.Equal(ids, []int{id}) {
+			t.Errorf("expected ids %v but got: %v", []int{id}, ids)
+		}
+	})
+
+	t.Run("get every permission", func(t *testing.T) {
+		var (
+			ctx               = context.Background()
+			roles, _, cleanup = c.NewRoles()
+		)
+		t.Cleanup(cleanup)
+
+		permissions, err := roles.GetAllPermissions(ctx)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		names := make([]string, 0, len(permissions))
+		for _, permission := range permissions {
+			names = append(names, permission.Name)
+		}
+
+		if !slices.Contains(names, "orders:read") || !slices.Contains(names, "orders:ship") {
+			t.Errorf("unexpected permissions: %v", names)
+		}
+	})
 }
@@ -3,6 +3,7 @@ package roles
 import (
 	"context"
 	"database/sql"
+	"strings"
 
 	"github.com/faizisyellow/indocoffee/internal/models"
 	"github.com/faizisyellow/indocoffee/internal/repository"
@@ -12,19 +13,25 @@ type RolesRepository struct {
 	Db *sql.DB
 }
 
-func (Roles *RolesRepository) Insert(ctx context.Context, nw models.RolesModel) error {
+// Insert inserts the role in the transaction and returns its id.
+func (Roles *RolesRepository) Insert(ctx context.Context, tx *sql.Tx, nw models.RolesModel) (int, error) {
 
 	qry := `INSERT INTO roles (name,level) VALUES (?,?)`
 
 	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
 	defer cancel()
 
-	_, err := Roles.Db.ExecContext(ctx, qry, nw.Name, nw.Level)
+	result, err := tx.ExecContext(ctx, qry, nw.Name, nw.Level)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
 }
 
 func (Roles *RolesRepository) GetAll(ctx context.Context) ([]models.RolesModel, error) {
@@ -82,14 +89,15 @@ func (Roles *RolesRepository) GetByName(ctx context.Context, rolename string) (m
 	return role, result.Scan(&role.Id, &role.Name, &role.Level)
 }
 
-func (Roles *RolesRepository) Update(ctx context.Context, nw models.RolesModel) error {
+// Update updates the role in the transaction.
+func (Roles *RolesRepository) Update(ctx context.Context, tx *sql.Tx, nw models.RolesModel) error {
 
 	qry := `UPDATE roles SET name = ?, level = ? WHERE id  = ? AND is_delete = FALSE`
 
 	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
 	defer cancel()
 
-	_, err := Roles.Db.ExecContext(ctx, qry, nw.Name, nw.Level, nw.Id)
+	_, err := tx.ExecContext(ctx, qry, nw.Name, nw.Level, nw.Id)
 	if err != nil {
 		return err
 	}
@@ -97,14 +105,15 @@ func (Roles *RolesRepository) Update(ctx context.Context, nw models.RolesModel)
 	return nil
 }
 
-func (Roles *RolesRepository) Delete(ctx context.Context, id int) error {
+// Delete marks the role as deleted in the transaction.
+func (Roles *RolesRepository) Delete(ctx context.Context, tx *sql.Tx, id int) error {
 
 	qry := `UPDATE roles SET is_delete = TRUE WHERE id  = ? AND is_delete = FALSE`
 
 	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
 	defer cancel()
 
-	result, err := Roles.Db.ExecContext(ctx, qry, id)
+	result, err := tx.ExecContext(ctx, qry, id)
 	if err != nil {
 		return err
 	}
@@ -122,17 +131,128 @@ func (Roles *RolesRepository) Delete(ctx context.Context, id int) error {
 	return nil
 }
 
-func (Roles *RolesRepository) DestroyMany(ctx context.Context) error {
+// DestroyMany deletes the roles in the trash for good in the transaction.
+// Returns the ids of the deleted roles.
+func (Roles *RolesRepository) DestroyMany(ctx context.Context, tx *sql.Tx) ([]int, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	rows, err := tx.QueryContext(ctx, `SELECT id FROM roles WHERE is_delete = TRUE FOR UPDATE`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return ids, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM roles WHERE id IN (`+placeholders+`)`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// GetPermissions gets the name of every permission granted to a role.
+func (Roles *RolesRepository) GetPermissions(ctx context.Context, roleId int) ([]string, error) {
 
-	query := `DELETE FROM roles WHERE is_delete = TRUE`
+	qry := `
+	SELECT permissions.name FROM role_permissions
+	JOIN permissions ON permissions.id = role_permissions.permission_id
+	WHERE role_permissions.role_id = ?
+	ORDER BY permissions.name`
 
 	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
 	defer cancel()
 
-	_, err := Roles.Db.ExecContext(ctx, query)
+	result, err := Roles.Db.QueryContext(ctx, qry, roleId)
 	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close()
+
+	permissions := make([]string, 0)
+
+	for result.Next() {
+		var name string
+		if err := result.Scan(&name); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, name)
+	}
+
+	return permissions, result.Err()
+}
+
+// SetPermissions replaces every permission granted to a role in the transaction.
+// Unknown permission names are ignored, they should be validated by the caller.
+func (Roles *RolesRepository) SetPermissions(ctx context.Context, tx *sql.Tx, roleId int, permissions []string) error {
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM role_permissions WHERE role_id = ?`, roleId); err != nil {
 		return err
 	}
 
+	qry := `INSERT INTO role_permissions(role_id,permission_id) SELECT ?, id FROM permissions WHERE name = ?`
+
+	for _, permission := range permissions {
+		if _, err := tx.ExecContext(ctx, qry, roleId, permission); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
+
+// GetAllPermissions gets every permission that can be granted to a role.
+func (Roles *RolesRepository) GetAllPermissions(ctx context.Context) ([]models.PermissionModel, error) {
+
+	qry := `SELECT id,name,description FROM permissions ORDER BY name`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	result, err := Roles.Db.QueryContext(ctx, qry)
+	if err != nil {
+		return nil, err
+	}
+
+	defer result.Close()
+
+	permissions := make([]models.PermissionModel, 0)
+
+	for result.Next() {
+		var permission models.PermissionModel
+		if err := result.Scan(&permission.Id, &permission.Name, &permission.Description); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, permission)
+	}
+
+	return permissions, result.Err()
+}
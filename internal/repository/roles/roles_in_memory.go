@@ -0,0 +1,141 @@
+package roles
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+
+	"github.com/faizisyellow/indocoffee/internal/models"
+)
+
+type InMemoryRoles struct {
+	Roles []models.RolesModel
+	// Catalogue is every permission that can be granted.
+	Catalogue []models.PermissionModel
+	deleted   []int
+}
+
+func (r *InMemoryRoles) Insert(ctx context.Context, _ *sql.Tx, nw models.RolesModel) (int, error) {
+
+	nextID := 1
+	for _, role := range r.Roles {
+		if role.Id >= nextID {
+			nextID = role.Id + 1
+		}
+	}
+
+	nw.Id = nextID
+	r.Roles = append(r.Roles, nw)
+
+	return nw.Id, nil
+}
+
+func (r *InMemoryRoles) GetAll(ctx context.Context) ([]models.RolesModel, error) {
+
+	roles := make([]models.RolesModel, 0)
+	for _, role := range r.Roles {
+		if !slices.Contains(r.deleted, role.Id) {
+			roles = append(roles, role)
+		}
+	}
+
+	return roles, nil
+}
+
+func (r *InMemoryRoles) GetById(ctx context.Context, id int) (models.RolesModel, error) {
+
+	for _, role := range r.Roles {
+		if role.Id == id && !slices.Contains(r.deleted, role.Id) {
+			return role, nil
+		}
+	}
+
+	return models.RolesModel{}, sql.ErrNoRows
+}
+
+func (r *InMemoryRoles) GetByName(ctx context.Context, rolename string) (models.RolesModel, error) {
+
+	for _, role := range r.Roles {
+		if role.Name == rolename && !slices.Contains(r.deleted, role.Id) {
+			return role, nil
+		}
+	}
+
+	return models.RolesModel{}, sql.ErrNoRows
+}
+
+func (r *InMemoryRoles) Update(ctx context.Context, _ *sql.Tx, nw models.RolesModel) error {
+
+	for i, role := range r.Roles {
+		if role.Id == nw.Id {
+			r.Roles[i].Name = nw.Name
+			r.Roles[i].Level = nw.Level
+		}
+	}
+
+	return nil
+}
+
+func (r *InMemoryRoles) Delete(ctx context.Context, _ *sql.Tx, id int) error {
+
+	if _, err := r.GetById(ctx, id); err != nil {
+		return err
+	}
+
+	r.deleted = append(r.deleted, id)
+
+	return nil
+}
+
+func (r *InMemoryRoles) DestroyMany(ctx context.Context, _ *sql.Tx) ([]int, error) {
+
+	ids := []int{}
+	remaining := r.Roles[:0]
+	for _, role := range r.Roles {
+		if slices.Contains(r.deleted, role.Id) {
+			ids = append(ids, role.Id)
+			continue
+		}
+		remaining = append(remaining, role)
+	}
+	r.Roles = remaining
+	r.deleted = nil
+
+	return ids, nil
+}
+
+func (r *InMemoryRoles) GetPermissions(ctx context.Context, roleId int) ([]string, error) {
+
+	for _, role := range r.Roles {
+		if role.Id == roleId {
+			permissions := slices.Clone(role.Permissions)
+			slices.Sort(permissions)
+			return permissions, nil
+		}
+	}
+
+	return []string{}, nil
+}
+
+func (r *InMemoryRoles) SetPermissions(ctx context.Context, _ *sql.Tx, roleId int, permissions []string) error {
+
+	granted := make([]string, 0, len(permissions))
+	for _, permission := range r.Catalogue {
+		if slices.Contains(permissions, permission.Name) {
+			granted = append(granted, permission.Name)
+		}
+	}
+
+	for i, role := range r.Roles {
+		if role.Id == roleId {
+			r.Roles[i].Permissions = granted
+		}
+	}
+
+	return nil
+}
+
+func (r *InMemoryRoles) GetAllPermissions(ctx context.Context) ([]models.PermissionModel, error) {
+
+	return slices.Clone(r.Catalogue), nil
+}
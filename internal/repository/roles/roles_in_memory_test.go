@@ -0,0 +1,22 @@
+package roles_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/faizisyellow/indocoffee/internal/models"
+	"github.com/faizisyellow/indocoffee/internal/repository/roles"
+)
+
+func TestInMemoryRoles(t *testing.T) {
+	roles.Contract{
+		NewRoles: func() (roles.Roles, *sql.Tx, func()) {
+			return &roles.InMemoryRoles{
+				Catalogue: []models.PermissionModel{
+					{Id: 1, Name: "orders:read"},
+					{Id: 2, Name: "orders:ship"},
+				},
+			}, nil, func() {}
+		},
+	}.Test(t)
+}
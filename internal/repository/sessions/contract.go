@@ -0,0 +1,181 @@
+package sessions
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/models"
+)
+
+type Sessions interface {
+	Insert(ctx context.Context, tx *sql.Tx, session models.Session) error
+	GetById(ctx context.Context, id string) (models.Session, error)
+	Revoke(ctx context.Context, tx *sql.Tx, id string) error
+	RevokeByUserId(ctx context.Context, tx *sql.Tx, usrId int) error
+	InsertRefreshToken(ctx context.Context, tx *sql.Tx, token models.RefreshToken) error
+	GetRefreshToken(ctx context.Context, tx *sql.Tx, token string) (models.RefreshToken, error)
+	UseRefreshToken(ctx context.Context, tx *sql.Tx, token string) error
+}
+
+type Contract struct {
+	NewSessions func() (Sessions, *sql.Tx, func())
+}
+
+func (c Contract) Test(t *testing.T) {
+	t.Run("create new session and get it by id", func(t *testing.T) {
+		var (
+			ctx                   = context.Background()
+			sessions, tx, cleanup = c.NewSessions()
+			session               = models.Session{Id: "01JSESSIONONE", UserId: 1}
+		)
+		t.Cleanup(cleanup)
+
+		if err := sessions.Insert(ctx, tx, session); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		result, err := sessions.GetById(ctx, session.Id)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if result.UserId != session.UserId {
+			t.Errorf("expected user id %v but got %v", session.UserId, result.UserId)
+		}
+
+		if result.RevokedAt != nil {
+			t.Error("expected new session not to be revoked")
+		}
+	})
+
+	t.Run("get session that does not exist", func(t *testing.T) {
+		var (
+			ctx                  = context.Background()
+			sessions, _, cleanup = c.NewSessions()
+		)
+		t.Cleanup(cleanup)
+
+		if _, err := sessions.GetById(ctx, "01JNOSUCHSESSION"); err != sql.ErrNoRows {
+			t.Errorf("expected sql.ErrNoRows but got: %v", err)
+		}
+	})
+
+	t.Run("revoke session", func(t *testing.T) {
+		var (
+			ctx                   = context.Background()
+			sessions, tx, cleanup = c.NewSessions()
+			session               = models.Session{Id: "01JSESSIONTWO", UserId: 1}
+		)
+		t.Cleanup(cleanup)
+
+		if err := sessions.Insert(ctx, tx, session); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if err := sessions.Revoke(ctx, tx, session.Id); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		result, err := sessions.GetById(ctx, session.Id)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if result.RevokedAt == nil {
+			t.Error("expected session to be revoked")
+		}
+	})
+
+	t.Run("revoke every session of a user", func(t *testing.T) {
+		var (
+			ctx                   = context.Background()
+			sessions, tx, cleanup = c.NewSessions()
+			initial               = []models.Session{
+				{Id: "01JSESSIONA", UserId: 1},
+				{Id: "01JSESSIONB", UserId: 1},
+				{Id: "01JSESSIONC", UserId: 2},
+			}
+		)
+		t.Cleanup(cleanup)
+
+		for _, session := range initial {
+			if err := sessions.Insert(ctx, tx, session); err != nil {
+				t.Fatalf("should not be error but got: %v", err)
+			}
+		}
+
+		if err := sessions.RevokeByUserId(ctx, tx, 1); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		for _, session := range initial {
+			result, err := sessions.GetById(ctx, session.Id)
+			if err != nil {
+				t.Fatalf("should not be error but got: %v", err)
+			}
+
+			revoked := result.RevokedAt != nil
+			if revoked != (session.UserId == 1) {
+				t.Errorf("session %v: expected revoked to be %v", session.Id, session.UserId == 1)
+			}
+		}
+	})
+
+	t.Run("use refresh token only once", func(t *testing.T) {
+		var (
+			ctx                   = context.Background()
+			sessions, tx, cleanup = c.NewSessions()
+			session               = models.Session{Id: "01JSESSIONREFRESH", UserId: 1}
+			token                 = models.RefreshToken{
+				Token:     "hashed-refresh-token",
+				SessionId: session.Id,
+				ExpireAt:  time.Now().Add(time.Hour),
+			}
+		)
+		t.Cleanup(cleanup)
+
+		if err := sessions.Insert(ctx, tx, session); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if err := sessions.InsertRefreshToken(ctx, tx, token); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		result, err := sessions.GetRefreshToken(ctx, tx, token.Token)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if result.SessionId != session.Id || result.UsedAt != nil {
+			t.Fatalf("unexpected refresh token: %+v", result)
+		}
+
+		if err := sessions.UseRefreshToken(ctx, tx, token.Token); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		result, err = sessions.GetRefreshToken(ctx, tx, token.Token)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if result.UsedAt == nil {
+			t.Error("expected refresh token to be marked as used")
+		}
+	})
+
+	t.Run("get refresh token that does not exist", func(t *testing.T) {
+		var (
+			ctx                   = context.Background()
+			sessions, tx, cleanup = c.NewSessions()
+		)
+		t.Cleanup(cleanup)
+
+		if _, err := sessions.GetRefreshToken(ctx, tx, "unknown"); err != sql.ErrNoRows {
+			t.Errorf("expected sql.ErrNoRows but got: %v", err)
+		}
+	})
+}
@@ -0,0 +1,144 @@
+package sessions
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/models"
+	"github.com/faizisyellow/indocoffee/internal/repository"
+)
+
+type SessionsRepository struct {
+	Db *sql.DB
+}
+
+// Insert inserts new session to database.
+// Returns nil on success or an error on failure.
+func (s *SessionsRepository) Insert(ctx context.Context, tx *sql.Tx, session models.Session) error {
+
+	query := `INSERT INTO sessions(id,user_id) VALUES(?,?)`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, session.Id, session.UserId)
+
+	return err
+}
+
+// GetById gets a session by session's id.
+// Returns sql.ErrNoRows if the session does not exist.
+func (s *SessionsRepository) GetById(ctx context.Context, id string) (models.Session, error) {
+
+	query := `SELECT id,user_id,revoked_at,created_at FROM sessions WHERE id = ?`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	var (
+		session   models.Session
+		revokedAt sql.NullTime
+	)
+
+	err := s.Db.QueryRowContext(ctx, query, id).Scan(
+		&session.Id,
+		&session.UserId,
+		&revokedAt,
+		&session.CreatedAt,
+	)
+	if err != nil {
+		return models.Session{}, err
+	}
+
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+
+	return session, nil
+}
+
+// Revoke marks a session as revoked, access tokens and
+// refresh tokens issued for the session stop working.
+func (s *SessionsRepository) Revoke(ctx context.Context, tx *sql.Tx, id string) error {
+
+	query := `UPDATE sessions SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, time.Now(), id)
+
+	return err
+}
+
+// RevokeByUserId revokes every active session of the user.
+func (s *SessionsRepository) RevokeByUserId(ctx context.Context, tx *sql.Tx, usrId int) error {
+
+	query := `UPDATE sessions SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, time.Now(), usrId)
+
+	return err
+}
+
+// InsertRefreshToken inserts new hashed refresh token of a session.
+func (s *SessionsRepository) InsertRefreshToken(ctx context.Context, tx *sql.Tx, token models.RefreshToken) error {
+
+	query := `INSERT INTO refresh_tokens(token,session_id,expire_at) VALUES(?,?,?)`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, token.Token, token.SessionId, token.ExpireAt)
+
+	return err
+}
+
+// GetRefreshToken gets a hashed refresh token and locks the row
+// until the transaction ends, so the token can not be rotated twice.
+// Returns sql.ErrNoRows if the token does not exist.
+func (s *SessionsRepository) GetRefreshToken(ctx context.Context, tx *sql.Tx, token string) (models.RefreshToken, error) {
+
+	query := `SELECT token,session_id,expire_at,used_at FROM refresh_tokens WHERE token = ? FOR UPDATE`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	var (
+		refreshToken models.RefreshToken
+		usedAt       sql.NullTime
+	)
+
+	err := tx.QueryRowContext(ctx, query, token).Scan(
+		&refreshToken.Token,
+		&refreshToken.SessionId,
+		&refreshToken.ExpireAt,
+		&usedAt,
+	)
+	if err != nil {
+		return models.RefreshToken{}, err
+	}
+
+	if usedAt.Valid {
+		refreshToken.UsedAt = &usedAt.Time
+	}
+
+	return refreshToken, nil
+}
+
+// UseRefreshToken marks a refresh token as used after it has been rotated.
+func (s *SessionsRepository) UseRefreshToken(ctx context.Context, tx *sql.Tx, token string) error {
+
+	query := `UPDATE refresh_tokens SET used_at = ? WHERE token = ?`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, time.Now(), token)
+
+	return err
+}
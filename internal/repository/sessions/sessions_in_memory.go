@@ -0,0 +1,82 @@
+package sessions
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/models"
+)
+
+type InMemorySessions struct {
+	Sessions      []models.Session
+	RefreshTokens []models.RefreshToken
+}
+
+func (s *InMemorySessions) Insert(ctx context.Context, _ *sql.Tx, session models.Session) error {
+
+	session.CreatedAt = time.Now()
+	s.Sessions = append(s.Sessions, session)
+
+	return nil
+}
+
+func (s *InMemorySessions) GetById(ctx context.Context, id string) (models.Session, error) {
+	for _, session := range s.Sessions {
+		if session.Id == id {
+			return session, nil
+		}
+	}
+
+	return models.Session{}, sql.ErrNoRows
+}
+
+func (s *InMemorySessions) Revoke(ctx context.Context, _ *sql.Tx, id string) error {
+	now := time.Now()
+	for i, session := range s.Sessions {
+		if session.Id == id && session.RevokedAt == nil {
+			s.Sessions[i].RevokedAt = &now
+		}
+	}
+
+	return nil
+}
+
+func (s *InMemorySessions) RevokeByUserId(ctx context.Context, _ *sql.Tx, usrId int) error {
+	now := time.Now()
+	for i, session := range s.Sessions {
+		if session.UserId == usrId && session.RevokedAt == nil {
+			s.Sessions[i].RevokedAt = &now
+		}
+	}
+
+	return nil
+}
+
+func (s *InMemorySessions) InsertRefreshToken(ctx context.Context, _ *sql.Tx, token models.RefreshToken) error {
+
+	s.RefreshTokens = append(s.RefreshTokens, token)
+
+	return nil
+}
+
+func (s *InMemorySessions) GetRefreshToken(ctx context.Context, _ *sql.Tx, token string) (models.RefreshToken, error) {
+	for _, refreshToken := range s.RefreshTokens {
+		if refreshToken.Token == token {
+			return refreshToken, nil
+		}
+	}
+
+	return models.RefreshToken{}, sql.ErrNoRows
+}
+
+func (s *InMemorySessions) UseRefreshToken(ctx context.Context, _ *sql.Tx, token string) error {
+	now := time.Now()
+	for i, refreshToken := range s.RefreshTokens {
+		if refreshToken.Token == token {
+			s.RefreshTokens[i].UsedAt = &now
+		}
+	}
+
+	return nil
+}
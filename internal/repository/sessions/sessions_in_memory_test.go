@@ -0,0 +1,16 @@
+package sessions_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/faizisyellow/indocoffee/internal/repository/sessions"
+)
+
+func TestInMemorySessions(t *testing.T) {
+	sessions.Contract{
+		NewSessions: func() (sessions.Sessions, *sql.Tx, func()) {
+			return &sessions.InMemorySessions{}, nil, func() {}
+		},
+	}.Test(t)
+}
@@ -0,0 +1,190 @@
+package twofactors
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/models"
+	"github.com/faizisyellow/indocoffee/internal/utils"
+)
+
+type TwoFactors interface {
+	Get(ctx context.Context, tx *sql.Tx, usrId int) (models.TwoFactor, error)
+	Upsert(ctx context.Context, tx *sql.Tx, tf models.TwoFactor) error
+	Enable(ctx context.Context, tx *sql.Tx, usrId int) error
+	UseStep(ctx context.Context, tx *sql.Tx, usrId int, step int64) error
+	Delete(ctx context.Context, tx *sql.Tx, usrId int) error
+	ReplaceRecoveryCodes(ctx context.Context, tx *sql.Tx, usrId int, codes []string) error
+	UseRecoveryCode(ctx context.Context, tx *sql.Tx, usrId int, code string) error
+	InsertChallenge(ctx context.Context, tx *sql.Tx, challenge models.TwoFactorChallenge) error
+	GetChallenge(ctx context.Context, tx *sql.Tx, token string) (models.TwoFactorChallenge, error)
+	IncrementChallengeAttempts(ctx context.Context, tx *sql.Tx, token string) error
+	DeleteChallenge(ctx context.Context, tx *sql.Tx, token string) error
+}
+
+type Contract struct {
+	NewTwoFactors func() (TwoFactors, *sql.Tx, func())
+}
+
+func (c Contract) Test(t *testing.T) {
+	t.Run("enroll and enable two factor", func(t *testing.T) {
+		var (
+			ctx                     = context.Background()
+			twoFactors, tx, cleanup = c.NewTwoFactors()
+		)
+		t.Cleanup(cleanup)
+
+		if err := twoFactors.Upsert(ctx, tx, models.TwoFactor{UserId: 1, Secret: "FIRSTSECRET"}); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		// enrolling again replaces the pending secret
+		if err := twoFactors.Upsert(ctx, tx, models.TwoFactor{UserId: 1, Secret: "SECONDSECRET"}); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		result, err := twoFactors.Get(ctx, tx, 1)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if result.Secret != "SECONDSECRET" || result.EnabledAt != nil {
+			t.Fatalf("expected pending enrollment with the second secret but got: %+v", result)
+		}
+
+		if err := twoFactors.Enable(ctx, tx, 1); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if err := twoFactors.UseStep(ctx, tx, 1, 42); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		result, err = twoFactors.Get(ctx, tx, 1)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if result.EnabledAt == nil || result.LastStep != 42 {
+			t.Errorf("expected enabled two factor at step 42 but got: %+v", result)
+		}
+	})
+
+	t.Run("delete two factor", func(t *testing.T) {
+		var (
+			ctx                     = context.Background()
+			twoFactors, tx, cleanup = c.NewTwoFactors()
+			code                    = utils.HashToken("recovery")
+		)
+		t.Cleanup(cleanup)
+
+		if err := twoFactors.Upsert(ctx, tx, models.TwoFactor{UserId: 1, Secret: "SECRET"}); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if err := twoFactors.ReplaceRecoveryCodes(ctx, tx, 1, []string{code}); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if err := twoFactors.Delete(ctx, tx, 1); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if _, err := twoFactors.Get(ctx, tx, 1); err != sql.ErrNoRows {
+			t.Errorf("expected sql.ErrNoRows but got: %v", err)
+		}
+
+		if err := twoFactors.UseRecoveryCode(ctx, tx, 1, code); err != sql.ErrNoRows {
+			t.Errorf("expected recovery codes to be deleted but got: %v", err)
+		}
+	})
+
+	t.Run("use recovery code only once", func(t *testing.T) {
+		var (
+			ctx                     = context.Background()
+			twoFactors, tx, cleanup = c.NewTwoFactors()
+			first                   = utils.HashToken("first")
+			second                  = utils.HashToken("second")
+		)
+		t.Cleanup(cleanup)
+
+		if err := twoFactors.Upsert(ctx, tx, models.TwoFactor{UserId: 1, Secret: "SECRET"}); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if err := twoFactors.ReplaceRecoveryCodes(ctx, tx, 1, []string{first}); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if err := twoFactors.ReplaceRecoveryCodes(ctx, tx, 1, []string{second}); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if err := twoFactors.UseRecoveryCode(ctx, tx, 1, first); err != sql.ErrNoRows {
+			t.Errorf("expected replaced code to be gone but got: %v", err)
+		}
+
+		if err := twoFactors.UseRecoveryCode(ctx, tx, 2, second); err != sql.ErrNoRows {
+			t.Errorf("expected code of other user to be not found but got: %v", err)
+		}
+
+		if err := twoFactors.UseRecoveryCode(ctx, tx, 1, second); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if err := twoFactors.UseRecoveryCode(ctx, tx, 1, second); err != sql.ErrNoRows {
+			t.Errorf("expected used code to be not found but got: %v", err)
+		}
+	})
+
+	t.Run("challenge attempts and expiry", func(t *testing.T) {
+		var (
+			ctx                     = context.Background()
+			twoFactors, tx, cleanup = c.NewTwoFactors()
+			challenge               = models.TwoFactorChallenge{
+				Token:    utils.HashToken("challenge"),
+				UserId:   1,
+				ExpireAt: time.Now().Add(time.Minute),
+			}
+			expired = models.TwoFactorChallenge{
+				Token:    utils.HashToken("expired"),
+				UserId:   1,
+				ExpireAt: time.Now().Add(-time.Minute),
+			}
+		)
+		t.Cleanup(cleanup)
+
+		for _, c := range []models.TwoFactorChallenge{challenge, expired} {
+			if err := twoFactors.InsertChallenge(ctx, tx, c); err != nil {
+				t.Fatalf("should not be error but got: %v", err)
+			}
+		}
+
+		if err := twoFactors.IncrementChallengeAttempts(ctx, tx, challenge.Token); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		result, err := twoFactors.GetChallenge(ctx, tx, challenge.Token)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if result.UserId != 1 || result.Attempts != 1 {
+			t.Errorf("expected one attempt of user 1 but got: %+v", result)
+		}
+
+		if _, err := twoFactors.GetChallenge(ctx, tx, expired.Token); err != sql.ErrNoRows {
+			t.Errorf("expected expired challenge to be not found but got: %v", err)
+		}
+
+		if err := twoFactors.DeleteChallenge(ctx, tx, challenge.Token); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if _, err := twoFactors.GetChallenge(ctx, tx, challenge.Token); err != sql.ErrNoRows {
+			t.Errorf("expected sql.ErrNoRows but got: %v", err)
+		}
+	})
+}
@@ -0,0 +1,205 @@
+package twofactors
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/models"
+	"github.com/faizisyellow/indocoffee/internal/repository"
+)
+
+type TwoFactorsRepository struct {
+	Db *sql.DB
+}
+
+// Get gets the two factor enrollment of a user.
+// Returns sql.ErrNoRows if the user has not enrolled.
+func (r *TwoFactorsRepository) Get(ctx context.Context, tx *sql.Tx, usrId int) (models.TwoFactor, error) {
+
+	query := `SELECT user_id,secret,last_step,enabled_at,created_at FROM two_factors WHERE user_id = ? FOR UPDATE`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	var tf models.TwoFactor
+
+	err := tx.QueryRowContext(ctx, query, usrId).Scan(
+		&tf.UserId,
+		&tf.Secret,
+		&tf.LastStep,
+		&tf.EnabledAt,
+		&tf.CreatedAt,
+	)
+
+	return tf, err
+}
+
+// Upsert starts a new enrollment of the user, replacing any previous secret.
+func (r *TwoFactorsRepository) Upsert(ctx context.Context, tx *sql.Tx, tf models.TwoFactor) error {
+
+	query := `
+		INSERT INTO two_factors(user_id,secret) VALUES(?,?)
+		ON DUPLICATE KEY UPDATE secret = VALUES(secret), last_step = 0, enabled_at = NULL, created_at = CURRENT_TIMESTAMP`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, tf.UserId, tf.Secret)
+
+	return err
+}
+
+func (r *TwoFactorsRepository) Enable(ctx context.Context, tx *sql.Tx, usrId int) error {
+
+	query := `UPDATE two_factors SET enabled_at = ? WHERE user_id = ?`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, time.Now(), usrId)
+
+	return err
+}
+
+// UseStep records the time step of the accepted code.
+func (r *TwoFactorsRepository) UseStep(ctx context.Context, tx *sql.Tx, usrId int, step int64) error {
+
+	query := `UPDATE two_factors SET last_step = ? WHERE user_id = ?`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, step, usrId)
+
+	return err
+}
+
+// Delete deletes the enrollment of the user and its recovery codes.
+func (r *TwoFactorsRepository) Delete(ctx context.Context, tx *sql.Tx, usrId int) error {
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = ?`, usrId); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, `DELETE FROM two_factors WHERE user_id = ?`, usrId)
+
+	return err
+}
+
+// ReplaceRecoveryCodes deletes the recovery codes of the user and inserts the hashed codes.
+func (r *TwoFactorsRepository) ReplaceRecoveryCodes(ctx context.Context, tx *sql.Tx, usrId int, codes []string) error {
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = ?`, usrId); err != nil {
+		return err
+	}
+
+	if len(codes) == 0 {
+		return nil
+	}
+
+	var (
+		placeholders = make([]string, 0, len(codes))
+		args         = make([]any, 0, len(codes)*2)
+	)
+	for _, code := range codes {
+		placeholders = append(placeholders, "(?,?)")
+		args = append(args, code, usrId)
+	}
+
+	query := `INSERT INTO recovery_codes(code,user_id) VALUES ` + strings.Join(placeholders, ",")
+
+	_, err := tx.ExecContext(ctx, query, args...)
+
+	return err
+}
+
+// UseRecoveryCode marks an unused recovery code of the user as used.
+// Returns sql.ErrNoRows if there is no such unused code.
+func (r *TwoFactorsRepository) UseRecoveryCode(ctx context.Context, tx *sql.Tx, usrId int, code string) error {
+
+	query := `UPDATE recovery_codes SET used_at = ? WHERE code = ? AND user_id = ? AND used_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	result, err := tx.ExecContext(ctx, query, time.Now(), code, usrId)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *TwoFactorsRepository) InsertChallenge(ctx context.Context, tx *sql.Tx, challenge models.TwoFactorChallenge) error {
+
+	query := `INSERT INTO two_factor_challenges(token,user_id,expire_at) VALUES(?,?,?)`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, challenge.Token, challenge.UserId, challenge.ExpireAt)
+
+	return err
+}
+
+// GetChallenge gets a challenge if it is not expired.
+// Returns sql.ErrNoRows if there is none.
+func (r *TwoFactorsRepository) GetChallenge(ctx context.Context, tx *sql.Tx, token string) (models.TwoFactorChallenge, error) {
+
+	query := `SELECT token,user_id,attempts,expire_at FROM two_factor_challenges WHERE token = ? AND expire_at > ? FOR UPDATE`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	var challenge models.TwoFactorChallenge
+
+	err := tx.QueryRowContext(ctx, query, token, time.Now()).Scan(
+		&challenge.Token,
+		&challenge.UserId,
+		&challenge.Attempts,
+		&challenge.ExpireAt,
+	)
+
+	return challenge, err
+}
+
+func (r *TwoFactorsRepository) IncrementChallengeAttempts(ctx context.Context, tx *sql.Tx, token string) error {
+
+	query := `UPDATE two_factor_challenges SET attempts = attempts + 1 WHERE token = ?`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, token)
+
+	return err
+}
+
+func (r *TwoFactorsRepository) DeleteChallenge(ctx context.Context, tx *sql.Tx, token string) error {
+
+	query := `DELETE FROM two_factor_challenges WHERE token = ?`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, token)
+
+	return err
+}
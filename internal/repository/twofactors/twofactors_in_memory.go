@@ -0,0 +1,152 @@
+package twofactors
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/models"
+)
+
+type recoveryCode struct {
+	code   string
+	userId int
+	used   bool
+}
+
+type InMemoryTwoFactors struct {
+	TwoFactors    []models.TwoFactor
+	Challenges    []models.TwoFactorChallenge
+	recoveryCodes []recoveryCode
+}
+
+func (r *InMemoryTwoFactors) Get(ctx context.Context, _ *sql.Tx, usrId int) (models.TwoFactor, error) {
+
+	for _, tf := range r.TwoFactors {
+		if tf.UserId == usrId {
+			return tf, nil
+		}
+	}
+
+	return models.TwoFactor{}, sql.ErrNoRows
+}
+
+func (r *InMemoryTwoFactors) Upsert(ctx context.Context, _ *sql.Tx, tf models.TwoFactor) error {
+
+	tf.CreatedAt = time.Now()
+	for i := range r.TwoFactors {
+		if r.TwoFactors[i].UserId == tf.UserId {
+			r.TwoFactors[i] = tf
+			return nil
+		}
+	}
+
+	r.TwoFactors = append(r.TwoFactors, tf)
+	return nil
+}
+
+func (r *InMemoryTwoFactors) Enable(ctx context.Context, _ *sql.Tx, usrId int) error {
+
+	now := time.Now()
+	for i := range r.TwoFactors {
+		if r.TwoFactors[i].UserId == usrId {
+			r.TwoFactors[i].EnabledAt = &now
+		}
+	}
+
+	return nil
+}
+
+func (r *InMemoryTwoFactors) UseStep(ctx context.Context, _ *sql.Tx, usrId int, step int64) error {
+
+	for i := range r.TwoFactors {
+		if r.TwoFactors[i].UserId == usrId {
+			r.TwoFactors[i].LastStep = step
+		}
+	}
+
+	return nil
+}
+
+func (r *InMemoryTwoFactors) Delete(ctx context.Context, tx *sql.Tx, usrId int) error {
+
+	remaining := r.TwoFactors[:0]
+	for _, tf := range r.TwoFactors {
+		if tf.UserId != usrId {
+			remaining = append(remaining, tf)
+		}
+	}
+	r.TwoFactors = remaining
+
+	return r.ReplaceRecoveryCodes(ctx, tx, usrId, nil)
+}
+
+func (r *InMemoryTwoFactors) ReplaceRecoveryCodes(ctx context.Context, _ *sql.Tx, usrId int, codes []string) error {
+
+	remaining := r.recoveryCodes[:0]
+	for _, code := range r.recoveryCodes {
+		if code.userId != usrId {
+			remaining = append(remaining, code)
+		}
+	}
+
+	for _, code := range codes {
+		remaining = append(remaining, recoveryCode{code: code, userId: usrId})
+	}
+	r.recoveryCodes = remaining
+
+	return nil
+}
+
+func (r *InMemoryTwoFactors) UseRecoveryCode(ctx context.Context, _ *sql.Tx, usrId int, code string) error {
+
+	for i := range r.recoveryCodes {
+		if r.recoveryCodes[i].userId == usrId && r.recoveryCodes[i].code == code && !r.recoveryCodes[i].used {
+			r.recoveryCodes[i].used = true
+			return nil
+		}
+	}
+
+	return sql.ErrNoRows
+}
+
+func (r *InMemoryTwoFactors) InsertChallenge(ctx context.Context, _ *sql.Tx, challenge models.TwoFactorChallenge) error {
+
+	r.Challenges = append(r.Challenges, challenge)
+	return nil
+}
+
+func (r *InMemoryTwoFactors) GetChallenge(ctx context.Context, _ *sql.Tx, token string) (models.TwoFactorChallenge, error) {
+
+	for _, challenge := range r.Challenges {
+		if challenge.Token == token && challenge.ExpireAt.After(time.Now()) {
+			return challenge, nil
+		}
+	}
+
+	return models.TwoFactorChallenge{}, sql.ErrNoRows
+}
+
+func (r *InMemoryTwoFactors) IncrementChallengeAttempts(ctx context.Context, _ *sql.Tx, token string) error {
+
+	for i := range r.Challenges {
+		if r.Challenges[i].Token == token {
+			r.Challenges[i].Attempts++
+		}
+	}
+
+	return nil
+}
+
+func (r *InMemoryTwoFactors) DeleteChallenge(ctx context.Context, _ *sql.Tx, token string) error {
+
+	remaining := r.Challenges[:0]
+	for _, challenge := range r.Challenges {
+		if challenge.Token != token {
+			remaining = append(remaining, challenge)
+		}
+	}
+	r.Challenges = remaining
+
+	return nil
+}
@@ -0,0 +1,16 @@
+package twofactors_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/faizisyellow/indocoffee/internal/repository/twofactors"
+)
+
+func TestInMemoryTwoFactors(t *testing.T) {
+	twofactors.Contract{
+		NewTwoFactors: func() (twofactors.TwoFactors, *sql.Tx, func()) {
+			return &twofactors.InMemoryTwoFactors{}, nil, func() {}
+		},
+	}.Test(t)
+}
@@ -20,8 +20,14 @@ type Users interface {
 	GetUsersOrders(ctx context.Context, r repository.PaginatedOrdersQuery, usrId int) ([]models.Order, error)
 	Update(ctx context.Context, tx *sql.Tx, usr models.User) error
 	Delete(ctx context.Context, tx *sql.Tx, id int) error
+	GetUsers(ctx context.Context, qry repository.PaginatedUsersQuery) ([]models.User, error)
+	UpdateRole(ctx context.Context, tx *sql.Tx, id, roleId int) error
+	UpdateUsername(ctx context.Context, tx *sql.Tx, id int, username string) error
+	SetActive(ctx context.Context, tx *sql.Tx, id int, active bool) error
 }
 
+// Contract expects every new store to be empty
+// and to know the roles with id 1 and 2.
 type Contract struct {
 	NewUsers func() (Users, *sql.Tx, func())
 }
@@ -34,6 +40,7 @@ func (u Contract) Test(t *testing.T) {
 			userPayload        = models.User{
 				Username: "lizzy",
 				Email:    "lizzy@test.test",
+				RoleId:   1,
 			}
 		)
 		t.Cleanup(cleanup)
@@ -58,6 +65,7 @@ func (u Contract) Test(t *testing.T) {
 				Username:  "lizzy",
 				Email:     "lizzy@test.test",
 				IsActive:  utils.BoolToPoint(false),
+				RoleId:    1,
 				CreatedAt: time.Time{},
 			}
 		)
@@ -73,7 +81,7 @@ func (u Contract) Test(t *testing.T) {
 			t.Error("should not be error")
 		}
 
-		if !reflect.DeepEqual(expected, result) {
+		if !sameUser(expected, result) {
 			t.Error("want to be matched")
 		}
 	})
@@ -87,6 +95,7 @@ func (u Contract) Test(t *testing.T) {
 				Username:  "lizzy",
 				Email:     "lizzy@test.test",
 				IsActive:  utils.BoolToPoint(false),
+				RoleId:    1,
 				CreatedAt: time.Time{},
 			}
 		)
@@ -102,7 +111,7 @@ func (u Contract) Test(t *testing.T) {
 			t.Error("should not be error")
 		}
 
-		if !reflect.DeepEqual(expected, result) {
+		if !sameUser(expected, result) {
 			t.Error("want to be matched")
 		}
 	})
@@ -116,6 +125,7 @@ func (u Contract) Test(t *testing.T) {
 				Username:  "lizzy",
 				Email:     "lizzy@test.test",
 				IsActive:  utils.BoolToPoint(false),
+				RoleId:    1,
 				CreatedAt: time.Time{},
 			}
 			payloadUpdateUser = models.User{
@@ -126,6 +136,7 @@ func (u Contract) Test(t *testing.T) {
 				Username:  "LizzyMCalpine",
 				Email:     "lizzy@test.test",
 				IsActive:  utils.BoolToPoint(false),
+				RoleId:    1,
 				CreatedAt: time.Time{},
 			}
 		)
@@ -148,7 +159,7 @@ func (u Contract) Test(t *testing.T) {
 
 		updatedUsr, err := users.GetById(ctx, initialUser.Id)
 
-		if !reflect.DeepEqual(updatedUsr, expectedAfterUpdateUser) {
+		if !sameUser(updatedUsr, expectedAfterUpdateUser) {
 			t.Error("should not be error")
 		}
 	})
@@ -162,6 +173,7 @@ func (u Contract) Test(t *testing.T) {
 				Username:  "lizzy",
 				Email:     "lizzy@test.test",
 				IsActive:  utils.BoolToPoint(false),
+				RoleId:    1,
 				CreatedAt: time.Time{},
 			}
 		)
@@ -177,4 +189,167 @@ func (u Contract) Test(t *testing.T) {
 			t.Error("should not be error")
 		}
 	})
+
+	t.Run("get users filtered by role, active state and email", func(t *testing.T) {
+		var (
+			ctx                = context.Background()
+			users, tx, cleanup = u.NewUsers()
+			initial            = []models.User{
+				{Username: "lizzy", Email: "lizzy@test.test", RoleId: 1},
+				{Username: "taylor", Email: "taylor@test.test", RoleId: 2},
+				{Username: "olivia", Email: "olivia@test.test", RoleId: 1},
+			}
+			ids []int
+		)
+		t.Cleanup(cleanup)
+
+		for _, usr := range initial {
+			id, err := users.Insert(ctx, tx, usr)
+			if err != nil {
+				t.Fatalf("should not be error but got: %v", err)
+			}
+			ids = append(ids, id)
+		}
+
+		if err := users.SetActive(ctx, tx, ids[2], true); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		tests := []struct {
+			name     string
+			qry      repository.PaginatedUsersQuery
+			expected []int
+		}{
+			{"all users", repository.PaginatedUsersQuery{Limit: 10, Sort: "asc"}, ids},
+			{"latest first", repository.PaginatedUsersQuery{Limit: 10, Sort: "desc"}, []int{ids[2], ids[1], ids[0]}},
+			{"paginated", repository.PaginatedUsersQuery{Limit: 1, Offset: 1, Sort: "asc"}, []int{ids[1]}},
+			{"by role", repository.PaginatedUsersQuery{Limit: 10, Sort: "asc", Role: 1}, []int{ids[0], ids[2]}},
+			{"by active state", repository.PaginatedUsersQuery{Limit: 10, Sort: "asc", Active: utils.BoolToPoint(true)}, []int{ids[2]}},
+			{"by email", repository.PaginatedUsersQuery{Limit: 10, Sort: "asc", Search: "taylor@"}, []int{ids[1]}},
+			{"by email in any case", repository.PaginatedUsersQuery{Limit: 10, Sort: "asc", Search: "TAYLOR@"}, []int{ids[1]}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				result, err := users.GetUsers(ctx, tt.qry)
+				if err != nil {
+					t.Fatalf("should not be error but got: %v", err)
+				}
+
+				var got []int
+				for _, usr := range result {
+					got = append(got, usr.Id)
+				}
+
+				if !reflect.DeepEqual(got, tt.expected) {
+					t.Errorf("expected users %v but got %v", tt.expected, got)
+				}
+			})
+		}
+	})
+
+	t.Run("change user's role", func(t *testing.T) {
+		var (
+			ctx                = context.Background()
+			users, tx, cleanup = u.NewUsers()
+		)
+		t.Cleanup(cleanup)
+
+		id, err := users.Insert(ctx, tx, models.User{Username: "lizzy", Email: "lizzy@test.test", RoleId: 1})
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if err := users.UpdateRole(ctx, tx, id, 2); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		result, err := users.GetUsers(ctx, repository.PaginatedUsersQuery{Limit: 10, Sort: "asc", Role: 2})
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if len(result) != 1 || result[0].Id != id {
+			t.Errorf("expected user %v to have role 2 but got: %+v", id, result)
+		}
+	})
+
+	t.Run("change only the username", func(t *testing.T) {
+		var (
+			ctx                = context.Background()
+			users, tx, cleanup = u.NewUsers()
+		)
+		t.Cleanup(cleanup)
+
+		id, err := users.Insert(ctx, tx, models.User{Username: "lizzy", Email: "lizzy@test.test", RoleId: 1})
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if err := users.SetActive(ctx, tx, id, false); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if err := users.UpdateUsername(ctx, tx, id, "LizzyMCalpine"); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		result, err := users.GetByEmail(ctx, "lizzy@test.test")
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if result.Username != "LizzyMCalpine" || *result.IsActive || result.DeactivatedAt == nil {
+			t.Errorf("expected only the username to change but got: %+v", result)
+		}
+	})
+
+	t.Run("deactivate and reactivate user", func(t *testing.T) {
+		var (
+			ctx                = context.Background()
+			users, tx, cleanup = u.NewUsers()
+		)
+		t.Cleanup(cleanup)
+
+		id, err := users.Insert(ctx, tx, models.User{Username: "lizzy", Email: "lizzy@test.test", RoleId: 1})
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if err := users.SetActive(ctx, tx, id, false); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		deactivated, err := users.GetByEmail(ctx, "lizzy@test.test")
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if *deactivated.IsActive || deactivated.DeactivatedAt == nil {
+			t.Errorf("expected user to be deactivated but got: %+v", deactivated)
+		}
+
+		if err := users.SetActive(ctx, tx, id, true); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		reactivated, err := users.GetByEmail(ctx, "lizzy@test.test")
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if !*reactivated.IsActive || reactivated.DeactivatedAt != nil {
+			t.Errorf("expected user to be active but got: %+v", reactivated)
+		}
+	})
+}
+
+// sameUser compares the fields every store gives back,
+// created_at, the role and the password hash are filled by the store itself.
+func sameUser(a, b models.User) bool {
+	return a.Id == b.Id &&
+		a.Username == b.Username &&
+		a.Email == b.Email &&
+		a.RoleId == b.RoleId &&
+		reflect.DeepEqual(a.IsActive, b.IsActive)
 }
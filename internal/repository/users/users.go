@@ -28,7 +28,7 @@ func (u *UsersRepository) Insert(ctx context.Context, tx *sql.Tx, usr models.Use
 	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
 	defer cancel()
 
-	res, err := tx.ExecContext(ctx, query, usr.Username, usr.Email, usr.Password.HashedText, usr.RoleId)
+	res, err := repository.Execer(u.Db, tx).ExecContext(ctx, query, usr.Username, usr.Email, usr.Password.HashedText, usr.RoleId)
 	if err != nil {
 		return -1, err
 	}
@@ -47,7 +47,7 @@ func (u *UsersRepository) GetById(ctx context.Context, id int) (models.User, err
 
 	var user models.User
 
-	query := `SELECT id,username,email,is_active,password,role_id,created_at FROM users WHERE id = ?`
+	query := `SELECT id,username,email,is_active,password,role_id,created_at,deactivated_at FROM users WHERE id = ?`
 
 	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
 	defer cancel()
@@ -60,6 +60,7 @@ func (u *UsersRepository) GetById(ctx context.Context, id int) (models.User, err
 		&user.Password.HashedText,
 		&user.RoleId,
 		&user.CreatedAt,
+		&user.DeactivatedAt,
 	)
 	if err != nil {
 		return user, err
@@ -82,6 +83,8 @@ func (u *UsersRepository) GetByEmail(ctx context.Context, email string) (models.
 			email,
 			password,
 			is_active,
+			deactivated_at,
+			users.role_id,
 			roles.name
 		FROM users JOIN roles ON users.role_id = roles.id
 	 	WHERE email = ?`
@@ -95,6 +98,8 @@ func (u *UsersRepository) GetByEmail(ctx context.Context, email string) (models.
 		&user.Email,
 		&user.Password.HashedText,
 		&user.IsActive,
+		&user.DeactivatedAt,
+		&user.RoleId,
 		&user.Role.Name,
 	)
 	if err != nil {
@@ -115,7 +120,7 @@ func (u *UsersRepository) Update(ctx context.Context, tx *sql.Tx, usr models.Use
 	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
 	defer cancel()
 
-	_, err := tx.ExecContext(ctx, query, &usr.Username, &usr.Email, &usr.Password.HashedText, &usr.IsActive, usr.Id)
+	_, err := repository.Execer(u.Db, tx).ExecContext(ctx, query, &usr.Username, &usr.Email, &usr.Password.HashedText, &usr.IsActive, usr.Id)
 	if err != nil {
 		return err
 	}
@@ -132,7 +137,7 @@ func (u *UsersRepository) Delete(ctx context.Context, tx *sql.Tx, id int) error
 	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
 	defer cancel()
 
-	_, err := tx.ExecContext(ctx, query, id)
+	_, err := repository.Execer(u.Db, tx).ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -147,16 +152,22 @@ func (u *UsersRepository) GetUsersCart(ctx context.Context, id int) (models.User
 		users.username,
 		cart_items.id,
 		cart_items.quantity,
+		product_variants.id,
+		product_variants.sku,
+		product_variants.weight,
+		product_variants.price,
+		product_variants.quantity AS variant_quantity,
 		products.id,
+		products.name,
 		products.roasted,
-		products.price,
-		products.image,
-		products.quantity AS product_quantity,
+		product_images.url,
 		beans.name AS bean,
 		forms.name AS form
 	FROM users
 	LEFT JOIN cart_items ON cart_items.user_id = users.id
-	LEFT JOIN products ON products.id = cart_items.product_id
+	LEFT JOIN product_variants ON product_variants.id = cart_items.variant_id
+	LEFT JOIN products ON products.id = product_variants.product_id
+	LEFT JOIN product_images ON product_images.product_id = products.id AND product_images.is_primary = TRUE
 	LEFT JOIN beans ON beans.id = products.bean_id
 	LEFT JOIN forms ON forms.id = products.form_id
 	WHERE users.id = ? AND cart_items.status="open";
@@ -179,11 +190,15 @@ func (u *UsersRepository) GetUsersCart(ctx context.Context, id int) (models.User
 			cart            models.Cart
 			cartId          sql.NullInt64
 			quantity        sql.NullInt64
+			variantId       sql.NullInt64
+			sku             sql.NullString
+			weight          sql.NullInt64
+			price           sql.NullFloat64
+			variantQuantity sql.NullInt64
 			productId       sql.NullInt64
+			productName     sql.NullString
 			roasted         sql.NullString
-			price           sql.NullFloat64
 			image           sql.NullString
-			productQuantity sql.NullInt64
 			beanName        sql.NullString
 			formName        sql.NullString
 		)
@@ -193,11 +208,15 @@ func (u *UsersRepository) GetUsersCart(ctx context.Context, id int) (models.User
 			&user.Username,
 			&cartId,
 			&quantity,
+			&variantId,
+			&sku,
+			&weight,
+			&price,
+			&variantQuantity,
 			&productId,
+			&productName,
 			&roasted,
-			&price,
 			&image,
-			&productQuantity,
 			&beanName,
 			&formName,
 		); err != nil {
@@ -212,11 +231,20 @@ func (u *UsersRepository) GetUsersCart(ctx context.Context, id int) (models.User
 		if cartId.Valid {
 			cart.Id = int(cartId.Int64)
 			cart.Quantity = int(quantity.Int64)
+			cart.VariantId = int(variantId.Int64)
 			cart.ProductId = int(productId.Int64)
+			cart.Variant = models.Variant{
+				Id:        cart.VariantId,
+				ProductId: cart.ProductId,
+				Sku:       sku.String,
+				Weight:    int(weight.Int64),
+				Price:     price.Float64,
+				Quantity:  int(variantQuantity.Int64),
+			}
+			cart.Product.Id = cart.ProductId
+			cart.Product.Name = productName.String
 			cart.Product.Roasted = roasted.String
-			cart.Product.Price = price.Float64
 			cart.Product.Image = image.String
-			cart.Product.Quantity = int(productQuantity.Int64)
 			cart.Product.BeansModel.Name = beanName.String
 			cart.Product.FormsModel.Name = formName.String
 
@@ -245,6 +273,9 @@ func (o *UsersRepository) GetUsersOrders(ctx context.Context, qry repository.Pag
 			alternative_phone_number,
 			street,
 			city,
+			province,
+			postal_code,
+			delivery_notes,
 			created_at,
 			items,
 			cart_ids
@@ -261,6 +292,9 @@ func (o *UsersRepository) GetUsersOrders(ctx context.Context, qry repository.Pag
 				alternative_phone_number,
 				street,
 				city,
+				province,
+				postal_code,
+				delivery_notes,
 				created_at,
 				items,
 				cart_ids
@@ -302,6 +336,9 @@ func (o *UsersRepository) GetUsersOrders(ctx context.Context, qry repository.Pag
 			&order.AlternativePhoneNumber,
 			&order.Street,
 			&order.City,
+			&order.Province,
+			&order.PostalCode,
+			&order.DeliveryNotes,
 			&order.CreatedAt,
 			&itemsJSON,
 			&cartIdsJSON,
@@ -330,3 +367,136 @@ func (o *UsersRepository) GetUsersOrders(ctx context.Context, qry repository.Pag
 
 	return orders, rowsResult.Err()
 }
+
+// GetUsers gets users with their role, filtered and paginated by qry.
+// Search matches part of the username or email.
+func (u *UsersRepository) GetUsers(ctx context.Context, qry repository.PaginatedUsersQuery) ([]models.User, error) {
+	query := `
+		SELECT
+			users.id,
+			users.username,
+			users.email,
+			users.is_active,
+			users.role_id,
+			users.created_at,
+			users.deactivated_at,
+			roles.name
+		FROM users
+		JOIN roles ON roles.id = users.role_id
+		WHERE 1=1
+	`
+
+	args := []any{}
+
+	if qry.Role > 0 {
+		query += " AND users.role_id = ?"
+		args = append(args, qry.Role)
+	}
+
+	if qry.Active != nil {
+		query += " AND users.is_active = ?"
+		args = append(args, *qry.Active)
+	}
+
+	if qry.Search != "" {
+		query += " AND (users.email LIKE CONCAT('%', ?, '%') OR users.username LIKE CONCAT('%', ?, '%'))"
+		args = append(args, qry.Search, qry.Search)
+	}
+
+	query += " ORDER BY users.created_at " + qry.Sort + ", users.id " + qry.Sort
+
+	if qry.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, qry.Limit, qry.Offset)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	rows, err := u.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []models.User
+
+	for rows.Next() {
+		user := models.User{Role: &models.RolesModel{}}
+
+		if err := rows.Scan(
+			&user.Id,
+			&user.Username,
+			&user.Email,
+			&user.IsActive,
+			&user.RoleId,
+			&user.CreatedAt,
+			&user.DeactivatedAt,
+			&user.Role.Name,
+		); err != nil {
+			return nil, err
+		}
+
+		user.Role.Id = user.RoleId
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+// UpdateRole moves the user to another role.
+// Returns nil on success or an error on failure.
+func (u *UsersRepository) UpdateRole(ctx context.Context, tx *sql.Tx, id, roleId int) error {
+
+	query := `UPDATE users SET role_id = ? WHERE id = ?`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	_, err := repository.Execer(u.Db, tx).ExecContext(ctx, query, roleId, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateUsername changes only the username, so it never writes back
+// columns another request changed meanwhile.
+// Returns nil on success or an error on failure.
+func (u *UsersRepository) UpdateUsername(ctx context.Context, tx *sql.Tx, id int, username string) error {
+
+	query := `UPDATE users SET username = ? WHERE id = ?`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	_, err := repository.Execer(u.Db, tx).ExecContext(ctx, query, username, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetActive activates or deactivates the user,
+// deactivating records when it happens.
+// Returns nil on success or an error on failure.
+func (u *UsersRepository) SetActive(ctx context.Context, tx *sql.Tx, id int, active bool) error {
+
+	query := `
+		UPDATE users
+		SET is_active = ?, deactivated_at = IF(?, NULL, CURRENT_TIMESTAMP)
+		WHERE id = ?
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, repository.QueryTimeout)
+	defer cancel()
+
+	_, err := repository.Execer(u.Db, tx).ExecContext(ctx, query, active, active, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -3,6 +3,8 @@ package users
 import (
 	"context"
 	"database/sql"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/faizisyellow/indocoffee/internal/models"
@@ -29,6 +31,7 @@ func (u *InMemoryUsers) Insert(ctx context.Context, _ *sql.Tx, usr models.User)
 		Email:     usr.Email,
 		Password:  usr.Password,
 		IsActive:  utils.BoolToPoint(false),
+		RoleId:    usr.RoleId,
 		CreatedAt: time.Time{},
 	}
 	u.Users = append(u.Users, newUser)
@@ -89,3 +92,85 @@ func (u *InMemoryUsers) GetUsersOrders(ctx context.Context, r repository.Paginat
 
 	return []models.Order{}, nil
 }
+
+func (u *InMemoryUsers) GetUsers(ctx context.Context, qry repository.PaginatedUsersQuery) ([]models.User, error) {
+
+	var result []models.User
+	for _, user := range u.Users {
+		if qry.Role > 0 && user.RoleId != qry.Role {
+			continue
+		}
+
+		if qry.Active != nil && (user.IsActive == nil || *user.IsActive != *qry.Active) {
+			continue
+		}
+
+		search := strings.ToLower(qry.Search)
+		if search != "" && !strings.Contains(strings.ToLower(user.Email), search) && !strings.Contains(strings.ToLower(user.Username), search) {
+			continue
+		}
+
+		user.Role = &models.RolesModel{Id: user.RoleId}
+		result = append(result, user)
+	}
+
+	// sorted like the database does, by created_at and then id.
+	slices.SortFunc(result, func(a, b models.User) int {
+		if qry.Sort == "desc" {
+			a, b = b, a
+		}
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return a.Id - b.Id
+	})
+
+	if qry.Offset >= len(result) {
+		return nil, nil
+	}
+	result = result[qry.Offset:]
+
+	if qry.Limit > 0 && qry.Limit < len(result) {
+		result = result[:qry.Limit]
+	}
+
+	return result, nil
+}
+
+func (u *InMemoryUsers) UpdateRole(ctx context.Context, _ *sql.Tx, id, roleId int) error {
+
+	for i, user := range u.Users {
+		if user.Id == id {
+			u.Users[i].RoleId = roleId
+		}
+	}
+
+	return nil
+}
+
+func (u *InMemoryUsers) UpdateUsername(ctx context.Context, _ *sql.Tx, id int, username string) error {
+
+	for i, user := range u.Users {
+		if user.Id == id {
+			u.Users[i].Username = username
+		}
+	}
+
+	return nil
+}
+
+func (u *InMemoryUsers) SetActive(ctx context.Context, _ *sql.Tx, id int, active bool) error {
+
+	for i, user := range u.Users {
+		if user.Id == id {
+			u.Users[i].IsActive = utils.BoolToPoint(active)
+			u.Users[i].DeactivatedAt = nil
+			if !active {
+				now := time.Now()
+				u.Users[i].DeactivatedAt = &now
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package users_test
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/faizisyellow/indocoffee/internal/db"
+	"github.com/faizisyellow/indocoffee/internal/repository/users"
+)
+
+func TestUsersWithRealDB(t *testing.T) {
+	if getEnvironment(t) != "development" {
+		t.Skip("skipping test: only runs in development environment")
+	}
+
+	users.Contract{NewUsers: func() (users.Users, *sql.Tx, func()) {
+		newDB, err := setupTestDB(t)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resetUsers(t, newDB)
+
+		// the users are written without transaction,
+		// so the contract can read them back.
+		return &users.UsersRepository{Db: newDB}, nil, func() {
+			resetUsers(t, newDB)
+			if err := newDB.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}}.Test(t)
+}
+
+// resetUsers empties users so each contract starts from id 1,
+// and makes sure the roles the contract uses exist.
+func resetUsers(t *testing.T, newDB *sql.DB) {
+	t.Helper()
+
+	queries := []string{
+		`DELETE FROM users`,
+		`ALTER TABLE users AUTO_INCREMENT = 1`,
+		`INSERT IGNORE INTO roles(id, name) VALUES (1, 'customer'), (2, 'admin')`,
+	}
+
+	for _, query := range queries {
+		if _, err := newDB.Exec(query); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func setupTestDB(t *testing.T) (*sql.DB, error) {
+	t.Helper()
+
+	return db.New(
+		os.Getenv("DB_TEST_ADDR"),
+		5,
+		5,
+		"1m",
+		"1m",
+	)
+}
+
+func getEnvironment(t *testing.T) string {
+	t.Helper()
+
+	return os.Getenv("ENV")
+}
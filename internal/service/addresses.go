@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/faizisyellow/indocoffee/internal/db"
+	"github.com/faizisyellow/indocoffee/internal/models"
+	"github.com/faizisyellow/indocoffee/internal/repository/addresses"
+	"github.com/faizisyellow/indocoffee/internal/service/dto"
+	errorService "github.com/faizisyellow/indocoffee/internal/service/error"
+	"github.com/faizisyellow/indocoffee/internal/utils"
+)
+
+type AddressesService struct {
+	AddressesStore addresses.Addresses
+	Transaction    db.Transactioner
+}
+
+const MAX_ADDRESSES = 10
+
+var (
+	ErrAddressNotFound = errors.New("addresses: no such as address")
+	ErrAddressLimit    = errors.New("addresses: address book is full, delete an address first")
+	ErrAddressInternal = errors.New("addresses: encounter internal error")
+)
+
+// Create saves new address of the user.
+// The first address of the user becomes the default one.
+func (a *AddressesService) Create(ctx context.Context, usrId int, req dto.CreateAddressRequest) (models.Address, error) {
+
+	existing, err := a.AddressesStore.GetByUserId(ctx, usrId)
+	if err != nil {
+		return models.Address{}, errorService.New(ErrAddressInternal, err)
+	}
+
+	if len(existing) >= MAX_ADDRESSES {
+		return models.Address{}, errorService.New(ErrAddressLimit, ErrAddressLimit)
+	}
+
+	phone, err := utils.ValidateAndFormatPhoneNumber(req.PhoneNumber)
+	if err != nil {
+		return models.Address{}, err
+	}
+
+	addr := models.Address{
+		UserId:        usrId,
+		Label:         req.Label,
+		RecipientName: req.RecipientName,
+		PhoneNumber:   phone,
+		Street:        req.Street,
+		City:          req.City,
+		Province:      req.Province,
+		PostalCode:    req.PostalCode,
+		Notes:         req.Notes,
+		IsDefault:     req.IsDefault || len(existing) == 0,
+	}
+
+	err = a.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+
+		if addr.IsDefault {
+			if err := a.AddressesStore.UnsetDefault(ctx, tx, usrId); err != nil {
+				return errorService.New(ErrAddressInternal, err)
+			}
+		}
+
+		addr.Id, err = a.AddressesStore.Insert(ctx, tx, addr)
+		if err != nil {
+			return errorService.New(ErrAddressInternal, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return models.Address{}, err
+	}
+
+	return addr, nil
+}
+
+func (a *AddressesService) FindAll(ctx context.Context, usrId int) ([]models.Address, error) {
+
+	addrs, err := a.AddressesStore.GetByUserId(ctx, usrId)
+	if err != nil {
+		return nil, errorService.New(ErrAddressInternal, err)
+	}
+
+	return addrs, nil
+}
+
+// FindById gets the user's address, addresses of other users are not found.
+func (a *AddressesService) FindById(ctx context.Context, usrId, id int) (models.Address, error) {
+
+	addr, err := a.AddressesStore.GetById(ctx, id)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return models.Address{}, errorService.New(ErrAddressNotFound, err)
+		default:
+			return models.Address{}, errorService.New(ErrAddressInternal, err)
+		}
+	}
+
+	if addr.UserId != usrId {
+		return models.Address{}, errorService.New(ErrAddressNotFound, ErrAddressNotFound)
+	}
+
+	return addr, nil
+}
+
+// FindDefault gets the default address of the user.
+// Returns ErrAddressNotFound when the user has no address.
+func (a *AddressesService) FindDefault(ctx context.Context, usrId int) (models.Address, error) {
+
+	addrs, err := a.AddressesStore.GetByUserId(ctx, usrId)
+	if err != nil {
+		return models.Address{}, errorService.New(ErrAddressInternal, err)
+	}
+
+	for _, addr := range addrs {
+		if addr.IsDefault {
+			return addr, nil
+		}
+	}
+
+	return models.Address{}, errorService.New(ErrAddressNotFound, ErrAddressNotFound)
+}
+
+func (a *AddressesService) Update(ctx context.Context, usrId, id int, req dto.UpdateAddressRequest) (models.Address, error) {
+
+	addr, err := a.FindById(ctx, usrId, id)
+	if err != nil {
+		return models.Address{}, err
+	}
+
+	if req.PhoneNumber != nil {
+		phone, err := utils.ValidateAndFormatPhoneNumber(*req.PhoneNumber)
+		if err != nil {
+			return models.Address{}, err
+		}
+		addr.PhoneNumber = phone
+	}
+
+	if req.Label != nil {
+		addr.Label = *req.Label
+	}
+
+	if req.RecipientName != nil {
+		addr.RecipientName = *req.RecipientName
+	}
+
+	if req.Street != nil {
+		addr.Street = *req.Street
+	}
+
+	if req.City != nil {
+		addr.City = *req.City
+	}
+
+	if req.Province != nil {
+		addr.Province = *req.Province
+	}
+
+	if req.PostalCode != nil {
+		addr.PostalCode = *req.PostalCode
+	}
+
+	if req.Notes != nil {
+		addr.Notes = req.Notes
+	}
+
+	err = a.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+
+		if err := a.AddressesStore.Update(ctx, tx, addr); err != nil {
+			return errorService.New(ErrAddressInternal, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return models.Address{}, err
+	}
+
+	return addr, nil
+}
+
+// SetDefault makes the address the one used at checkout when none is chosen.
+func (a *AddressesService) SetDefault(ctx context.Context, usrId, id int) error {
+
+	addr, err := a.FindById(ctx, usrId, id)
+	if err != nil {
+		return err
+	}
+
+	addr.IsDefault = true
+
+	return a.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+
+		if err := a.AddressesStore.UnsetDefault(ctx, tx, usrId); err != nil {
+			return errorService.New(ErrAddressInternal, err)
+		}
+
+		if err := a.AddressesStore.Update(ctx, tx, addr); err != nil {
+			return errorService.New(ErrAddressInternal, err)
+		}
+
+		return nil
+	})
+}
+
+// Delete removes the user's address, when it was the default one
+// the oldest remaining address becomes the default.
+func (a *AddressesService) Delete(ctx context.Context, usrId, id int) error {
+
+	addr, err := a.FindById(ctx, usrId, id)
+	if err != nil {
+		return err
+	}
+
+	return a.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+
+		if err := a.AddressesStore.Delete(ctx, tx, addr.Id); err != nil {
+			return errorService.New(ErrAddressInternal, err)
+		}
+
+		if !addr.IsDefault {
+			return nil
+		}
+
+		remaining, err := a.AddressesStore.GetByUserId(ctx, usrId)
+		if err != nil {
+			return errorService.New(ErrAddressInternal, err)
+		}
+
+		for _, next := range remaining {
+			if next.Id == addr.Id {
+				continue
+			}
+
+			next.IsDefault = true
+			if err := a.AddressesStore.Update(ctx, tx, next); err != nil {
+				return errorService.New(ErrAddressInternal, err)
+			}
+			break
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,156 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/faizisyellow/indocoffee/internal/repository/addresses"
+	"github.com/faizisyellow/indocoffee/internal/service"
+	"github.com/faizisyellow/indocoffee/internal/service/dto"
+	errorService "github.com/faizisyellow/indocoffee/internal/service/error"
+)
+
+func TestAddressesService(t *testing.T) {
+	newSut := func() *service.AddressesService {
+		return &service.AddressesService{
+			AddressesStore: &addresses.InMemoryAddresses{},
+			Transaction:    &transactionFake{state: initial},
+		}
+	}
+
+	newRequest := func(label string, isDefault bool) dto.CreateAddressRequest {
+		return dto.CreateAddressRequest{
+			Label:         label,
+			RecipientName: "lizzy",
+			PhoneNumber:   "0812-3456-7890",
+			Street:        "Jl. Malioboro No. 52",
+			City:          "Yogyakarta",
+			Province:      "DI Yogyakarta",
+			PostalCode:    "55271",
+			IsDefault:     isDefault,
+		}
+	}
+
+	t.Run("first address becomes the default", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+			sut = newSut()
+		)
+
+		home, err := sut.Create(ctx, 1, newRequest("home", false))
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if !home.IsDefault {
+			t.Error("expected the first address to be the default")
+		}
+
+		if home.PhoneNumber != "081234567890" {
+			t.Errorf("expected phone number to be cleaned but got: %v", home.PhoneNumber)
+		}
+
+		office, err := sut.Create(ctx, 1, newRequest("office", false))
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if office.IsDefault {
+			t.Error("expected the second address not to be the default")
+		}
+	})
+
+	t.Run("only one default address", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+			sut = newSut()
+		)
+
+		home, _ := sut.Create(ctx, 1, newRequest("home", false))
+		office, _ := sut.Create(ctx, 1, newRequest("office", true))
+
+		addrs, err := sut.FindAll(ctx, 1)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if addrs[0].Id != office.Id || addrs[1].IsDefault {
+			t.Fatalf("expected office to be the only default but got: %+v", addrs)
+		}
+
+		if err := sut.SetDefault(ctx, 1, home.Id); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		addrs, _ = sut.FindAll(ctx, 1)
+		if addrs[0].Id != home.Id || addrs[1].IsDefault {
+			t.Errorf("expected home to be the only default but got: %+v", addrs)
+		}
+	})
+
+	t.Run("deleting the default promotes another address", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+			sut = newSut()
+		)
+
+		home, _ := sut.Create(ctx, 1, newRequest("home", false))
+		office, _ := sut.Create(ctx, 1, newRequest("office", false))
+
+		if err := sut.Delete(ctx, 1, home.Id); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		addr, err := sut.FindById(ctx, 1, office.Id)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if !addr.IsDefault {
+			t.Error("expected the remaining address to become the default")
+		}
+	})
+
+	t.Run("address of other user is not found", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+			sut = newSut()
+		)
+
+		home, _ := sut.Create(ctx, 1, newRequest("home", false))
+
+		_, err := sut.FindById(ctx, 2, home.Id)
+		if errorService.GetError(err).E != service.ErrAddressNotFound {
+			t.Errorf("expected %v but got: %v", service.ErrAddressNotFound, err)
+		}
+
+		err = sut.Delete(ctx, 2, home.Id)
+		if errorService.GetError(err).E != service.ErrAddressNotFound {
+			t.Errorf("expected %v but got: %v", service.ErrAddressNotFound, err)
+		}
+	})
+
+	t.Run("find the default address", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+			sut = newSut()
+		)
+
+		_, err := sut.FindDefault(ctx, 1)
+		if errorService.GetError(err).E != service.ErrAddressNotFound {
+			t.Fatalf("expected %v but got: %v", service.ErrAddressNotFound, err)
+		}
+
+		sut.Create(ctx, 1, newRequest("home", false))
+		office, _ := sut.Create(ctx, 1, newRequest("office", true))
+
+		addr, err := sut.FindDefault(ctx, 1)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if addr.Id != office.Id {
+			t.Errorf("expected office to be the default but got: %+v", addr)
+		}
+	})
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/audit"
+	"github.com/faizisyellow/indocoffee/internal/db"
+	"github.com/faizisyellow/indocoffee/internal/models"
+	"github.com/faizisyellow/indocoffee/internal/repository/apikeys"
+	"github.com/faizisyellow/indocoffee/internal/repository/roles"
+	"github.com/faizisyellow/indocoffee/internal/service/dto"
+	errorService "github.com/faizisyellow/indocoffee/internal/service/error"
+	"github.com/faizisyellow/indocoffee/internal/utils"
+)
+
+type ApiKeysService struct {
+	ApiKeysStore apikeys.ApiKeys
+	RolesStore   roles.Roles
+	Transaction  db.Transactioner
+	Audit        audit.Recorder
+}
+
+const (
+	API_KEY_PREFIX = "ick_"
+	// API_KEY_TOUCH_INTERVAL limits how often the last use of a key is written.
+	API_KEY_TOUCH_INTERVAL = time.Minute
+)
+
+var (
+	ErrApiKeyInvalid  = errors.New("api keys: invalid api key")
+	ErrApiKeyExpired  = errors.New("api keys: api key has expired")
+	ErrApiKeyRevoked  = errors.New("api keys: api key has been revoked")
+	ErrApiKeyNotFound = errors.New("api keys: api key not found")
+	ErrApiKeyInternal = errors.New("api keys: encounter internal error")
+)
+
+// Create issues a new api key acting with the role's permissions.
+// The plaintext key is only returned here, only its hash is stored.
+func (a *ApiKeysService) Create(ctx context.Context, adminId int, req dto.CreateApiKeyRequest) (dto.CreatedApiKey, error) {
+
+	role, err := a.RolesStore.GetById(ctx, req.RoleId)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return dto.CreatedApiKey{}, errorService.New(ErrNotFoundRole, err)
+		default:
+			return dto.CreatedApiKey{}, errorService.New(ErrApiKeyInternal, err)
+		}
+	}
+
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return dto.CreatedApiKey{}, errorService.New(ErrApiKeyInternal, err)
+	}
+
+	plaintext := API_KEY_PREFIX + hex.EncodeToString(raw)
+
+	key := models.ApiKey{
+		Name:      req.Name,
+		Prefix:    plaintext[:len(API_KEY_PREFIX)+8],
+		Hash:      utils.HashToken(plaintext),
+		RoleId:    role.Id,
+		CreatedBy: &adminId,
+		CreatedAt: time.Now(),
+		Role:      &role,
+	}
+
+	if req.ExpiresInDays != nil {
+		expire := time.Now().AddDate(0, 0, *req.ExpiresInDays)
+		key.ExpireAt = &expire
+	}
+
+	err = a.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+
+		id, err := a.ApiKeysStore.Insert(ctx, tx, key)
+		if err != nil {
+			return errorService.New(ErrApiKeyInternal, err)
+		}
+
+		key.Id = id
+
+		if err := recordAudit(ctx, tx, a.Audit, audit.Entry{Action: AUDIT_API_KEYS_CREATE, TargetType: "api_key", TargetId: strconv.Itoa(id), After: key}); err != nil {
+			return errorService.New(ErrApiKeyInternal, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return dto.CreatedApiKey{}, err
+	}
+
+	return dto.CreatedApiKey{Key: plaintext, ApiKey: key}, nil
+}
+
+func (a *ApiKeysService) FindAll(ctx context.Context) ([]models.ApiKey, error) {
+
+	keys, err := a.ApiKeysStore.GetAll(ctx)
+	if err != nil {
+		return nil, errorService.New(ErrApiKeyInternal, err)
+	}
+
+	return keys, nil
+}
+
+func (a *ApiKeysService) Revoke(ctx context.Context, id int) error {
+
+	key, err := a.ApiKeysStore.GetById(ctx, id)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return errorService.New(ErrApiKeyNotFound, err)
+		default:
+			return errorService.New(ErrApiKeyInternal, err)
+		}
+	}
+
+	return a.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+
+		if err := a.ApiKeysStore.Revoke(ctx, tx, id); err != nil {
+			return errorService.New(ErrApiKeyInternal, err)
+		}
+
+		if err := recordAudit(ctx, tx, a.Audit, audit.Entry{Action: AUDIT_API_KEYS_REVOKE, TargetType: "api_key", TargetId: strconv.Itoa(id), Before: key}); err != nil {
+			return errorService.New(ErrApiKeyInternal, err)
+		}
+
+		return nil
+	})
+}
+
+// Authenticate resolves the plaintext key to a usable api key and records its use.
+func (a *ApiKeysService) Authenticate(ctx context.Context, plaintext string) (models.ApiKey, error) {
+
+	if !strings.HasPrefix(plaintext, API_KEY_PREFIX) {
+		return models.ApiKey{}, errorService.New(ErrApiKeyInvalid, ErrApiKeyInvalid)
+	}
+
+	key, err := a.ApiKeysStore.GetByHash(ctx, utils.HashToken(plaintext))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return models.ApiKey{}, errorService.New(ErrApiKeyInvalid, err)
+		default:
+			return models.ApiKey{}, errorService.New(ErrApiKeyInternal, err)
+		}
+	}
+
+	if key.RevokedAt != nil {
+		return models.ApiKey{}, errorService.New(ErrApiKeyRevoked, ErrApiKeyRevoked)
+	}
+
+	now := time.Now()
+
+	if key.ExpireAt != nil && now.After(*key.ExpireAt) {
+		return models.ApiKey{}, errorService.New(ErrApiKeyExpired, ErrApiKeyExpired)
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > API_KEY_TOUCH_INTERVAL {
+		if err := a.ApiKeysStore.Touch(ctx, key.Id, now); err != nil {
+			return models.ApiKey{}, errorService.New(ErrApiKeyInternal, err)
+		}
+		key.LastUsedAt = &now
+	}
+
+	return key, nil
+}
@@ -0,0 +1,87 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/models"
+	"github.com/faizisyellow/indocoffee/internal/repository/apikeys"
+	"github.com/faizisyellow/indocoffee/internal/repository/roles"
+	"github.com/faizisyellow/indocoffee/internal/service"
+	"github.com/faizisyellow/indocoffee/internal/service/dto"
+	errorService "github.com/faizisyellow/indocoffee/internal/service/error"
+)
+
+func TestApiKeysService(t *testing.T) {
+	newSut := func() (*service.ApiKeysService, *apikeys.InMemoryApiKeys) {
+		store := &apikeys.InMemoryApiKeys{}
+
+		return &service.ApiKeysService{
+			ApiKeysStore: store,
+			RolesStore:   &roles.InMemoryRoles{Roles: []models.RolesModel{{Id: 4, Name: "warehouse"}}},
+			Transaction:  &transactionFake{state: initial},
+		}, store
+	}
+
+	t.Run("only the hash of the key is stored", func(t *testing.T) {
+		var (
+			ctx        = context.Background()
+			sut, store = newSut()
+			days       = 30
+		)
+
+		created, err := sut.Create(ctx, 1, dto.CreateApiKeyRequest{Name: "accounting", RoleId: 4, ExpiresInDays: &days})
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		stored := store.ApiKeys[0]
+		if stored.Hash == created.Key || stored.Prefix != created.Key[:len(stored.Prefix)] {
+			t.Errorf("unexpected stored key: %+v", stored)
+		}
+
+		if stored.ExpireAt == nil || *stored.CreatedBy != 1 {
+			t.Errorf("expected expiry and creator to be kept but got: %+v", stored)
+		}
+
+		key, err := sut.Authenticate(ctx, created.Key)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if key.RoleId != 4 || store.ApiKeys[0].LastUsedAt == nil {
+			t.Errorf("expected key of role 4 with its last use but got: %+v", store.ApiKeys[0])
+		}
+	})
+
+	t.Run("expired key is rejected", func(t *testing.T) {
+		var (
+			ctx        = context.Background()
+			sut, store = newSut()
+			days       = 1
+		)
+
+		created, err := sut.Create(ctx, 1, dto.CreateApiKeyRequest{Name: "accounting", RoleId: 4, ExpiresInDays: &days})
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		past := time.Now().Add(-time.Minute)
+		store.ApiKeys[0].ExpireAt = &past
+
+		_, err = sut.Authenticate(ctx, created.Key)
+		if errorService.GetError(err).E != service.ErrApiKeyExpired {
+			t.Errorf("expected %v but got: %v", service.ErrApiKeyExpired, err)
+		}
+	})
+
+	t.Run("key of unknown role", func(t *testing.T) {
+		sut, _ := newSut()
+
+		_, err := sut.Create(context.Background(), 1, dto.CreateApiKeyRequest{Name: "accounting", RoleId: 9})
+		if errorService.GetError(err).E != service.ErrNotFoundRole {
+			t.Errorf("expected %v but got: %v", service.ErrNotFoundRole, err)
+		}
+	})
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/faizisyellow/indocoffee/internal/audit"
+	"github.com/faizisyellow/indocoffee/internal/models"
+	"github.com/faizisyellow/indocoffee/internal/repository"
+	"github.com/faizisyellow/indocoffee/internal/repository/audits"
+	errorService "github.com/faizisyellow/indocoffee/internal/service/error"
+)
+
+type AuditService struct {
+	AuditsStore audits.Audits
+}
+
+// Actions recorded to the audit log.
+const (
+	AUDIT_ROLES_CREATE      = "roles.create"
+	AUDIT_ROLES_UPDATE      = "roles.update"
+	AUDIT_ROLES_DELETE      = "roles.delete"
+	AUDIT_ROLES_EMPTY_TRASH = "roles.empty_trash"
+
+	AUDIT_BEANS_CREATE      = "beans.create"
+	AUDIT_BEANS_UPDATE      = "beans.update"
+	AUDIT_BEANS_DELETE      = "beans.delete"
+	AUDIT_BEANS_EMPTY_TRASH = "beans.empty_trash"
+
+	AUDIT_FORMS_CREATE      = "forms.create"
+	AUDIT_FORMS_UPDATE      = "forms.update"
+	AUDIT_FORMS_DELETE      = "forms.delete"
+	AUDIT_FORMS_EMPTY_TRASH = "forms.empty_trash"
+
+	AUDIT_PRODUCTS_CREATE = "products.create"
+	AUDIT_PRODUCTS_UPDATE = "products.update"
+	AUDIT_PRODUCTS_DELETE = "products.delete"
+	AUDIT_VARIANTS_CREATE = "variants.create"
+	AUDIT_VARIANTS_UPDATE = "variants.update"
+	AUDIT_VARIANTS_DELETE = "variants.delete"
+	AUDIT_IMAGES_CREATE   = "images.create"
+	AUDIT_IMAGES_REORDER  = "images.reorder"
+	AUDIT_IMAGES_PRIMARY  = "images.primary"
+	AUDIT_IMAGES_DELETE   = "images.delete"
+
+	AUDIT_ORDERS_ROAST    = "orders.roast"
+	AUDIT_ORDERS_CANCEL   = "orders.cancel"
+	AUDIT_ORDERS_SHIP     = "orders.ship"
+	AUDIT_ORDERS_COMPLETE = "orders.complete"
+
+	AUDIT_USERS_ROLE           = "users.role"
+	AUDIT_USERS_DEACTIVATE     = "users.deactivate"
+	AUDIT_USERS_REACTIVATE     = "users.reactivate"
+	AUDIT_USERS_UNLOCK         = "users.unlock"
+	AUDIT_USERS_PASSWORD_RESET = "users.password_reset"
+
+	AUDIT_API_KEYS_CREATE = "api_keys.create"
+	AUDIT_API_KEYS_REVOKE = "api_keys.revoke"
+)
+
+var ErrInternalAudit = errors.New("audit: encountered an internal error")
+
+// orderStatus is what an order transition changes.
+type orderStatus struct {
+	Status string `json:"status"`
+}
+
+// userRole is what changing the role of a user changes.
+type userRole struct {
+	RoleId int `json:"role_id"`
+}
+
+func (a *AuditService) FindAll(ctx context.Context, qry repository.PaginatedAuditQuery) ([]models.AuditLog, error) {
+
+	logs, err := a.AuditsStore.Find(ctx, qry)
+	if err != nil {
+		return nil, errorService.New(ErrInternalAudit, err)
+	}
+
+	return logs, nil
+}
+
+// recordAudit records the entry in tx when the service has a recorder,
+// an action that can not be recorded is rolled back with tx.
+func recordAudit(ctx context.Context, tx *sql.Tx, recorder audit.Recorder, entry audit.Entry) error {
+	if recorder == nil {
+		return nil
+	}
+
+	return recorder.Record(ctx, tx, entry)
+}
@@ -0,0 +1,136 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/faizisyellow/indocoffee/internal/audit"
+	"github.com/faizisyellow/indocoffee/internal/cache"
+	"github.com/faizisyellow/indocoffee/internal/models"
+	"github.com/faizisyellow/indocoffee/internal/repository"
+	"github.com/faizisyellow/indocoffee/internal/repository/apikeys"
+	"github.com/faizisyellow/indocoffee/internal/repository/audits"
+	"github.com/faizisyellow/indocoffee/internal/repository/roles"
+	"github.com/faizisyellow/indocoffee/internal/repository/users"
+	"github.com/faizisyellow/indocoffee/internal/service"
+	"github.com/faizisyellow/indocoffee/internal/service/dto"
+)
+
+func TestAuditService(t *testing.T) {
+	var (
+		ctx         = audit.WithActor(context.Background(), audit.Actor{Id: 7, Name: "lizzy"})
+		auditsStore = &audits.InMemoryAudits{}
+		rolesStore  = &roles.InMemoryRoles{
+			Roles: []models.RolesModel{
+				{Id: 1, Name: "roaster", Level: 2, Permissions: []string{service.PERMISSION_ORDERS_ROAST}},
+			},
+			Catalogue: []models.PermissionModel{
+				{Id: 1, Name: service.PERMISSION_ORDERS_ROAST},
+				{Id: 2, Name: service.PERMISSION_ORDERS_SHIP},
+			},
+		}
+		rolesService = &service.RolesServices{
+			RolesStore:  rolesStore,
+			Transaction: &transactionFake{state: initial},
+			Cache:       &cache.InMemoryCache{},
+			Audit:       &audit.StoreRecorder{Store: auditsStore},
+		}
+		sut = &service.AuditService{AuditsStore: auditsStore}
+	)
+
+	level := 3
+	if err := rolesService.Update(ctx, 1, dto.UpdateRoleRequest{Level: &level}); err != nil {
+		t.Fatalf("should not be error but got: %v", err)
+	}
+
+	logs, err := sut.FindAll(ctx, repository.PaginatedAuditQuery{Limit: 10, ActorId: 7, TargetType: "role"})
+	if err != nil {
+		t.Fatalf("should not be error but got: %v", err)
+	}
+
+	if len(logs) != 1 {
+		t.Fatalf("expected 1 log but got %v", len(logs))
+	}
+
+	log := logs[0]
+	if log.Action != service.AUDIT_ROLES_UPDATE || log.TargetId != "1" {
+		t.Errorf("expected the role update but got: %+v", log)
+	}
+
+	if string(log.Before) != `{"level":2}` || string(log.After) != `{"level":3}` {
+		t.Errorf("expected only the level to be recorded but got %s and %s", log.Before, log.After)
+	}
+
+	t.Run("record changing the role of a user", func(t *testing.T) {
+		usersService := &service.UsersServices{
+			UsersStore:  &users.InMemoryUsers{Users: []models.User{{Id: 1, Username: "nadia", RoleId: 2}}},
+			RolesStore:  rolesStore,
+			Transaction: &transactionFake{state: initial},
+			Audit:       &audit.StoreRecorder{Store: auditsStore},
+		}
+
+		if err := usersService.ChangeRole(ctx, 7, 1, service.ChangeUserRoleRequest{RoleId: 1}); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		logs, err := sut.FindAll(ctx, repository.PaginatedAuditQuery{Limit: 10, TargetType: "user", TargetId: "1"})
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if len(logs) != 1 || logs[0].Action != service.AUDIT_USERS_ROLE || string(logs[0].Before) != `{"role_id":2}` || string(logs[0].After) != `{"role_id":1}` {
+			t.Errorf("expected the role change to be recorded but got: %+v", logs)
+		}
+	})
+
+	t.Run("record issuing and revoking an api key", func(t *testing.T) {
+		apiKeysService := &service.ApiKeysService{
+			ApiKeysStore: &apikeys.InMemoryApiKeys{},
+			RolesStore:   rolesStore,
+			Transaction:  &transactionFake{state: initial},
+			Audit:        &audit.StoreRecorder{Store: auditsStore},
+		}
+
+		created, err := apiKeysService.Create(ctx, 7, dto.CreateApiKeyRequest{Name: "warehouse scanner", RoleId: 1})
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if err := apiKeysService.Revoke(ctx, created.ApiKey.Id); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		logs, err := sut.FindAll(ctx, repository.PaginatedAuditQuery{Limit: 10, TargetType: "api_key"})
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if len(logs) != 2 || logs[0].Action != service.AUDIT_API_KEYS_REVOKE || logs[1].Action != service.AUDIT_API_KEYS_CREATE {
+			t.Fatalf("expected the key to be recorded issued then revoked but got: %+v", logs)
+		}
+
+		if strings.Contains(string(logs[1].After), created.Key) {
+			t.Errorf("expected the plaintext key not to be recorded but got %s", logs[1].After)
+		}
+	})
+
+	t.Run("record every role of the emptied trash", func(t *testing.T) {
+		if err := rolesService.Delete(ctx, 1); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if err := rolesService.Remove(ctx); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		logs, err := sut.FindAll(ctx, repository.PaginatedAuditQuery{Limit: 10, TargetType: "role", TargetId: "1"})
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if len(logs) == 0 || logs[0].Action != service.AUDIT_ROLES_EMPTY_TRASH {
+			t.Errorf("expected the emptied role to be recorded but got: %+v", logs)
+		}
+	})
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strconv"
 	"strings"
 
+	"github.com/faizisyellow/indocoffee/internal/audit"
+	"github.com/faizisyellow/indocoffee/internal/db"
 	"github.com/faizisyellow/indocoffee/internal/models"
 	"github.com/faizisyellow/indocoffee/internal/repository/beans"
 	"github.com/faizisyellow/indocoffee/internal/service/dto"
@@ -13,7 +16,9 @@ import (
 )
 
 type BeansServices struct {
-	BeansStore beans.Beans
+	BeansStore  beans.Beans
+	Transaction db.Transactioner
+	Audit       audit.Recorder
 }
 
 const (
@@ -32,14 +37,26 @@ func (b *BeansServices) Create(ctx context.Context, req dto.CreateBeanRequest) (
 		Name: req.Name,
 	}
 
-	err := b.BeansStore.Insert(ctx, newBean)
-	if err != nil {
+	err := b.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+		id, err := b.BeansStore.Insert(ctx, tx, newBean)
+		if err != nil {
 
-		// TODO: should success create new bean if the existing bean is deleted
-		if strings.Contains(err.Error(), CONFLICT_CODE) {
-			return "", errorService.New(ErrConflictBean, err)
+			// TODO: should success create new bean if the existing bean is deleted
+			if strings.Contains(err.Error(), CONFLICT_CODE) {
+				return errorService.New(ErrConflictBean, err)
+			}
+			return errorService.New(ErrInternalBean, err)
 		}
-		return "", errorService.New(ErrInternalBean, err)
+		newBean.Id = id
+
+		if err := recordAudit(ctx, tx, b.Audit, audit.Entry{Action: AUDIT_BEANS_CREATE, TargetType: "bean", TargetId: strconv.Itoa(id), After: newBean}); err != nil {
+			return errorService.New(ErrInternalBean, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
 	}
 
 	return SUCCESS_CREATE_BEAN_MESSAGE, nil
@@ -76,19 +93,26 @@ func (b *BeansServices) Update(ctx context.Context, id int, req dto.UpdateBeanRe
 	if err != nil {
 		return err
 	}
+	before := bean
 	bean.Name = req.Name
 
-	err = b.BeansStore.Update(ctx, bean)
-	if err != nil {
-		// TODO: should success update bean if the existing bean is deleted
-		if strings.Contains(err.Error(), CONFLICT_CODE) {
-			return errorService.New(ErrConflictBean, err)
+	return b.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+		err := b.BeansStore.Update(ctx, tx, bean)
+		if err != nil {
+			// TODO: should success update bean if the existing bean is deleted
+			if strings.Contains(err.Error(), CONFLICT_CODE) {
+				return errorService.New(ErrConflictBean, err)
+			}
+
+			return errorService.New(ErrInternalBean, err)
 		}
 
-		return errorService.New(ErrInternalBean, err)
-	}
+		if err := recordAudit(ctx, tx, b.Audit, audit.Entry{Action: AUDIT_BEANS_UPDATE, TargetType: "bean", TargetId: strconv.Itoa(bean.Id), Before: before, After: bean}); err != nil {
+			return errorService.New(ErrInternalBean, err)
+		}
 
-	return nil
+		return nil
+	})
 }
 
 func (b *BeansServices) Delete(ctx context.Context, id int) error {
@@ -98,24 +122,39 @@ func (b *BeansServices) Delete(ctx context.Context, id int) error {
 		return err
 	}
 
-	if err := b.BeansStore.Delete(ctx, bean.Id); err != nil {
-		return errorService.New(ErrInternalBean, err)
-	}
+	return b.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+		if err := b.BeansStore.Delete(ctx, tx, bean.Id); err != nil {
+			return errorService.New(ErrInternalBean, err)
+		}
 
-	return nil
+		if err := recordAudit(ctx, tx, b.Audit, audit.Entry{Action: AUDIT_BEANS_DELETE, TargetType: "bean", TargetId: strconv.Itoa(bean.Id), Before: bean}); err != nil {
+			return errorService.New(ErrInternalBean, err)
+		}
+
+		return nil
+	})
 }
 
+// Remove empties the trash, every destroyed bean is recorded as a target.
 func (b *BeansServices) Remove(ctx context.Context) error {
 
-	err := b.BeansStore.DestroyMany(ctx)
-	if err != nil {
-		switch err {
-		case sql.ErrNoRows:
-			return errorService.New(ErrNotFoundBean, err)
-		default:
-			return errorService.New(ErrInternalBean, err)
+	return b.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+		ids, err := b.BeansStore.DestroyMany(ctx, tx)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return errorService.New(ErrNotFoundBean, err)
+			default:
+				return errorService.New(ErrInternalBean, err)
+			}
+		}
+
+		for _, id := range ids {
+			if err := recordAudit(ctx, tx, b.Audit, audit.Entry{Action: AUDIT_BEANS_EMPTY_TRASH, TargetType: "bean", TargetId: strconv.Itoa(id)}); err != nil {
+				return errorService.New(ErrInternalBean, err)
+			}
 		}
-	}
 
-	return nil
+		return nil
+	})
 }
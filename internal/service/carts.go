@@ -29,13 +29,13 @@ var (
 )
 
 func (c *CartsService) Create(ctx context.Context, req dto.CreateCartRequest, usrId int) error {
-	prd, err := c.ProductsService.FindById(ctx, req.ProductId)
+	variant, err := c.ProductsService.FindVariantById(ctx, req.VariantId)
 	if err != nil {
 		return err
 	}
 
 	if err := c.CartsStore.Insert(ctx, models.Cart{
-		ProductId: prd.Id,
+		VariantId: variant.Id,
 		UserId:    usrId,
 	}); err != nil {
 		if strings.Contains(err.Error(), conflictOpenCartCode) {
@@ -62,17 +62,8 @@ func (c *CartsService) FindById(ctx context.Context, id int) (models.Cart, error
 	return cart, nil
 }
 
-func (c *CartsService) IncrementItem(ctx context.Context, cartId int) error {
-	cart, err := c.CartsStore.GetById(ctx, cartId)
-	if err != nil {
-		switch err {
-		case sql.ErrNoRows:
-			return errorService.New(ErrCartNotFound, err)
-		default:
-			return errorService.New(ErrInternalCart, err)
-		}
-	}
-
+// IncrementItem expects the cart that has already been loaded by the caller.
+func (c *CartsService) IncrementItem(ctx context.Context, cart models.Cart) error {
 	if err := c.CartsStore.IncrementQuantity(ctx, cart.Id); err != nil {
 		if strings.Contains(err.Error(), CHECK_CONSTRAINT_CART_QUANTITY_CODE) {
 			return errorService.New(ErrCartOverflowQuantity, err)
@@ -83,17 +74,8 @@ func (c *CartsService) IncrementItem(ctx context.Context, cartId int) error {
 	return nil
 }
 
-func (c *CartsService) DecrementItem(ctx context.Context, cartId int) error {
-	cart, err := c.CartsStore.GetById(ctx, cartId)
-	if err != nil {
-		switch err {
-		case sql.ErrNoRows:
-			return errorService.New(ErrCartNotFound, err)
-		default:
-			return errorService.New(ErrInternalCart, err)
-		}
-	}
-
+// DecrementItem expects the cart that has already been loaded by the caller.
+func (c *CartsService) DecrementItem(ctx context.Context, cart models.Cart) error {
 	if err := c.CartsStore.DecrementQuantity(ctx, cart.Id); err != nil {
 		if strings.Contains(err.Error(), CHECK_CONSTRAINT_CART_QUANTITY_CODE) {
 			return errorService.New(ErrCartMinQuantity, err)
@@ -105,17 +87,8 @@ func (c *CartsService) DecrementItem(ctx context.Context, cartId int) error {
 
 }
 
-func (c *CartsService) Destroy(ctx context.Context, id int) error {
-	cart, err := c.CartsStore.GetById(ctx, id)
-	if err != nil {
-		switch err {
-		case sql.ErrNoRows:
-			return errorService.New(ErrCartNotFound, err)
-		default:
-			return errorService.New(ErrInternalCart, err)
-		}
-	}
-
+// Destroy expects the cart that has already been loaded by the caller.
+func (c *CartsService) Destroy(ctx context.Context, cart models.Cart) error {
 	if err := c.CartsStore.Delete(ctx, cart.Id); err != nil {
 		return errorService.New(ErrInternalCart, err)
 	}
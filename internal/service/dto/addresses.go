@@ -0,0 +1,24 @@
+package dto
+
+type CreateAddressRequest struct {
+	Label         string  `json:"label" validate:"required,min=1,max=32"`
+	RecipientName string  `json:"recipient_name" validate:"required,min=1,max=32"`
+	PhoneNumber   string  `json:"phone_number" validate:"required,min=10,max=18"`
+	Street        string  `json:"street" validate:"required,min=5,max=255"`
+	City          string  `json:"city" validate:"required,min=2,max=64"`
+	Province      string  `json:"province" validate:"required,min=2,max=64"`
+	PostalCode    string  `json:"postal_code" validate:"required,numeric,min=5,max=10"`
+	Notes         *string `json:"notes" validate:"omitempty,max=255"`
+	IsDefault     bool    `json:"is_default"`
+}
+
+type UpdateAddressRequest struct {
+	Label         *string `json:"label" validate:"omitempty,min=1,max=32"`
+	RecipientName *string `json:"recipient_name" validate:"omitempty,min=1,max=32"`
+	PhoneNumber   *string `json:"phone_number" validate:"omitempty,min=10,max=18"`
+	Street        *string `json:"street" validate:"omitempty,min=5,max=255"`
+	City          *string `json:"city" validate:"omitempty,min=2,max=64"`
+	Province      *string `json:"province" validate:"omitempty,min=2,max=64"`
+	PostalCode    *string `json:"postal_code" validate:"omitempty,numeric,min=5,max=10"`
+	Notes         *string `json:"notes" validate:"omitempty,max=255"`
+}
@@ -0,0 +1,16 @@
+package dto
+
+import "github.com/faizisyellow/indocoffee/internal/models"
+
+type CreateApiKeyRequest struct {
+	Name   string `json:"name" validate:"required,min=3,max=64"`
+	RoleId int    `json:"role_id" validate:"required,gte=1"`
+	// ExpiresInDays leaves the key without expiry when it is empty.
+	ExpiresInDays *int `json:"expires_in_days" validate:"omitempty,gte=1,lte=365"`
+}
+
+// CreatedApiKey carries the plaintext key, it can not be shown again.
+type CreatedApiKey struct {
+	Key    string        `json:"key"`
+	ApiKey models.ApiKey `json:"api_key"`
+}
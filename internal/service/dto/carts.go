@@ -1,5 +1,5 @@
 package dto
 
 type CreateCartRequest struct {
-	ProductId int `json:"product_id"`
+	VariantId int `json:"variant_id" validate:"required,min=1"`
 }
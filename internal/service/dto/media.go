@@ -0,0 +1,19 @@
+package dto
+
+import "time"
+
+type OrphanFile struct {
+	Key        string    `json:"key"`
+	Size       int64     `json:"size"`
+	ModifiedAt time.Time `json:"modified_at"`
+}
+
+type MediaGarbageReport struct {
+	DryRun  bool         `json:"dry_run"`
+	Scanned int          `json:"scanned"`
+	Orphans []OrphanFile `json:"orphans"`
+	// Bytes is the size of the orphans, freed when they are deleted.
+	Bytes   int64 `json:"bytes"`
+	Deleted int   `json:"deleted"`
+	Failed  int   `json:"failed"`
+}
@@ -6,14 +6,20 @@ import (
 	"github.com/faizisyellow/indocoffee/internal/models"
 )
 
+// CreateOrderRequest ships the order to the saved address when AddressId is given,
+// otherwise to the address typed in the request, or to the default address when none is typed.
 type CreateOrderRequest struct {
 	CartIds                []int   `json:"cart_ids" validate:"required,min=1,max=16"`
-	CustomerName           string  `json:"customer_name" validate:"required,min=1,max=32"`
-	CustomerEmail          string  `json:"customer_email" validate:"required,email,min=6,max=32"`
-	PhoneNumber            string  `json:"phone_number" validate:"required,min=10,max=15"`
+	AddressId              *int    `json:"address_id" validate:"omitempty,gte=1"`
+	CustomerName           string  `json:"customer_name" validate:"required_with=Street,omitempty,min=1,max=32"`
+	CustomerEmail          string  `json:"customer_email" validate:"omitempty,email,min=6,max=32"`
+	PhoneNumber            string  `json:"phone_number" validate:"required_with=Street,omitempty,min=10,max=15"`
 	AlternativePhoneNumber *string `json:"alternative_phone_number" validate:"omitempty,min=10,max=15"`
-	City                   string  `json:"city" validate:"required,min=5,max=32"`
-	Street                 string  `json:"street" validate:"required,min=5,max=32"`
+	City                   string  `json:"city" validate:"required_with=Street,omitempty,min=2,max=64"`
+	Street                 string  `json:"street" validate:"required_with=CustomerName PhoneNumber City,omitempty,min=5,max=255"`
+	Province               string  `json:"province" validate:"omitempty,min=2,max=64"`
+	PostalCode             string  `json:"postal_code" validate:"omitempty,numeric,min=5,max=10"`
+	DeliveryNotes          *string `json:"delivery_notes" validate:"omitempty,max=255"`
 }
 
 type GetOrderResponse struct {
@@ -27,6 +33,9 @@ type GetOrderResponse struct {
 	AlternativePhoneNumber *string            `json:"alternative_phone_number"`
 	Street                 string             `json:"street"`
 	City                   string             `json:"city"`
+	Province               string             `json:"province"`
+	PostalCode             string             `json:"postal_code"`
+	DeliveryNotes          *string            `json:"delivery_notes"`
 	CreatedAt              time.Time          `json:"created_at"`
 }
 
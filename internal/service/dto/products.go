@@ -1,22 +1,89 @@
 package dto
 
+import "time"
+
 type CreateProductMetadataRequest struct {
-	Roasted  string  `json:"roasted" validate:"required,oneof=light medium dark"`
+	Name         string                 `json:"name" validate:"required,max=128"`
+	Description  string                 `json:"description" validate:"omitempty,max=2000"`
+	Origin       string                 `json:"origin" validate:"omitempty,max=64"`
+	Process      string                 `json:"process" validate:"omitempty,oneof=washed natural honey"`
+	Altitude     int                    `json:"altitude" validate:"omitempty,min=1,max=5000"`
+	TastingNotes []string               `json:"tasting_notes" validate:"omitempty,max=10,dive,required,max=32"`
+	Roasted      string                 `json:"roasted" validate:"required,oneof=light medium dark"`
+	Bean         int                    `json:"bean" validate:"required,min=1"`
+	Form         int                    `json:"form" validate:"required,min=1"`
+	Variants     []CreateVariantRequest `json:"variants" validate:"required,min=1,max=10,dive"`
+	// ImageKey is the key of an image uploaded to a presigned target, sent instead of the file.
+	ImageKey string `json:"image_key" validate:"omitempty,max=512"`
+}
+
+type CreateVariantRequest struct {
+	Sku string `json:"sku" validate:"required,max=64"`
+	// Weight is in grams.
+	Weight   int     `json:"weight" validate:"required,min=1,max=50000"`
 	Price    float64 `json:"price" validate:"required,min=1"`
-	Quantity int     `json:"quantity" validate:"required,min=1,max=500"`
-	Bean     int     `json:"bean" validate:"required,min=1"`
-	Form     int     `json:"form" validate:"required,min=1"`
+	Quantity int     `json:"quantity" validate:"min=0,max=10000"`
 }
 
-type GetProductResponse struct {
+type UpdateVariantRequest struct {
+	Sku      string  `json:"sku" validate:"omitempty,max=64"`
+	Weight   int     `json:"weight" validate:"omitempty,min=1,max=50000"`
+	Price    float64 `json:"price" validate:"omitempty,min=1"`
+	Quantity *int    `json:"quantity" validate:"omitempty,min=0,max=10000"`
+}
+
+type ReorderImagesRequest struct {
+	ImageIds []int `json:"image_ids" validate:"required,min=1,max=10,unique,dive,min=1"`
+}
+
+type PresignImageRequest struct {
+	Name     string `json:"name" validate:"required,max=255"`
+	MimeType string `json:"mime_type" validate:"required,oneof=image/png image/jpeg"`
+	// Size is in bytes, up to 2MB.
+	Size int64 `json:"size" validate:"required,min=1,max=2097152"`
+}
+
+type UploadTargetResponse struct {
+	Key       string            `json:"key"`
+	Url       string            `json:"url"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers"`
+	FormField string            `json:"form_field,omitempty"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+type ImageResponse struct {
+	Id         int               `json:"id"`
+	Url        string            `json:"url"`
+	Renditions map[string]string `json:"renditions"`
+	Position   int               `json:"position"`
+	IsPrimary  bool              `json:"is_primary"`
+}
+
+type VariantResponse struct {
 	Id       int     `json:"id"`
-	Roasted  string  `json:"roasted"`
+	Sku      string  `json:"sku"`
+	Weight   int     `json:"weight"`
 	Price    float64 `json:"price"`
 	Quantity int     `json:"quantity"`
-	Image    string  `json:"image"`
-	BeanId   int     `json:"bean_id"`
-	FormId   int     `json:"form_id"`
-	Bean     struct {
+}
+
+type GetProductResponse struct {
+	Id              int               `json:"id"`
+	Name            string            `json:"name"`
+	Description     string            `json:"description"`
+	Origin          string            `json:"origin"`
+	Process         string            `json:"process"`
+	Altitude        int               `json:"altitude"`
+	TastingNotes    []string          `json:"tasting_notes"`
+	Roasted         string            `json:"roasted"`
+	Image           string            `json:"image"`
+	ImageRenditions map[string]string `json:"image_renditions"`
+	BeanId          int               `json:"bean_id"`
+	FormId          int               `json:"form_id"`
+	Variants        []VariantResponse `json:"variants"`
+	Images          []ImageResponse   `json:"images"`
+	Bean            struct {
 		Name string `json:"name"`
 	} `json:"bean"`
 
@@ -26,14 +93,19 @@ type GetProductResponse struct {
 }
 
 type GetProductsResponse struct {
-	Id       int     `json:"id"`
-	Roasted  string  `json:"roasted"`
-	Price    float64 `json:"price"`
-	Quantity int     `json:"quantity"`
-	Image    string  `json:"image"`
-	BeanId   int     `json:"bean_id"`
-	FormId   int     `json:"form_id"`
-	Bean     struct {
+	Id              int               `json:"id"`
+	Name            string            `json:"name"`
+	Origin          string            `json:"origin"`
+	Process         string            `json:"process"`
+	TastingNotes    []string          `json:"tasting_notes"`
+	Roasted         string            `json:"roasted"`
+	Image           string            `json:"image"`
+	ImageRenditions map[string]string `json:"image_renditions"`
+	BeanId          int               `json:"bean_id"`
+	FormId          int               `json:"form_id"`
+	Variants        []VariantResponse `json:"variants"`
+	Snippet         string            `json:"snippet,omitempty"`
+	Bean            struct {
 		Name string `json:"name"`
 	} `json:"bean"`
 
@@ -43,9 +115,15 @@ type GetProductsResponse struct {
 }
 
 type UpdateProductMetadataRequest struct {
-	Roasted  string  `json:"roasted" validate:"omitempty,oneof=light medium dark"`
-	Price    float64 `json:"price" validate:"omitempty,min=1"`
-	Quantity int     `json:"quantity" validate:"omitempty,min=1,max=500"`
-	Bean     int     `json:"bean" validate:"omitempty,min=1"`
-	Form     int     `json:"form" validate:"omitempty,min=1"`
+	Name        string `json:"name" validate:"omitempty,max=128"`
+	Description string `json:"description" validate:"omitempty,max=2000"`
+	Origin      string `json:"origin" validate:"omitempty,max=64"`
+	Process     string `json:"process" validate:"omitempty,oneof=washed natural honey"`
+	Altitude    int    `json:"altitude" validate:"omitempty,min=1,max=5000"`
+	// TastingNotes replaces the notes when present, an empty list clears them.
+	TastingNotes []string `json:"tasting_notes" validate:"omitempty,max=10,dive,required,max=32"`
+	Roasted      string   `json:"roasted" validate:"omitempty,oneof=light medium dark"`
+	Bean         int      `json:"bean" validate:"omitempty,min=1"`
+	Form         int      `json:"form" validate:"omitempty,min=1"`
+	ImageKey     string   `json:"image_key" validate:"omitempty,max=512"`
 }
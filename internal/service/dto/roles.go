@@ -1,17 +1,22 @@
 package dto
 
 type CreateRoleRequest struct {
-	Name  string `json:"name" validate:"required,min=4,max=16"`
-	Level int    `json:"level" validate:"required,min=1,max=5"`
+	Name        string   `json:"name" validate:"required,min=4,max=16"`
+	Level       int      `json:"level" validate:"required,min=1,max=5"`
+	Permissions []string `json:"permissions" validate:"omitempty,dive,required"`
 }
 
 type RolesResponse struct {
-	Id    int    `json:"id"`
-	Name  string `json:"name"`
-	Level int    `json:"level"`
+	Id          int      `json:"id"`
+	Name        string   `json:"name"`
+	Level       int      `json:"level"`
+	Permissions []string `json:"permissions"`
 }
 
+// UpdateRoleRequest replaces the role's permissions when Permissions is given,
+// an empty list revokes every permission.
 type UpdateRoleRequest struct {
-	Name  *string `json:"name" validate:"omitempty,min=4,max=16"`
-	Level *int    `json:"level" validate:"omitempty,min=1,max=5"`
+	Name        *string  `json:"name" validate:"omitempty,min=4,max=16"`
+	Level       *int     `json:"level" validate:"omitempty,min=1,max=5"`
+	Permissions []string `json:"permissions" validate:"omitempty,dive,required"`
 }
@@ -0,0 +1,16 @@
+package dto
+
+type SessionTokens struct {
+	SessionId    string
+	UserId       int
+	RefreshToken string
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type RefreshTokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
@@ -0,0 +1,37 @@
+package dto
+
+// TwoFactorChallenge is returned by sign in instead of the tokens
+// when the account has to pass the second step.
+type TwoFactorChallenge struct {
+	ChallengeToken string `json:"challenge_token"`
+	// EnrollmentRequired is true when the role requires two factor
+	// but the account has not enrolled yet.
+	EnrollmentRequired bool `json:"enrollment_required"`
+	ExpireIn           int  `json:"expire_in"`
+}
+
+type TwoFactorEnrollment struct {
+	Secret          string   `json:"secret"`
+	ProvisioningUri string   `json:"provisioning_uri"`
+	RecoveryCodes   []string `json:"recovery_codes"`
+}
+
+type TwoFactorChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+}
+
+type VerifyTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	// Code is the current TOTP code or one of the recovery codes.
+	Code string `json:"code" validate:"required,max=32"`
+	Ip   string `json:"-"`
+}
+
+type ConfirmTwoFactorRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required,max=32"`
+}
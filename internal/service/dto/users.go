@@ -1,6 +1,10 @@
 package dto
 
-import "time"
+import (
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/models"
+)
 
 type GetUsersProfileResponse struct {
 	Id        int       `json:"id"`
@@ -23,13 +27,17 @@ type CartItemDetail struct {
 }
 
 type CartProductDTO struct {
-	Id      int         `json:"id"`
-	Roasted string      `json:"roasted"`
-	Image   string      `json:"image"`
-	Stock   int         `json:"stock"` // (quantity)
-	Price   float64     `json:"price"`
-	Bean    CartBeanDTO `json:"bean"`
-	Form    CartFormDTO `json:"form"`
+	Id        int         `json:"id"`
+	Name      string      `json:"name"`
+	VariantId int         `json:"variant_id"`
+	Sku       string      `json:"sku"`
+	Weight    int         `json:"weight"`
+	Roasted   string      `json:"roasted"`
+	Image     string      `json:"image"`
+	Stock     int         `json:"stock"` // (quantity of the variant)
+	Price     float64     `json:"price"`
+	Bean      CartBeanDTO `json:"bean"`
+	Form      CartFormDTO `json:"form"`
 }
 
 type CartBeanDTO struct {
@@ -39,3 +47,27 @@ type CartBeanDTO struct {
 type CartFormDTO struct {
 	Name string `json:"name"`
 }
+
+type AdminUserResponse struct {
+	Id            int           `json:"id"`
+	Username      string        `json:"username"`
+	Email         string        `json:"email"`
+	IsActive      bool          `json:"is_active"`
+	Role          AdminUserRole `json:"role"`
+	CreatedAt     time.Time     `json:"created_at"`
+	DeactivatedAt *time.Time    `json:"deactivated_at"`
+}
+
+type AdminUserRole struct {
+	Id   int    `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// UserExport is every personal data kept about the user.
+type UserExport struct {
+	Profile    GetUsersProfileResponse `json:"profile"`
+	Addresses  []models.Address        `json:"addresses"`
+	Cart       []CartItemDetail        `json:"cart"`
+	Orders     []models.Order          `json:"orders"`
+	ExportedAt time.Time               `json:"exported_at"`
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strconv"
 	"strings"
 
+	"github.com/faizisyellow/indocoffee/internal/audit"
+	"github.com/faizisyellow/indocoffee/internal/db"
 	"github.com/faizisyellow/indocoffee/internal/models"
 	"github.com/faizisyellow/indocoffee/internal/repository/forms"
 	"github.com/faizisyellow/indocoffee/internal/service/dto"
@@ -13,7 +16,9 @@ import (
 )
 
 type FormsServices struct {
-	FormsStore forms.Forms
+	FormsStore  forms.Forms
+	Transaction db.Transactioner
+	Audit       audit.Recorder
 }
 
 const (
@@ -32,13 +37,25 @@ func (Forms *FormsServices) Create(ctx context.Context, req dto.CreateFormReques
 		Name: req.Name,
 	}
 
-	err := Forms.FormsStore.Insert(ctx, newForm)
-	if err != nil {
-		if strings.Contains(err.Error(), CONFLICT_CODE) {
-			return "", errorService.New(ErrConflictForm, err)
+	err := Forms.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+		id, err := Forms.FormsStore.Insert(ctx, tx, newForm)
+		if err != nil {
+			if strings.Contains(err.Error(), CONFLICT_CODE) {
+				return errorService.New(ErrConflictForm, err)
+			}
+
+			return errorService.New(ErrInternalForm, err)
 		}
+		newForm.Id = id
 
-		return "", errorService.New(ErrInternalForm, err)
+		if err := recordAudit(ctx, tx, Forms.Audit, audit.Entry{Action: AUDIT_FORMS_CREATE, TargetType: "form", TargetId: strconv.Itoa(id), After: newForm}); err != nil {
+			return errorService.New(ErrInternalForm, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
 	}
 
 	return SUCCESS_CREATE_FORMS_MESSAGE, nil
@@ -75,18 +92,25 @@ func (Forms *FormsServices) Update(ctx context.Context, id int, req dto.UpdateFo
 	if err != nil {
 		return err
 	}
+	before := form
 	form.Name = req.Name
 
-	err = Forms.FormsStore.Update(ctx, form)
-	if err != nil {
-		if strings.Contains(err.Error(), CONFLICT_CODE) {
-			return errorService.New(ErrConflictForm, err)
+	return Forms.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+		err := Forms.FormsStore.Update(ctx, tx, form)
+		if err != nil {
+			if strings.Contains(err.Error(), CONFLICT_CODE) {
+				return errorService.New(ErrConflictForm, err)
+			}
+
+			return errorService.New(ErrInternalForm, err)
 		}
 
-		return errorService.New(ErrInternalForm, err)
-	}
+		if err := recordAudit(ctx, tx, Forms.Audit, audit.Entry{Action: AUDIT_FORMS_UPDATE, TargetType: "form", TargetId: strconv.Itoa(form.Id), Before: before, After: form}); err != nil {
+			return errorService.New(ErrInternalForm, err)
+		}
 
-	return nil
+		return nil
+	})
 }
 
 func (Forms *FormsServices) Delete(ctx context.Context, id int) error {
@@ -96,25 +120,40 @@ func (Forms *FormsServices) Delete(ctx context.Context, id int) error {
 		return err
 	}
 
-	err = Forms.FormsStore.Delete(ctx, form.Id)
-	if err != nil {
-		return errorService.New(ErrInternalForm, err)
-	}
+	return Forms.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+		err := Forms.FormsStore.Delete(ctx, tx, form.Id)
+		if err != nil {
+			return errorService.New(ErrInternalForm, err)
+		}
 
-	return nil
+		if err := recordAudit(ctx, tx, Forms.Audit, audit.Entry{Action: AUDIT_FORMS_DELETE, TargetType: "form", TargetId: strconv.Itoa(form.Id), Before: form}); err != nil {
+			return errorService.New(ErrInternalForm, err)
+		}
+
+		return nil
+	})
 }
 
+// Remove empties the trash, every destroyed form is recorded as a target.
 func (Forms *FormsServices) Remove(ctx context.Context) error {
 
-	err := Forms.FormsStore.DestroyMany(ctx)
-	if err != nil {
-		switch err {
-		case sql.ErrNoRows:
-			return errorService.New(ErrNotFoundForm, err)
-		default:
-			return errorService.New(ErrInternalForm, err)
+	return Forms.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+		ids, err := Forms.FormsStore.DestroyMany(ctx, tx)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return errorService.New(ErrNotFoundForm, err)
+			default:
+				return errorService.New(ErrInternalForm, err)
+			}
+		}
+
+		for _, id := range ids {
+			if err := recordAudit(ctx, tx, Forms.Audit, audit.Entry{Action: AUDIT_FORMS_EMPTY_TRASH, TargetType: "form", TargetId: strconv.Itoa(id)}); err != nil {
+				return errorService.New(ErrInternalForm, err)
+			}
 		}
-	}
 
-	return nil
+		return nil
+	})
 }
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/audit"
+	"github.com/faizisyellow/indocoffee/internal/repository/products"
+	"github.com/faizisyellow/indocoffee/internal/service/dto"
+	errorService "github.com/faizisyellow/indocoffee/internal/service/error"
+	"github.com/faizisyellow/indocoffee/internal/uploader"
+)
+
+// MediaService deletes stored files no product image refers to, such as the
+// files of a failed update or uploads a client never used.
+type MediaService struct {
+	ProductsStore products.Products
+	Uploader      uploader.Uploader
+	Audit         audit.Recorder
+	// GracePeriod keeps files younger than it, they may belong to a product
+	// being saved or an upload not used yet.
+	GracePeriod time.Duration
+}
+
+const (
+	// MEDIA_GC_GRACE_PERIOD is longer than a presigned upload lives.
+	MEDIA_GC_GRACE_PERIOD = 24 * time.Hour
+
+	AUDIT_MEDIA_COLLECT = "media.collect"
+)
+
+var ErrInternalMedia = errors.New("media: encountered an internal error")
+
+// deletedFiles is what collecting the garbage changes.
+type deletedFiles struct {
+	Keys []string `json:"keys"`
+}
+
+// CollectGarbage finds the orphaned files older than the grace period and deletes
+// them, a dry run only reports them.
+func (m *MediaService) CollectGarbage(ctx context.Context, dryRun bool) (dto.MediaGarbageReport, error) {
+
+	// files are listed before the references are read, a file stored and referenced
+	// in between is either not listed or referenced
+	files, err := m.Uploader.List(ctx)
+	if err != nil {
+		return dto.MediaGarbageReport{}, errorService.New(ErrInternalMedia, err)
+	}
+
+	referenced, err := m.referencedKeys(ctx)
+	if err != nil {
+		return dto.MediaGarbageReport{}, errorService.New(ErrInternalMedia, err)
+	}
+
+	gracePeriod := m.GracePeriod
+	if gracePeriod <= 0 {
+		gracePeriod = MEDIA_GC_GRACE_PERIOD
+	}
+	cutoff := time.Now().Add(-gracePeriod)
+
+	report := dto.MediaGarbageReport{DryRun: dryRun, Scanned: len(files), Orphans: []dto.OrphanFile{}}
+	for _, file := range files {
+		if referenced[file.Key] || file.ModifiedAt.After(cutoff) {
+			continue
+		}
+
+		report.Orphans = append(report.Orphans, dto.OrphanFile{Key: file.Key, Size: file.Size, ModifiedAt: file.ModifiedAt})
+		report.Bytes += file.Size
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	// a product saved since the scan may refer to an orphan now, the references
+	// are read again once right before the files are deleted
+	referenced, err = m.referencedKeys(ctx)
+	if err != nil {
+		return dto.MediaGarbageReport{}, errorService.New(ErrInternalMedia, err)
+	}
+
+	var deleted []string
+	for _, orphan := range report.Orphans {
+		if referenced[orphan.Key] {
+			continue
+		}
+
+		if err := m.Uploader.DeleteFile(ctx, orphan.Key); err != nil {
+			log.Printf("error deleting orphaned file %v: %v", orphan.Key, err.Error())
+			report.Failed++
+			continue
+		}
+
+		deleted = append(deleted, orphan.Key)
+	}
+	report.Deleted = len(deleted)
+
+	// deleting a file can not be rolled back, the entry is written on its own
+	if len(deleted) > 0 {
+		err := recordAudit(ctx, nil, m.Audit, audit.Entry{
+			Action:     AUDIT_MEDIA_COLLECT,
+			TargetType: "media",
+			Before:     deletedFiles{Keys: deleted},
+		})
+		if err != nil {
+			log.Printf("error recording collected files: %v", err.Error())
+		}
+	}
+
+	return report, nil
+}
+
+// Run collects the garbage every interval until the context is done.
+func (m *MediaService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := m.CollectGarbage(ctx, false)
+			if err != nil {
+				log.Printf("error collecting orphaned files: %v", errorService.GetError(err).InternalError())
+				continue
+			}
+
+			if len(report.Orphans) > 0 {
+				log.Printf("deleted %v of %v orphaned files, %v failed", report.Deleted, len(report.Orphans), report.Failed)
+			}
+		}
+	}
+}
+
+// referencedKeys gets the key of every file a product image refers to.
+func (m *MediaService) referencedKeys(ctx context.Context) (map[string]bool, error) {
+	urls, err := m.ProductsStore.GetImageUrls(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	referenced := make(map[string]bool, len(urls))
+	for _, url := range urls {
+		if key := m.Uploader.FileKey(url); key != "" {
+			referenced[key] = true
+		}
+	}
+
+	return referenced, nil
+}
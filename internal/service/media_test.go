@@ -0,0 +1,135 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/audit"
+	"github.com/faizisyellow/indocoffee/internal/models"
+	"github.com/faizisyellow/indocoffee/internal/repository"
+	"github.com/faizisyellow/indocoffee/internal/repository/audits"
+	"github.com/faizisyellow/indocoffee/internal/repository/products"
+	"github.com/faizisyellow/indocoffee/internal/service"
+	"github.com/faizisyellow/indocoffee/internal/uploader"
+)
+
+func TestMediaService(t *testing.T) {
+	newSut := func() (*service.MediaService, *uploaderFake, *audits.InMemoryAudits) {
+		old := time.Now().Add(-48 * time.Hour)
+
+		files := &uploaderFake{stored: []uploader.StoredFile{
+			{Key: "lizzy.jpeg", Size: 10, ModifiedAt: old},
+			{Key: "lizzy-thumb.webp", Size: 2, ModifiedAt: old},
+			{Key: "nadia.jpeg", Size: 8, ModifiedAt: old},
+			{Key: "uploads/fresh.png", Size: 4, ModifiedAt: time.Now()},
+		}}
+
+		productsStore := &products.InMemoryProducts{Products: []models.Product{
+			{Id: 1, Images: []models.Image{{Id: 1, Url: "https://app.ufs.sh/f/lizzy.jpeg", Renditions: map[string]string{"thumb": "https://app.ufs.sh/f/lizzy-thumb.webp"}}}},
+		}}
+
+		auditsStore := &audits.InMemoryAudits{}
+
+		return &service.MediaService{
+			ProductsStore: productsStore,
+			Uploader:      files,
+			Audit:         &audit.StoreRecorder{Store: auditsStore},
+			GracePeriod:   time.Hour,
+		}, files, auditsStore
+	}
+
+	t.Run("dry run reports the orphans older than the grace period", func(t *testing.T) {
+		sut, files, _ := newSut()
+
+		report, err := sut.CollectGarbage(context.Background(), true)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if report.Scanned != 4 || len(report.Orphans) != 1 || report.Orphans[0].Key != "nadia.jpeg" || report.Bytes != 8 {
+			t.Errorf("expected only nadia.jpeg to be an orphan but got: %+v", report)
+		}
+
+		if len(files.deleted) != 0 || report.Deleted != 0 {
+			t.Errorf("expected nothing to be deleted on a dry run but got: %v", files.deleted)
+		}
+	})
+
+	t.Run("delete the orphans and record it", func(t *testing.T) {
+		sut, files, auditsStore := newSut()
+
+		report, err := sut.CollectGarbage(context.Background(), false)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if report.Deleted != 1 || len(files.deleted) != 1 || files.deleted[0] != "nadia.jpeg" {
+			t.Errorf("expected nadia.jpeg to be deleted but got: %v", files.deleted)
+		}
+
+		logs, err := auditsStore.Find(context.Background(), repository.PaginatedAuditQuery{Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(logs) != 1 || logs[0].Action != service.AUDIT_MEDIA_COLLECT || string(logs[0].Before) != `{"keys":["nadia.jpeg"]}` {
+			t.Errorf("expected the deleted keys to be recorded but got: %+v", logs)
+		}
+	})
+
+	t.Run("keep an orphan a product refers to before it is deleted", func(t *testing.T) {
+		sut, files, auditsStore := newSut()
+		files.stored = append(files.stored, uploader.StoredFile{Key: "aisha.jpeg", Size: 6, ModifiedAt: time.Now().Add(-48 * time.Hour)})
+
+		productsStore := &referencingProducts{
+			Products: sut.ProductsStore,
+			url:      "https://app.ufs.sh/f/nadia.jpeg",
+		}
+		sut.ProductsStore = productsStore
+
+		report, err := sut.CollectGarbage(context.Background(), false)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if report.Deleted != 1 || len(files.deleted) != 1 || files.deleted[0] != "aisha.jpeg" {
+			t.Errorf("expected only aisha.jpeg to be deleted but got: %v", files.deleted)
+		}
+
+		if productsStore.reads != 2 {
+			t.Errorf("expected the references to be read twice but got %v", productsStore.reads)
+		}
+
+		logs, err := auditsStore.Find(context.Background(), repository.PaginatedAuditQuery{Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(logs) != 1 || string(logs[0].Before) != `{"keys":["aisha.jpeg"]}` {
+			t.Errorf("expected only aisha.jpeg to be recorded but got: %+v", logs)
+		}
+	})
+}
+
+// referencingProducts refers to url from the second read of the image urls on,
+// like a product saved while the garbage is collected.
+type referencingProducts struct {
+	products.Products
+	url   string
+	reads int
+}
+
+func (r *referencingProducts) GetImageUrls(ctx context.Context) ([]string, error) {
+	urls, err := r.Products.GetImageUrls(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.reads++
+	if r.reads > 1 {
+		urls = append(urls, r.url)
+	}
+
+	return urls, nil
+}
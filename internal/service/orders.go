@@ -7,7 +7,9 @@ import (
 	"log"
 	"strings"
 
+	"github.com/faizisyellow/indocoffee/internal/audit"
 	"github.com/faizisyellow/indocoffee/internal/db"
+	"github.com/faizisyellow/indocoffee/internal/mailer"
 	"github.com/faizisyellow/indocoffee/internal/models"
 	"github.com/faizisyellow/indocoffee/internal/repository"
 	"github.com/faizisyellow/indocoffee/internal/repository/carts"
@@ -18,12 +20,15 @@ import (
 )
 
 type OrdersService struct {
-	UsersService    UsersServiceInterface
-	ProductsService ProductsServiceInterface
-	CartsStore      carts.Carts
-	OrderStore      orders.Orders
-	Transaction     db.Transactioner
-	Uuid            utils.Token
+	UsersService     UsersServiceInterface
+	ProductsService  ProductsServiceInterface
+	AddressesService AddressesServiceInterface
+	CartsStore       carts.Carts
+	OrderStore       orders.Orders
+	Transaction      db.Transactioner
+	Uuid             utils.Token
+	Mailer           mailer.Mailer
+	Audit            audit.Recorder
 }
 
 const (
@@ -71,30 +76,40 @@ func (o *OrdersService) Create(ctx context.Context, idemKey string, req dto.Crea
 		cartItems = append(cartItems, models.Cart{
 			Id:        cart.Id,
 			ProductId: cart.ProductId,
+			VariantId: cart.VariantId,
 			UserId:    cart.UserId,
 			Quantity:  cart.Quantity,
 		})
 	}
 
 	for _, item := range cartItems {
-		product, err := o.ProductsService.FindById(ctx, item.ProductId)
+		variant, err := o.ProductsService.FindVariantById(ctx, item.VariantId)
+		if err != nil {
+			log.Printf("error getting variant: %v", err.Error())
+			continue
+		}
+
+		product, err := o.ProductsService.FindById(ctx, variant.ProductId)
 		if err != nil {
 			log.Printf("error getting product: %v", err.Error())
 			continue
 		}
 
-		if item.Quantity >= product.Quantity || product.Quantity <= 0 {
+		if item.Quantity >= variant.Quantity || variant.Quantity <= 0 {
 			return "", ErrOrdersQuantityIssue
 		}
 
-		totalPrice += product.Price * float64(item.Quantity)
+		totalPrice += variant.Price * float64(item.Quantity)
 		items = append(items, models.OrderItem{
 			Id:            product.Id,
+			VariantId:     variant.Id,
+			Sku:           variant.Sku,
+			Weight:        variant.Weight,
 			Image:         product.Image,
 			BeanName:      product.BeansModel.Name,
 			FormName:      product.FormsModel.Name,
 			Roasted:       product.Roasted,
-			Price:         product.Price,
+			Price:         variant.Price,
 			OrderQuantity: item.Quantity,
 		})
 	}
@@ -116,6 +131,38 @@ func (o *OrdersService) Create(ctx context.Context, idemKey string, req dto.Crea
 	id.WriteString("-")
 	id.WriteString(o.Uuid.Generate())
 
+	// snapshot the saved address, later changes to it must not move the order.
+	// When no address is typed nor chosen the customer's default address is used.
+	var savedAddr *models.Address
+	switch {
+	case req.AddressId != nil:
+		addr, err := o.AddressesService.FindById(ctx, customer.Id, *req.AddressId)
+		if err != nil {
+			return "", err
+		}
+		savedAddr = &addr
+	case req.Street == "":
+		addr, err := o.AddressesService.FindDefault(ctx, customer.Id)
+		if err != nil {
+			return "", err
+		}
+		savedAddr = &addr
+	}
+
+	if savedAddr != nil {
+		req.CustomerName = savedAddr.RecipientName
+		req.PhoneNumber = savedAddr.PhoneNumber
+		req.Street = savedAddr.Street
+		req.City = savedAddr.City
+		req.Province = savedAddr.Province
+		req.PostalCode = savedAddr.PostalCode
+		req.DeliveryNotes = savedAddr.Notes
+	}
+
+	if req.CustomerEmail == "" {
+		req.CustomerEmail = customer.Email
+	}
+
 	req.PhoneNumber, err = utils.ValidateAndFormatPhoneNumber(req.PhoneNumber)
 	if err != nil {
 		return "", err
@@ -131,6 +178,9 @@ func (o *OrdersService) Create(ctx context.Context, idemKey string, req dto.Crea
 		PhoneNumber:            req.PhoneNumber,
 		Street:                 req.Street,
 		City:                   req.City,
+		Province:               req.Province,
+		PostalCode:             req.PostalCode,
+		DeliveryNotes:          req.DeliveryNotes,
 		AlternativePhoneNumber: alternativePhone,
 		Items:                  items,
 		TotalPrice:             totalPrice,
@@ -139,7 +189,7 @@ func (o *OrdersService) Create(ctx context.Context, idemKey string, req dto.Crea
 
 	var newOrderId string
 
-	return newOrderId, o.Transaction.WithTx(ctx, func(tx *sql.Tx) (err error) {
+	err = o.Transaction.WithTx(ctx, func(tx *sql.Tx) (err error) {
 		newOrderId, err = o.OrderStore.Create(ctx, tx, newOrder)
 		if err != nil {
 			return errorService.New(ErrOrdersInternal, err)
@@ -153,7 +203,7 @@ func (o *OrdersService) Create(ctx context.Context, idemKey string, req dto.Crea
 		}
 
 		for _, item := range cartItems {
-			err := o.ProductsService.DecreaseQuantityProduct(ctx, tx, item.ProductId, item.Quantity)
+			err := o.ProductsService.DecreaseQuantityVariant(ctx, tx, item.VariantId, item.Quantity)
 			if err != nil {
 				if strings.Contains(err.Error(), "quantity_non_negative") {
 					return errorService.New(ErrCartMinQuantity, err)
@@ -164,7 +214,14 @@ func (o *OrdersService) Create(ctx context.Context, idemKey string, req dto.Crea
 
 		return nil
 	})
+	if err != nil {
+		return "", err
+	}
+
+	newOrder.Id = newOrderId
+	o.notify(ctx, mailer.ORDER_CONFIRMATION_TEMPLATE, newOrder)
 
+	return newOrderId, nil
 }
 
 func (o *OrdersService) ExecuteItems(ctx context.Context, orderId string) error {
@@ -182,11 +239,13 @@ func (o *OrdersService) ExecuteItems(ctx context.Context, orderId string) error
 		return errorService.New(ErrOrdersInvalidStatus, ErrOrdersInvalidStatus)
 	}
 
-	if err := o.OrderStore.UpdateOrdersStatus(ctx, orderId, orders.Roasting); err != nil {
-		return errorService.New(ErrOrdersInternal, err)
-	}
+	return o.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+		if err := o.OrderStore.UpdateOrdersStatusWithTx(ctx, tx, orderId, orders.Roasting); err != nil {
+			return errorService.New(ErrOrdersInternal, err)
+		}
 
-	return nil
+		return o.recordTransition(ctx, tx, AUDIT_ORDERS_ROAST, orderId, statusOrder, orders.Roasting.String())
+	})
 }
 
 func (o *OrdersService) FindById(ctx context.Context, orderId string) (models.Order, error) {
@@ -203,50 +262,45 @@ func (o *OrdersService) FindById(ctx context.Context, orderId string) (models.Or
 	return order, nil
 }
 
-func (o *OrdersService) CancelOrder(ctx context.Context, orderId string) error {
-	statusOrder, err := o.OrderStore.GetOrderStatusById(ctx, orderId)
-	if err != nil {
-		switch err {
-		case sql.ErrNoRows:
-			return errorService.New(ErrOrdersNotFound, err)
-		default:
-			return errorService.New(ErrOrdersInternal, err)
-		}
-	}
+// CancelOrder expects the order with its items that has already been loaded by the caller.
+func (o *OrdersService) CancelOrder(ctx context.Context, order models.Order) error {
+	statusOrder := order.Status
 
 	if statusOrder != orders.Confirm.String() && statusOrder != orders.Cancelled.String() {
 		return errorService.New(ErrOrdersInvalidStatus, ErrOrdersInvalidStatus)
 	}
 
-	return o.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
-		if err := o.OrderStore.UpdateOrdersStatusWithTx(ctx, tx, orderId, orders.Cancelled); err != nil {
-			return errorService.New(ErrOrdersInternal, err)
-		}
+	// cancelling an already cancelled order is a no-op, its stock is not restored twice
+	if statusOrder == orders.Cancelled.String() {
+		return nil
+	}
 
-		orderWithItems, err := o.OrderStore.GetOrderById(ctx, orderId)
-		if err != nil {
-			switch err {
-			case sql.ErrNoRows:
-				return errorService.New(ErrOrdersNotFound, err)
-			default:
-				return errorService.New(ErrOrdersInternal, err)
-			}
+	err := o.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+		if err := o.OrderStore.UpdateOrdersStatusWithTx(ctx, tx, order.Id, orders.Cancelled); err != nil {
+			return errorService.New(ErrOrdersInternal, err)
 		}
 
-		for _, item := range orderWithItems.Items {
-			if err := o.ProductsService.IncreaseQuantityProduct(ctx, tx, item.Id, item.OrderQuantity); err != nil {
+		for _, item := range order.Items {
+			if err := o.ProductsService.IncreaseQuantityVariant(ctx, tx, item.VariantId, item.OrderQuantity); err != nil {
 				return err
 			}
 		}
 
-		for _, cartId := range orderWithItems.CartIds {
+		for _, cartId := range order.CartIds {
 			if err := o.CartsStore.DeleteWithTx(ctx, tx, cartId); err != nil {
 				return errorService.New(ErrOrdersInternal, err)
 			}
 		}
 
-		return nil
+		return o.recordTransition(ctx, tx, AUDIT_ORDERS_CANCEL, order.Id, statusOrder, orders.Cancelled.String())
 	})
+	if err != nil {
+		return err
+	}
+
+	o.notify(ctx, mailer.ORDER_CANCELLED_TEMPLATE, order)
+
+	return nil
 }
 
 func (o *OrdersService) ShipOrder(ctx context.Context, orderId string) error {
@@ -264,33 +318,44 @@ func (o *OrdersService) ShipOrder(ctx context.Context, orderId string) error {
 		return errorService.New(ErrOrdersInvalidStatus, ErrOrdersInvalidStatus)
 	}
 
-	if err := o.OrderStore.UpdateOrdersStatus(ctx, orderId, orders.Shipped); err != nil {
-		return errorService.New(ErrOrdersInternal, err)
-	}
-
-	return nil
-}
+	err = o.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+		if err := o.OrderStore.UpdateOrdersStatusWithTx(ctx, tx, orderId, orders.Shipped); err != nil {
+			return errorService.New(ErrOrdersInternal, err)
+		}
 
-func (o *OrdersService) CompleteOrder(ctx context.Context, orderId string) error {
-	statusOrder, err := o.OrderStore.GetOrderStatusById(ctx, orderId)
+		return o.recordTransition(ctx, tx, AUDIT_ORDERS_SHIP, orderId, statusOrder, orders.Shipped.String())
+	})
 	if err != nil {
-		switch err {
-		case sql.ErrNoRows:
-			return errorService.New(ErrOrdersNotFound, err)
-		default:
-			return errorService.New(ErrOrdersInternal, err)
+		return err
+	}
+
+	// shipping an already shipped order is a no-op, do not notify twice
+	if statusOrder == orders.Roasting.String() {
+		order, err := o.OrderStore.GetOrderById(ctx, orderId)
+		if err != nil {
+			log.Printf("error getting order to notify: %v", err.Error())
+			return nil
 		}
+
+		o.notify(ctx, mailer.ORDER_SHIPPED_TEMPLATE, order)
 	}
 
-	if statusOrder != orders.Shipped.String() && statusOrder != orders.Complete.String() {
+	return nil
+}
+
+// CompleteOrder expects the order that has already been loaded by the caller.
+func (o *OrdersService) CompleteOrder(ctx context.Context, order models.Order) error {
+	if order.Status != orders.Shipped.String() && order.Status != orders.Complete.String() {
 		return errorService.New(ErrOrdersInvalidStatus, ErrOrdersInvalidStatus)
 	}
 
-	if err := o.OrderStore.UpdateOrdersStatus(ctx, orderId, orders.Complete); err != nil {
-		return errorService.New(ErrOrdersInternal, err)
-	}
+	return o.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+		if err := o.OrderStore.UpdateOrdersStatusWithTx(ctx, tx, order.Id, orders.Complete); err != nil {
+			return errorService.New(ErrOrdersInternal, err)
+		}
 
-	return nil
+		return o.recordTransition(ctx, tx, AUDIT_ORDERS_COMPLETE, order.Id, order.Status, orders.Complete.String())
+	})
 }
 
 func (o *OrdersService) FindOrders(ctx context.Context, r repository.PaginatedOrdersQuery) ([]models.Order, error) {
@@ -301,3 +366,36 @@ func (o *OrdersService) FindOrders(ctx context.Context, r repository.PaginatedOr
 
 	return orders, nil
 }
+
+// notify emails the customer about the order.
+// The order has already been saved, so a failure is only logged.
+func (o *OrdersService) notify(ctx context.Context, templateFile string, order models.Order) {
+	if o.Mailer == nil {
+		return
+	}
+
+	if err := o.Mailer.Send(ctx, order.CustomerEmail, templateFile, order); err != nil {
+		log.Printf("error sending %v for order %v: %v", templateFile, order.Id, err.Error())
+	}
+}
+
+// recordTransition records a status transition of the order in tx, repeating
+// a transition the order has already made is not recorded.
+func (o *OrdersService) recordTransition(ctx context.Context, tx *sql.Tx, action, orderId, from, to string) error {
+	if from == to {
+		return nil
+	}
+
+	err := recordAudit(ctx, tx, o.Audit, audit.Entry{
+		Action:     action,
+		TargetType: "order",
+		TargetId:   orderId,
+		Before:     orderStatus{Status: from},
+		After:      orderStatus{Status: to},
+	})
+	if err != nil {
+		return errorService.New(ErrOrdersInternal, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/db"
+	"github.com/faizisyellow/indocoffee/internal/repository/addresses"
+	"github.com/faizisyellow/indocoffee/internal/repository/invitations"
+	"github.com/faizisyellow/indocoffee/internal/repository/orders"
+	"github.com/faizisyellow/indocoffee/internal/repository/users"
+	"github.com/faizisyellow/indocoffee/internal/service/dto"
+	errorService "github.com/faizisyellow/indocoffee/internal/service/error"
+)
+
+// PrivacyService exports and erases the personal data of users.
+type PrivacyService struct {
+	UsersService     *UsersServices
+	UsersStore       users.Users
+	AddressesStore   addresses.Addresses
+	OrdersStore      orders.Orders
+	InvitationsStore invitations.Invitations
+	Transaction      db.Transactioner
+}
+
+var (
+	ErrPrivacyOrdersInProgress = errors.New("privacy: orders are still in progress, wait until they complete or cancel them first")
+	ErrPrivacyInternal         = errors.New("privacy: encounter internal error")
+)
+
+// Export gathers the profile, addresses, cart and orders of the user.
+func (p *PrivacyService) Export(ctx context.Context, usrId int) (dto.UserExport, error) {
+
+	user, err := p.UsersService.FindUserById(ctx, usrId)
+	if err != nil {
+		return dto.UserExport{}, err
+	}
+
+	cart, err := p.UsersService.FindUsersCart(ctx, usrId)
+	if err != nil {
+		return dto.UserExport{}, err
+	}
+
+	addrs, err := p.AddressesStore.GetByUserId(ctx, usrId)
+	if err != nil {
+		return dto.UserExport{}, errorService.New(ErrPrivacyInternal, err)
+	}
+
+	ords, err := p.OrdersStore.GetByCustomerId(ctx, usrId)
+	if err != nil {
+		return dto.UserExport{}, errorService.New(ErrPrivacyInternal, err)
+	}
+
+	return dto.UserExport{
+		Profile: dto.GetUsersProfileResponse{
+			Id:        user.Id,
+			Username:  user.Username,
+			Email:     user.Email,
+			IsActive:  user.IsActive != nil && *user.IsActive,
+			CreatedAt: user.CreatedAt,
+		},
+		Addresses:  addrs,
+		Cart:       cart.Carts,
+		Orders:     ords,
+		ExportedAt: time.Now(),
+	}, nil
+}
+
+// DeleteAccount deletes the user and everything kept about the user.
+// Orders are kept for the books but their personal data is removed,
+// so the account can not be deleted while an order still has to be delivered.
+func (p *PrivacyService) DeleteAccount(ctx context.Context, usrId int) error {
+
+	err := p.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+
+		// the orders of the customer are locked, so none is placed or changed before they are anonymized
+		statuses, err := p.OrdersStore.GetStatusesByCustomerId(ctx, tx, usrId)
+		if err != nil {
+			return errorService.New(ErrPrivacyInternal, err)
+		}
+
+		for _, status := range statuses {
+			if status != orders.Complete.String() && status != orders.Cancelled.String() {
+				return errorService.New(ErrPrivacyOrdersInProgress, ErrPrivacyOrdersInProgress)
+			}
+		}
+
+		if err := p.OrdersStore.AnonymizeByCustomerId(ctx, tx, usrId); err != nil {
+			return errorService.New(ErrPrivacyInternal, err)
+		}
+
+		// invitations are not removed with the user
+		if err := p.InvitationsStore.DeleteByUserId(ctx, tx, usrId); err != nil {
+			return errorService.New(ErrPrivacyInternal, err)
+		}
+
+		if err := p.UsersStore.Delete(ctx, tx, usrId); err != nil {
+			return errorService.New(ErrPrivacyInternal, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return p.UsersService.invalidate(ctx, usrId)
+}
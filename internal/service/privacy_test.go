@@ -0,0 +1,96 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/faizisyellow/indocoffee/internal/models"
+	"github.com/faizisyellow/indocoffee/internal/repository/addresses"
+	"github.com/faizisyellow/indocoffee/internal/repository/invitations"
+	"github.com/faizisyellow/indocoffee/internal/repository/orders"
+	"github.com/faizisyellow/indocoffee/internal/repository/users"
+	"github.com/faizisyellow/indocoffee/internal/service"
+	errorService "github.com/faizisyellow/indocoffee/internal/service/error"
+	"github.com/faizisyellow/indocoffee/internal/utils"
+)
+
+func TestPrivacyService(t *testing.T) {
+	newSut := func(status orders.OrderStatus) (*service.PrivacyService, *users.InMemoryUsers, *orders.InMemoryOrders) {
+		usersStore := &users.InMemoryUsers{Users: []models.User{
+			{Id: 1, Username: "lizzy", Email: "lizzy@test.test", IsActive: utils.BoolToPoint(true)},
+		}}
+
+		ordersStore := &orders.InMemoryOrders{Orders: []models.Order{
+			{
+				Id:            "01JORDER",
+				CustomerId:    1,
+				CustomerName:  "lizzy",
+				CustomerEmail: "lizzy@test.test",
+				Status:        status.String(),
+				Items:         []models.OrderItem{{Id: 1, Price: 12.5, OrderQuantity: 2}},
+				TotalPrice:    25,
+				PhoneNumber:   "081234567890",
+				Street:        "Jl. Malioboro No. 52",
+				City:          "Yogyakarta",
+			},
+		}}
+
+		transaction := &transactionFake{state: initial}
+
+		return &service.PrivacyService{
+			UsersService:     &service.UsersServices{UsersStore: usersStore, Transaction: transaction},
+			UsersStore:       usersStore,
+			AddressesStore:   &addresses.InMemoryAddresses{Addresses: []models.Address{{Id: 1, UserId: 1, Label: "home"}}},
+			OrdersStore:      ordersStore,
+			InvitationsStore: &invitations.InMemoryInvitations{},
+			Transaction:      transaction,
+		}, usersStore, ordersStore
+	}
+
+	t.Run("export personal data of the user", func(t *testing.T) {
+		sut, _, _ := newSut(orders.Complete)
+
+		export, err := sut.Export(context.Background(), 1)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if export.Profile.Email != "lizzy@test.test" || len(export.Addresses) != 1 || len(export.Orders) != 1 {
+			t.Errorf("expected profile, addresses and orders of the user but got: %+v", export)
+		}
+	})
+
+	t.Run("delete account and anonymize its orders", func(t *testing.T) {
+		sut, usersStore, ordersStore := newSut(orders.Complete)
+
+		if err := sut.DeleteAccount(context.Background(), 1); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if len(usersStore.Users) != 0 {
+			t.Error("expected the user to be deleted")
+		}
+
+		order := ordersStore.Orders[0]
+		if order.CustomerId != 0 || order.CustomerEmail != "" || order.PhoneNumber != "" || order.Street != "" {
+			t.Errorf("expected personal data to be removed from the order but got: %+v", order)
+		}
+
+		if order.TotalPrice != 25 || len(order.Items) != 1 || order.City != "Yogyakarta" {
+			t.Errorf("expected the financial record to be kept but got: %+v", order)
+		}
+	})
+
+	t.Run("keep account while an order is in progress", func(t *testing.T) {
+		sut, usersStore, _ := newSut(orders.Shipped)
+
+		err := sut.DeleteAccount(context.Background(), 1)
+		if errorService.GetError(err).E != service.ErrPrivacyOrdersInProgress {
+			t.Fatalf("expected %v but got: %v", service.ErrPrivacyOrdersInProgress, err)
+		}
+
+		if len(usersStore.Users) != 1 {
+			t.Error("expected the user to be kept")
+		}
+	})
+}
@@ -5,75 +5,125 @@ import (
 	"database/sql"
 	"errors"
 	"log"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/faizisyellow/indocoffee/internal/audit"
+	"github.com/faizisyellow/indocoffee/internal/db"
 	"github.com/faizisyellow/indocoffee/internal/models"
 	"github.com/faizisyellow/indocoffee/internal/repository"
 	"github.com/faizisyellow/indocoffee/internal/repository/products"
 	"github.com/faizisyellow/indocoffee/internal/service/dto"
 	errorService "github.com/faizisyellow/indocoffee/internal/service/error"
 	"github.com/faizisyellow/indocoffee/internal/uploader"
-	"github.com/faizisyellow/indocoffee/internal/uploader/uploadthing"
+	"github.com/faizisyellow/indocoffee/internal/uploader/imaging"
 )
 
 type ProductsService struct {
 	ProductsStore products.Products
+	Transaction   db.Transactioner
 	Uploader      uploader.Uploader
+	Audit         audit.Recorder
+	Imaging       imaging.Pipeline
 }
 
-const FILE_SUPPORTED_MAIN = "png"
-const FILE_SUPPORTED_SECOND = "jpeg"
+const (
+	// MAX_PRODUCT_IMAGES is how many images the gallery of a product can hold.
+	MAX_PRODUCT_IMAGES = 10
+	// MAX_IMAGE_SIZE is the largest image upload in bytes.
+	MAX_IMAGE_SIZE = 2 << 20
+	// PRESIGN_EXPIRES is how long a client has to upload to a presigned target.
+	PRESIGN_EXPIRES = 15 * time.Minute
+)
 
 var (
 	ErrFileTooBigProducts       = errors.New("products: request upload image too big")
 	ErrUploadImageProducts      = errors.New("products: error uploading image file")
 	ErrFileNotSupportedProducts = errors.New("products: file not supported. Only png and jpeg files are supported")
+	ErrTooManyPixelsProducts    = errors.New("products: image dimensions are too large")
 	ErrInternalProducts         = errors.New("products: internal error")
 	ErrConflictProducts         = errors.New("products: already exist")
 	ErrReferenceFailedProducts  = errors.New("products: form or beans not found")
 	ErrNotFoundProduct          = errors.New("products: product not found")
+	ErrNotFoundVariant          = errors.New("products: variant not found")
+	ErrConflictVariant          = errors.New("products: variant sku or weight already exist")
+	ErrLastVariant              = errors.New("products: a product must keep at least one variant")
+	ErrNotFoundImage            = errors.New("products: image not found")
+	ErrImageLimit               = errors.New("products: gallery is full")
+	ErrImageOrder               = errors.New("products: order must list every image of the product once")
+	ErrPrimaryImage             = errors.New("products: the primary image can not be deleted, set another primary image first")
+	ErrPresignNotSupported      = errors.New("products: the storage does not support direct uploads")
+	ErrNotFoundUpload           = errors.New("products: uploaded image not found, upload it to a presigned target first")
+	ErrImageSourceProducts      = errors.New("products: send either an image file or an image key")
 )
 
 func (p *ProductsService) Create(ctx context.Context, metadatReq dto.CreateProductMetadataRequest, file uploader.FileInput) error {
-	// // max upload file 2mb
-	if file.Size > 2<<20 {
-		return errorService.New(ErrFileTooBigProducts, ErrFileTooBigProducts)
-	}
-
-	if !strings.Contains(file.MimeType, FILE_SUPPORTED_MAIN) && !strings.Contains(file.MimeType, FILE_SUPPORTED_SECOND) {
-		return errorService.New(ErrFileNotSupportedProducts, ErrFileNotSupportedProducts)
+	file, err := p.imageFile(ctx, metadatReq.ImageKey, file)
+	if err != nil {
+		return err
 	}
 
-	filename, err := p.Uploader.UploadFile(ctx, file)
+	url, renditions, err := p.storeImage(ctx, file)
 	if err != nil {
-		return errorService.New(ErrUploadImageProducts, err)
+		return err
 	}
 
 	newProduct := models.Product{
-		Roasted:  metadatReq.Roasted,
-		Price:    metadatReq.Price,
-		Quantity: metadatReq.Quantity,
-		BeanId:   metadatReq.Bean,
-		FormId:   metadatReq.Form,
-		Image:    filename,
+		Name:            metadatReq.Name,
+		Description:     metadatReq.Description,
+		Origin:          metadatReq.Origin,
+		Process:         metadatReq.Process,
+		Altitude:        metadatReq.Altitude,
+		TastingNotes:    metadatReq.TastingNotes,
+		Roasted:         metadatReq.Roasted,
+		BeanId:          metadatReq.Bean,
+		FormId:          metadatReq.Form,
+		Image:           url,
+		ImageRenditions: renditions,
 	}
 
-	if err := p.ProductsStore.Insert(ctx, newProduct); err != nil {
-		if err := p.Uploader.DeleteFile(ctx, filename); err != nil {
-			return errorService.New(ErrInternalProducts, err)
+	for _, variant := range metadatReq.Variants {
+		newProduct.Variants = append(newProduct.Variants, models.Variant{
+			Sku:      variant.Sku,
+			Weight:   variant.Weight,
+			Price:    variant.Price,
+			Quantity: variant.Quantity,
+		})
+	}
+
+	err = p.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+		id, err := p.ProductsStore.Insert(ctx, tx, newProduct)
+		if err != nil {
+			if strings.Contains(err.Error(), CONFLICT_CODE) {
+				return errorService.New(ErrConflictProducts, err)
+			}
+
+			if strings.Contains(err.Error(), REFERENCES_CODE) {
+				return errorService.New(ErrReferenceFailedProducts, err)
+			}
+
+			return errorService.New(err, err)
 		}
+		newProduct.Id = id
 
-		if strings.Contains(err.Error(), CONFLICT_CODE) {
-			return errorService.New(ErrConflictProducts, err)
+		if err := recordAudit(ctx, tx, p.Audit, audit.Entry{Action: AUDIT_PRODUCTS_CREATE, TargetType: "product", TargetId: strconv.Itoa(id), After: newProduct}); err != nil {
+			return errorService.New(ErrInternalProducts, err)
 		}
 
-		if strings.Contains(err.Error(), REFERENCES_CODE) {
-			return errorService.New(ErrReferenceFailedProducts, err)
+		return nil
+	})
+	if err != nil {
+		if err := p.deleteImageFiles(ctx, url, renditions); err != nil {
+			return errorService.New(ErrInternalProducts, err)
 		}
 
-		return errorService.New(err, err)
+		return err
 	}
 
+	p.deleteUpload(ctx, metadatReq.ImageKey)
+
 	return nil
 
 }
@@ -110,16 +160,34 @@ func (p *ProductsService) Update(ctx context.Context, id int, req dto.UpdateProd
 		return err
 	}
 
-	if req.Roasted != "" {
-		product.Roasted = req.Roasted
+	before := product
+
+	if req.Name != "" {
+		product.Name = req.Name
 	}
 
-	if req.Quantity != 0 {
-		product.Quantity = req.Quantity
+	if req.Description != "" {
+		product.Description = req.Description
 	}
 
-	if req.Price != 0 {
-		product.Price = req.Price
+	if req.Origin != "" {
+		product.Origin = req.Origin
+	}
+
+	if req.Process != "" {
+		product.Process = req.Process
+	}
+
+	if req.Altitude != 0 {
+		product.Altitude = req.Altitude
+	}
+
+	if req.TastingNotes != nil {
+		product.TastingNotes = req.TastingNotes
+	}
+
+	if req.Roasted != "" {
+		product.Roasted = req.Roasted
 	}
 
 	if req.Form != 0 {
@@ -130,63 +198,220 @@ func (p *ProductsService) Update(ctx context.Context, id int, req dto.UpdateProd
 		product.BeanId = req.Bean
 	}
 
-	var existingImage = product.Image
-
-	if len(file.Content) != 0 {
-		if file.Size > 2<<20 {
-			return errorService.New(ErrFileTooBigProducts, ErrFileTooBigProducts)
-		}
+	file, err = p.imageFile(ctx, req.ImageKey, file)
+	if err != nil {
+		return err
+	}
 
-		if !strings.Contains(file.MimeType, FILE_SUPPORTED_MAIN) && !strings.Contains(file.MimeType, FILE_SUPPORTED_SECOND) {
-			return errorService.New(ErrFileNotSupportedProducts, ErrFileNotSupportedProducts)
-		}
+	var (
+		existingImage      = product.Image
+		existingRenditions = product.ImageRenditions
+	)
 
-		filename, err := p.Uploader.UploadFile(ctx, file)
+	if len(file.Content) != 0 {
+		url, renditions, err := p.storeImage(ctx, file)
 		if err != nil {
-			return errorService.New(ErrUploadImageProducts, err)
+			return err
 		}
 
-		product.Image = filename
+		product.Image = url
+		product.ImageRenditions = renditions
 	}
 
-	if err := p.ProductsStore.Update(ctx, product); err != nil {
-		if len(file.Content) != 0 {
-			if err := p.Uploader.DeleteFile(ctx, uploadthing.GetFileKey(product.Image)); err != nil {
-				log.Printf("error delete image in error update product: %v", err.Error())
+	err = p.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+		if err := p.ProductsStore.Update(ctx, tx, product); err != nil {
+			if strings.Contains(err.Error(), CONFLICT_CODE) {
+				return errorService.New(ErrConflictProducts, err)
+			}
+
+			if strings.Contains(err.Error(), REFERENCES_CODE) {
+				return errorService.New(ErrReferenceFailedProducts, err)
 			}
+
+			return errorService.New(ErrInternalProducts, err)
 		}
 
-		if strings.Contains(err.Error(), CONFLICT_CODE) {
-			return errorService.New(ErrConflictProducts, err)
+		if err := recordAudit(ctx, tx, p.Audit, audit.Entry{Action: AUDIT_PRODUCTS_UPDATE, TargetType: "product", TargetId: strconv.Itoa(product.Id), Before: before, After: product}); err != nil {
+			return errorService.New(ErrInternalProducts, err)
 		}
 
-		if strings.Contains(err.Error(), REFERENCES_CODE) {
-			return errorService.New(ErrReferenceFailedProducts, err)
+		return nil
+	})
+	if err != nil {
+		if len(file.Content) != 0 {
+			if err := p.deleteImageFiles(ctx, product.Image, product.ImageRenditions); err != nil {
+				log.Printf("error delete image in error update product: %v", err.Error())
+			}
 		}
 
-		return errorService.New(ErrInternalProducts, err)
+		return err
 	}
 
 	// if there's a file request, delete previous
 	// image in file storage provider
 	if len(file.Content) != 0 {
-		err := p.Uploader.DeleteFile(ctx, uploadthing.GetFileKey(existingImage))
+		err := p.deleteImageFiles(ctx, existingImage, existingRenditions)
 		if err != nil {
 			return errorService.New(ErrInternalProducts, err)
 		}
 	}
 
+	p.deleteUpload(ctx, req.ImageKey)
+
 	return nil
 }
 
-func (p *ProductsService) DecreaseQuantityProduct(ctx context.Context, tx *sql.Tx, prdId, quantity int) error {
+func (p *ProductsService) DecreaseQuantityVariant(ctx context.Context, tx *sql.Tx, variantId, quantity int) error {
+
+	return p.ProductsStore.DecrementQuantity(ctx, tx, variantId, quantity)
+}
+
+func (p *ProductsService) IncreaseQuantityVariant(ctx context.Context, tx *sql.Tx, variantId, quantity int) error {
+
+	return p.ProductsStore.IncrementQuantity(ctx, tx, variantId, quantity)
+}
+
+func (p *ProductsService) FindVariantById(ctx context.Context, id int) (models.Variant, error) {
+
+	variant, err := p.ProductsStore.GetVariantById(ctx, id)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return models.Variant{}, errorService.New(ErrNotFoundVariant, err)
+		default:
+			return models.Variant{}, errorService.New(ErrInternalProducts, err)
+		}
+	}
+
+	return variant, nil
+}
+
+func (p *ProductsService) CreateVariant(ctx context.Context, productId int, req dto.CreateVariantRequest) (int, error) {
+
+	product, err := p.FindById(ctx, productId)
+	if err != nil {
+		return 0, err
+	}
+
+	variant := models.Variant{
+		ProductId: product.Id,
+		Sku:       req.Sku,
+		Weight:    req.Weight,
+		Price:     req.Price,
+		Quantity:  req.Quantity,
+	}
+
+	err = p.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+		id, err := p.ProductsStore.InsertVariant(ctx, tx, variant)
+		if err != nil {
+			if strings.Contains(err.Error(), CONFLICT_CODE) {
+				return errorService.New(ErrConflictVariant, err)
+			}
+
+			return errorService.New(ErrInternalProducts, err)
+		}
+		variant.Id = id
+
+		if err := recordAudit(ctx, tx, p.Audit, audit.Entry{Action: AUDIT_VARIANTS_CREATE, TargetType: "variant", TargetId: strconv.Itoa(id), After: variant}); err != nil {
+			return errorService.New(ErrInternalProducts, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return variant.Id, nil
+}
+
+func (p *ProductsService) UpdateVariant(ctx context.Context, productId, variantId int, req dto.UpdateVariantRequest) error {
+
+	variant, err := p.findProductVariant(ctx, productId, variantId)
+	if err != nil {
+		return err
+	}
+
+	before := variant
+
+	if req.Sku != "" {
+		variant.Sku = req.Sku
+	}
+
+	if req.Weight != 0 {
+		variant.Weight = req.Weight
+	}
+
+	if req.Price != 0 {
+		variant.Price = req.Price
+	}
+
+	if req.Quantity != nil {
+		variant.Quantity = *req.Quantity
+	}
+
+	return p.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+		if err := p.ProductsStore.UpdateVariant(ctx, tx, variant); err != nil {
+			if strings.Contains(err.Error(), CONFLICT_CODE) {
+				return errorService.New(ErrConflictVariant, err)
+			}
+
+			return errorService.New(ErrInternalProducts, err)
+		}
+
+		if err := recordAudit(ctx, tx, p.Audit, audit.Entry{Action: AUDIT_VARIANTS_UPDATE, TargetType: "variant", TargetId: strconv.Itoa(variant.Id), Before: before, After: variant}); err != nil {
+			return errorService.New(ErrInternalProducts, err)
+		}
+
+		return nil
+	})
+}
+
+func (p *ProductsService) DeleteVariant(ctx context.Context, productId, variantId int) error {
+
+	variant, err := p.findProductVariant(ctx, productId, variantId)
+	if err != nil {
+		return err
+	}
 
-	return p.ProductsStore.DecrementQuantity(ctx, tx, prdId, quantity)
+	return p.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+		// the variants stay locked until the delete commits, two concurrent
+		// deletes can not remove the last two variants
+		count, err := p.ProductsStore.CountVariants(ctx, tx, productId)
+		if err != nil {
+			return errorService.New(ErrInternalProducts, err)
+		}
+
+		if count <= 1 {
+			return errorService.New(ErrLastVariant, ErrLastVariant)
+		}
+
+		if err := p.ProductsStore.DeleteVariant(ctx, tx, variant.Id); err != nil {
+			return errorService.New(ErrInternalProducts, err)
+		}
+
+		if err := recordAudit(ctx, tx, p.Audit, audit.Entry{Action: AUDIT_VARIANTS_DELETE, TargetType: "variant", TargetId: strconv.Itoa(variant.Id), Before: variant}); err != nil {
+			return errorService.New(ErrInternalProducts, err)
+		}
+
+		return nil
+	})
 }
 
-func (p *ProductsService) IncreaseQuantityProduct(ctx context.Context, tx *sql.Tx, prdId, quantity int) error {
+// findProductVariant looks up a variant and makes sure it belongs to the product,
+// a variant of another product is reported as not found.
+func (p *ProductsService) findProductVariant(ctx context.Context, productId, variantId int) (models.Variant, error) {
 
-	return p.ProductsStore.IncrementQuantity(ctx, tx, prdId, quantity)
+	variant, err := p.FindVariantById(ctx, variantId)
+	if err != nil {
+		return models.Variant{}, err
+	}
+
+	if variant.ProductId != productId {
+		return models.Variant{}, errorService.New(ErrNotFoundVariant, ErrNotFoundVariant)
+	}
+
+	return variant, nil
 }
 
 func (p *ProductsService) Destroy(ctx context.Context, id int) error {
@@ -195,15 +420,331 @@ func (p *ProductsService) Destroy(ctx context.Context, id int) error {
 		return err
 	}
 
-	err = p.ProductsStore.Delete(ctx, product.Id)
+	err = p.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+		if err := p.ProductsStore.Delete(ctx, tx, product.Id); err != nil {
+			return errorService.New(ErrInternalProducts, err)
+		}
+
+		if err := recordAudit(ctx, tx, p.Audit, audit.Entry{Action: AUDIT_PRODUCTS_DELETE, TargetType: "product", TargetId: strconv.Itoa(product.Id), Before: product}); err != nil {
+			return errorService.New(ErrInternalProducts, err)
+		}
+
+		return nil
+	})
 	if err != nil {
-		return errorService.New(ErrInternalProducts, err)
+		return err
 	}
 
-	err = p.Uploader.DeleteFile(ctx, uploadthing.GetFileKey(product.Image))
+	// the images rows are gone with the product, remove their files too,
+	// a file left behind is only logged and collected as an orphan later
+	for _, image := range product.Images {
+		if err := p.deleteImageFiles(ctx, image.Url, image.Renditions); err != nil {
+			log.Printf("error delete image file of deleted product %v: %v", product.Id, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (p *ProductsService) AddImage(ctx context.Context, productId int, file uploader.FileInput) (models.Image, error) {
+
+	product, err := p.FindById(ctx, productId)
+	if err != nil {
+		return models.Image{}, err
+	}
+
+	// checked early to skip processing a file that can not be added
+	if len(product.Images) >= MAX_PRODUCT_IMAGES {
+		return models.Image{}, errorService.New(ErrImageLimit, ErrImageLimit)
+	}
+
+	url, renditions, err := p.storeImage(ctx, file)
+	if err != nil {
+		return models.Image{}, err
+	}
+
+	image := models.Image{ProductId: product.Id, Url: url, Renditions: renditions}
+
+	err = p.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+
+		// counted again with the product locked, images may have been added meanwhile
+		count, err := p.ProductsStore.CountImages(ctx, tx, product.Id)
+		if err != nil {
+			return errorService.New(ErrInternalProducts, err)
+		}
+
+		if count >= MAX_PRODUCT_IMAGES {
+			return errorService.New(ErrImageLimit, ErrImageLimit)
+		}
+
+		id, err := p.ProductsStore.InsertImage(ctx, tx, image)
+		if err != nil {
+			return errorService.New(ErrInternalProducts, err)
+		}
+		image.Id = id
+
+		if err := recordAudit(ctx, tx, p.Audit, audit.Entry{Action: AUDIT_IMAGES_CREATE, TargetType: "image", TargetId: strconv.Itoa(id), After: image}); err != nil {
+			return errorService.New(ErrInternalProducts, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		// nothing references the uploaded files, do not leave them behind
+		if err := p.deleteImageFiles(ctx, url, renditions); err != nil {
+			log.Printf("error delete image in error add product image: %v", err.Error())
+		}
+
+		return models.Image{}, err
+	}
+
+	return p.findProductImage(ctx, product.Id, image.Id)
+}
+
+// ReorderImages orders the gallery as listed, the list must hold every image of the product.
+func (p *ProductsService) ReorderImages(ctx context.Context, productId int, req dto.ReorderImagesRequest) error {
+
+	product, err := p.FindById(ctx, productId)
+	if err != nil {
+		return err
+	}
+
+	if len(req.ImageIds) != len(product.Images) {
+		return errorService.New(ErrImageOrder, ErrImageOrder)
+	}
+
+	for _, image := range product.Images {
+		if !slices.Contains(req.ImageIds, image.Id) {
+			return errorService.New(ErrImageOrder, ErrImageOrder)
+		}
+	}
+
+	return p.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+		if err := p.ProductsStore.ReorderImages(ctx, tx, product.Id, req.ImageIds); err != nil {
+			return errorService.New(ErrInternalProducts, err)
+		}
+
+		if err := recordAudit(ctx, tx, p.Audit, audit.Entry{Action: AUDIT_IMAGES_REORDER, TargetType: "product", TargetId: strconv.Itoa(product.Id), After: req}); err != nil {
+			return errorService.New(ErrInternalProducts, err)
+		}
+
+		return nil
+	})
+}
+
+func (p *ProductsService) SetPrimaryImage(ctx context.Context, productId, imageId int) error {
+
+	image, err := p.findProductImage(ctx, productId, imageId)
 	if err != nil {
-		return errorService.New(ErrInternalProducts, err)
+		return err
+	}
+
+	if image.IsPrimary {
+		return nil
+	}
+
+	return p.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+		if err := p.ProductsStore.SetPrimaryImage(ctx, tx, productId, image.Id); err != nil {
+			return errorService.New(ErrInternalProducts, err)
+		}
+
+		if err := recordAudit(ctx, tx, p.Audit, audit.Entry{Action: AUDIT_IMAGES_PRIMARY, TargetType: "image", TargetId: strconv.Itoa(image.Id)}); err != nil {
+			return errorService.New(ErrInternalProducts, err)
+		}
+
+		return nil
+	})
+}
+
+func (p *ProductsService) DeleteImage(ctx context.Context, productId, imageId int) error {
+
+	image, err := p.findProductImage(ctx, productId, imageId)
+	if err != nil {
+		return err
+	}
+
+	if image.IsPrimary {
+		return errorService.New(ErrPrimaryImage, ErrPrimaryImage)
+	}
+
+	err = p.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+		if err := p.ProductsStore.DeleteImage(ctx, tx, image.Id); err != nil {
+			return errorService.New(ErrInternalProducts, err)
+		}
+
+		if err := recordAudit(ctx, tx, p.Audit, audit.Entry{Action: AUDIT_IMAGES_DELETE, TargetType: "image", TargetId: strconv.Itoa(image.Id), Before: image}); err != nil {
+			return errorService.New(ErrInternalProducts, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// the image is already gone from the gallery, a file left behind is only logged
+	if err := p.deleteImageFiles(ctx, image.Url, image.Renditions); err != nil {
+		log.Printf("error delete image file of deleted product image: %v", err.Error())
 	}
 
 	return nil
 }
+
+// findProductImage looks up an image and makes sure it belongs to the product,
+// an image of another product is reported as not found.
+func (p *ProductsService) findProductImage(ctx context.Context, productId, imageId int) (models.Image, error) {
+
+	image, err := p.ProductsStore.GetImageById(ctx, imageId)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return models.Image{}, errorService.New(ErrNotFoundImage, err)
+		default:
+			return models.Image{}, errorService.New(ErrInternalProducts, err)
+		}
+	}
+
+	if image.ProductId != productId {
+		return models.Image{}, errorService.New(ErrNotFoundImage, ErrNotFoundImage)
+	}
+
+	return image, nil
+}
+
+// PresignImage returns a target for the client to upload an image to directly,
+// its key is then sent as the image key of a product.
+func (p *ProductsService) PresignImage(ctx context.Context, req dto.PresignImageRequest) (dto.UploadTargetResponse, error) {
+	target, err := p.Uploader.PresignUpload(ctx, uploader.PresignInput{
+		Name:     req.Name,
+		MimeType: req.MimeType,
+		Size:     req.Size,
+		Expires:  PRESIGN_EXPIRES,
+	})
+	if err != nil {
+		if errors.Is(err, uploader.ErrPresignNotSupported) {
+			return dto.UploadTargetResponse{}, errorService.New(ErrPresignNotSupported, err)
+		}
+		return dto.UploadTargetResponse{}, errorService.New(ErrUploadImageProducts, err)
+	}
+
+	return dto.UploadTargetResponse{
+		Key:       target.Key,
+		Url:       target.Url,
+		Method:    target.Method,
+		Headers:   target.Headers,
+		FormField: target.FormField,
+		ExpiresAt: target.ExpiresAt,
+	}, nil
+}
+
+// imageFile reads the image uploaded to a presigned target when there is a key,
+// its type and size are checked by storeImage like any other file.
+func (p *ProductsService) imageFile(ctx context.Context, key string, file uploader.FileInput) (uploader.FileInput, error) {
+	if key == "" {
+		return file, nil
+	}
+
+	if len(file.Content) != 0 {
+		return uploader.FileInput{}, errorService.New(ErrImageSourceProducts, ErrImageSourceProducts)
+	}
+
+	uploaded, err := p.Uploader.ReadFile(ctx, key, MAX_IMAGE_SIZE)
+	if err != nil {
+		switch {
+		case errors.Is(err, uploader.ErrFileNotFound):
+			return uploader.FileInput{}, errorService.New(ErrNotFoundUpload, err)
+		case errors.Is(err, uploader.ErrFileTooLarge):
+			return uploader.FileInput{}, errorService.New(ErrFileTooBigProducts, err)
+		default:
+			return uploader.FileInput{}, errorService.New(ErrUploadImageProducts, err)
+		}
+	}
+
+	return uploaded, nil
+}
+
+// deleteUpload removes the image uploaded to a presigned target once its renditions are stored.
+func (p *ProductsService) deleteUpload(ctx context.Context, key string) {
+	if key == "" {
+		return
+	}
+
+	if err := p.Uploader.DeleteFile(ctx, key); err != nil {
+		log.Printf("error delete uploaded image %v: %v", key, err.Error())
+	}
+}
+
+// storeImage uploads every rendition of the file and returns them by name,
+// with the url of the largest one in the format of the upload as the url of the image.
+// Nothing is left in the storage when one of the uploads fails.
+func (p *ProductsService) storeImage(ctx context.Context, file uploader.FileInput) (string, map[string]string, error) {
+	if file.Size > MAX_IMAGE_SIZE {
+		return "", nil, errorService.New(ErrFileTooBigProducts, ErrFileTooBigProducts)
+	}
+
+	outputs, err := p.Imaging.Process(file)
+	if err != nil {
+		switch err {
+		case imaging.ErrUnsupportedFormat:
+			return "", nil, errorService.New(ErrFileNotSupportedProducts, err)
+		case imaging.ErrTooManyPixels:
+			return "", nil, errorService.New(ErrTooManyPixelsProducts, err)
+		default:
+			return "", nil, errorService.New(ErrInternalProducts, err)
+		}
+	}
+
+	var (
+		url        string
+		renditions = make(map[string]string)
+	)
+
+	for _, output := range outputs {
+		uploaded, err := p.Uploader.UploadFile(ctx, output.File)
+		if err != nil {
+			if err := p.deleteImageFiles(ctx, "", renditions); err != nil {
+				log.Printf("error delete renditions in error upload image: %v", err.Error())
+			}
+
+			return "", nil, errorService.New(ErrUploadImageProducts, err)
+		}
+
+		renditions[output.Key()] = uploaded
+		if !output.Converted {
+			url = uploaded
+		}
+	}
+
+	if url == "" {
+		return "", nil, errorService.New(ErrInternalProducts, errors.New("products: imaging has no renditions"))
+	}
+
+	return url, renditions, nil
+}
+
+// deleteImageFiles removes the image and every rendition of it from the storage,
+// images uploaded before renditions only have their url.
+func (p *ProductsService) deleteImageFiles(ctx context.Context, url string, renditions map[string]string) error {
+	urls := []string{url}
+	for _, rendition := range renditions {
+		urls = append(urls, rendition)
+	}
+
+	// a failing file does not stop the others from being deleted
+	var errs []error
+
+	slices.Sort(urls)
+	for _, url := range slices.Compact(urls) {
+		// a url the uploader does not know has no file to delete
+		key := p.Uploader.FileKey(url)
+		if key == "" {
+			continue
+		}
+
+		if err := p.Uploader.DeleteFile(ctx, key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -2,14 +2,22 @@ package service_test
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/faizisyellow/indocoffee/internal/models"
 	"github.com/faizisyellow/indocoffee/internal/repository/products"
 	"github.com/faizisyellow/indocoffee/internal/service"
 	"github.com/faizisyellow/indocoffee/internal/service/dto"
+	errorService "github.com/faizisyellow/indocoffee/internal/service/error"
 	"github.com/faizisyellow/indocoffee/internal/uploader"
+	"github.com/faizisyellow/indocoffee/internal/uploader/imaging"
 	"github.com/faizisyellow/indocoffee/internal/uploader/local"
 	"github.com/faizisyellow/indocoffee/internal/uploader/uploadthing"
 )
@@ -64,13 +72,15 @@ func (p ProductsServiceTest) Test(t *testing.T) {
 			var (
 				ctx                                 = context.Background()
 				productsStore, uploadFile, teardown = p.CreateDependencies()
-				sut                                 = service.ProductsService{productsStore, uploadFile}
+				sut                                 = service.ProductsService{productsStore, &transactionFake{state: initial}, uploadFile, nil, imaging.NewPipeline()}
 				request                             = dto.CreateProductMetadataRequest{
-					Roasted:  "light",
-					Price:    18.5,
-					Quantity: 100,
-					Bean:     1,
-					Form:     1,
+					Name:    "Lizzy Blend",
+					Roasted: "light",
+					Bean:    1,
+					Form:    1,
+					Variants: []dto.CreateVariantRequest{
+						{Sku: "LIZZY-250", Weight: 250, Price: 18.5, Quantity: 100},
+					},
 				}
 			)
 			t.Cleanup(teardown)
@@ -98,13 +108,15 @@ func (p ProductsServiceTest) Test(t *testing.T) {
 			var (
 				ctx                                 = context.Background()
 				productsStore, uploadFile, teardown = p.CreateDependencies()
-				sut                                 = service.ProductsService{productsStore, uploadFile}
+				sut                                 = service.ProductsService{productsStore, &transactionFake{state: initial}, uploadFile, nil, imaging.NewPipeline()}
 				request                             = dto.CreateProductMetadataRequest{
-					Roasted:  "light",
-					Price:    18.5,
-					Quantity: 100,
-					Bean:     1,
-					Form:     1,
+					Name:    "Lizzy Blend",
+					Roasted: "light",
+					Bean:    1,
+					Form:    1,
+					Variants: []dto.CreateVariantRequest{
+						{Sku: "LIZZY-250", Weight: 250, Price: 18.5, Quantity: 100},
+					},
 				}
 			)
 			t.Cleanup(teardown)
@@ -144,8 +156,364 @@ func SetupUploadthing(t *testing.T) (*uploadthing.Uploadthing, error) {
 		os.Getenv("UPLOADTHING_META_URL"),
 		os.Getenv("UPLOADTHING_CALLBACK_URL"),
 		os.Getenv("UPLOADTHING_DELETE_URL"),
+		os.Getenv("UPLOADTHING_LIST_URL"),
 		os.Getenv("UPLOADTHING_APP_ID"),
 	)
 
 	return upt, nil
 }
+
+func TestProductVariants(t *testing.T) {
+	var (
+		ctx           = context.Background()
+		productsStore = &products.InMemoryProducts{}
+		sut           = service.ProductsService{ProductsStore: productsStore, Transaction: &transactionFake{state: initial}}
+	)
+
+	_, err := productsStore.Insert(ctx, nil, models.Product{
+		Name:     "Lizzy Blend",
+		Image:    "lizzy.jpeg",
+		Roasted:  "light",
+		BeanId:   1,
+		FormId:   1,
+		Variants: []models.Variant{{Sku: "LIZZY-250", Weight: 250, Price: 17.5, Quantity: 10}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("add a variant with its own price and stock", func(t *testing.T) {
+		id, err := sut.CreateVariant(ctx, 1, dto.CreateVariantRequest{Sku: "LIZZY-1000", Weight: 1000, Price: 60, Quantity: 4})
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		variant, err := sut.FindVariantById(ctx, id)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if variant.ProductId != 1 || variant.Price != 60 || variant.Quantity != 4 {
+			t.Errorf("unexpected variant: %+v", variant)
+		}
+	})
+
+	t.Run("stock is tracked per variant", func(t *testing.T) {
+		if err := sut.DecreaseQuantityVariant(ctx, nil, 1, 3); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		product, err := sut.FindById(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if product.Variants[0].Quantity != 7 || product.Variants[1].Quantity != 4 {
+			t.Errorf("expected only the first variant to be decremented but got: %+v", product.Variants)
+		}
+
+		if err := sut.DecreaseQuantityVariant(ctx, nil, 2, 5); err == nil {
+			t.Error("expected error when the variant stock goes below zero")
+		}
+	})
+
+	t.Run("update a variant of another product is not found", func(t *testing.T) {
+		err := sut.UpdateVariant(ctx, 2, 1, dto.UpdateVariantRequest{Price: 20})
+		if errorService.GetError(err).E != service.ErrNotFoundVariant {
+			t.Errorf("expected error %v but got %v", service.ErrNotFoundVariant, err)
+		}
+	})
+
+	t.Run("delete a variant but never the last one", func(t *testing.T) {
+		if err := sut.DeleteVariant(ctx, 1, 2); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		err := sut.DeleteVariant(ctx, 1, 1)
+		if errorService.GetError(err).E != service.ErrLastVariant {
+			t.Errorf("expected error %v but got %v", service.ErrLastVariant, err)
+		}
+	})
+}
+
+func TestProductImages(t *testing.T) {
+	var (
+		ctx           = context.Background()
+		productsStore = &products.InMemoryProducts{}
+		files         = &uploaderFake{}
+		sut           = service.ProductsService{ProductsStore: productsStore, Transaction: &transactionFake{state: initial}, Uploader: files, Imaging: imaging.NewPipeline()}
+	)
+
+	content, err := os.ReadFile("file_test/lizzy.jpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	image := uploader.FileInput{Name: "gallery.jpeg", Size: int64(len(content)), MimeType: "image/jpeg", Content: content}
+
+	_, err = productsStore.Insert(ctx, nil, models.Product{
+		Name:     "Lizzy Blend",
+		Image:    "https://app.ufs.sh/f/lizzy.jpeg",
+		Roasted:  "light",
+		BeanId:   1,
+		FormId:   1,
+		Variants: []models.Variant{{Sku: "LIZZY-250", Weight: 250, Price: 17.5, Quantity: 10}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("add an image to the end of the gallery", func(t *testing.T) {
+		added, err := sut.AddImage(ctx, 1, image)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if added.IsPrimary || added.Position != 1 {
+			t.Errorf("expected a second image that is not primary but got: %+v", added)
+		}
+
+		if len(added.Renditions) != 3 || added.Url != added.Renditions["large"] {
+			t.Errorf("expected every rendition with the large one as the url but got: %+v", added)
+		}
+	})
+
+	t.Run("reject a file that is not an image whatever its content type", func(t *testing.T) {
+		_, err := sut.AddImage(ctx, 1, uploader.FileInput{Name: "gallery.png", Size: 6, MimeType: "image/png", Content: []byte("GIF89a")})
+		if errorService.GetError(err).E != service.ErrFileNotSupportedProducts {
+			t.Errorf("expected error %v but got %v", service.ErrFileNotSupportedProducts, err)
+		}
+	})
+
+	t.Run("remove the uploaded renditions when the image can not be saved", func(t *testing.T) {
+		failing := service.ProductsService{ProductsStore: failingImagesStore{productsStore}, Transaction: &transactionFake{state: initial}, Uploader: files, Imaging: imaging.NewPipeline()}
+
+		if _, err := failing.AddImage(ctx, 1, image); err == nil {
+			t.Fatal("expected error but got nil")
+		}
+
+		expected := []string{"3-gallery-thumbnail.jpeg", "4-gallery-medium.jpeg", "5-gallery-large.jpeg"}
+		if !slices.Equal(files.deleted, expected) {
+			t.Errorf("expected the orphaned files %v to be deleted but got: %v", expected, files.deleted)
+		}
+	})
+
+	t.Run("refuse an image when the gallery filled up meanwhile", func(t *testing.T) {
+		full := service.ProductsService{ProductsStore: fullGalleryStore{productsStore}, Transaction: &transactionFake{state: initial}, Uploader: files, Imaging: imaging.NewPipeline()}
+		deleted := len(files.deleted)
+
+		_, err := full.AddImage(ctx, 1, image)
+		if errorService.GetError(err).E != service.ErrImageLimit {
+			t.Fatalf("expected error %v but got: %v", service.ErrImageLimit, err)
+		}
+
+		if len(files.deleted) != deleted+3 {
+			t.Errorf("expected the uploaded renditions to be deleted but got: %v", files.deleted)
+		}
+	})
+
+	t.Run("reorder must list every image once", func(t *testing.T) {
+		err := sut.ReorderImages(ctx, 1, dto.ReorderImagesRequest{ImageIds: []int{2}})
+		if errorService.GetError(err).E != service.ErrImageOrder {
+			t.Errorf("expected error %v but got %v", service.ErrImageOrder, err)
+		}
+
+		if err := sut.ReorderImages(ctx, 1, dto.ReorderImagesRequest{ImageIds: []int{2, 1}}); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		product, _ := sut.FindById(ctx, 1)
+		if product.Images[0].Id != 2 {
+			t.Errorf("expected the second image to be first but got: %+v", product.Images)
+		}
+	})
+
+	t.Run("the primary image can only be deleted once another is primary", func(t *testing.T) {
+		err := sut.DeleteImage(ctx, 1, 1)
+		if errorService.GetError(err).E != service.ErrPrimaryImage {
+			t.Fatalf("expected error %v but got %v", service.ErrPrimaryImage, err)
+		}
+
+		if err := sut.SetPrimaryImage(ctx, 1, 2); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if err := sut.DeleteImage(ctx, 1, 1); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		product, _ := sut.FindById(ctx, 1)
+		if len(product.Images) != 1 || product.Image != files.uploaded[2] {
+			t.Errorf("expected only the new primary image to be left but got: %+v", product.Images)
+		}
+
+		if files.deleted[len(files.deleted)-1] != "lizzy.jpeg" {
+			t.Errorf("expected the file of the deleted image to be removed but got: %v", files.deleted)
+		}
+	})
+	t.Run("destroy the product even when a file can not be deleted", func(t *testing.T) {
+		product, _ := sut.FindById(ctx, 1)
+		renditions := product.Images[0].Renditions
+
+		files.failing = map[string]bool{files.FileKey(renditions["large"]): true}
+		t.Cleanup(func() { files.failing = nil })
+
+		if err := sut.Destroy(ctx, 1); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		for _, name := range []string{"thumbnail", "medium"} {
+			if !slices.Contains(files.deleted, files.FileKey(renditions[name])) {
+				t.Errorf("expected the %v rendition to be deleted but got: %v", name, files.deleted)
+			}
+		}
+	})
+}
+
+func TestProductsPresignedUpload(t *testing.T) {
+	var (
+		ctx           = context.Background()
+		productsStore = &products.InMemoryProducts{}
+		files         = &uploaderFake{staged: map[string][]byte{}}
+		sut           = service.ProductsService{ProductsStore: productsStore, Transaction: &transactionFake{state: initial}, Uploader: files, Imaging: imaging.NewPipeline()}
+	)
+
+	content, err := os.ReadFile("file_test/lizzy.jpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metadata := dto.CreateProductMetadataRequest{
+		Name:     "Lizzy Blend",
+		Roasted:  "light",
+		Bean:     1,
+		Form:     1,
+		Variants: []dto.CreateVariantRequest{{Sku: "LIZZY-250", Weight: 250, Price: 17.5, Quantity: 10}},
+	}
+
+	t.Run("create a product with an image uploaded to a presigned target", func(t *testing.T) {
+		target, err := sut.PresignImage(ctx, dto.PresignImageRequest{Name: "lizzy.jpeg", MimeType: "image/jpeg", Size: int64(len(content))})
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		files.staged[target.Key] = content
+
+		metadata.ImageKey = target.Key
+		if err := sut.Create(ctx, metadata, uploader.FileInput{}); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		product, err := sut.FindById(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if product.Image == "" || len(product.ImageRenditions) != len(imaging.DefaultRenditions) {
+			t.Errorf("expected the renditions of the upload but got: %v %v", product.Image, product.ImageRenditions)
+		}
+
+		if !slices.Contains(files.deleted, target.Key) {
+			t.Errorf("expected the upload to be deleted once processed but got: %v", files.deleted)
+		}
+	})
+
+	t.Run("fail when the image was not uploaded", func(t *testing.T) {
+		metadata.Name = "Nadia Blend"
+		metadata.ImageKey = "uploads/missing.jpeg"
+
+		err := sut.Create(ctx, metadata, uploader.FileInput{})
+		if errorService.GetError(err).E != service.ErrNotFoundUpload {
+			t.Errorf("expected error %v but got %v", service.ErrNotFoundUpload, err)
+		}
+	})
+
+	t.Run("fail when the upload is too big", func(t *testing.T) {
+		files.staged["uploads/big.jpeg"] = make([]byte, service.MAX_IMAGE_SIZE+1)
+		metadata.ImageKey = "uploads/big.jpeg"
+
+		err := sut.Update(ctx, 1, dto.UpdateProductMetadataRequest{ImageKey: "uploads/big.jpeg"}, uploader.FileInput{})
+		if errorService.GetError(err).E != service.ErrFileTooBigProducts {
+			t.Errorf("expected error %v but got %v", service.ErrFileTooBigProducts, err)
+		}
+	})
+
+	t.Run("fail when both a file and a key are sent", func(t *testing.T) {
+		files.staged["uploads/lizzy.jpeg"] = content
+		file := uploader.FileInput{Name: "lizzy.jpeg", Size: int64(len(content)), Content: content}
+
+		err := sut.Update(ctx, 1, dto.UpdateProductMetadataRequest{ImageKey: "uploads/lizzy.jpeg"}, file)
+		if errorService.GetError(err).E != service.ErrImageSourceProducts {
+			t.Errorf("expected error %v but got %v", service.ErrImageSourceProducts, err)
+		}
+	})
+}
+
+type uploaderFake struct {
+	uploaded []string
+	deleted  []string
+	// staged are the files clients uploaded to a presigned target by key
+	staged map[string][]byte
+	// stored are the files listed by the storage
+	stored []uploader.StoredFile
+	// failing are the keys that can not be deleted
+	failing map[string]bool
+}
+
+func (u *uploaderFake) UploadFile(ctx context.Context, file uploader.FileInput) (string, error) {
+	url := "https://app.ufs.sh/f/" + strconv.Itoa(len(u.uploaded)) + "-" + file.Name
+	u.uploaded = append(u.uploaded, url)
+	return url, nil
+}
+
+func (u *uploaderFake) DeleteFile(ctx context.Context, filename string) error {
+	if u.failing[filename] {
+		return errors.New("delete file failed")
+	}
+
+	u.deleted = append(u.deleted, filename)
+	return nil
+}
+
+func (u *uploaderFake) FileKey(url string) string {
+	key, _ := strings.CutPrefix(url, "https://app.ufs.sh/f/")
+	return key
+}
+
+func (u *uploaderFake) PresignUpload(ctx context.Context, file uploader.PresignInput) (uploader.UploadTarget, error) {
+	key := uploader.UploadsPrefix + strconv.Itoa(len(u.staged)) + "-" + file.Name
+	return uploader.UploadTarget{Key: key, Url: "https://app.ufs.sh/f/" + key, Method: "PUT"}, nil
+}
+
+func (u *uploaderFake) ReadFile(ctx context.Context, key string, limit int64) (uploader.FileInput, error) {
+	content, ok := u.staged[key]
+	if !ok {
+		return uploader.FileInput{}, uploader.ErrFileNotFound
+	}
+
+	if int64(len(content)) > limit {
+		return uploader.FileInput{}, uploader.ErrFileTooLarge
+	}
+
+	return uploader.FileInput{Name: key, Size: int64(len(content)), Content: content}, nil
+}
+
+func (u *uploaderFake) List(ctx context.Context) ([]uploader.StoredFile, error) {
+	return u.stored, nil
+}
+
+// fullGalleryStore counts a full gallery, like images added by another request.
+type fullGalleryStore struct {
+	*products.InMemoryProducts
+}
+
+func (fullGalleryStore) CountImages(ctx context.Context, _ *sql.Tx, productId int) (int, error) {
+	return service.MAX_PRODUCT_IMAGES, nil
+}
+
+type failingImagesStore struct {
+	*products.InMemoryProducts
+}
+
+func (failingImagesStore) InsertImage(ctx context.Context, _ *sql.Tx, image models.Image) (int, error) {
+	return 0, errors.New("insert image failed")
+}
@@ -4,8 +4,15 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/faizisyellow/indocoffee/internal/audit"
+	"github.com/faizisyellow/indocoffee/internal/cache"
+	"github.com/faizisyellow/indocoffee/internal/db"
 	"github.com/faizisyellow/indocoffee/internal/models"
 	"github.com/faizisyellow/indocoffee/internal/repository/roles"
 	"github.com/faizisyellow/indocoffee/internal/service/dto"
@@ -13,11 +20,37 @@ import (
 )
 
 type RolesServices struct {
-	RolesStore roles.Roles
+	RolesStore  roles.Roles
+	Transaction db.Transactioner
+	// Cache keeps roles with their permissions,
+	// every change to a role must invalidate it.
+	Cache cache.Cache
+	Audit audit.Recorder
 }
 
 const (
 	SUCCESS_CREATE_ROLES_MESSAGE = "success create new role"
+
+	ROLES_CACHE_TTL = 10 * time.Minute
+)
+
+// Permissions checked by the API, roles are granted them through the roles endpoints.
+const (
+	PERMISSION_ROLES_MANAGE    = "roles:manage"
+	PERMISSION_USERS_MANAGE    = "users:manage"
+	PERMISSION_API_KEYS_MANAGE = "api-keys:manage"
+	PERMISSION_BEANS_WRITE     = "beans:write"
+	PERMISSION_FORMS_WRITE     = "forms:write"
+	PERMISSION_PRODUCTS_WRITE  = "products:write"
+	PERMISSION_CARTS_WRITE     = "carts:write"
+	PERMISSION_ORDERS_CREATE   = "orders:create"
+	PERMISSION_ORDERS_READ     = "orders:read"
+	PERMISSION_ORDERS_ROAST    = "orders:roast"
+	PERMISSION_ORDERS_SHIP     = "orders:ship"
+	PERMISSION_ORDERS_CANCEL   = "orders:cancel"
+	PERMISSION_ORDERS_COMPLETE = "orders:complete"
+	PERMISSION_AUDIT_READ      = "audit:read"
+	PERMISSION_MEDIA_MANAGE    = "media:manage"
 )
 
 var (
@@ -25,22 +58,50 @@ var (
 	ErrInternalRole = errors.New("roles: encountered an internal error")
 	ErrNotFoundRole = errors.New("roles: no such as role")
 	ErrUpdateRole   = errors.New("roles: fields not specify")
+
+	ErrUnknownPermission = errors.New("roles: no such as permission")
 )
 
 func (Roles *RolesServices) Create(ctx context.Context, req dto.CreateRoleRequest) (string, error) {
 
+	if err := Roles.validatePermissions(ctx, req.Permissions); err != nil {
+		return "", err
+	}
+
 	newRole := models.RolesModel{
 		Name:  req.Name,
 		Level: req.Level,
 	}
 
-	err := Roles.RolesStore.Insert(ctx, newRole)
-	if err != nil {
-		if strings.Contains(err.Error(), CONFLICT_CODE) {
-			return "", errorService.New(ErrConflictRole, err)
+	// a role is created with its permissions or not at all
+	err := Roles.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+		id, err := Roles.RolesStore.Insert(ctx, tx, newRole)
+		if err != nil {
+			if strings.Contains(err.Error(), CONFLICT_CODE) {
+				return errorService.New(ErrConflictRole, err)
+			}
+
+			return errorService.New(ErrInternalRole, err)
+		}
+		newRole.Id = id
+
+		if len(req.Permissions) != 0 {
+			if err := Roles.RolesStore.SetPermissions(ctx, tx, id, req.Permissions); err != nil {
+				return errorService.New(ErrInternalRole, err)
+			}
 		}
 
-		return "", errorService.New(ErrInternalRole, err)
+		created := newRole
+		created.Permissions = req.Permissions
+
+		if err := recordAudit(ctx, tx, Roles.Audit, audit.Entry{Action: AUDIT_ROLES_CREATE, TargetType: "role", TargetId: strconv.Itoa(created.Id), After: created}); err != nil {
+			return errorService.New(ErrInternalRole, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
 	}
 
 	return SUCCESS_CREATE_ROLES_MESSAGE, nil
@@ -53,11 +114,26 @@ func (Roles *RolesServices) FindAll(ctx context.Context) ([]models.RolesModel, e
 		return nil, errorService.New(ErrInternalRole, err)
 	}
 
+	for i := range roles {
+		roles[i].Permissions, err = Roles.RolesStore.GetPermissions(ctx, roles[i].Id)
+		if err != nil {
+			return nil, errorService.New(ErrInternalRole, err)
+		}
+	}
+
 	return roles, nil
 }
 
+// FindById gets a role with its permissions, the role is served from the cache when possible.
 func (Roles *RolesServices) FindById(ctx context.Context, id int) (models.RolesModel, error) {
 
+	var role models.RolesModel
+
+	// a failing cache should not fail the request, fall back to the database
+	if found, err := Roles.Cache.Get(ctx, roleCacheKey(id), &role); err == nil && found {
+		return role, nil
+	}
+
 	role, err := Roles.RolesStore.GetById(ctx, id)
 	if err != nil {
 		switch err {
@@ -68,6 +144,13 @@ func (Roles *RolesServices) FindById(ctx context.Context, id int) (models.RolesM
 		}
 	}
 
+	role.Permissions, err = Roles.RolesStore.GetPermissions(ctx, role.Id)
+	if err != nil {
+		return models.RolesModel{}, errorService.New(ErrInternalRole, err)
+	}
+
+	_ = Roles.Cache.Set(ctx, roleCacheKey(id), role, ROLES_CACHE_TTL)
+
 	return role, nil
 }
 
@@ -93,10 +176,16 @@ func (Roles *RolesServices) Update(ctx context.Context, id int, req dto.UpdateRo
 		return err
 	}
 
-	if req.Name == nil && req.Level == nil {
+	if req.Name == nil && req.Level == nil && req.Permissions == nil {
 		return errorService.New(ErrUpdateRole, errors.New("roles: update fields request not specify"))
 	}
 
+	if err := Roles.validatePermissions(ctx, req.Permissions); err != nil {
+		return err
+	}
+
+	before := existingRole
+
 	if req.Name != nil {
 		existingRole.Name = *req.Name
 	}
@@ -105,16 +194,35 @@ func (Roles *RolesServices) Update(ctx context.Context, id int, req dto.UpdateRo
 		existingRole.Level = *req.Level
 	}
 
-	err = Roles.RolesStore.Update(ctx, existingRole)
-	if err != nil {
-		if strings.Contains(err.Error(), CONFLICT_CODE) {
-			return errorService.New(ErrConflictRole, err)
+	// the role, its permissions and the audit entry are written together
+	err = Roles.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+		err := Roles.RolesStore.Update(ctx, tx, existingRole)
+		if err != nil {
+			if strings.Contains(err.Error(), CONFLICT_CODE) {
+				return errorService.New(ErrConflictRole, err)
+			}
+
+			return errorService.New(ErrInternalRole, err)
 		}
 
-		return errorService.New(ErrInternalRole, err)
+		if req.Permissions != nil {
+			if err := Roles.RolesStore.SetPermissions(ctx, tx, existingRole.Id, req.Permissions); err != nil {
+				return errorService.New(ErrInternalRole, err)
+			}
+			existingRole.Permissions = req.Permissions
+		}
+
+		if err := recordAudit(ctx, tx, Roles.Audit, audit.Entry{Action: AUDIT_ROLES_UPDATE, TargetType: "role", TargetId: strconv.Itoa(existingRole.Id), Before: before, After: existingRole}); err != nil {
+			return errorService.New(ErrInternalRole, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return Roles.invalidate(ctx, existingRole.Id)
 }
 
 func (Roles *RolesServices) Delete(ctx context.Context, id int) error {
@@ -124,23 +232,106 @@ func (Roles *RolesServices) Delete(ctx context.Context, id int) error {
 		return err
 	}
 
-	err = Roles.RolesStore.Delete(ctx, role.Id)
+	err = Roles.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+		if err := Roles.RolesStore.Delete(ctx, tx, role.Id); err != nil {
+			return errorService.New(ErrInternalRole, err)
+		}
+
+		if err := recordAudit(ctx, tx, Roles.Audit, audit.Entry{Action: AUDIT_ROLES_DELETE, TargetType: "role", TargetId: strconv.Itoa(role.Id), Before: role}); err != nil {
+			return errorService.New(ErrInternalRole, err)
+		}
+
+		return nil
+	})
 	if err != nil {
+		return err
+	}
+
+	return Roles.invalidate(ctx, role.Id)
+}
+
+// Remove empties the trash, every destroyed role is recorded as a target.
+func (Roles *RolesServices) Remove(ctx context.Context) error {
+
+	return Roles.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+		ids, err := Roles.RolesStore.DestroyMany(ctx, tx)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return errorService.New(ErrNotFoundRole, err)
+			default:
+				return errorService.New(ErrConflictRole, err)
+			}
+		}
+
+		for _, id := range ids {
+			if err := recordAudit(ctx, tx, Roles.Audit, audit.Entry{Action: AUDIT_ROLES_EMPTY_TRASH, TargetType: "role", TargetId: strconv.Itoa(id)}); err != nil {
+				return errorService.New(ErrInternalRole, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (Roles *RolesServices) FindPermissions(ctx context.Context) ([]models.PermissionModel, error) {
+
+	permissions, err := Roles.RolesStore.GetAllPermissions(ctx)
+	if err != nil {
+		return nil, errorService.New(ErrInternalRole, err)
+	}
+
+	return permissions, nil
+}
+
+// HasPermission checks the role has been granted the permission.
+// A deleted role has no permission.
+func (Roles *RolesServices) HasPermission(ctx context.Context, roleId int, permission string) (bool, error) {
+
+	role, err := Roles.FindById(ctx, roleId)
+	if err != nil {
+		if errorService.GetError(err).E == ErrNotFoundRole {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return slices.Contains(role.Permissions, permission), nil
+}
+
+// invalidate removes the cached role, so the next lookup reads the change.
+func (Roles *RolesServices) invalidate(ctx context.Context, roleId int) error {
+
+	if err := Roles.Cache.Delete(ctx, roleCacheKey(roleId)); err != nil {
 		return errorService.New(ErrInternalRole, err)
 	}
 
 	return nil
 }
 
-func (Roles *RolesServices) Remove(ctx context.Context) error {
+func roleCacheKey(roleId int) string {
+	return fmt.Sprintf("roles:%d", roleId)
+}
 
-	err := Roles.RolesStore.DestroyMany(ctx)
+// validatePermissions makes sure every permission name is known.
+func (Roles *RolesServices) validatePermissions(ctx context.Context, permissions []string) error {
+
+	if len(permissions) == 0 {
+		return nil
+	}
+
+	known, err := Roles.RolesStore.GetAllPermissions(ctx)
 	if err != nil {
-		switch err {
-		case sql.ErrNoRows:
-			return errorService.New(ErrNotFoundRole, err)
-		default:
-			return errorService.New(ErrConflictRole, err)
+		return errorService.New(ErrInternalRole, err)
+	}
+
+	for _, permission := range permissions {
+		found := slices.ContainsFunc(known, func(p models.PermissionModel) bool {
+			return p.Name == permission
+		})
+
+		if !found {
+			return errorService.New(ErrUnknownPermission, errors.New("roles: no such as permission "+permission))
 		}
 	}
 
@@ -0,0 +1,86 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/faizisyellow/indocoffee/internal/cache"
+	"github.com/faizisyellow/indocoffee/internal/models"
+	"github.com/faizisyellow/indocoffee/internal/repository/roles"
+	"github.com/faizisyellow/indocoffee/internal/service"
+	"github.com/faizisyellow/indocoffee/internal/service/dto"
+)
+
+func TestRolesServiceCache(t *testing.T) {
+	newSut := func() (*service.RolesServices, *roles.InMemoryRoles) {
+		store := &roles.InMemoryRoles{
+			Roles: []models.RolesModel{
+				{Id: 1, Name: "roaster", Level: 2, Permissions: []string{service.PERMISSION_ORDERS_ROAST}},
+			},
+			Catalogue: []models.PermissionModel{
+				{Id: 1, Name: service.PERMISSION_ORDERS_ROAST},
+				{Id: 2, Name: service.PERMISSION_ORDERS_SHIP},
+			},
+		}
+
+		return &service.RolesServices{RolesStore: store, Transaction: &transactionFake{state: initial}, Cache: &cache.InMemoryCache{}}, store
+	}
+
+	t.Run("permissions are served from the cache", func(t *testing.T) {
+		var (
+			ctx        = context.Background()
+			sut, store = newSut()
+		)
+
+		if granted, _ := sut.HasPermission(ctx, 1, service.PERMISSION_ORDERS_ROAST); !granted {
+			t.Fatal("expected orders:roast to be granted")
+		}
+
+		// changed behind the service's back, the cache has not been invalidated
+		store.Roles[0].Permissions = nil
+
+		if granted, _ := sut.HasPermission(ctx, 1, service.PERMISSION_ORDERS_ROAST); !granted {
+			t.Error("expected the cached permissions to be used")
+		}
+	})
+
+	t.Run("update invalidates the cached role", func(t *testing.T) {
+		var (
+			ctx    = context.Background()
+			sut, _ = newSut()
+		)
+
+		if granted, _ := sut.HasPermission(ctx, 1, service.PERMISSION_ORDERS_SHIP); granted {
+			t.Fatal("expected orders:ship not to be granted yet")
+		}
+
+		err := sut.Update(ctx, 1, dto.UpdateRoleRequest{Permissions: []string{service.PERMISSION_ORDERS_SHIP}})
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if granted, _ := sut.HasPermission(ctx, 1, service.PERMISSION_ORDERS_SHIP); !granted {
+			t.Error("expected orders:ship to be granted after update")
+		}
+	})
+
+	t.Run("delete invalidates the cached role", func(t *testing.T) {
+		var (
+			ctx    = context.Background()
+			sut, _ = newSut()
+		)
+
+		if granted, _ := sut.HasPermission(ctx, 1, service.PERMISSION_ORDERS_ROAST); !granted {
+			t.Fatal("expected orders:roast to be granted")
+		}
+
+		if err := sut.Delete(ctx, 1); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		granted, err := sut.HasPermission(ctx, 1, service.PERMISSION_ORDERS_ROAST)
+		if err != nil || granted {
+			t.Errorf("expected deleted role to have no permission but got %v, %v", granted, err)
+		}
+	})
+}
@@ -3,32 +3,56 @@ package service
 import (
 	"context"
 	"database/sql"
+	"time"
 
+	"github.com/faizisyellow/indocoffee/internal/audit"
+	"github.com/faizisyellow/indocoffee/internal/cache"
 	"github.com/faizisyellow/indocoffee/internal/db"
 	loginLimiter "github.com/faizisyellow/indocoffee/internal/limiter/login"
+	"github.com/faizisyellow/indocoffee/internal/mailer"
 	"github.com/faizisyellow/indocoffee/internal/models"
 	"github.com/faizisyellow/indocoffee/internal/repository"
+	"github.com/faizisyellow/indocoffee/internal/repository/addresses"
+	"github.com/faizisyellow/indocoffee/internal/repository/apikeys"
+	"github.com/faizisyellow/indocoffee/internal/repository/audits"
 	"github.com/faizisyellow/indocoffee/internal/repository/beans"
 	"github.com/faizisyellow/indocoffee/internal/repository/carts"
 	"github.com/faizisyellow/indocoffee/internal/repository/forms"
 	"github.com/faizisyellow/indocoffee/internal/repository/invitations"
 	"github.com/faizisyellow/indocoffee/internal/repository/orders"
 	"github.com/faizisyellow/indocoffee/internal/repository/products"
+	"github.com/faizisyellow/indocoffee/internal/repository/resets"
 	"github.com/faizisyellow/indocoffee/internal/repository/roles"
+	"github.com/faizisyellow/indocoffee/internal/repository/sessions"
+	"github.com/faizisyellow/indocoffee/internal/repository/twofactors"
 	"github.com/faizisyellow/indocoffee/internal/repository/users"
 	"github.com/faizisyellow/indocoffee/internal/service/dto"
 	"github.com/faizisyellow/indocoffee/internal/uploader"
+	"github.com/faizisyellow/indocoffee/internal/uploader/imaging"
 	"github.com/faizisyellow/indocoffee/internal/utils"
 )
 
 type UsersServiceInterface interface {
-	RegisterAccount(ctx context.Context, req RegisterRequest) (*RegisterResponse, error)
+	RegisterAccount(ctx context.Context, req RegisterRequest) error
 	ActivateAccount(ctx context.Context, token string) error
+	ResendActivation(ctx context.Context, req ResendActivationRequest) error
+	ForgotPassword(ctx context.Context, req ForgotPasswordRequest) error
+	ResetPassword(ctx context.Context, req ResetPasswordRequest) error
 	Login(ctx context.Context, req LoginRequest) (*models.User, error)
-	DeleteAccount(ctx context.Context, id int) error
 	FindUserById(ctx context.Context, id int) (*models.User, error)
+	FindIdentity(ctx context.Context, usrId int) (*models.User, error)
 	FindUsersCart(ctx context.Context, usrId int) (dto.GetUsersCartResponse, error)
 	FindUsersOrders(ctx context.Context, r repository.PaginatedOrdersQuery, usrId int) ([]models.Order, error)
+	UpdateProfile(ctx context.Context, usrId int, req UpdateProfileRequest) (*models.User, error)
+	ChangePassword(ctx context.Context, usrId int, req ChangePasswordRequest) error
+	RequestEmailChange(ctx context.Context, usrId int, req ChangeEmailRequest) error
+	ConfirmEmailChange(ctx context.Context, token string) error
+	FindUsers(ctx context.Context, r repository.PaginatedUsersQuery) ([]models.User, error)
+	ChangeRole(ctx context.Context, adminId, usrId int, req ChangeUserRoleRequest) error
+	Deactivate(ctx context.Context, adminId, usrId int) error
+	Reactivate(ctx context.Context, usrId int) error
+	Unlock(ctx context.Context, usrId int) error
+	ForcePasswordReset(ctx context.Context, usrId int) error
 }
 
 type RolesServiceInterface interface {
@@ -39,6 +63,8 @@ type RolesServiceInterface interface {
 	Update(ctx context.Context, id int, req dto.UpdateRoleRequest) error
 	Delete(ctx context.Context, id int) error
 	Remove(ctx context.Context) error
+	FindPermissions(ctx context.Context) ([]models.PermissionModel, error)
+	HasPermission(ctx context.Context, roleId int, permission string) (bool, error)
 }
 
 type BeansServiceInterface interface {
@@ -65,36 +91,100 @@ type ProductsServiceInterface interface {
 	FindProducts(ctx context.Context, r repository.PaginatedProductsQuery) ([]models.Product, error)
 	Update(ctx context.Context, id int, req dto.UpdateProductMetadataRequest, file uploader.FileInput) error
 	Destroy(ctx context.Context, id int) error
-	DecreaseQuantityProduct(ctx context.Context, tx *sql.Tx, prdId, quantity int) error
-	IncreaseQuantityProduct(ctx context.Context, tx *sql.Tx, prdId, quantity int) error
+	DecreaseQuantityVariant(ctx context.Context, tx *sql.Tx, variantId, quantity int) error
+	IncreaseQuantityVariant(ctx context.Context, tx *sql.Tx, variantId, quantity int) error
+	FindVariantById(ctx context.Context, id int) (models.Variant, error)
+	CreateVariant(ctx context.Context, productId int, req dto.CreateVariantRequest) (int, error)
+	UpdateVariant(ctx context.Context, productId, variantId int, req dto.UpdateVariantRequest) error
+	DeleteVariant(ctx context.Context, productId, variantId int) error
+	AddImage(ctx context.Context, productId int, file uploader.FileInput) (models.Image, error)
+	ReorderImages(ctx context.Context, productId int, req dto.ReorderImagesRequest) error
+	SetPrimaryImage(ctx context.Context, productId, imageId int) error
+	DeleteImage(ctx context.Context, productId, imageId int) error
+	PresignImage(ctx context.Context, req dto.PresignImageRequest) (dto.UploadTargetResponse, error)
 }
 
 type CartsServiceInterface interface {
 	Create(ctx context.Context, req dto.CreateCartRequest, userId int) error
-	IncrementItem(ctx context.Context, cartId int) error
-	DecrementItem(ctx context.Context, cartId int) error
+	IncrementItem(ctx context.Context, cart models.Cart) error
+	DecrementItem(ctx context.Context, cart models.Cart) error
 	FindById(ctx context.Context, id int) (models.Cart, error)
-	Destroy(ctx context.Context, id int) error
+	Destroy(ctx context.Context, cart models.Cart) error
 }
 
 type OrdersServiceInterface interface {
 	Create(ctx context.Context, idempKey string, req dto.CreateOrderRequest, usrId int) (string, error)
 	ExecuteItems(ctx context.Context, orderId string) error
 	FindById(ctx context.Context, orderId string) (models.Order, error)
-	CancelOrder(ctx context.Context, orderId string) error
+	CancelOrder(ctx context.Context, order models.Order) error
 	ShipOrder(ctx context.Context, orderId string) error
-	CompleteOrder(ctx context.Context, orderId string) error
+	CompleteOrder(ctx context.Context, order models.Order) error
 	FindOrders(ctx context.Context, r repository.PaginatedOrdersQuery) ([]models.Order, error)
 }
 
+type AddressesServiceInterface interface {
+	Create(ctx context.Context, usrId int, req dto.CreateAddressRequest) (models.Address, error)
+	FindAll(ctx context.Context, usrId int) ([]models.Address, error)
+	FindById(ctx context.Context, usrId, id int) (models.Address, error)
+	FindDefault(ctx context.Context, usrId int) (models.Address, error)
+	Update(ctx context.Context, usrId, id int, req dto.UpdateAddressRequest) (models.Address, error)
+	SetDefault(ctx context.Context, usrId, id int) error
+	Delete(ctx context.Context, usrId, id int) error
+}
+
+type SessionsServiceInterface interface {
+	Create(ctx context.Context, usrId int) (dto.SessionTokens, error)
+	Refresh(ctx context.Context, refreshToken string) (dto.SessionTokens, error)
+	Verify(ctx context.Context, sessionId string, usrId int) error
+	Revoke(ctx context.Context, sessionId string) error
+	RevokeAll(ctx context.Context, usrId int) error
+}
+
+type TwoFactorServiceInterface interface {
+	Begin(ctx context.Context, user models.User, ip string) (*dto.TwoFactorChallenge, error)
+	Enroll(ctx context.Context, usrId int) (dto.TwoFactorEnrollment, error)
+	EnrollWithChallenge(ctx context.Context, req dto.TwoFactorChallengeRequest) (dto.TwoFactorEnrollment, error)
+	Confirm(ctx context.Context, usrId int, req dto.ConfirmTwoFactorRequest) error
+	Verify(ctx context.Context, req dto.VerifyTwoFactorRequest) (*models.User, error)
+	Disable(ctx context.Context, usrId int, req dto.DisableTwoFactorRequest) error
+}
+
+type ApiKeysServiceInterface interface {
+	Create(ctx context.Context, adminId int, req dto.CreateApiKeyRequest) (dto.CreatedApiKey, error)
+	FindAll(ctx context.Context) ([]models.ApiKey, error)
+	Revoke(ctx context.Context, id int) error
+	Authenticate(ctx context.Context, plaintext string) (models.ApiKey, error)
+}
+
+type PrivacyServiceInterface interface {
+	Export(ctx context.Context, usrId int) (dto.UserExport, error)
+	DeleteAccount(ctx context.Context, usrId int) error
+}
+
+type AuditServiceInterface interface {
+	FindAll(ctx context.Context, qry repository.PaginatedAuditQuery) ([]models.AuditLog, error)
+}
+
+type MediaServiceInterface interface {
+	CollectGarbage(ctx context.Context, dryRun bool) (dto.MediaGarbageReport, error)
+	Run(ctx context.Context, interval time.Duration)
+}
+
 type Service struct {
-	UsersService    UsersServiceInterface
-	RolesService    RolesServiceInterface
-	BeansService    BeansServiceInterface
-	FormsService    FormsServiceInterface
-	ProductsService ProductsServiceInterface
-	CartsService    CartsServiceInterface
-	OrdersService   OrdersServiceInterface
+	UsersService     UsersServiceInterface
+	RolesService     RolesServiceInterface
+	BeansService     BeansServiceInterface
+	FormsService     FormsServiceInterface
+	ProductsService  ProductsServiceInterface
+	CartsService     CartsServiceInterface
+	OrdersService    OrdersServiceInterface
+	SessionsService  SessionsServiceInterface
+	AddressesService AddressesServiceInterface
+	TwoFactorService TwoFactorServiceInterface
+	ApiKeysService   ApiKeysServiceInterface
+	PrivacyService   PrivacyServiceInterface
+	AuditService     AuditServiceInterface
+	MediaService     MediaServiceInterface
 }
 
 var (
@@ -111,15 +201,30 @@ func New(
 	rolesStore roles.Roles,
 	productsStore products.Products,
 	uploadService uploader.Uploader,
+	imagePipeline imaging.Pipeline,
 	tx db.Transactioner,
 	uuid utils.Token,
 	ulid utils.Token,
 	cartsStore carts.Carts,
 	ordersStore orders.Orders,
+	sessionsStore sessions.Sessions,
+	resetsStore resets.Resets,
+	mailer mailer.Mailer,
+	cache cache.Cache,
+	addressesStore addresses.Addresses,
+	twoFactorsStore twofactors.TwoFactors,
+	twoFactorPolicy TwoFactorPolicy,
+	apiKeysStore apikeys.ApiKeys,
+	auditsStore audits.Audits,
 ) *Service {
+	recorder := &audit.StoreRecorder{Store: auditsStore}
+
 	productsService := &ProductsService{
 		ProductsStore: productsStore,
+		Transaction:   tx,
 		Uploader:      uploadService,
+		Audit:         recorder,
+		Imaging:       imagePipeline,
 	}
 
 	cartsService := &CartsService{
@@ -127,28 +232,79 @@ func New(
 		ProductsService: productsService,
 	}
 
+	addressesService := &AddressesService{
+		AddressesStore: addressesStore,
+		Transaction:    tx,
+	}
+
 	usersService := &UsersServices{
 		UsersStore:       usersStore,
 		InvitationsStore: invitationsStore,
 		Token:            uuid,
 		Transaction:      tx,
 		LoginLimiter:     loginLimiter,
+		ResetsStore:      resetsStore,
+		SessionsStore:    sessionsStore,
+		Mailer:           mailer,
+		RolesStore:       rolesStore,
+		Audit:            recorder,
+		Cache:            cache,
 	}
 
 	return &Service{
 		UsersService:    usersService,
-		BeansService:    &BeansServices{BeansStore: beansStore},
-		FormsService:    &FormsServices{FormsStore: formsStore},
-		RolesService:    &RolesServices{RolesStore: rolesStore},
+		BeansService:    &BeansServices{BeansStore: beansStore, Transaction: tx, Audit: recorder},
+		FormsService:    &FormsServices{FormsStore: formsStore, Transaction: tx, Audit: recorder},
+		RolesService:    &RolesServices{RolesStore: rolesStore, Transaction: tx, Cache: cache, Audit: recorder},
 		ProductsService: productsService,
 		CartsService:    cartsService,
 		OrdersService: &OrdersService{
-			CartsStore:      cartsStore,
-			ProductsService: productsService,
-			UsersService:    usersService,
-			OrderStore:      ordersStore,
+			CartsStore:       cartsStore,
+			ProductsService:  productsService,
+			UsersService:     usersService,
+			AddressesService: addressesService,
+			OrderStore:       ordersStore,
+			Transaction:      tx,
+			Uuid:             ulid,
+			Mailer:           mailer,
+			Audit:            recorder,
+		},
+		SessionsService: &SessionsService{
+			SessionsStore: sessionsStore,
+			Transaction:   tx,
+			Token:         utils.UUID{},
+			Id:            ulid,
+		},
+		AddressesService: addressesService,
+		TwoFactorService: &TwoFactorService{
+			TwoFactorsStore: twoFactorsStore,
+			UsersStore:      usersStore,
+			RolesStore:      rolesStore,
 			Transaction:     tx,
-			Uuid:            ulid,
+			LoginLimiter:    loginLimiter,
+			Token:           utils.UUID{},
+			Policy:          twoFactorPolicy,
+		},
+		ApiKeysService: &ApiKeysService{
+			ApiKeysStore: apiKeysStore,
+			RolesStore:   rolesStore,
+			Transaction:  tx,
+			Audit:        recorder,
+		},
+		PrivacyService: &PrivacyService{
+			UsersService:     usersService,
+			UsersStore:       usersStore,
+			AddressesStore:   addressesStore,
+			OrdersStore:      ordersStore,
+			InvitationsStore: invitationsStore,
+			Transaction:      tx,
+		},
+		AuditService: &AuditService{AuditsStore: auditsStore},
+		MediaService: &MediaService{
+			ProductsStore: productsStore,
+			Uploader:      uploadService,
+			Audit:         recorder,
+			GracePeriod:   MEDIA_GC_GRACE_PERIOD,
 		},
 	}
 }
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/db"
+	"github.com/faizisyellow/indocoffee/internal/models"
+	"github.com/faizisyellow/indocoffee/internal/repository/sessions"
+	"github.com/faizisyellow/indocoffee/internal/service/dto"
+	errorService "github.com/faizisyellow/indocoffee/internal/service/error"
+	"github.com/faizisyellow/indocoffee/internal/utils"
+)
+
+type SessionsService struct {
+	SessionsStore sessions.Sessions
+	Transaction   db.Transactioner
+	// Token generates the plaintext refresh token.
+	Token utils.Token
+	// Id generates the session's id.
+	Id utils.Token
+}
+
+const REFRESH_TOKEN_EXPIRE = 7 * 24 * time.Hour
+
+var (
+	ErrSessionInvalid  = errors.New("sessions: invalid refresh token")
+	ErrSessionExpired  = errors.New("sessions: refresh token expired, please sign in again")
+	ErrSessionRevoked  = errors.New("sessions: session has been revoked, please sign in again")
+	ErrSessionReused   = errors.New("sessions: refresh token has already been used, session revoked")
+	ErrSessionInternal = errors.New("sessions: encounter internal error")
+)
+
+// Create starts new session for the user and issues the first refresh token.
+func (s *SessionsService) Create(ctx context.Context, usrId int) (dto.SessionTokens, error) {
+
+	tokens := dto.SessionTokens{
+		SessionId:    s.Id.Generate(),
+		UserId:       usrId,
+		RefreshToken: s.Token.Generate(),
+	}
+
+	return tokens, s.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+
+		err := s.SessionsStore.Insert(ctx, tx, models.Session{Id: tokens.SessionId, UserId: usrId})
+		if err != nil {
+			return errorService.New(ErrSessionInternal, err)
+		}
+
+		err = s.SessionsStore.InsertRefreshToken(ctx, tx, models.RefreshToken{
+			Token:     utils.HashToken(tokens.RefreshToken),
+			SessionId: tokens.SessionId,
+			ExpireAt:  time.Now().Add(REFRESH_TOKEN_EXPIRE),
+		})
+		if err != nil {
+			return errorService.New(ErrSessionInternal, err)
+		}
+
+		return nil
+	})
+}
+
+// Refresh rotates the refresh token, the given token can only be used once.
+// Presenting a token that has already been rotated revokes the whole session,
+// since either the client or an attacker holds a stolen copy of it.
+func (s *SessionsService) Refresh(ctx context.Context, refreshToken string) (dto.SessionTokens, error) {
+
+	var (
+		tokens dto.SessionTokens
+		reused bool
+	)
+
+	err := s.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+
+		stored, err := s.SessionsStore.GetRefreshToken(ctx, tx, utils.HashToken(refreshToken))
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return errorService.New(ErrSessionInvalid, err)
+			default:
+				return errorService.New(ErrSessionInternal, err)
+			}
+		}
+
+		session, err := s.SessionsStore.GetById(ctx, stored.SessionId)
+		if err != nil {
+			return errorService.New(ErrSessionInternal, err)
+		}
+
+		if session.RevokedAt != nil {
+			return errorService.New(ErrSessionRevoked, ErrSessionRevoked)
+		}
+
+		if stored.UsedAt != nil {
+			reused = true
+			if err := s.SessionsStore.Revoke(ctx, tx, session.Id); err != nil {
+				return errorService.New(ErrSessionInternal, err)
+			}
+			return nil
+		}
+
+		if time.Now().After(stored.ExpireAt) {
+			return errorService.New(ErrSessionExpired, ErrSessionExpired)
+		}
+
+		if err := s.SessionsStore.UseRefreshToken(ctx, tx, stored.Token); err != nil {
+			return errorService.New(ErrSessionInternal, err)
+		}
+
+		tokens = dto.SessionTokens{
+			SessionId:    session.Id,
+			UserId:       session.UserId,
+			RefreshToken: s.Token.Generate(),
+		}
+
+		err = s.SessionsStore.InsertRefreshToken(ctx, tx, models.RefreshToken{
+			Token:     utils.HashToken(tokens.RefreshToken),
+			SessionId: session.Id,
+			ExpireAt:  time.Now().Add(REFRESH_TOKEN_EXPIRE),
+		})
+		if err != nil {
+			return errorService.New(ErrSessionInternal, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return dto.SessionTokens{}, err
+	}
+
+	// the revocation has to be committed before telling the client
+	if reused {
+		return dto.SessionTokens{}, errorService.New(ErrSessionReused, ErrSessionReused)
+	}
+
+	return tokens, nil
+}
+
+// Verify checks the session of an access token still belongs
+// to the user and has not been revoked.
+func (s *SessionsService) Verify(ctx context.Context, sessionId string, usrId int) error {
+
+	session, err := s.SessionsStore.GetById(ctx, sessionId)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return errorService.New(ErrSessionRevoked, err)
+		default:
+			return errorService.New(ErrSessionInternal, err)
+		}
+	}
+
+	if session.UserId != usrId || session.RevokedAt != nil {
+		return errorService.New(ErrSessionRevoked, ErrSessionRevoked)
+	}
+
+	return nil
+}
+
+func (s *SessionsService) Revoke(ctx context.Context, sessionId string) error {
+
+	return s.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+
+		if err := s.SessionsStore.Revoke(ctx, tx, sessionId); err != nil {
+			return errorService.New(ErrSessionInternal, err)
+		}
+
+		return nil
+	})
+}
+
+func (s *SessionsService) RevokeAll(ctx context.Context, usrId int) error {
+
+	return s.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+
+		if err := s.SessionsStore.RevokeByUserId(ctx, tx, usrId); err != nil {
+			return errorService.New(ErrSessionInternal, err)
+		}
+
+		return nil
+	})
+}
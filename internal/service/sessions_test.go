@@ -0,0 +1,130 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/faizisyellow/indocoffee/internal/repository/sessions"
+	"github.com/faizisyellow/indocoffee/internal/service"
+	errorService "github.com/faizisyellow/indocoffee/internal/service/error"
+)
+
+func TestSessionsService(t *testing.T) {
+	newSut := func() *service.SessionsService {
+		return &service.SessionsService{
+			SessionsStore: &sessions.InMemorySessions{},
+			Transaction:   &transactionFake{state: initial},
+			Token:         &tokenSequenceFake{prefix: "refresh"},
+			Id:            &tokenSequenceFake{prefix: "session"},
+		}
+	}
+
+	t.Run("refresh rotates the refresh token", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+			sut = newSut()
+		)
+
+		created, err := sut.Create(ctx, 1)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		refreshed, err := sut.Refresh(ctx, created.RefreshToken)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if refreshed.SessionId != created.SessionId || refreshed.UserId != 1 {
+			t.Errorf("expected same session for user 1 but got: %+v", refreshed)
+		}
+
+		if refreshed.RefreshToken == created.RefreshToken {
+			t.Error("expected a new refresh token")
+		}
+
+		if err := sut.Verify(ctx, created.SessionId, 1); err != nil {
+			t.Errorf("should not be error but got: %v", err)
+		}
+	})
+
+	t.Run("reusing a refresh token revokes the session", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+			sut = newSut()
+		)
+
+		created, err := sut.Create(ctx, 1)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		refreshed, err := sut.Refresh(ctx, created.RefreshToken)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		_, err = sut.Refresh(ctx, created.RefreshToken)
+		if errorService.GetError(err).E != service.ErrSessionReused {
+			t.Fatalf("expected %v but got: %v", service.ErrSessionReused, err)
+		}
+
+		_, err = sut.Refresh(ctx, refreshed.RefreshToken)
+		if errorService.GetError(err).E != service.ErrSessionRevoked {
+			t.Errorf("expected %v but got: %v", service.ErrSessionRevoked, err)
+		}
+
+		err = sut.Verify(ctx, created.SessionId, 1)
+		if errorService.GetError(err).E != service.ErrSessionRevoked {
+			t.Errorf("expected %v but got: %v", service.ErrSessionRevoked, err)
+		}
+	})
+
+	t.Run("revoke every session of a user", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+			sut = newSut()
+		)
+
+		first, err := sut.Create(ctx, 1)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		second, err := sut.Create(ctx, 1)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if err := sut.RevokeAll(ctx, 1); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		for _, session := range []string{first.SessionId, second.SessionId} {
+			err := sut.Verify(ctx, session, 1)
+			if errorService.GetError(err).E != service.ErrSessionRevoked {
+				t.Errorf("session %v: expected %v but got: %v", session, service.ErrSessionRevoked, err)
+			}
+		}
+	})
+
+	t.Run("refresh with unknown token", func(t *testing.T) {
+		sut := newSut()
+
+		_, err := sut.Refresh(context.Background(), "not a refresh token")
+		if errorService.GetError(err).E != service.ErrSessionInvalid {
+			t.Errorf("expected %v but got: %v", service.ErrSessionInvalid, err)
+		}
+	})
+}
+
+type tokenSequenceFake struct {
+	prefix string
+	n      int
+}
+
+func (t *tokenSequenceFake) Generate() string {
+	t.n++
+	return fmt.Sprintf("%v-%v", t.prefix, t.n)
+}
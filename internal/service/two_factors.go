@@ -0,0 +1,472 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/auth"
+	"github.com/faizisyellow/indocoffee/internal/db"
+	loginLimiter "github.com/faizisyellow/indocoffee/internal/limiter/login"
+	"github.com/faizisyellow/indocoffee/internal/models"
+	"github.com/faizisyellow/indocoffee/internal/repository/roles"
+	"github.com/faizisyellow/indocoffee/internal/repository/twofactors"
+	"github.com/faizisyellow/indocoffee/internal/repository/users"
+	"github.com/faizisyellow/indocoffee/internal/service/dto"
+	errorService "github.com/faizisyellow/indocoffee/internal/service/error"
+	"github.com/faizisyellow/indocoffee/internal/utils"
+)
+
+type TwoFactorPolicy struct {
+	// Issuer is the name authenticator apps show next to the account.
+	Issuer string
+	// Required makes two factor mandatory for every role above the customer's level.
+	Required bool
+}
+
+type TwoFactorService struct {
+	TwoFactorsStore twofactors.TwoFactors
+	UsersStore      users.Users
+	RolesStore      roles.Roles
+	Transaction     db.Transactioner
+	// LoginLimiter counts wrong codes as failed sign ins of the account,
+	// the sign in only succeeds once the second factor is checked.
+	LoginLimiter loginLimiter.LoginLimiter
+	// Token generates the plaintext challenge token.
+	Token  utils.Token
+	Policy TwoFactorPolicy
+}
+
+const (
+	TWO_FACTOR_CHALLENGE_EXPIRE = 5 * time.Minute
+	TWO_FACTOR_MAX_ATTEMPTS     = 5
+	RECOVERY_CODES              = 10
+)
+
+var (
+	ErrTwoFactorChallengeInvalid = errors.New("two factor: challenge is invalid or expired, please sign in again")
+	ErrTwoFactorCodeInvalid      = errors.New("two factor: code is invalid")
+	ErrTwoFactorEnabled          = errors.New("two factor: already enabled")
+	ErrTwoFactorNotEnrolled      = errors.New("two factor: not enrolled, please enroll first")
+	ErrTwoFactorMandatory        = errors.New("two factor: required for your role and can not be disabled")
+	ErrTwoFactorInternal         = errors.New("two factor: encounter internal error")
+)
+
+// Begin starts the second step of the sign in if the user has enabled two factor
+// or its role requires it. Returns nil if the user can be signed in right away,
+// then the sign in from the ip succeeds.
+func (t *TwoFactorService) Begin(ctx context.Context, user models.User, ip string) (*dto.TwoFactorChallenge, error) {
+
+	required, err := t.isRequired(ctx, user.RoleId)
+	if err != nil {
+		return nil, err
+	}
+
+	var challenge *dto.TwoFactorChallenge
+
+	err = t.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+
+		tf, err := t.TwoFactorsStore.Get(ctx, tx, user.Id)
+		if err != nil && err != sql.ErrNoRows {
+			return errorService.New(ErrTwoFactorInternal, err)
+		}
+
+		enabled := err == nil && tf.EnabledAt != nil
+		if !enabled && !required {
+			return nil
+		}
+
+		token := t.Token.Generate()
+
+		err = t.TwoFactorsStore.InsertChallenge(ctx, tx, models.TwoFactorChallenge{
+			Token:    utils.HashToken(token),
+			UserId:   user.Id,
+			ExpireAt: time.Now().Add(TWO_FACTOR_CHALLENGE_EXPIRE),
+		})
+		if err != nil {
+			return errorService.New(ErrTwoFactorInternal, err)
+		}
+
+		challenge = &dto.TwoFactorChallenge{
+			ChallengeToken:     token,
+			EnrollmentRequired: !enabled,
+			ExpireIn:           int(TWO_FACTOR_CHALLENGE_EXPIRE.Seconds()),
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if challenge == nil {
+		if err := t.LoginLimiter.Succeed(ctx, ip, user.Email); err != nil {
+			return nil, errorService.New(ErrTwoFactorInternal, err)
+		}
+	}
+
+	return challenge, nil
+}
+
+// Enroll generates a new secret and recovery codes for the user,
+// two factor is enabled once the first code is confirmed.
+// The recovery codes are only returned here, they are stored hashed.
+func (t *TwoFactorService) Enroll(ctx context.Context, usrId int) (dto.TwoFactorEnrollment, error) {
+
+	user, err := t.UsersStore.GetById(ctx, usrId)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return dto.TwoFactorEnrollment{}, errorService.New(ErrUserNotFound, err)
+		default:
+			return dto.TwoFactorEnrollment{}, errorService.New(ErrTwoFactorInternal, err)
+		}
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return dto.TwoFactorEnrollment{}, errorService.New(ErrTwoFactorInternal, err)
+	}
+
+	codes, err := generateRecoveryCodes()
+	if err != nil {
+		return dto.TwoFactorEnrollment{}, errorService.New(ErrTwoFactorInternal, err)
+	}
+
+	err = t.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+
+		tf, err := t.TwoFactorsStore.Get(ctx, tx, usrId)
+		if err != nil && err != sql.ErrNoRows {
+			return errorService.New(ErrTwoFactorInternal, err)
+		}
+
+		if err == nil && tf.EnabledAt != nil {
+			return errorService.New(ErrTwoFactorEnabled, ErrTwoFactorEnabled)
+		}
+
+		if err := t.TwoFactorsStore.Upsert(ctx, tx, models.TwoFactor{UserId: usrId, Secret: secret}); err != nil {
+			return errorService.New(ErrTwoFactorInternal, err)
+		}
+
+		hashed := make([]string, 0, len(codes))
+		for _, code := range codes {
+			hashed = append(hashed, utils.HashToken(code))
+		}
+
+		if err := t.TwoFactorsStore.ReplaceRecoveryCodes(ctx, tx, usrId, hashed); err != nil {
+			return errorService.New(ErrTwoFactorInternal, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return dto.TwoFactorEnrollment{}, err
+	}
+
+	return dto.TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningUri: auth.TOTPProvisioningURI(t.Policy.Issuer, user.Email, secret),
+		RecoveryCodes:   codes,
+	}, nil
+}
+
+// EnrollWithChallenge enrolls the user of a sign in challenge,
+// it lets a user whose role requires two factor enroll before getting a token.
+func (t *TwoFactorService) EnrollWithChallenge(ctx context.Context, req dto.TwoFactorChallengeRequest) (dto.TwoFactorEnrollment, error) {
+
+	var usrId int
+
+	err := t.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+
+		challenge, err := t.getChallenge(ctx, tx, req.ChallengeToken)
+		if err != nil {
+			return err
+		}
+
+		usrId = challenge.UserId
+
+		return nil
+	})
+	if err != nil {
+		return dto.TwoFactorEnrollment{}, err
+	}
+
+	return t.Enroll(ctx, usrId)
+}
+
+// Confirm enables two factor of the user with the first code of the authenticator app.
+func (t *TwoFactorService) Confirm(ctx context.Context, usrId int, req dto.ConfirmTwoFactorRequest) error {
+
+	return t.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+
+		tf, err := t.TwoFactorsStore.Get(ctx, tx, usrId)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return errorService.New(ErrTwoFactorNotEnrolled, err)
+			default:
+				return errorService.New(ErrTwoFactorInternal, err)
+			}
+		}
+
+		if tf.EnabledAt != nil {
+			return errorService.New(ErrTwoFactorEnabled, ErrTwoFactorEnabled)
+		}
+
+		ok, err := t.checkCode(ctx, tx, tf, req.Code)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return errorService.New(ErrTwoFactorCodeInvalid, ErrTwoFactorCodeInvalid)
+		}
+
+		if err := t.TwoFactorsStore.Enable(ctx, tx, usrId); err != nil {
+			return errorService.New(ErrTwoFactorInternal, err)
+		}
+
+		return nil
+	})
+}
+
+// Verify finishes the sign in challenge with a TOTP or recovery code.
+// A pending enrollment is enabled by its first valid code.
+// The challenge is invalid after too many wrong codes, and every wrong code
+// counts against the account, so new challenges do not give more tries.
+func (t *TwoFactorService) Verify(ctx context.Context, req dto.VerifyTwoFactorRequest) (*models.User, error) {
+
+	var (
+		user   models.User
+		failed bool
+	)
+
+	err := t.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+
+		challenge, err := t.getChallenge(ctx, tx, req.ChallengeToken)
+		if err != nil {
+			return err
+		}
+
+		user, err = t.UsersStore.GetById(ctx, challenge.UserId)
+		if err != nil {
+			return errorService.New(ErrTwoFactorInternal, err)
+		}
+
+		decision, err := t.LoginLimiter.Check(ctx, req.Ip, user.Email)
+		if err != nil {
+			return errorService.New(ErrTwoFactorInternal, err)
+		}
+
+		if err := limitedError(decision); err != nil {
+			return err
+		}
+
+		tf, err := t.TwoFactorsStore.Get(ctx, tx, challenge.UserId)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return errorService.New(ErrTwoFactorNotEnrolled, err)
+			default:
+				return errorService.New(ErrTwoFactorInternal, err)
+			}
+		}
+
+		ok, err := t.checkCode(ctx, tx, tf, req.Code)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			failed = true
+			if err := t.TwoFactorsStore.IncrementChallengeAttempts(ctx, tx, challenge.Token); err != nil {
+				return errorService.New(ErrTwoFactorInternal, err)
+			}
+			if _, err := t.LoginLimiter.Fail(ctx, req.Ip, user.Email); err != nil {
+				return errorService.New(ErrTwoFactorInternal, err)
+			}
+			return nil
+		}
+
+		if tf.EnabledAt == nil {
+			if err := t.TwoFactorsStore.Enable(ctx, tx, tf.UserId); err != nil {
+				return errorService.New(ErrTwoFactorInternal, err)
+			}
+		}
+
+		if err := t.TwoFactorsStore.DeleteChallenge(ctx, tx, challenge.Token); err != nil {
+			return errorService.New(ErrTwoFactorInternal, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the attempt has to be committed before telling the client
+	if failed {
+		return nil, errorService.New(ErrTwoFactorCodeInvalid, ErrTwoFactorCodeInvalid)
+	}
+
+	if user.DeactivatedAt != nil {
+		return nil, errorService.New(ErrUserDeactivated, ErrUserDeactivated)
+	}
+
+	if err := t.LoginLimiter.Succeed(ctx, req.Ip, user.Email); err != nil {
+		return nil, errorService.New(ErrTwoFactorInternal, err)
+	}
+
+	role, err := t.RolesStore.GetById(ctx, user.RoleId)
+	if err != nil {
+		return nil, errorService.New(ErrTwoFactorInternal, err)
+	}
+	user.Role = &role
+
+	return &user, nil
+}
+
+// Disable removes two factor of the user, it needs both the password and a code.
+func (t *TwoFactorService) Disable(ctx context.Context, usrId int, req dto.DisableTwoFactorRequest) error {
+
+	user, err := t.UsersStore.GetById(ctx, usrId)
+	if err != nil {
+		return errorService.New(ErrTwoFactorInternal, err)
+	}
+
+	required, err := t.isRequired(ctx, user.RoleId)
+	if err != nil {
+		return err
+	}
+
+	if required {
+		return errorService.New(ErrTwoFactorMandatory, ErrTwoFactorMandatory)
+	}
+
+	if err := user.Password.ComparePassword(req.Password); err != nil {
+		return errorService.New(ErrPasswordIncorrect, err)
+	}
+
+	return t.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+
+		tf, err := t.TwoFactorsStore.Get(ctx, tx, usrId)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return errorService.New(ErrTwoFactorNotEnrolled, err)
+			default:
+				return errorService.New(ErrTwoFactorInternal, err)
+			}
+		}
+
+		if tf.EnabledAt == nil {
+			return errorService.New(ErrTwoFactorNotEnrolled, ErrTwoFactorNotEnrolled)
+		}
+
+		ok, err := t.checkCode(ctx, tx, tf, req.Code)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return errorService.New(ErrTwoFactorCodeInvalid, ErrTwoFactorCodeInvalid)
+		}
+
+		if err := t.TwoFactorsStore.Delete(ctx, tx, usrId); err != nil {
+			return errorService.New(ErrTwoFactorInternal, err)
+		}
+
+		return nil
+	})
+}
+
+// isRequired tells whether the policy makes two factor mandatory for the role.
+func (t *TwoFactorService) isRequired(ctx context.Context, roleId int) (bool, error) {
+
+	if !t.Policy.Required {
+		return false, nil
+	}
+
+	role, err := t.RolesStore.GetById(ctx, roleId)
+	if err != nil {
+		return false, errorService.New(ErrTwoFactorInternal, err)
+	}
+
+	customer, err := t.RolesStore.GetById(ctx, CUSTOMER_ROLE)
+	if err != nil {
+		return false, errorService.New(ErrTwoFactorInternal, err)
+	}
+
+	return role.Level > customer.Level, nil
+}
+
+func (t *TwoFactorService) getChallenge(ctx context.Context, tx *sql.Tx, token string) (models.TwoFactorChallenge, error) {
+
+	challenge, err := t.TwoFactorsStore.GetChallenge(ctx, tx, utils.HashToken(token))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return challenge, errorService.New(ErrTwoFactorChallengeInvalid, err)
+		default:
+			return challenge, errorService.New(ErrTwoFactorInternal, err)
+		}
+	}
+
+	if challenge.Attempts >= TWO_FACTOR_MAX_ATTEMPTS {
+		return challenge, errorService.New(ErrTwoFactorChallengeInvalid, ErrTwoFactorChallengeInvalid)
+	}
+
+	return challenge, nil
+}
+
+// checkCode accepts a TOTP code that has not been used yet,
+// or an unused recovery code once two factor is enabled.
+func (t *TwoFactorService) checkCode(ctx context.Context, tx *sql.Tx, tf models.TwoFactor, code string) (bool, error) {
+
+	code = strings.ToLower(strings.TrimSpace(code))
+
+	if step, ok := auth.ValidateTOTP(tf.Secret, code, time.Now(), tf.LastStep); ok {
+		if err := t.TwoFactorsStore.UseStep(ctx, tx, tf.UserId, step); err != nil {
+			return false, errorService.New(ErrTwoFactorInternal, err)
+		}
+		return true, nil
+	}
+
+	if tf.EnabledAt == nil {
+		return false, nil
+	}
+
+	err := t.TwoFactorsStore.UseRecoveryCode(ctx, tx, tf.UserId, utils.HashToken(code))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return false, nil
+		default:
+			return false, errorService.New(ErrTwoFactorInternal, err)
+		}
+	}
+
+	return true, nil
+}
+
+// generateRecoveryCodes returns codes formatted as "xxxxx-xxxxx".
+func generateRecoveryCodes() ([]string, error) {
+
+	codes := make([]string, 0, RECOVERY_CODES)
+	for range RECOVERY_CODES {
+
+		raw := make([]byte, 5)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, err
+		}
+
+		code := hex.EncodeToString(raw)
+		codes = append(codes, code[:5]+"-"+code[5:])
+	}
+
+	return codes, nil
+}
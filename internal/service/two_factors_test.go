@@ -0,0 +1,232 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/auth"
+	loginLimiter "github.com/faizisyellow/indocoffee/internal/limiter/login"
+	"github.com/faizisyellow/indocoffee/internal/models"
+	"github.com/faizisyellow/indocoffee/internal/repository/roles"
+	"github.com/faizisyellow/indocoffee/internal/repository/twofactors"
+	"github.com/faizisyellow/indocoffee/internal/repository/users"
+	"github.com/faizisyellow/indocoffee/internal/service"
+	"github.com/faizisyellow/indocoffee/internal/service/dto"
+	errorService "github.com/faizisyellow/indocoffee/internal/service/error"
+)
+
+func TestTwoFactorService(t *testing.T) {
+	// lenient enough for the challenge to run out of attempts first.
+	lenientPolicy := loginLimiter.Policy{IpLimit: 100, AccountLimit: 100, LockoutThreshold: 100, Window: time.Hour}
+
+	newSut := func(t *testing.T, roleId int, required bool) *service.TwoFactorService {
+		user := models.User{Id: 1, Email: "lizzy@example.com", RoleId: roleId}
+		if err := user.Password.ParseFromPassword("Password$123"); err != nil {
+			t.Fatal(err)
+		}
+
+		return &service.TwoFactorService{
+			TwoFactorsStore: &twofactors.InMemoryTwoFactors{},
+			UsersStore:      &users.InMemoryUsers{Users: []models.User{user}},
+			RolesStore: &roles.InMemoryRoles{Roles: []models.RolesModel{
+				{Id: 1, Name: "customer", Level: 1},
+				{Id: 2, Name: "admin", Level: 2},
+			}},
+			Transaction:  &transactionFake{state: initial},
+			LoginLimiter: &loginLimiter.InMemoryLoginLimiter{Policy: lenientPolicy},
+			Token:        &tokenSequenceFake{prefix: "challenge"},
+			Policy:       service.TwoFactorPolicy{Issuer: "Indocoffee", Required: required},
+		}
+	}
+
+	currentCode := func(t *testing.T, secret string) string {
+		code, err := auth.TOTPCode(secret, auth.TOTPStep(time.Now()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	t.Run("sign in without two factor", func(t *testing.T) {
+		sut := newSut(t, 2, false)
+
+		challenge, err := sut.Begin(context.Background(), models.User{Id: 1, RoleId: 2}, "127.0.0.1")
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if challenge != nil {
+			t.Errorf("expected no challenge but got: %+v", challenge)
+		}
+	})
+
+	t.Run("enroll, confirm and sign in with a recovery code", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+			sut = newSut(t, 1, false)
+		)
+
+		enrollment, err := sut.Enroll(ctx, 1)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if len(enrollment.RecoveryCodes) != service.RECOVERY_CODES || enrollment.ProvisioningUri == "" {
+			t.Fatalf("unexpected enrollment: %+v", enrollment)
+		}
+
+		code := currentCode(t, enrollment.Secret)
+		if err := sut.Confirm(ctx, 1, dto.ConfirmTwoFactorRequest{Code: code}); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		challenge, err := sut.Begin(ctx, models.User{Id: 1, RoleId: 1}, "127.0.0.1")
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if challenge == nil || challenge.EnrollmentRequired {
+			t.Fatalf("expected a challenge of an enrolled user but got: %+v", challenge)
+		}
+
+		_, err = sut.Verify(ctx, dto.VerifyTwoFactorRequest{ChallengeToken: challenge.ChallengeToken, Code: code})
+		if errorService.GetError(err).E != service.ErrTwoFactorCodeInvalid {
+			t.Fatalf("expected replayed code to be rejected but got: %v", err)
+		}
+
+		user, err := sut.Verify(ctx, dto.VerifyTwoFactorRequest{ChallengeToken: challenge.ChallengeToken, Code: enrollment.RecoveryCodes[0]})
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if user.Id != 1 || user.Role.Name != "customer" {
+			t.Errorf("expected customer 1 but got: %+v", user)
+		}
+
+		_, err = sut.Verify(ctx, dto.VerifyTwoFactorRequest{ChallengeToken: challenge.ChallengeToken, Code: enrollment.RecoveryCodes[1]})
+		if errorService.GetError(err).E != service.ErrTwoFactorChallengeInvalid {
+			t.Errorf("expected used challenge to be invalid but got: %v", err)
+		}
+	})
+
+	t.Run("role above customer has to enroll while signing in", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+			sut = newSut(t, 2, true)
+		)
+
+		challenge, err := sut.Begin(ctx, models.User{Id: 1, RoleId: 2}, "127.0.0.1")
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if challenge == nil || !challenge.EnrollmentRequired {
+			t.Fatalf("expected enrollment to be required but got: %+v", challenge)
+		}
+
+		enrollment, err := sut.EnrollWithChallenge(ctx, dto.TwoFactorChallengeRequest{ChallengeToken: challenge.ChallengeToken})
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		_, err = sut.Verify(ctx, dto.VerifyTwoFactorRequest{ChallengeToken: challenge.ChallengeToken, Code: enrollment.RecoveryCodes[0]})
+		if errorService.GetError(err).E != service.ErrTwoFactorCodeInvalid {
+			t.Fatalf("expected recovery code before enabling to be rejected but got: %v", err)
+		}
+
+		if _, err := sut.Verify(ctx, dto.VerifyTwoFactorRequest{
+			ChallengeToken: challenge.ChallengeToken,
+			Code:           currentCode(t, enrollment.Secret),
+		}); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		err = sut.Disable(ctx, 1, dto.DisableTwoFactorRequest{Password: "Password$123", Code: enrollment.RecoveryCodes[0]})
+		if errorService.GetError(err).E != service.ErrTwoFactorMandatory {
+			t.Errorf("expected %v but got: %v", service.ErrTwoFactorMandatory, err)
+		}
+	})
+
+	t.Run("challenge is invalid after too many wrong codes", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+			sut = newSut(t, 1, false)
+		)
+
+		enrollment, _ := sut.Enroll(ctx, 1)
+		if err := sut.Confirm(ctx, 1, dto.ConfirmTwoFactorRequest{Code: currentCode(t, enrollment.Secret)}); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		challenge, _ := sut.Begin(ctx, models.User{Id: 1, RoleId: 1}, "127.0.0.1")
+
+		for range service.TWO_FACTOR_MAX_ATTEMPTS {
+			_, err := sut.Verify(ctx, dto.VerifyTwoFactorRequest{ChallengeToken: challenge.ChallengeToken, Code: "00000-00000"})
+			if errorService.GetError(err).E != service.ErrTwoFactorCodeInvalid {
+				t.Fatalf("expected %v but got: %v", service.ErrTwoFactorCodeInvalid, err)
+			}
+		}
+
+		_, err := sut.Verify(ctx, dto.VerifyTwoFactorRequest{ChallengeToken: challenge.ChallengeToken, Code: enrollment.RecoveryCodes[0]})
+		if errorService.GetError(err).E != service.ErrTwoFactorChallengeInvalid {
+			t.Errorf("expected %v but got: %v", service.ErrTwoFactorChallengeInvalid, err)
+		}
+	})
+
+	t.Run("wrong codes count against the account across challenges", func(t *testing.T) {
+		var (
+			ctx     = context.Background()
+			sut     = newSut(t, 1, false)
+			limiter = &loginLimiter.InMemoryLoginLimiter{Policy: loginLimiter.DefaultPolicy}
+		)
+		sut.LoginLimiter = limiter
+
+		enrollment, _ := sut.Enroll(ctx, 1)
+		if err := sut.Confirm(ctx, 1, dto.ConfirmTwoFactorRequest{Code: currentCode(t, enrollment.Secret)}); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		for range loginLimiter.DefaultPolicy.AccountLimit {
+			challenge, _ := sut.Begin(ctx, models.User{Id: 1, RoleId: 1}, "127.0.0.1")
+
+			_, err := sut.Verify(ctx, dto.VerifyTwoFactorRequest{ChallengeToken: challenge.ChallengeToken, Code: "00000-00000", Ip: "127.0.0.1"})
+			if errorService.GetError(err).E != service.ErrTwoFactorCodeInvalid {
+				t.Fatalf("expected %v but got: %v", service.ErrTwoFactorCodeInvalid, err)
+			}
+		}
+
+		challenge, _ := sut.Begin(ctx, models.User{Id: 1, RoleId: 1}, "127.0.0.1")
+
+		_, err := sut.Verify(ctx, dto.VerifyTwoFactorRequest{ChallengeToken: challenge.ChallengeToken, Code: enrollment.RecoveryCodes[0], Ip: "127.0.0.1"})
+		if errorService.GetError(err).E != service.ErrUserLimited {
+			t.Errorf("expected %v but got: %v", service.ErrUserLimited, err)
+		}
+	})
+
+	t.Run("disable two factor", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+			sut = newSut(t, 1, true)
+		)
+
+		enrollment, _ := sut.Enroll(ctx, 1)
+		if err := sut.Confirm(ctx, 1, dto.ConfirmTwoFactorRequest{Code: currentCode(t, enrollment.Secret)}); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		err := sut.Disable(ctx, 1, dto.DisableTwoFactorRequest{Password: "wrong", Code: enrollment.RecoveryCodes[0]})
+		if errorService.GetError(err).E != service.ErrPasswordIncorrect {
+			t.Fatalf("expected %v but got: %v", service.ErrPasswordIncorrect, err)
+		}
+
+		if err := sut.Disable(ctx, 1, dto.DisableTwoFactorRequest{Password: "Password$123", Code: enrollment.RecoveryCodes[0]}); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		challenge, err := sut.Begin(ctx, models.User{Id: 1, RoleId: 1}, "127.0.0.1")
+		if err != nil || challenge != nil {
+			t.Errorf("expected no challenge after disabling but got: %+v, %v", challenge, err)
+		}
+	})
+}
@@ -4,14 +4,22 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/faizisyellow/indocoffee/internal/audit"
+	"github.com/faizisyellow/indocoffee/internal/cache"
 	"github.com/faizisyellow/indocoffee/internal/db"
 	loginLimiter "github.com/faizisyellow/indocoffee/internal/limiter/login"
+	"github.com/faizisyellow/indocoffee/internal/mailer"
 	"github.com/faizisyellow/indocoffee/internal/models"
 	"github.com/faizisyellow/indocoffee/internal/repository"
 	"github.com/faizisyellow/indocoffee/internal/repository/invitations"
+	"github.com/faizisyellow/indocoffee/internal/repository/resets"
+	"github.com/faizisyellow/indocoffee/internal/repository/roles"
+	"github.com/faizisyellow/indocoffee/internal/repository/sessions"
 	"github.com/faizisyellow/indocoffee/internal/repository/users"
 	"github.com/faizisyellow/indocoffee/internal/service/dto"
 	errorService "github.com/faizisyellow/indocoffee/internal/service/error"
@@ -24,6 +32,14 @@ type UsersServices struct {
 	Token            utils.Token
 	Transaction      db.Transactioner
 	LoginLimiter     loginLimiter.LoginLimiter
+	ResetsStore      resets.Resets
+	SessionsStore    sessions.Sessions
+	Mailer           mailer.Mailer
+	RolesStore       roles.Roles
+	Audit            audit.Recorder
+	// Cache keeps the identity of signed in users, every change to
+	// the username, email, role or active state must invalidate it.
+	Cache cache.Cache
 }
 
 type RegisterRequest struct {
@@ -32,10 +48,6 @@ type RegisterRequest struct {
 	Password string `json:"password" validate:"required,max=18"`
 }
 
-type RegisterResponse struct {
-	Token string `json:"token"`
-}
-
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
@@ -46,6 +58,58 @@ type ActivatedRequest struct {
 	Token string `json:"token"`
 }
 
+// tokenMail is the data of the emails carrying a token.
+type tokenMail struct {
+	Username string
+	Token    string
+	ExpireIn string
+}
+
+// expireIn is how long a token lives in words such as 24 hours or 30 minutes.
+func expireIn(d time.Duration) string {
+	value, unit := int(d/time.Minute), "minute"
+	if d%time.Hour == 0 {
+		value, unit = int(d/time.Hour), "hour"
+	}
+
+	if value != 1 {
+		unit += "s"
+	}
+
+	return strconv.Itoa(value) + " " + unit
+}
+
+type ResendActivationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,max=18"`
+}
+
+type UpdateProfileRequest struct {
+	Username string `json:"username" validate:"required,min=3,max=16"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,max=18"`
+}
+
+type ChangeEmailRequest struct {
+	Email    string `json:"email" validate:"required,email,min=6,max=32"`
+	Password string `json:"password" validate:"required"`
+}
+
+type ChangeUserRoleRequest struct {
+	RoleId int `json:"role_id" validate:"required,gte=1"`
+}
+
 var (
 	ErrTokenInvitationNotFound = errors.New("invitation not found, please register first")
 	ErrUserRegisteredNotFound  = errors.New("user not found, please register first")
@@ -54,27 +118,46 @@ var (
 	ErrUserAlreadyExist        = errors.New("this user already exists")
 	ErrUserInternal            = errors.New("server incounter internal error")
 	ErrUserLimited             = errors.New("too many login attempts, please try again later")
+	ErrUserLocked              = errors.New("account locked after too many login attempts, please contact support")
+	ErrTokenResetNotFound      = errors.New("password reset not found or expired, please request a new one")
+	ErrActivationLimited       = errors.New("activation email was sent recently, please try again later")
+	ErrUserDeactivated         = errors.New("user has been deactivated, please contact support")
+	ErrUserManageSelf          = errors.New("can not change your own account, ask another admin")
+	ErrUserNotDeactivated      = errors.New("user was not deactivated, users that never verified their email must activate it first")
+	ErrPasswordIncorrect       = errors.New("current password is incorrect")
+	ErrTokenEmailNotFound      = errors.New("email change not found or expired, please request a new one")
 )
 
-const CUSTOMER_ROLE = 1
+const (
+	CUSTOMER_ROLE = 1
 
-func (us *UsersServices) RegisterAccount(ctx context.Context, req RegisterRequest) (*RegisterResponse, error) {
+	INVITATION_EXPIRE          = 24 * time.Hour
+	ACTIVATION_RESEND_COOLDOWN = 2 * time.Minute
+	PASSWORD_RESET_EXPIRE      = 30 * time.Minute
+	EMAIL_CHANGE_EXPIRE        = 24 * time.Hour
 
-	var response = new(RegisterResponse)
+	USERS_CACHE_TTL = time.Minute
+)
+
+// RegisterAccount creates an inactive account and emails its activation token.
+func (us *UsersServices) RegisterAccount(ctx context.Context, req RegisterRequest) error {
 
 	err := utils.IsPasswordValid(req.Password)
 	if err != nil {
-		return nil, errorService.New(err, err)
+		return errorService.New(err, err)
 	}
 
-	return response, us.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+	var (
+		newAccount models.User
+		tokenIvt   = us.Token.Generate()
+	)
+	newAccount.Email = req.Email
+	newAccount.Username = req.Username
+	newAccount.RoleId = CUSTOMER_ROLE
 
-		var newAccount models.User
-		newAccount.Email = req.Email
-		newAccount.Username = req.Username
-		newAccount.RoleId = CUSTOMER_ROLE
+	err = us.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
 
-		if err = newAccount.Password.ParseFromPassword(req.Password); err != nil {
+		if err := newAccount.Password.ParseFromPassword(req.Password); err != nil {
 			return errorService.New(err, err)
 		}
 
@@ -89,12 +172,10 @@ func (us *UsersServices) RegisterAccount(ctx context.Context, req RegisterReques
 
 		}
 
-		tokenIvt := us.Token.Generate()
-
 		invt := models.InvitationModel{
 			UserId:   usrId,
 			Token:    tokenIvt,
-			ExpireAt: time.Hour * 24,
+			ExpireAt: INVITATION_EXPIRE,
 		}
 
 		err = us.InvitationsStore.Insert(ctx, tx, invt)
@@ -102,12 +183,29 @@ func (us *UsersServices) RegisterAccount(ctx context.Context, req RegisterReques
 			return errorService.New(ErrUserInternal, err)
 		}
 
-		// register and invite success, send to response
-		response.Token = tokenIvt
-
 		return nil
 	})
+	if err != nil {
+		return err
+	}
 
+	return us.mailActivation(ctx, newAccount, tokenIvt)
+}
+
+// mailActivation emails the activation token once it is committed, so a slow mail
+// server holds no locks. A failed send keeps the account, the user can ask again.
+func (us *UsersServices) mailActivation(ctx context.Context, user models.User, token string) error {
+
+	err := us.Mailer.Send(ctx, user.Email, mailer.ACTIVATION_TEMPLATE, tokenMail{
+		Username: user.Username,
+		Token:    token,
+		ExpireIn: expireIn(INVITATION_EXPIRE),
+	})
+	if err != nil {
+		return errorService.New(ErrUserInternal, err)
+	}
+
+	return nil
 }
 
 func (us *UsersServices) ActivateAccount(ctx context.Context, token string) error {
@@ -141,29 +239,93 @@ func (us *UsersServices) ActivateAccount(ctx context.Context, token string) erro
 
 }
 
-func (us *UsersServices) Login(ctx context.Context, req LoginRequest) (*models.User, error) {
+// ResendActivation emails a new activation token, the previous token stops working.
+// Returns nil if there is no such inactive user,
+// so the caller can not tell which emails are registered.
+func (us *UsersServices) ResendActivation(ctx context.Context, req ResendActivationRequest) error {
 
 	user, err := us.UsersStore.GetByEmail(ctx, req.Email)
 	if err != nil {
-		return nil, errorService.New(ErrUserNotFound, err)
+		switch err {
+		case sql.ErrNoRows:
+			return nil
+		default:
+			return errorService.New(ErrUserInternal, err)
+		}
 	}
 
-	if !*user.IsActive {
-		return nil, errorService.New(ErrUserNotActivated, ErrUserNotActivated)
+	if user.Id == 0 || user.IsActive == nil || *user.IsActive || user.DeactivatedAt != nil {
+		return nil
+	}
+
+	tokenIvt := us.Token.Generate()
+
+	err = us.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+
+		latest, err := us.InvitationsStore.GetLatestByUserId(ctx, tx, user.Id)
+		if err != nil && err != sql.ErrNoRows {
+			return errorService.New(ErrUserInternal, err)
+		}
+
+		if err == nil && time.Since(latest.CreatedAt) < ACTIVATION_RESEND_COOLDOWN {
+			return errorService.New(ErrActivationLimited, ErrActivationLimited)
+		}
+
+		if err := us.InvitationsStore.DeleteByUserId(ctx, tx, user.Id); err != nil {
+			return errorService.New(ErrUserInternal, err)
+		}
+
+		err = us.InvitationsStore.Insert(ctx, tx, models.InvitationModel{
+			UserId:   user.Id,
+			Token:    tokenIvt,
+			ExpireAt: INVITATION_EXPIRE,
+		})
+		if err != nil {
+			return errorService.New(ErrUserInternal, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	canLogin, err := us.LoginLimiter.CanLogin(ctx, req.Ip, req.Email)
+	return us.mailActivation(ctx, user, tokenIvt)
+}
+
+// Login checks the user's password, the sign in is not succeeded yet
+// until the second factor is checked, see TwoFactorService.Begin.
+func (us *UsersServices) Login(ctx context.Context, req LoginRequest) (*models.User, error) {
+
+	decision, err := us.LoginLimiter.Check(ctx, req.Ip, req.Email)
 	if err != nil {
 		return nil, errorService.New(ErrUserInternal, err)
 	}
 
-	if !canLogin {
-		return nil, errorService.New(ErrUserLimited, ErrUserLimited)
+	if err := limitedError(decision); err != nil {
+		return nil, err
+	}
+
+	user, err := us.UsersStore.GetByEmail(ctx, req.Email)
+	if err != nil {
+		// unknown emails still count against the ip
+		if _, err := us.LoginLimiter.Fail(ctx, req.Ip, req.Email); err != nil {
+			return nil, errorService.New(ErrUserInternal, err)
+		}
+		return nil, errorService.New(ErrUserNotFound, err)
+	}
+
+	if user.DeactivatedAt != nil {
+		return nil, errorService.New(ErrUserDeactivated, ErrUserDeactivated)
+	}
+
+	if !*user.IsActive {
+		return nil, errorService.New(ErrUserNotActivated, ErrUserNotActivated)
 	}
 
 	err = user.Password.ComparePassword(req.Password)
 	if err != nil {
-		if err := us.LoginLimiter.SetAttemptLogin(ctx, req.Ip, req.Email, 2); err != nil {
+		if _, err := us.LoginLimiter.Fail(ctx, req.Ip, req.Email); err != nil {
 			return nil, errorService.New(ErrUserInternal, err)
 		}
 		return nil, errorService.New(err, err)
@@ -172,18 +334,312 @@ func (us *UsersServices) Login(ctx context.Context, req LoginRequest) (*models.U
 	return &user, nil
 }
 
-func (us *UsersServices) DeleteAccount(ctx context.Context, usrid int) error {
+// limitedError tells why the login limiter does not allow signing in, nil if it does.
+func limitedError(decision loginLimiter.Decision) error {
+
+	switch {
+	case decision.Allowed:
+		return nil
+	case decision.Locked:
+		return errorService.New(ErrUserLocked, ErrUserLocked)
+	default:
+		return errorService.New(ErrUserLimited, fmt.Errorf("%w: retry after %v", ErrUserLimited, decision.RetryAfter.Round(time.Second)))
+	}
+}
+
+// ForgotPassword emails a password reset token to an active user.
+// Returns nil if there is no such user,
+// so the caller can not tell which emails are registered.
+func (us *UsersServices) ForgotPassword(ctx context.Context, req ForgotPasswordRequest) error {
+
+	user, err := us.UsersStore.GetByEmail(ctx, req.Email)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil
+		default:
+			return errorService.New(ErrUserInternal, err)
+		}
+	}
+
+	if user.Id == 0 || user.IsActive == nil || !*user.IsActive {
+		return nil
+	}
+
+	token := us.Token.Generate()
+
+	err = us.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+		return us.insertPasswordReset(ctx, tx, user.Id, token)
+	})
+	if err != nil {
+		return err
+	}
+
+	return us.mailPasswordReset(ctx, user, token)
+}
+
+// insertPasswordReset stores a new reset token of the user, only its hash is kept.
+func (us *UsersServices) insertPasswordReset(ctx context.Context, tx *sql.Tx, usrId int, token string) error {
+
+	err := us.ResetsStore.Insert(ctx, tx, models.PasswordReset{
+		UserId:   usrId,
+		Token:    utils.HashToken(token),
+		ExpireAt: time.Now().Add(PASSWORD_RESET_EXPIRE),
+	})
+	if err != nil {
+		return errorService.New(ErrUserInternal, err)
+	}
+
+	return nil
+}
+
+// mailPasswordReset emails the reset token once it is committed,
+// a failed send does not undo it and the user can request again.
+func (us *UsersServices) mailPasswordReset(ctx context.Context, user models.User, token string) error {
+
+	err := us.Mailer.Send(ctx, user.Email, mailer.PASSWORD_RESET_TEMPLATE, tokenMail{
+		Username: user.Username,
+		Token:    token,
+		ExpireIn: expireIn(PASSWORD_RESET_EXPIRE),
+	})
+	if err != nil {
+		return errorService.New(ErrUserInternal, err)
+	}
+
+	return nil
+}
+
+// ResetPassword sets the new password of the user owning the reset token.
+// The token can only be used once, and every session of the user is revoked.
+func (us *UsersServices) ResetPassword(ctx context.Context, req ResetPasswordRequest) error {
+
+	if err := utils.IsPasswordValid(req.Password); err != nil {
+		return errorService.New(err, err)
+	}
 
 	return us.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
 
-		err := us.UsersStore.Delete(ctx, tx, usrid)
+		usrId, err := us.ResetsStore.Get(ctx, tx, utils.HashToken(req.Token))
 		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return errorService.New(ErrTokenResetNotFound, err)
+			default:
+				return errorService.New(ErrUserInternal, err)
+			}
+		}
+
+		user, err := us.UsersStore.GetById(ctx, usrId)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return errorService.New(ErrUserNotFound, err)
+			default:
+				return errorService.New(ErrUserInternal, err)
+			}
+		}
+
+		if err := user.Password.ParseFromPassword(req.Password); err != nil {
+			return errorService.New(ErrUserInternal, err)
+		}
+
+		if err := us.UsersStore.Update(ctx, tx, user); err != nil {
+			return errorService.New(ErrUserInternal, err)
+		}
+
+		if err := us.ResetsStore.DeleteByUserId(ctx, tx, user.Id); err != nil {
+			return errorService.New(ErrUserInternal, err)
+		}
+
+		if err := us.SessionsStore.RevokeByUserId(ctx, tx, user.Id); err != nil {
 			return errorService.New(ErrUserInternal, err)
 		}
 
 		return nil
 	})
+}
+
+func (us *UsersServices) UpdateProfile(ctx context.Context, usrId int, req UpdateProfileRequest) (*models.User, error) {
+
+	user, err := us.FindUserById(ctx, usrId)
+	if err != nil {
+		return nil, err
+	}
+
+	// only the username is written, a deactivation or a new password
+	// saved meanwhile is not overwritten by the user read above
+	err = us.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+
+		if err := us.UsersStore.UpdateUsername(ctx, tx, user.Id, req.Username); err != nil {
+			return errorService.New(ErrUserInternal, err)
+		}
 
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := us.invalidate(ctx, user.Id); err != nil {
+		return nil, err
+	}
+
+	user.Username = req.Username
+
+	return user, nil
+}
+
+// ChangePassword sets the new password once the current one is confirmed,
+// every session of the user is revoked so the user has to sign in again.
+func (us *UsersServices) ChangePassword(ctx context.Context, usrId int, req ChangePasswordRequest) error {
+
+	user, err := us.FindUserById(ctx, usrId)
+	if err != nil {
+		return err
+	}
+
+	if err := user.Password.ComparePassword(req.CurrentPassword); err != nil {
+		return errorService.New(ErrPasswordIncorrect, err)
+	}
+
+	if err := utils.IsPasswordValid(req.NewPassword); err != nil {
+		return errorService.New(err, err)
+	}
+
+	return us.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+
+		if err := user.Password.ParseFromPassword(req.NewPassword); err != nil {
+			return errorService.New(ErrUserInternal, err)
+		}
+
+		if err := us.UsersStore.Update(ctx, tx, *user); err != nil {
+			return errorService.New(ErrUserInternal, err)
+		}
+
+		if err := us.SessionsStore.RevokeByUserId(ctx, tx, user.Id); err != nil {
+			return errorService.New(ErrUserInternal, err)
+		}
+
+		return nil
+	})
+}
+
+// RequestEmailChange emails a confirmation token to the new address.
+// The user's email stays the same until the token is confirmed.
+func (us *UsersServices) RequestEmailChange(ctx context.Context, usrId int, req ChangeEmailRequest) error {
+
+	user, err := us.FindUserById(ctx, usrId)
+	if err != nil {
+		return err
+	}
+
+	if err := user.Password.ComparePassword(req.Password); err != nil {
+		return errorService.New(ErrPasswordIncorrect, err)
+	}
+
+	existing, err := us.UsersStore.GetByEmail(ctx, req.Email)
+	if err != nil && err != sql.ErrNoRows {
+		return errorService.New(ErrUserInternal, err)
+	}
+
+	if err == nil && existing.Id != 0 {
+		return errorService.New(ErrUserAlreadyExist, ErrUserAlreadyExist)
+	}
+
+	token := us.Token.Generate()
+
+	err = us.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+
+		if err := us.InvitationsStore.DeleteEmailChangeByUserId(ctx, tx, user.Id); err != nil {
+			return errorService.New(ErrUserInternal, err)
+		}
+
+		err := us.InvitationsStore.Insert(ctx, tx, models.InvitationModel{
+			UserId:   user.Id,
+			Token:    token,
+			ExpireAt: EMAIL_CHANGE_EXPIRE,
+			Email:    req.Email,
+		})
+		if err != nil {
+			return errorService.New(ErrUserInternal, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// the email is sent once the token is saved,
+	// a failed send does not undo it and the user can request again.
+	err = us.Mailer.Send(ctx, req.Email, mailer.EMAIL_CHANGE_TEMPLATE, tokenMail{
+		Username: user.Username,
+		Token:    token,
+		ExpireIn: expireIn(EMAIL_CHANGE_EXPIRE),
+	})
+	if err != nil {
+		return errorService.New(ErrUserInternal, err)
+	}
+
+	return nil
+}
+
+// ConfirmEmailChange swaps the user's email to the address the token was sent to.
+// Every session of the user is revoked, so the user signs in again with the new email.
+func (us *UsersServices) ConfirmEmailChange(ctx context.Context, token string) error {
+
+	var usrId int
+
+	err := us.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+
+		invt, err := us.InvitationsStore.GetEmailChange(ctx, tx, token)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return errorService.New(ErrTokenEmailNotFound, err)
+			default:
+				return errorService.New(ErrUserInternal, err)
+			}
+		}
+
+		user, err := us.UsersStore.GetById(ctx, invt.UserId)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return errorService.New(ErrUserNotFound, err)
+			default:
+				return errorService.New(ErrUserInternal, err)
+			}
+		}
+
+		user.Email = invt.Email
+		usrId = user.Id
+
+		if err := us.UsersStore.Update(ctx, tx, user); err != nil {
+			switch {
+			case strings.Contains(err.Error(), CONFLICT_CODE):
+				return errorService.New(ErrUserAlreadyExist, err)
+			default:
+				return errorService.New(ErrUserInternal, err)
+			}
+		}
+
+		if err := us.InvitationsStore.DeleteEmailChangeByUserId(ctx, tx, user.Id); err != nil {
+			return errorService.New(ErrUserInternal, err)
+		}
+
+		// sessions signed in with the old email are signed out.
+		if err := us.SessionsStore.RevokeByUserId(ctx, tx, user.Id); err != nil {
+			return errorService.New(ErrUserInternal, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return us.invalidate(ctx, usrId)
 }
 
 func (us *UsersServices) FindUserById(ctx context.Context, usrid int) (*models.User, error) {
@@ -202,6 +658,49 @@ func (us *UsersServices) FindUserById(ctx context.Context, usrid int) (*models.U
 	return &user, nil
 }
 
+// FindIdentity gets the user a signed in request acts as, served from the cache when possible.
+// The password is never cached, use FindUserById to check it.
+func (us *UsersServices) FindIdentity(ctx context.Context, usrId int) (*models.User, error) {
+
+	var user models.User
+
+	// a failing cache should not fail the request, fall back to the database
+	if us.Cache != nil {
+		if found, err := us.Cache.Get(ctx, userCacheKey(usrId), &user); err == nil && found {
+			return &user, nil
+		}
+	}
+
+	found, err := us.FindUserById(ctx, usrId)
+	if err != nil {
+		return nil, err
+	}
+
+	if us.Cache != nil {
+		_ = us.Cache.Set(ctx, userCacheKey(usrId), found, USERS_CACHE_TTL)
+	}
+
+	return found, nil
+}
+
+// invalidate removes the cached identity, so the next request reads the change.
+func (us *UsersServices) invalidate(ctx context.Context, usrId int) error {
+
+	if us.Cache == nil {
+		return nil
+	}
+
+	if err := us.Cache.Delete(ctx, userCacheKey(usrId)); err != nil {
+		return errorService.New(ErrUserInternal, err)
+	}
+
+	return nil
+}
+
+func userCacheKey(usrId int) string {
+	return fmt.Sprintf("users:%d", usrId)
+}
+
 func (us *UsersServices) FindUsersCart(ctx context.Context, usrId int) (dto.GetUsersCartResponse, error) {
 
 	userWithCart, err := us.UsersStore.GetUsersCart(ctx, usrId)
@@ -218,13 +717,17 @@ func (us *UsersServices) FindUsersCart(ctx context.Context, usrId int) (dto.GetU
 			Id:       crt.Id,
 			Quantity: crt.Quantity,
 			Product: dto.CartProductDTO{
-				Id:      crt.Product.Id,
-				Roasted: crt.Product.Roasted,
-				Image:   crt.Product.Image,
-				Stock:   crt.Product.Quantity,
-				Price:   crt.Product.Price,
-				Bean:    dto.CartBeanDTO{Name: crt.Product.BeansModel.Name},
-				Form:    dto.CartFormDTO{Name: crt.Product.FormsModel.Name},
+				Id:        crt.Product.Id,
+				Name:      crt.Product.Name,
+				VariantId: crt.Variant.Id,
+				Sku:       crt.Variant.Sku,
+				Weight:    crt.Variant.Weight,
+				Roasted:   crt.Product.Roasted,
+				Image:     crt.Product.Image,
+				Stock:     crt.Variant.Quantity,
+				Price:     crt.Variant.Price,
+				Bean:      dto.CartBeanDTO{Name: crt.Product.BeansModel.Name},
+				Form:      dto.CartFormDTO{Name: crt.Product.FormsModel.Name},
 			},
 		}
 
@@ -248,3 +751,190 @@ func (u *UsersServices) FindUsersOrders(ctx context.Context, r repository.Pagina
 
 	return orders, nil
 }
+
+func (us *UsersServices) FindUsers(ctx context.Context, r repository.PaginatedUsersQuery) ([]models.User, error) {
+
+	usrs, err := us.UsersStore.GetUsers(ctx, r)
+	if err != nil {
+		return nil, errorService.New(ErrUserInternal, err)
+	}
+
+	return usrs, nil
+}
+
+// ChangeRole moves the user to another role, admins can not change their own role.
+func (us *UsersServices) ChangeRole(ctx context.Context, adminId, usrId int, req ChangeUserRoleRequest) error {
+
+	if adminId == usrId {
+		return errorService.New(ErrUserManageSelf, ErrUserManageSelf)
+	}
+
+	user, err := us.FindUserById(ctx, usrId)
+	if err != nil {
+		return err
+	}
+
+	if _, err := us.RolesStore.GetById(ctx, req.RoleId); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return errorService.New(ErrNotFoundRole, err)
+		default:
+			return errorService.New(ErrUserInternal, err)
+		}
+	}
+
+	err = us.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+
+		if err := us.UsersStore.UpdateRole(ctx, tx, usrId, req.RoleId); err != nil {
+			return errorService.New(ErrUserInternal, err)
+		}
+
+		entry := audit.Entry{
+			Action:     AUDIT_USERS_ROLE,
+			TargetType: "user",
+			TargetId:   strconv.Itoa(usrId),
+			Before:     userRole{RoleId: user.RoleId},
+			After:      userRole{RoleId: req.RoleId},
+		}
+		if err := recordAudit(ctx, tx, us.Audit, entry); err != nil {
+			return errorService.New(ErrUserInternal, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return us.invalidate(ctx, usrId)
+}
+
+// Deactivate stops the user from signing in and revokes every session of the user.
+// Deactivated users can not activate themselves again.
+func (us *UsersServices) Deactivate(ctx context.Context, adminId, usrId int) error {
+
+	if adminId == usrId {
+		return errorService.New(ErrUserManageSelf, ErrUserManageSelf)
+	}
+
+	if _, err := us.FindUserById(ctx, usrId); err != nil {
+		return err
+	}
+
+	err := us.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+
+		if err := us.UsersStore.SetActive(ctx, tx, usrId, false); err != nil {
+			return errorService.New(ErrUserInternal, err)
+		}
+
+		if err := us.SessionsStore.RevokeByUserId(ctx, tx, usrId); err != nil {
+			return errorService.New(ErrUserInternal, err)
+		}
+
+		if err := recordAudit(ctx, tx, us.Audit, audit.Entry{Action: AUDIT_USERS_DEACTIVATE, TargetType: "user", TargetId: strconv.Itoa(usrId)}); err != nil {
+			return errorService.New(ErrUserInternal, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return us.invalidate(ctx, usrId)
+}
+
+// Reactivate lets a deactivated user sign in again.
+// Users that never verified their email are not activated by it.
+func (us *UsersServices) Reactivate(ctx context.Context, usrId int) error {
+
+	user, err := us.FindUserById(ctx, usrId)
+	if err != nil {
+		return err
+	}
+
+	if user.DeactivatedAt == nil {
+		return errorService.New(ErrUserNotDeactivated, ErrUserNotDeactivated)
+	}
+
+	err = us.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+
+		if err := us.UsersStore.SetActive(ctx, tx, usrId, true); err != nil {
+			return errorService.New(ErrUserInternal, err)
+		}
+
+		if err := recordAudit(ctx, tx, us.Audit, audit.Entry{Action: AUDIT_USERS_REACTIVATE, TargetType: "user", TargetId: strconv.Itoa(usrId)}); err != nil {
+			return errorService.New(ErrUserInternal, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return us.invalidate(ctx, usrId)
+}
+
+// Unlock lifts the login lockout of the user and forgets its failed sign ins.
+func (us *UsersServices) Unlock(ctx context.Context, usrId int) error {
+
+	user, err := us.FindUserById(ctx, usrId)
+	if err != nil {
+		return err
+	}
+
+	if err := us.LoginLimiter.Unlock(ctx, user.Email); err != nil {
+		return errorService.New(ErrUserInternal, err)
+	}
+
+	// the lockout is not kept in the database, the entry is written on its own
+	if err := recordAudit(ctx, nil, us.Audit, audit.Entry{Action: AUDIT_USERS_UNLOCK, TargetType: "user", TargetId: strconv.Itoa(user.Id)}); err != nil {
+		return errorService.New(ErrUserInternal, err)
+	}
+
+	return nil
+}
+
+// ForcePasswordReset replaces the user's password with a random one,
+// revokes every session and emails a password reset token,
+// so the user has to choose a new password before signing in again.
+func (us *UsersServices) ForcePasswordReset(ctx context.Context, usrId int) error {
+
+	user, err := us.FindUserById(ctx, usrId)
+	if err != nil {
+		return err
+	}
+
+	token := us.Token.Generate()
+
+	err = us.Transaction.WithTx(ctx, func(tx *sql.Tx) error {
+
+		if err := user.Password.ParseFromPassword(us.Token.Generate()); err != nil {
+			return errorService.New(ErrUserInternal, err)
+		}
+
+		if err := us.UsersStore.Update(ctx, tx, *user); err != nil {
+			return errorService.New(ErrUserInternal, err)
+		}
+
+		if err := us.ResetsStore.DeleteByUserId(ctx, tx, user.Id); err != nil {
+			return errorService.New(ErrUserInternal, err)
+		}
+
+		if err := us.SessionsStore.RevokeByUserId(ctx, tx, user.Id); err != nil {
+			return errorService.New(ErrUserInternal, err)
+		}
+
+		if err := recordAudit(ctx, tx, us.Audit, audit.Entry{Action: AUDIT_USERS_PASSWORD_RESET, TargetType: "user", TargetId: strconv.Itoa(user.Id)}); err != nil {
+			return errorService.New(ErrUserInternal, err)
+		}
+
+		return us.insertPasswordReset(ctx, tx, user.Id, token)
+	})
+	if err != nil {
+		return err
+	}
+
+	return us.mailPasswordReset(ctx, *user, token)
+}
@@ -4,13 +4,22 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/faizisyellow/indocoffee/internal/cache"
 	"github.com/faizisyellow/indocoffee/internal/db"
 	loginLimiter "github.com/faizisyellow/indocoffee/internal/limiter/login"
+	mailLocal "github.com/faizisyellow/indocoffee/internal/mailer/local"
+	"github.com/faizisyellow/indocoffee/internal/models"
 	"github.com/faizisyellow/indocoffee/internal/repository/invitations"
+	"github.com/faizisyellow/indocoffee/internal/repository/resets"
+	"github.com/faizisyellow/indocoffee/internal/repository/roles"
+	"github.com/faizisyellow/indocoffee/internal/repository/sessions"
 	"github.com/faizisyellow/indocoffee/internal/repository/users"
 	"github.com/faizisyellow/indocoffee/internal/service"
+	errorService "github.com/faizisyellow/indocoffee/internal/service/error"
 	"github.com/faizisyellow/indocoffee/internal/utils"
 )
 
@@ -38,7 +47,7 @@ func (u UserServiceTest) Test(t *testing.T) {
 		var (
 			ctx                             = context.Background()
 			usr, invt, tkn, tranx, teardown = u.CreateDependencies()
-			sut                             = service.UsersServices{usr, invt, tkn, tranx, nil}
+			sut                             = service.UsersServices{usr, invt, tkn, tranx, nil, nil, nil, &mailLocal.InMemoryMailer{}, nil, nil, nil}
 			request                         = service.RegisterRequest{
 				Username: "lizzy",
 				Email:    "lizzymcalpine@test.test",
@@ -47,24 +56,47 @@ func (u UserServiceTest) Test(t *testing.T) {
 		)
 		t.Cleanup(teardown)
 
-		_, err := sut.RegisterAccount(ctx, request)
+		err := sut.RegisterAccount(ctx, request)
 		if err != nil {
 			t.Error("should not be error")
 		}
 	})
 
+	t.Run("keep the account when the activation email can not be sent", func(t *testing.T) {
+		var (
+			ctx                             = context.Background()
+			usr, invt, tkn, tranx, teardown = u.CreateDependencies()
+			sut                             = service.UsersServices{usr, invt, tkn, tranx, nil, nil, nil, failingMailer{}, nil, nil, nil}
+		)
+		t.Cleanup(teardown)
+
+		err := sut.RegisterAccount(ctx, service.RegisterRequest{
+			Username: "nadia",
+			Email:    "nadia@test.test",
+			Password: "Nadia2442$",
+		})
+		if err == nil {
+			t.Fatal("expected the failed email to be reported")
+		}
+
+		user, err := usr.GetByEmail(ctx, "nadia@test.test")
+		if err != nil || user.Id == 0 {
+			t.Errorf("expected the account to be committed before the email but got: %+v %v", user, err)
+		}
+	})
+
 	t.Run("activate new user", func(t *testing.T) {
 		var (
 			ctx                             = context.Background()
 			usr, invt, tkn, tranx, teardown = u.CreateDependencies()
-			sut                             = service.UsersServices{usr, invt, tkn, tranx, nil}
+			sut                             = service.UsersServices{usr, invt, tkn, tranx, nil, nil, nil, &mailLocal.InMemoryMailer{}, nil, nil, nil}
 			request                         = service.ActivatedRequest{
-				Token: "lizzy is the goddess of sadness",
+				Token: "lizzy is the goddess of saddness",
 			}
 		)
 		t.Cleanup(teardown)
 
-		_, err := sut.RegisterAccount(ctx, service.RegisterRequest{
+		err := sut.RegisterAccount(ctx, service.RegisterRequest{
 			Username: "elizabeth",
 			Email:    "elizabeth@test.test",
 			Password: "Lizzy2442$",
@@ -81,13 +113,13 @@ func (u UserServiceTest) Test(t *testing.T) {
 
 	t.Run("login user", func(t *testing.T) {
 		tc := &loginLimiter.InMemoryLoginLimiter{
-			Limit: 3,
+			Policy: loginLimiter.DefaultPolicy,
 		}
 
 		var (
 			ctx                             = context.Background()
 			usr, invt, tkn, tranx, teardown = u.CreateDependencies()
-			sut                             = service.UsersServices{usr, invt, tkn, tranx, tc}
+			sut                             = service.UsersServices{usr, invt, tkn, tranx, tc, nil, nil, &mailLocal.InMemoryMailer{}, nil, nil, nil}
 			request                         = service.LoginRequest{
 				Email:    "elizabeth@test.test",
 				Password: "Lizzy2442$",
@@ -95,7 +127,7 @@ func (u UserServiceTest) Test(t *testing.T) {
 		)
 		t.Cleanup(teardown)
 
-		tok, err := sut.RegisterAccount(ctx, service.RegisterRequest{
+		err := sut.RegisterAccount(ctx, service.RegisterRequest{
 			Username: "elizabeth",
 			Email:    "elizabeth@test.test",
 			Password: "Lizzy2442$",
@@ -104,7 +136,7 @@ func (u UserServiceTest) Test(t *testing.T) {
 			t.Error("should not be error")
 		}
 
-		err = sut.ActivateAccount(ctx, tok.Token)
+		err = sut.ActivateAccount(ctx, tkn.Generate())
 		if err != nil {
 			t.Error("should not be error")
 		}
@@ -115,11 +147,62 @@ func (u UserServiceTest) Test(t *testing.T) {
 		}
 	})
 
+	t.Run("lock user after too many failed logins until unlocked", func(t *testing.T) {
+		tc := &loginLimiter.InMemoryLoginLimiter{
+			Policy: loginLimiter.Policy{IpLimit: 10, AccountLimit: 10, LockoutThreshold: 2, Window: time.Minute},
+		}
+
+		var (
+			ctx                             = context.Background()
+			usr, invt, tkn, tranx, teardown = u.CreateDependencies()
+			sut                             = service.UsersServices{usr, invt, tkn, tranx, tc, nil, nil, &mailLocal.InMemoryMailer{}, nil, nil, nil}
+			request                         = service.LoginRequest{
+				Email:    "elizabeth@test.test",
+				Password: "wrong",
+				Ip:       "10.0.0.1",
+			}
+		)
+		t.Cleanup(teardown)
+
+		err := sut.RegisterAccount(ctx, service.RegisterRequest{
+			Username: "elizabeth",
+			Email:    "elizabeth@test.test",
+			Password: "Lizzy2442$",
+		})
+		if err != nil {
+			t.Fatal("should not be error")
+		}
+
+		if err := sut.ActivateAccount(ctx, tkn.Generate()); err != nil {
+			t.Fatal("should not be error")
+		}
+
+		for range 2 {
+			if _, err := sut.Login(ctx, request); err == nil {
+				t.Fatal("expected wrong password to fail")
+			}
+		}
+
+		request.Password = "Lizzy2442$"
+		_, err = sut.Login(ctx, request)
+		if errorService.GetError(err).E != service.ErrUserLocked {
+			t.Fatalf("expected user to be locked but got: %v", err)
+		}
+
+		if err := sut.Unlock(ctx, 1); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := sut.Login(ctx, request); err != nil {
+			t.Errorf("expected user to login after unlocking but got: %v", err)
+		}
+	})
+
 	t.Run("get user's profile that already activate", func(t *testing.T) {
 		var (
 			ctx                             = context.Background()
 			usr, invt, tkn, tranx, teardown = u.CreateDependencies()
-			sut                             = service.UsersServices{usr, invt, tkn, tranx, nil}
+			sut                             = service.UsersServices{usr, invt, tkn, tranx, nil, nil, nil, &mailLocal.InMemoryMailer{}, nil, nil, nil}
 		)
 		t.Cleanup(teardown)
 
@@ -130,19 +213,433 @@ func (u UserServiceTest) Test(t *testing.T) {
 
 	})
 
-	t.Run("delete user", func(t *testing.T) {
+	t.Run("reset password", func(t *testing.T) {
 		var (
 			ctx                             = context.Background()
 			usr, invt, tkn, tranx, teardown = u.CreateDependencies()
-			sut                             = service.UsersServices{usr, invt, tkn, tranx, nil}
+			sessionsStore                   = &sessions.InMemorySessions{}
+			mail                            = &mailLocal.InMemoryMailer{}
+			sut                             = service.UsersServices{usr, invt, tkn, tranx, nil, &resets.InMemoryResets{}, sessionsStore, mail, nil, nil, nil}
 		)
 		t.Cleanup(teardown)
-		err := sut.DeleteAccount(ctx, 2)
+
+		err := sut.RegisterAccount(ctx, service.RegisterRequest{
+			Username: "elizabeth",
+			Email:    "elizabeth@test.test",
+			Password: "Lizzy2442$",
+		})
 		if err != nil {
-			t.Error("should not be error")
+			t.Fatal("should not be error")
+		}
+
+		if err := sut.ActivateAccount(ctx, tkn.Generate()); err != nil {
+			t.Fatal("should not be error")
+		}
+
+		if err := sessionsStore.Insert(ctx, nil, models.Session{Id: "01JSESSION", UserId: 1}); err != nil {
+			t.Fatal("should not be error")
+		}
+
+		err = sut.ForgotPassword(ctx, service.ForgotPasswordRequest{Email: "elizabeth@test.test"})
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		token := tkn.Generate()
+		msg, ok := mail.Last("elizabeth@test.test")
+		if !ok || !strings.Contains(msg.PlainBody, token) {
+			t.Fatal("expected the reset token to be emailed")
+		}
+
+		if !strings.Contains(msg.PlainBody, "expire in 30 minutes") {
+			t.Errorf("expected the email to tell the lifetime of the token but got: %v", msg.PlainBody)
+		}
+
+		err = sut.ResetPassword(ctx, service.ResetPasswordRequest{Token: token, Password: "NewLizzy2442$"})
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		user, _ := sut.FindUserById(ctx, 1)
+		if err := user.Password.ComparePassword("NewLizzy2442$"); err != nil {
+			t.Error("expected password to be changed")
+		}
+
+		session, _ := sessionsStore.GetById(ctx, "01JSESSION")
+		if session.RevokedAt == nil {
+			t.Error("expected sessions to be revoked")
+		}
+
+		err = sut.ResetPassword(ctx, service.ResetPasswordRequest{Token: token, Password: "Again2442$"})
+		if errorService.GetError(err).E != service.ErrTokenResetNotFound {
+			t.Errorf("expected reset token to be single use but got: %v", err)
+		}
+	})
+
+	t.Run("resend activation", func(t *testing.T) {
+		var (
+			ctx                             = context.Background()
+			usr, invt, tkn, tranx, teardown = u.CreateDependencies()
+			mail                            = &mailLocal.InMemoryMailer{}
+			sut                             = service.UsersServices{usr, invt, tkn, tranx, nil, nil, nil, mail, nil, nil, nil}
+			request                         = service.ResendActivationRequest{Email: "elizabeth@test.test"}
+		)
+		t.Cleanup(teardown)
+
+		err := sut.RegisterAccount(ctx, service.RegisterRequest{
+			Username: "elizabeth",
+			Email:    "elizabeth@test.test",
+			Password: "Lizzy2442$",
+		})
+		if err != nil {
+			t.Fatal("should not be error")
+		}
+
+		err = sut.ResendActivation(ctx, request)
+		if errorService.GetError(err).E != service.ErrActivationLimited {
+			t.Fatalf("expected %v but got: %v", service.ErrActivationLimited, err)
+		}
+
+		// pretend the activation email was sent before the cooldown
+		store := invt.(*invitations.InMemoryInvitations)
+		store.Invitation[0].CreatedAt = time.Now().Add(-service.ACTIVATION_RESEND_COOLDOWN)
+
+		if err := sut.ResendActivation(ctx, request); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if len(store.Invitation) != 1 || len(mail.Messages) != 2 {
+			t.Errorf("expected the previous invitation to be replaced and a new email sent, got %v invitations and %v emails", len(store.Invitation), len(mail.Messages))
+		}
+	})
+
+	t.Run("forgot password of unknown email", func(t *testing.T) {
+		var (
+			ctx                             = context.Background()
+			usr, invt, tkn, tranx, teardown = u.CreateDependencies()
+			sut                             = service.UsersServices{usr, invt, tkn, tranx, nil, &resets.InMemoryResets{}, nil, &mailLocal.InMemoryMailer{}, nil, nil, nil}
+		)
+		t.Cleanup(teardown)
+
+		mail := &mailLocal.InMemoryMailer{}
+		sut.Mailer = mail
+
+		err := sut.ForgotPassword(ctx, service.ForgotPasswordRequest{Email: "nobody@test.test"})
+		if err != nil {
+			t.Errorf("should not be error but got: %v", err)
+		}
+
+		if len(mail.Messages) != 0 {
+			t.Error("expected no email to be sent")
 		}
 	})
 
+	t.Run("change password", func(t *testing.T) {
+		var (
+			ctx                             = context.Background()
+			usr, invt, tkn, tranx, teardown = u.CreateDependencies()
+			sessionsStore                   = &sessions.InMemorySessions{}
+			sut                             = service.UsersServices{usr, invt, tkn, tranx, nil, nil, sessionsStore, &mailLocal.InMemoryMailer{}, nil, nil, nil}
+		)
+		t.Cleanup(teardown)
+
+		err := sut.RegisterAccount(ctx, service.RegisterRequest{
+			Username: "elizabeth",
+			Email:    "elizabeth@test.test",
+			Password: "Lizzy2442$",
+		})
+		if err != nil {
+			t.Fatal("should not be error")
+		}
+
+		if err := sessionsStore.Insert(ctx, nil, models.Session{Id: "01JSESSION", UserId: 1}); err != nil {
+			t.Fatal("should not be error")
+		}
+
+		err = sut.ChangePassword(ctx, 1, service.ChangePasswordRequest{CurrentPassword: "wrong", NewPassword: "NewLizzy2442$"})
+		if errorService.GetError(err).E != service.ErrPasswordIncorrect {
+			t.Fatalf("expected %v but got: %v", service.ErrPasswordIncorrect, err)
+		}
+
+		err = sut.ChangePassword(ctx, 1, service.ChangePasswordRequest{CurrentPassword: "Lizzy2442$", NewPassword: "NewLizzy2442$"})
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		user, _ := sut.FindUserById(ctx, 1)
+		if err := user.Password.ComparePassword("NewLizzy2442$"); err != nil {
+			t.Error("expected password to be changed")
+		}
+
+		session, _ := sessionsStore.GetById(ctx, "01JSESSION")
+		if session.RevokedAt == nil {
+			t.Error("expected sessions to be revoked")
+		}
+	})
+
+	t.Run("change email after confirmation", func(t *testing.T) {
+		var (
+			ctx                             = context.Background()
+			usr, invt, tkn, tranx, teardown = u.CreateDependencies()
+			sessionsStore                   = &sessions.InMemorySessions{}
+			mail                            = &mailLocal.InMemoryMailer{}
+			sut                             = service.UsersServices{usr, invt, tkn, tranx, nil, nil, sessionsStore, mail, nil, nil, nil}
+		)
+		t.Cleanup(teardown)
+
+		err := sut.RegisterAccount(ctx, service.RegisterRequest{
+			Username: "elizabeth",
+			Email:    "elizabeth@test.test",
+			Password: "Lizzy2442$",
+		})
+		if err != nil {
+			t.Fatal("should not be error")
+		}
+
+		if err := sut.ActivateAccount(ctx, tkn.Generate()); err != nil {
+			t.Fatal("should not be error")
+		}
+
+		err = sut.RequestEmailChange(ctx, 1, service.ChangeEmailRequest{Email: "elizabeth@test.test", Password: "Lizzy2442$"})
+		if errorService.GetError(err).E != service.ErrUserAlreadyExist {
+			t.Fatalf("expected %v but got: %v", service.ErrUserAlreadyExist, err)
+		}
+
+		err = sut.RequestEmailChange(ctx, 1, service.ChangeEmailRequest{Email: "lizzy@test.test", Password: "Lizzy2442$"})
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		msg, ok := mail.Last("lizzy@test.test")
+		if !ok || !strings.Contains(msg.PlainBody, tkn.Generate()) {
+			t.Fatal("expected the confirmation token to be emailed to the new address")
+		}
+
+		if !strings.Contains(msg.PlainBody, "expire in 24 hours") {
+			t.Errorf("expected the email to tell when the token expires but got: %v", msg.PlainBody)
+		}
+
+		if err := sessionsStore.Insert(ctx, nil, models.Session{Id: "01JSESSION", UserId: 1}); err != nil {
+			t.Fatal("should not be error")
+		}
+
+		user, _ := sut.FindUserById(ctx, 1)
+		if user.Email != "elizabeth@test.test" {
+			t.Fatalf("expected email not to change before confirmation but got: %v", user.Email)
+		}
+
+		if err := sut.ConfirmEmailChange(ctx, tkn.Generate()); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		user, _ = sut.FindUserById(ctx, 1)
+		if user.Email != "lizzy@test.test" {
+			t.Errorf("expected email to be changed but got: %v", user.Email)
+		}
+
+		session, _ := sessionsStore.GetById(ctx, "01JSESSION")
+		if session.RevokedAt == nil {
+			t.Error("expected sessions to be revoked")
+		}
+
+		err = sut.ConfirmEmailChange(ctx, tkn.Generate())
+		if errorService.GetError(err).E != service.ErrTokenEmailNotFound {
+			t.Errorf("expected token to be single use but got: %v", err)
+		}
+	})
+
+	t.Run("deactivate user", func(t *testing.T) {
+		var (
+			ctx                             = context.Background()
+			usr, invt, tkn, tranx, teardown = u.CreateDependencies()
+			sessionsStore                   = &sessions.InMemorySessions{}
+			mail                            = &mailLocal.InMemoryMailer{}
+			limiter                         = &loginLimiter.InMemoryLoginLimiter{Policy: loginLimiter.DefaultPolicy}
+			sut                             = service.UsersServices{usr, invt, tkn, tranx, limiter, nil, sessionsStore, mail, nil, nil, nil}
+		)
+		t.Cleanup(teardown)
+
+		err := sut.RegisterAccount(ctx, service.RegisterRequest{
+			Username: "elizabeth",
+			Email:    "elizabeth@test.test",
+			Password: "Lizzy2442$",
+		})
+		if err != nil {
+			t.Fatal("should not be error")
+		}
+
+		if err := sessionsStore.Insert(ctx, nil, models.Session{Id: "01JSESSION", UserId: 1}); err != nil {
+			t.Fatal("should not be error")
+		}
+
+		err = sut.Deactivate(ctx, 1, 1)
+		if errorService.GetError(err).E != service.ErrUserManageSelf {
+			t.Fatalf("expected %v but got: %v", service.ErrUserManageSelf, err)
+		}
+
+		if err := sut.Deactivate(ctx, 2, 1); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		session, _ := sessionsStore.GetById(ctx, "01JSESSION")
+		if session.RevokedAt == nil {
+			t.Error("expected sessions to be revoked")
+		}
+
+		_, err = sut.Login(ctx, service.LoginRequest{Email: "elizabeth@test.test", Password: "Lizzy2442$"})
+		if errorService.GetError(err).E != service.ErrUserDeactivated {
+			t.Errorf("expected %v but got: %v", service.ErrUserDeactivated, err)
+		}
+
+		// the user can not activate the account again by itself
+		store := invt.(*invitations.InMemoryInvitations)
+		store.Invitation[0].CreatedAt = time.Now().Add(-service.ACTIVATION_RESEND_COOLDOWN)
+
+		if err := sut.ResendActivation(ctx, service.ResendActivationRequest{Email: "elizabeth@test.test"}); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if len(mail.Messages) != 1 {
+			t.Errorf("expected no activation email to be resent, got %v emails", len(mail.Messages))
+		}
+	})
+
+	t.Run("reactivate only deactivated user", func(t *testing.T) {
+		var (
+			ctx                             = context.Background()
+			usr, invt, tkn, tranx, teardown = u.CreateDependencies()
+			sessionsStore                   = &sessions.InMemorySessions{}
+			mail                            = &mailLocal.InMemoryMailer{}
+			limiter                         = &loginLimiter.InMemoryLoginLimiter{Policy: loginLimiter.DefaultPolicy}
+			sut                             = service.UsersServices{usr, invt, tkn, tranx, limiter, nil, sessionsStore, mail, nil, nil, nil}
+		)
+		t.Cleanup(teardown)
+
+		err := sut.RegisterAccount(ctx, service.RegisterRequest{
+			Username: "elizabeth",
+			Email:    "elizabeth@test.test",
+			Password: "Lizzy2442$",
+		})
+		if err != nil {
+			t.Fatal("should not be error")
+		}
+
+		err = sut.Reactivate(ctx, 1)
+		if errorService.GetError(err).E != service.ErrUserNotDeactivated {
+			t.Fatalf("expected %v but got: %v", service.ErrUserNotDeactivated, err)
+		}
+
+		if err := sut.Deactivate(ctx, 2, 1); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if err := sut.Reactivate(ctx, 1); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		user, _ := sut.FindUserById(ctx, 1)
+		if !*user.IsActive || user.DeactivatedAt != nil {
+			t.Errorf("expected user to be active but got: %+v", user)
+		}
+	})
+
+	t.Run("force password reset", func(t *testing.T) {
+		var (
+			ctx                             = context.Background()
+			usr, invt, tkn, tranx, teardown = u.CreateDependencies()
+			sessionsStore                   = &sessions.InMemorySessions{}
+			mail                            = &mailLocal.InMemoryMailer{}
+			sut                             = service.UsersServices{usr, invt, tkn, tranx, nil, &resets.InMemoryResets{}, sessionsStore, mail, nil, nil, nil}
+		)
+		t.Cleanup(teardown)
+
+		err := sut.RegisterAccount(ctx, service.RegisterRequest{
+			Username: "elizabeth",
+			Email:    "elizabeth@test.test",
+			Password: "Lizzy2442$",
+		})
+		if err != nil {
+			t.Fatal("should not be error")
+		}
+
+		if err := sessionsStore.Insert(ctx, nil, models.Session{Id: "01JSESSION", UserId: 1}); err != nil {
+			t.Fatal("should not be error")
+		}
+
+		if err := sut.ForcePasswordReset(ctx, 1); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		user, _ := sut.FindUserById(ctx, 1)
+		if err := user.Password.ComparePassword("Lizzy2442$"); err == nil {
+			t.Error("expected the old password to stop working")
+		}
+
+		session, _ := sessionsStore.GetById(ctx, "01JSESSION")
+		if session.RevokedAt == nil {
+			t.Error("expected sessions to be revoked")
+		}
+
+		msg, ok := mail.Last("elizabeth@test.test")
+		if !ok || msg.Subject == "" || !strings.Contains(msg.PlainBody, tkn.Generate()) {
+			t.Fatal("expected the reset token to be emailed")
+		}
+
+		err = sut.ResetPassword(ctx, service.ResetPasswordRequest{Token: tkn.Generate(), Password: "NewLizzy2442$"})
+		if err != nil {
+			t.Errorf("should not be error but got: %v", err)
+		}
+	})
+
+}
+
+func TestUserIdentityCache(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		usersStore = &users.InMemoryUsers{Users: []models.User{{Id: 1, Username: "nadia", RoleId: 1}}}
+		sut        = &service.UsersServices{
+			UsersStore:  usersStore,
+			RolesStore:  &roles.InMemoryRoles{Roles: []models.RolesModel{{Id: 1, Name: "customer"}, {Id: 2, Name: "admin"}}},
+			Transaction: &transactionFake{state: initial},
+			Cache:       &cache.InMemoryCache{},
+		}
+	)
+
+	if _, err := sut.FindIdentity(ctx, 1); err != nil {
+		t.Fatalf("should not be error but got: %v", err)
+	}
+
+	// written behind the service, only the cached identity is seen
+	usersStore.Users[0].Username = "lizzy"
+
+	cached, err := sut.FindIdentity(ctx, 1)
+	if err != nil {
+		t.Fatalf("should not be error but got: %v", err)
+	}
+
+	if cached.Username != "nadia" {
+		t.Errorf("expected the identity to be cached but got: %+v", cached)
+	}
+
+	if err := sut.ChangeRole(ctx, 7, 1, service.ChangeUserRoleRequest{RoleId: 2}); err != nil {
+		t.Fatalf("should not be error but got: %v", err)
+	}
+
+	changed, err := sut.FindIdentity(ctx, 1)
+	if err != nil {
+		t.Fatalf("should not be error but got: %v", err)
+	}
+
+	if changed.RoleId != 2 || changed.Username != "lizzy" {
+		t.Errorf("expected the identity to be read again after the role change but got: %+v", changed)
+	}
+}
+
+// failingMailer can not send any email.
+type failingMailer struct{}
+
+func (failingMailer) Send(ctx context.Context, to string, templateFile string, data any) error {
+	return errors.New("mail server is down")
 }
 
 type tokenInvitationFake struct {
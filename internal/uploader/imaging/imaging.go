@@ -0,0 +1,244 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/faizisyellow/indocoffee/internal/uploader"
+)
+
+const (
+	// DefaultMaxPixels is about a 12 megapixel photo, its pixels take 48 MB once decoded.
+	DefaultMaxPixels = 12_000_000
+	// DefaultConcurrency is how many images are processed at once,
+	// the others wait so the decoded pixels do not exhaust the memory.
+	DefaultConcurrency = 2
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("imaging: only png and jpeg images are supported")
+	ErrTooManyPixels     = errors.New("imaging: image has too many pixels")
+)
+
+// Rendition is a resized copy of an uploaded image.
+type Rendition struct {
+	Name string
+	// Width is the maximum width in pixels, smaller images are never upscaled.
+	Width int
+}
+
+// DefaultRenditions are ordered smallest first.
+var DefaultRenditions = []Rendition{
+	{Name: "thumbnail", Width: 160},
+	{Name: "medium", Width: 640},
+	{Name: "large", Width: 1600},
+}
+
+// Encoder encodes every rendition to another format next to the format of the upload.
+type Encoder interface {
+	Format() string
+	MimeType() string
+	Encode(w io.Writer, img image.Image) error
+}
+
+// Pipeline checks an upload by its content and turns it into renditions.
+// Renditions are decoded and encoded again, so no metadata such as EXIF
+// of the upload is kept, jpeg orientation is applied to the pixels first.
+type Pipeline struct {
+	MaxPixels int
+	// Renditions are ordered smallest first, the last one is the main image.
+	Renditions []Rendition
+	// Encoders add a copy of every rendition in another format.
+	// None is set by default, WebP is not produced since the standard
+	// library has no encoder for it.
+	Encoders []Encoder
+	// slots limits how many images are processed at once, a pipeline
+	// without them is not limited. Copies of a pipeline share them.
+	slots chan struct{}
+}
+
+func NewPipeline() Pipeline {
+	return Pipeline{
+		MaxPixels:  DefaultMaxPixels,
+		Renditions: DefaultRenditions,
+		slots:      make(chan struct{}, DefaultConcurrency),
+	}
+}
+
+// Output is an encoded rendition ready to be uploaded.
+type Output struct {
+	Rendition string
+	Format    string
+	// Converted is true when the output is in the format of an encoder.
+	Converted bool
+	File      uploader.FileInput
+}
+
+// Key names the output in the renditions of an image, the rendition name
+// for the format of the upload and the name with the format for the others.
+func (o Output) Key() string {
+	if !o.Converted {
+		return o.Rendition
+	}
+
+	return o.Rendition + "." + o.Format
+}
+
+// Process returns every rendition of the file in the format it was uploaded in
+// followed by the same rendition in the formats of the encoders.
+func (p Pipeline) Process(file uploader.FileInput) ([]Output, error) {
+
+	format, err := Sniff(file.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	// check the size from the header before decoding the pixels
+	config, _, err := image.DecodeConfig(bytes.NewReader(file.Content))
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+
+	if p.MaxPixels > 0 && config.Width*config.Height > p.MaxPixels {
+		return nil, ErrTooManyPixels
+	}
+
+	if p.slots != nil {
+		p.slots <- struct{}{}
+		defer func() { <-p.slots }()
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(file.Content))
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+
+	src := toRGBA(decoded)
+	if format == "jpeg" {
+		src = orient(src, exifOrientation(file.Content))
+	}
+	name := strings.TrimSuffix(file.Name, filepath.Ext(file.Name))
+
+	var outputs []Output
+	for _, rendition := range p.Renditions {
+		resized := resize(src, rendition.Width)
+
+		var buf bytes.Buffer
+		if err := encode(&buf, format, resized); err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, newOutput(name, rendition.Name, format, mimeTypes[format], buf.Bytes()))
+
+		for _, encoder := range p.Encoders {
+			var buf bytes.Buffer
+			if err := encoder.Encode(&buf, resized); err != nil {
+				return nil, err
+			}
+			output := newOutput(name, rendition.Name, encoder.Format(), encoder.MimeType(), buf.Bytes())
+			output.Converted = true
+			outputs = append(outputs, output)
+		}
+	}
+
+	return outputs, nil
+}
+
+var mimeTypes = map[string]string{
+	"png":  "image/png",
+	"jpeg": "image/jpeg",
+}
+
+// Sniff tells the format from the magic bytes of the content,
+// the content type sent by the client is never trusted.
+func Sniff(content []byte) (string, error) {
+	switch {
+	case bytes.HasPrefix(content, []byte("\x89PNG\r\n\x1a\n")):
+		return "png", nil
+	case bytes.HasPrefix(content, []byte{0xFF, 0xD8, 0xFF}):
+		return "jpeg", nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+func newOutput(name, rendition, format, mimeType string, content []byte) Output {
+	return Output{
+		Rendition: rendition,
+		Format:    format,
+		File: uploader.FileInput{
+			Name:     name + "-" + rendition + "." + format,
+			Size:     int64(len(content)),
+			MimeType: mimeType,
+			Content:  content,
+		},
+	}
+}
+
+func encode(w io.Writer, format string, img image.Image) error {
+	if format == "png" {
+		return png.Encode(w, img)
+	}
+
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: 85})
+}
+
+// toRGBA copies the image into the pixels of an RGBA image starting at 0,0.
+// draw.Draw converts the types png and jpeg decode to straight into Pix.
+func toRGBA(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	if rgba, ok := img.(*image.RGBA); ok && bounds.Min == (image.Point{}) {
+		return rgba
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+
+	return dst
+}
+
+// resize scales the image down to the width keeping its aspect ratio,
+// every pixel is the average of the pixels it covers.
+func resize(src *image.RGBA, width int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	if sw <= width {
+		return src
+	}
+
+	height := max(1, sh*width/sw)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for dy := 0; dy < height; dy++ {
+		y0, y1 := dy*sh/height, max((dy+1)*sh/height, dy*sh/height+1)
+
+		for dx := 0; dx < width; dx++ {
+			x0, x1 := dx*sw/width, max((dx+1)*sw/width, dx*sw/width+1)
+
+			var r, g, b, a, n int
+			for y := y0; y < y1; y++ {
+				row := src.Pix[y*src.Stride:]
+				for x := x0; x < x1; x++ {
+					r += int(row[x*4])
+					g += int(row[x*4+1])
+					b += int(row[x*4+2])
+					a += int(row[x*4+3])
+					n++
+				}
+			}
+
+			i := dy*dst.Stride + dx*4
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
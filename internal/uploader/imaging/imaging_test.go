@@ -0,0 +1,209 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/uploader"
+)
+
+func TestPipeline(t *testing.T) {
+	t.Run("reject content that is not an image whatever the client says", func(t *testing.T) {
+		file := uploader.FileInput{Name: "lizzy.png", MimeType: "image/png", Content: []byte("GIF89a not a png")}
+
+		if _, err := NewPipeline().Process(file); err != ErrUnsupportedFormat {
+			t.Errorf("expected error %v but got %v", ErrUnsupportedFormat, err)
+		}
+	})
+
+	t.Run("reject an image with too many pixels", func(t *testing.T) {
+		file := uploader.FileInput{Name: "lizzy.png", Content: encodePng(t, 100, 100)}
+
+		if _, err := (Pipeline{MaxPixels: 9_999, Renditions: DefaultRenditions}).Process(file); err != ErrTooManyPixels {
+			t.Errorf("expected error %v but got %v", ErrTooManyPixels, err)
+		}
+	})
+
+	t.Run("renditions are scaled down but never up", func(t *testing.T) {
+		file := uploader.FileInput{Name: "lizzy.png", Content: encodePng(t, 800, 400)}
+
+		outputs, err := NewPipeline().Process(file)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		expected := map[string]image.Point{
+			"thumbnail": {160, 80},
+			"medium":    {640, 320},
+			"large":     {800, 400},
+		}
+
+		if len(outputs) != len(expected) {
+			t.Fatalf("expected %v outputs but got %v", len(expected), len(outputs))
+		}
+
+		for _, output := range outputs {
+			config, format, err := image.DecodeConfig(bytes.NewReader(output.File.Content))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			size := expected[output.Key()]
+			if config.Width != size.X || config.Height != size.Y || format != "png" {
+				t.Errorf("unexpected %v rendition %vx%v %v", output.Key(), config.Width, config.Height, format)
+			}
+
+			if output.File.Name != "lizzy-"+output.Rendition+".png" || output.File.MimeType != "image/png" {
+				t.Errorf("unexpected file of %v rendition: %v %v", output.Key(), output.File.Name, output.File.MimeType)
+			}
+		}
+	})
+
+	t.Run("exif is stripped and its orientation applied", func(t *testing.T) {
+		file := uploader.FileInput{Name: "lizzy.jpeg", Content: withOrientation(t, encodeJpeg(t, 40, 20), 6)}
+
+		outputs, err := (Pipeline{Renditions: []Rendition{{Name: "large", Width: 100}}}).Process(file)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		content := outputs[0].File.Content
+		if bytes.Contains(content, []byte("Exif")) {
+			t.Error("expected the exif segment to be stripped")
+		}
+
+		config, err := jpeg.DecodeConfig(bytes.NewReader(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if config.Width != 20 || config.Height != 40 {
+			t.Errorf("expected the image to be turned a quarter but got %vx%v", config.Width, config.Height)
+		}
+	})
+
+	t.Run("orientation moves every pixel", func(t *testing.T) {
+		src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+		src.Set(0, 0, color.RGBA{255, 0, 0, 255})
+		src.Set(1, 0, color.RGBA{0, 0, 255, 255})
+
+		// 6 is turned a quarter clockwise, the left pixel ends up on top
+		turned := orient(src, 6)
+		if turned.Bounds().Dx() != 1 || turned.Bounds().Dy() != 2 {
+			t.Fatalf("expected 1x2 but got %vx%v", turned.Bounds().Dx(), turned.Bounds().Dy())
+		}
+
+		if turned.RGBAAt(0, 0) != (color.RGBA{255, 0, 0, 255}) || turned.RGBAAt(0, 1) != (color.RGBA{0, 0, 255, 255}) {
+			t.Errorf("unexpected pixels %v %v", turned.RGBAAt(0, 0), turned.RGBAAt(0, 1))
+		}
+	})
+
+	t.Run("images wait for a free slot", func(t *testing.T) {
+		pipeline := NewPipeline()
+		for range DefaultConcurrency {
+			pipeline.slots <- struct{}{}
+		}
+
+		file := uploader.FileInput{Name: "lizzy.png", Content: encodePng(t, 10, 10)}
+
+		done := make(chan error)
+		go func() {
+			_, err := pipeline.Process(file)
+			done <- err
+		}()
+
+		select {
+		case <-done:
+			t.Fatal("expected the image to wait for a slot")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		<-pipeline.slots
+
+		if err := <-done; err != nil {
+			t.Errorf("should not be error but got: %v", err)
+		}
+	})
+
+	t.Run("encoders add a rendition in their format", func(t *testing.T) {
+		file := uploader.FileInput{Name: "lizzy.png", Content: encodePng(t, 10, 10)}
+		pipeline := Pipeline{Renditions: []Rendition{{Name: "thumbnail", Width: 160}}, Encoders: []Encoder{encoderFake{}}}
+
+		outputs, err := pipeline.Process(file)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if len(outputs) != 2 || outputs[1].Key() != "thumbnail.fake" || outputs[1].File.Name != "lizzy-thumbnail.fake" {
+			t.Errorf("expected the converted thumbnail but got: %+v", outputs)
+		}
+	})
+}
+
+func encodePng(t *testing.T, w, h int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 120, 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func encodeJpeg(t *testing.T, w, h int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+// withOrientation inserts an EXIF segment with only the orientation right after the start of image.
+func withOrientation(t *testing.T, content []byte, orientation uint16) []byte {
+	t.Helper()
+
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, orientationTag)
+	// type short, one value
+	tiff = binary.BigEndian.AppendUint16(tiff, 3)
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+
+	exif := []byte{0xFF, 0xE1}
+	exif = binary.BigEndian.AppendUint16(exif, uint16(len(segment)+2))
+	exif = append(exif, segment...)
+
+	return append(append(append([]byte{}, content[:2]...), exif...), content[2:]...)
+}
+
+type encoderFake struct{}
+
+func (encoderFake) Format() string   { return "fake" }
+func (encoderFake) MimeType() string { return "image/fake" }
+
+func (encoderFake) Encode(w io.Writer, img image.Image) error {
+	_, err := w.Write([]byte("fake"))
+	return err
+}
@@ -0,0 +1,328 @@
+package local
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/uploader"
+)
+
+// CacheControl lets clients keep a file forever, a name is never given to another file.
+const CacheControl = "public, max-age=31536000, immutable"
+
+var ErrInvalidKey = errors.New("local: invalid file key")
+
+// Storage keeps files in a directory and serves them over http, for self hosting
+// and offline development. Every upload is named by a random part so it never
+// overwrites another file, and is written to a temporary file first so a file
+// is either missing or complete.
+type Storage struct {
+	Dir string
+	// BaseUrl is where the files are served such as http://localhost:8080/media.
+	BaseUrl string
+	// Secret signs the urls clients upload to, direct uploads are not supported without it.
+	Secret []byte
+}
+
+func (s *Storage) UploadFile(ctx context.Context, file uploader.FileInput) (string, error) {
+	key := uploader.StoreName(file.Name)
+
+	path, err := s.path(key)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	if err := writeFile(path, file.Content); err != nil {
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return s.url(key), nil
+}
+
+func (s *Storage) DeleteFile(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to delete file %s: %w", key, err)
+	}
+
+	// the directory of the random part is removed once it is empty
+	if dir := filepath.Dir(path); dir != filepath.Clean(s.Dir) {
+		os.Remove(dir)
+	}
+
+	return nil
+}
+
+// FileKey is the key of a file from its url, empty when the url is not served by this storage.
+func (s *Storage) FileKey(url string) string {
+	key, found := strings.CutPrefix(url, s.url(""))
+	if !found || !filepath.IsLocal(key) {
+		return ""
+	}
+
+	return key
+}
+
+// PresignUpload returns a signed url the client PUTs the file to,
+// the key, expiry, size and content type of the upload are signed.
+func (s *Storage) PresignUpload(ctx context.Context, file uploader.PresignInput) (uploader.UploadTarget, error) {
+	if len(s.Secret) == 0 {
+		return uploader.UploadTarget{}, uploader.ErrPresignNotSupported
+	}
+
+	key := uploader.UploadName(file.Name)
+	expiresAt := time.Now().Add(file.Expires)
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expiresAt.Unix(), 10))
+	query.Set("size", strconv.FormatInt(file.Size, 10))
+	query.Set("signature", s.signature(key, query.Get("expires"), query.Get("size"), file.MimeType))
+
+	return uploader.UploadTarget{
+		Key:       key,
+		Url:       s.url(key) + "?" + query.Encode(),
+		Method:    http.MethodPut,
+		Headers:   map[string]string{"Content-Type": file.MimeType},
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// ReadFile reads a file uploaded to a signed url, files out of the uploads are never read.
+func (s *Storage) ReadFile(ctx context.Context, key string, limit int64) (uploader.FileInput, error) {
+	path, err := s.path(key)
+	if err != nil || !strings.HasPrefix(key, uploader.UploadsPrefix) {
+		return uploader.FileInput{}, uploader.ErrFileNotFound
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return uploader.FileInput{}, uploader.ErrFileNotFound
+		}
+		return uploader.FileInput{}, err
+	}
+
+	if info.Size() > limit {
+		return uploader.FileInput{}, uploader.ErrFileTooLarge
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return uploader.FileInput{}, err
+	}
+
+	return uploader.FileInput{
+		Name:     info.Name(),
+		Size:     int64(len(content)),
+		MimeType: http.DetectContentType(content),
+		Content:  content,
+	}, nil
+}
+
+// List walks the directory, temporary files left by an interrupted write are listed too.
+func (s *Storage) List(ctx context.Context) ([]uploader.StoredFile, error) {
+	var files []uploader.StoredFile
+
+	err := filepath.WalkDir(s.Dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) && path == s.Dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+
+		if entry.IsDir() {
+			return ctx.Err()
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		key, err := filepath.Rel(s.Dir, path)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, uploader.StoredFile{Key: filepath.ToSlash(key), Size: info.Size(), ModifiedAt: info.ModTime()})
+		return nil
+	})
+
+	return files, err
+}
+
+// ServeHTTP serves the file of the key in the path of the request, strip the route
+// prefix before it. Range and conditional requests are answered by http.ServeContent,
+// PUT requests store an upload to a signed url.
+func (s *Storage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/")
+
+	if r.Method == http.MethodPut {
+		s.receive(w, r, key)
+		return
+	}
+
+	// uploads are not processed yet, they may still carry metadata
+	path, err := s.path(key)
+	if err != nil || strings.HasPrefix(key, uploader.UploadsPrefix) {
+		http.NotFound(w, r)
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+
+	// the first part of the key is random and never given to another content
+	tag, _, _ := strings.Cut(key, "/")
+
+	w.Header().Set("ETag", `"`+tag+`"`)
+	w.Header().Set("Cache-Control", CacheControl)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+}
+
+// receive stores the body of a PUT to a url signed by PresignUpload.
+func (s *Storage) receive(w http.ResponseWriter, r *http.Request, key string) {
+	query := r.URL.Query()
+
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		http.Error(w, "upload url expired", http.StatusForbidden)
+		return
+	}
+
+	expected := s.signature(key, query.Get("expires"), query.Get("size"), r.Header.Get("Content-Type"))
+	if len(s.Secret) == 0 || !hmac.Equal([]byte(query.Get("signature")), []byte(expected)) {
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	}
+
+	path, err := s.path(key)
+	if err != nil || !strings.HasPrefix(key, uploader.UploadsPrefix) {
+		http.NotFound(w, r)
+		return
+	}
+
+	size, err := strconv.ParseInt(query.Get("size"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid size", http.StatusBadRequest)
+		return
+	}
+
+	content, err := io.ReadAll(http.MaxBytesReader(w, r.Body, size))
+	if err != nil {
+		http.Error(w, "file larger than signed", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if int64(len(content)) != size {
+		http.Error(w, "file size does not match the signed size", http.StatusBadRequest)
+		return
+	}
+
+	// a signed url uploads once
+	if _, err := os.Stat(path); err == nil {
+		http.Error(w, "file already uploaded", http.StatusConflict)
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		http.Error(w, "failed to store file", http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeFile(path, content); err != nil {
+		http.Error(w, "failed to store file", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Storage) signature(key, expires, size, mimeType string) string {
+	mac := hmac.New(sha256.New, s.Secret)
+	mac.Write([]byte(key + "\n" + expires + "\n" + size + "\n" + mimeType))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// path is where the file of the key is stored, keys can not leave the directory.
+// A key must be clean, so the prefix of the key is the directory of the path.
+func (s *Storage) path(key string) (string, error) {
+	if path.Clean(key) != key || !filepath.IsLocal(filepath.FromSlash(key)) {
+		return "", ErrInvalidKey
+	}
+
+	return filepath.Join(s.Dir, filepath.FromSlash(key)), nil
+}
+
+func (s *Storage) url(key string) string {
+	return strings.TrimSuffix(s.BaseUrl, "/") + "/" + key
+}
+
+// writeFile writes to a temporary file next to the path and renames it,
+// a rename in the same directory replaces the file at once.
+func writeFile(path string, content []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,289 @@
+package local
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/uploader"
+)
+
+func TestStorage(t *testing.T) {
+	storage := &Storage{Dir: t.TempDir(), BaseUrl: "http://localhost:8080/media/"}
+	file := uploader.FileInput{Name: "../../Lizzy.png", MimeType: "image/png", Content: []byte("lizzy coffee")}
+
+	t.Run("upload a file under a name of its own", func(t *testing.T) {
+		url, err := storage.UploadFile(context.Background(), file)
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		key := storage.FileKey(url)
+		if !strings.HasPrefix(url, "http://localhost:8080/media/") || !strings.HasSuffix(key, "/lizzy.png") {
+			t.Fatalf("unexpected url %v", url)
+		}
+
+		content, err := os.ReadFile(filepath.Join(storage.Dir, key))
+		if err != nil || string(content) != "lizzy coffee" {
+			t.Errorf("expected the file to be stored in the directory but got: %q %v", content, err)
+		}
+	})
+
+	t.Run("another file with the same name does not overwrite it", func(t *testing.T) {
+		first, err := storage.UploadFile(context.Background(), file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		other := file
+		other.Content = []byte("nadia coffee")
+		second, err := storage.UploadFile(context.Background(), other)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if first == second {
+			t.Fatalf("expected another url but got %v", second)
+		}
+
+		content, _ := os.ReadFile(filepath.Join(storage.Dir, storage.FileKey(first)))
+		if string(content) != "lizzy coffee" {
+			t.Errorf("expected the first file to be kept but got: %q", content)
+		}
+	})
+
+	t.Run("deleting a file keeps the same content uploaded again", func(t *testing.T) {
+		first, err := storage.UploadFile(context.Background(), file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		second, err := storage.UploadFile(context.Background(), file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := storage.DeleteFile(context.Background(), storage.FileKey(first)); err != nil {
+			t.Fatal(err)
+		}
+
+		content, err := os.ReadFile(filepath.Join(storage.Dir, storage.FileKey(second)))
+		if err != nil || string(content) != "lizzy coffee" {
+			t.Errorf("expected the second upload to be kept but got: %q %v", content, err)
+		}
+	})
+
+	t.Run("serve a file with range and etag", func(t *testing.T) {
+		url, err := storage.UploadFile(context.Background(), file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key := storage.FileKey(url)
+
+		req := httptest.NewRequest(http.MethodGet, "/"+key, nil)
+		req.Header.Set("Range", "bytes=0-4")
+		rec := httptest.NewRecorder()
+		storage.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusPartialContent || rec.Body.String() != "lizzy" {
+			t.Fatalf("expected the first bytes but got %v %q", rec.Code, rec.Body.String())
+		}
+
+		if rec.Header().Get("Cache-Control") != CacheControl || rec.Header().Get("Content-Type") != "image/png" {
+			t.Errorf("unexpected headers %v", rec.Header())
+		}
+
+		etag := rec.Header().Get("ETag")
+		if etag == "" {
+			t.Fatal("expected an etag")
+		}
+
+		req = httptest.NewRequest(http.MethodGet, "/"+key, nil)
+		req.Header.Set("If-None-Match", etag)
+		rec = httptest.NewRecorder()
+		storage.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusNotModified {
+			t.Errorf("expected status %v but got %v", http.StatusNotModified, rec.Code)
+		}
+	})
+
+	t.Run("paths out of the directory are not found", func(t *testing.T) {
+		for _, path := range []string{"/../storage.go", "/", "/" + filepath.Base(storage.Dir) + "/../x"} {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.URL.Path = path
+			rec := httptest.NewRecorder()
+			storage.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusNotFound {
+				t.Errorf("expected %v to be not found but got %v", path, rec.Code)
+			}
+		}
+
+		if err := storage.DeleteFile(context.Background(), "../storage.go"); err != ErrInvalidKey {
+			t.Errorf("expected error %v but got %v", ErrInvalidKey, err)
+		}
+	})
+
+	t.Run("keys that are not clean are refused", func(t *testing.T) {
+		url, err := storage.UploadFile(context.Background(), file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key := storage.FileKey(url)
+
+		if _, err := storage.ReadFile(context.Background(), uploader.UploadsPrefix+"../"+key, 1024); err != uploader.ErrFileNotFound {
+			t.Errorf("expected error %v but got %v", uploader.ErrFileNotFound, err)
+		}
+
+		if err := storage.DeleteFile(context.Background(), uploader.UploadsPrefix+"../"+key); err != ErrInvalidKey {
+			t.Errorf("expected error %v but got %v", ErrInvalidKey, err)
+		}
+
+		upload := filepath.Join(storage.Dir, filepath.FromSlash(uploader.UploadsPrefix), "raw.png")
+		if err := os.MkdirAll(filepath.Dir(upload), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(upload, []byte("raw"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.URL.Path = "/x/../" + uploader.UploadsPrefix + "raw.png"
+		rec := httptest.NewRecorder()
+		storage.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("expected an upload not to be served but got %v", rec.Code)
+		}
+
+		if err := os.Remove(upload); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("delete a file by the key of its url", func(t *testing.T) {
+		url, err := storage.UploadFile(context.Background(), file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key := storage.FileKey(url)
+
+		if err := storage.DeleteFile(context.Background(), key); err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		if _, err := os.Stat(filepath.Dir(filepath.Join(storage.Dir, key))); !os.IsNotExist(err) {
+			t.Errorf("expected the file and its directory to be removed but got: %v", err)
+		}
+
+		if key := storage.FileKey("https://app.ufs.sh/f/lizzy.png"); key != "" {
+			t.Errorf("expected no key for a url of another storage but got %v", key)
+		}
+	})
+
+	t.Run("upload to a signed url and read it back", func(t *testing.T) {
+		signed := &Storage{Dir: storage.Dir, BaseUrl: storage.BaseUrl, Secret: []byte("secret")}
+
+		target, err := signed.PresignUpload(context.Background(), uploader.PresignInput{Name: "lizzy.png", MimeType: "image/png", Size: 5, Expires: time.Minute})
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		put := func(url, contentType, body string) int {
+			req := httptest.NewRequest(http.MethodPut, strings.TrimPrefix(url, "http://localhost:8080/media"), strings.NewReader(body))
+			req.Header.Set("Content-Type", contentType)
+			rec := httptest.NewRecorder()
+			signed.ServeHTTP(rec, req)
+			return rec.Code
+		}
+
+		if code := put(target.Url, "text/html", "lizzy"); code != http.StatusForbidden {
+			t.Errorf("expected another content type to be refused but got %v", code)
+		}
+
+		if code := put(target.Url, "image/png", "lizzy coffee"); code != http.StatusRequestEntityTooLarge {
+			t.Errorf("expected a larger file to be refused but got %v", code)
+		}
+
+		if code := put(target.Url, "image/png", "lizzy"); code != http.StatusCreated {
+			t.Fatalf("expected the upload to be stored but got %v", code)
+		}
+
+		if code := put(target.Url, "image/png", "nadia"); code != http.StatusConflict {
+			t.Errorf("expected a second upload to be refused but got %v", code)
+		}
+
+		file, err := signed.ReadFile(context.Background(), target.Key, 1024)
+		if err != nil || string(file.Content) != "lizzy" {
+			t.Fatalf("expected the uploaded file but got: %q %v", file.Content, err)
+		}
+
+		rec := httptest.NewRecorder()
+		signed.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/"+target.Key, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("expected uploads not to be served but got %v", rec.Code)
+		}
+	})
+
+	t.Run("refuse an expired signed url", func(t *testing.T) {
+		signed := &Storage{Dir: storage.Dir, BaseUrl: storage.BaseUrl, Secret: []byte("secret")}
+
+		target, err := signed.PresignUpload(context.Background(), uploader.PresignInput{Name: "lizzy.png", MimeType: "image/png", Size: 5, Expires: -time.Minute})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req := httptest.NewRequest(http.MethodPut, strings.TrimPrefix(target.Url, "http://localhost:8080/media"), strings.NewReader("lizzy"))
+		req.Header.Set("Content-Type", "image/png")
+		rec := httptest.NewRecorder()
+		signed.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("expected status %v but got %v", http.StatusForbidden, rec.Code)
+		}
+	})
+
+	t.Run("list the stored files by key", func(t *testing.T) {
+		url, err := storage.UploadFile(context.Background(), file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		files, err := storage.List(context.Background())
+		if err != nil {
+			t.Fatalf("should not be error but got: %v", err)
+		}
+
+		found := false
+		for _, stored := range files {
+			if stored.Key == storage.FileKey(url) {
+				found = stored.Size == int64(len(file.Content)) && !stored.ModifiedAt.IsZero()
+			}
+		}
+
+		if !found {
+			t.Errorf("expected %v to be listed but got %+v", storage.FileKey(url), files)
+		}
+
+		missing := &Storage{Dir: filepath.Join(storage.Dir, "missing")}
+		if files, err := missing.List(context.Background()); err != nil || len(files) != 0 {
+			t.Errorf("expected a missing directory to list nothing but got: %v %v", files, err)
+		}
+	})
+
+	t.Run("presign is not supported without a secret", func(t *testing.T) {
+		if _, err := storage.PresignUpload(context.Background(), uploader.PresignInput{Name: "lizzy.png"}); err != uploader.ErrPresignNotSupported {
+			t.Errorf("expected error %v but got %v", uploader.ErrPresignNotSupported, err)
+		}
+
+		if _, err := storage.ReadFile(context.Background(), storage.FileKey("http://localhost:8080/media/"+uploader.StoreName(file.Name)), 1024); err != uploader.ErrFileNotFound {
+			t.Errorf("expected files out of the uploads not to be read but got %v", err)
+		}
+	})
+}
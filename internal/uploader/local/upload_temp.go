@@ -4,12 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
 
 	"github.com/faizisyellow/indocoffee/internal/uploader"
 )
 
+// TempUpload writes files under their own name and returns their path, it is meant for tests.
 type TempUpload struct {
 	LocSavePath string
 }
@@ -48,3 +50,69 @@ func (t *TempUpload) DeleteFile(ctx context.Context, filename string) error {
 
 	return nil
 }
+
+// FileKey is the name of the file when the path is in the save directory.
+func (t *TempUpload) FileKey(path string) string {
+	saveDir := t.LocSavePath
+	if saveDir == "" {
+		saveDir = "."
+	}
+
+	if filepath.Dir(path) != filepath.Clean(saveDir) {
+		return ""
+	}
+
+	return filepath.Base(path)
+}
+
+func (t *TempUpload) PresignUpload(ctx context.Context, file uploader.PresignInput) (uploader.UploadTarget, error) {
+	return uploader.UploadTarget{}, uploader.ErrPresignNotSupported
+}
+
+// ReadFile reads a file of the save directory by its name.
+func (t *TempUpload) ReadFile(ctx context.Context, key string, limit int64) (uploader.FileInput, error) {
+	if !filepath.IsLocal(key) {
+		return uploader.FileInput{}, uploader.ErrFileNotFound
+	}
+
+	content, err := os.ReadFile(filepath.Join(t.LocSavePath, key))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return uploader.FileInput{}, uploader.ErrFileNotFound
+		}
+		return uploader.FileInput{}, err
+	}
+
+	if int64(len(content)) > limit {
+		return uploader.FileInput{}, uploader.ErrFileTooLarge
+	}
+
+	return uploader.FileInput{
+		Name:     filepath.Base(key),
+		Size:     int64(len(content)),
+		MimeType: http.DetectContentType(content),
+		Content:  content,
+	}, nil
+}
+
+func (t *TempUpload) List(ctx context.Context) ([]uploader.StoredFile, error) {
+	entries, err := os.ReadDir(t.LocSavePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var files []uploader.StoredFile
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || entry.IsDir() {
+			continue
+		}
+
+		files = append(files, uploader.StoredFile{Key: entry.Name(), Size: info.Size(), ModifiedAt: info.ModTime()})
+	}
+
+	return files, nil
+}
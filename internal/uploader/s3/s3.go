@@ -0,0 +1,302 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/faizisyellow/indocoffee/internal/uploader"
+)
+
+// DefaultCacheControl lets clients keep an object forever,
+// a key is random and never given to another content.
+const DefaultCacheControl = "public, max-age=31536000, immutable"
+
+type Config struct {
+	// Endpoint is the url of the service such as https://s3.ap-southeast-1.amazonaws.com
+	// or http://localhost:9000 for MinIO.
+	Endpoint  string
+	Region    string
+	Bucket    string
+	Prefix    string
+	AccessKey string
+	SecretKey string
+	// PathStyle puts the bucket in the path instead of the host, MinIO needs it.
+	PathStyle bool
+	// PublicUrl is where the objects are served from such as a CDN,
+	// the url of the bucket when empty.
+	PublicUrl    string
+	CacheControl string
+}
+
+// S3 stores files in a bucket of an S3 compatible object storage.
+type S3 struct {
+	config Config
+	client *http.Client
+	// now is replaced in tests to sign with a fixed time.
+	now func() time.Time
+}
+
+func New(config Config) *S3 {
+	if config.CacheControl == "" {
+		config.CacheControl = DefaultCacheControl
+	}
+
+	config.Endpoint = strings.TrimSuffix(config.Endpoint, "/")
+	config.PublicUrl = strings.TrimSuffix(config.PublicUrl, "/")
+
+	return &S3{
+		config: config,
+		client: &http.Client{Timeout: 20 * time.Second},
+		now:    time.Now,
+	}
+}
+
+// UploadFile stores the file as a new object keyed by the prefix, a random part
+// and the file name, uploading the same file again is another object.
+func (s *S3) UploadFile(ctx context.Context, file uploader.FileInput) (string, error) {
+	key := s.config.Prefix + uploader.StoreName(file.Name)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectUrl(key), bytes.NewReader(file.Content))
+	if err != nil {
+		return "", err
+	}
+
+	req.ContentLength = int64(len(file.Content))
+	req.Header.Set("Content-Type", file.MimeType)
+	req.Header.Set("Cache-Control", s.config.CacheControl)
+
+	if err := s.do(req, file.Content, http.StatusOK); err != nil {
+		return "", fmt.Errorf("upload %s: %w", key, err)
+	}
+
+	return s.publicUrl(key), nil
+}
+
+// DeleteFile deletes the object by its key, deleting a missing object is not an error.
+func (s *S3) DeleteFile(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectUrl(key), nil)
+	if err != nil {
+		return err
+	}
+
+	if err := s.do(req, nil, http.StatusNoContent, http.StatusOK); err != nil {
+		return fmt.Errorf("delete %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// FileKey is the key of an object from its public url, empty when the url is not in the bucket.
+func (s *S3) FileKey(fileUrl string) string {
+	key, found := strings.CutPrefix(fileUrl, s.publicUrl(""))
+	if !found {
+		return ""
+	}
+
+	key, err := url.PathUnescape(key)
+	if err != nil {
+		return ""
+	}
+
+	return key
+}
+
+// PresignUpload signs a PUT of the object for the client, the content type
+// and length are signed so the upload must match what was asked for.
+func (s *S3) PresignUpload(ctx context.Context, file uploader.PresignInput) (uploader.UploadTarget, error) {
+	key := s.config.Prefix + uploader.UploadName(file.Name)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectUrl(key), nil)
+	if err != nil {
+		return uploader.UploadTarget{}, err
+	}
+
+	headers := map[string]string{
+		"Content-Type":   file.MimeType,
+		"Content-Length": strconv.FormatInt(file.Size, 10),
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	now := s.now()
+	presign(req, s.config.AccessKey, s.config.SecretKey, s.config.Region, now, file.Expires)
+
+	return uploader.UploadTarget{
+		Key:       key,
+		Url:       req.URL.String(),
+		Method:    http.MethodPut,
+		Headers:   headers,
+		ExpiresAt: now.Add(file.Expires),
+	}, nil
+}
+
+// ReadFile downloads an object uploaded to a presigned target, objects out of the
+// uploads of the prefix are never read.
+func (s *S3) ReadFile(ctx context.Context, key string, limit int64) (uploader.FileInput, error) {
+	if !strings.HasPrefix(key, s.config.Prefix+uploader.UploadsPrefix) {
+		return uploader.FileInput{}, uploader.ErrFileNotFound
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectUrl(key), nil)
+	if err != nil {
+		return uploader.FileInput{}, err
+	}
+
+	res, err := s.send(req, nil)
+	if err != nil {
+		return uploader.FileInput{}, fmt.Errorf("read %s: %w", key, err)
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return uploader.FileInput{}, uploader.ErrFileNotFound
+	case res.StatusCode != http.StatusOK:
+		return uploader.FileInput{}, fmt.Errorf("read %s: %w", key, unexpectedStatus(res))
+	case res.ContentLength > limit:
+		return uploader.FileInput{}, uploader.ErrFileTooLarge
+	}
+
+	content, err := io.ReadAll(io.LimitReader(res.Body, limit+1))
+	if err != nil {
+		return uploader.FileInput{}, fmt.Errorf("read %s: %w", key, err)
+	}
+
+	if int64(len(content)) > limit {
+		return uploader.FileInput{}, uploader.ErrFileTooLarge
+	}
+
+	return uploader.FileInput{
+		Name:     path.Base(key),
+		Size:     int64(len(content)),
+		MimeType: res.Header.Get("Content-Type"),
+		Content:  content,
+	}, nil
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List lists every object under the prefix a page of ListObjectsV2 at a time.
+func (s *S3) List(ctx context.Context) ([]uploader.StoredFile, error) {
+	var (
+		files []uploader.StoredFile
+		token string
+	)
+
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", s.config.Prefix)
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.bucketUrl()+"/?"+query.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := s.send(req, nil)
+		if err != nil {
+			return nil, fmt.Errorf("list %s: %w", s.config.Prefix, err)
+		}
+
+		if res.StatusCode != http.StatusOK {
+			err := unexpectedStatus(res)
+			res.Body.Close()
+			return nil, fmt.Errorf("list %s: %w", s.config.Prefix, err)
+		}
+
+		var page listBucketResult
+		err = xml.NewDecoder(res.Body).Decode(&page)
+		res.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("list %s: %w", s.config.Prefix, err)
+		}
+
+		for _, object := range page.Contents {
+			files = append(files, uploader.StoredFile{Key: object.Key, Size: object.Size, ModifiedAt: object.LastModified})
+		}
+
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return files, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+func (s *S3) do(req *http.Request, payload []byte, expected ...int) error {
+	res, err := s.send(req, payload)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	for _, status := range expected {
+		if res.StatusCode == status {
+			return nil
+		}
+	}
+
+	return unexpectedStatus(res)
+}
+
+func (s *S3) send(req *http.Request, payload []byte) (*http.Response, error) {
+	sign(req, payload, s.config.AccessKey, s.config.SecretKey, s.config.Region, s.now())
+
+	return s.client.Do(req)
+}
+
+func unexpectedStatus(res *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	return fmt.Errorf("unexpected status %d: %s", res.StatusCode, body)
+}
+
+func (s *S3) bucketUrl() string {
+	if s.config.PathStyle {
+		return s.config.Endpoint + "/" + s.config.Bucket
+	}
+
+	scheme, host, _ := strings.Cut(s.config.Endpoint, "://")
+	return scheme + "://" + s.config.Bucket + "." + host
+}
+
+func (s *S3) objectUrl(key string) string {
+	return s.bucketUrl() + "/" + escapePath(key)
+}
+
+func (s *S3) publicUrl(key string) string {
+	if s.config.PublicUrl != "" {
+		return s.config.PublicUrl + "/" + escapePath(key)
+	}
+
+	return s.objectUrl(key)
+}
+
+// escapePath escapes every segment of the key the way it is signed.
+func escapePath(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,145 @@
+package s3
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	algorithm = "AWS4-HMAC-SHA256"
+	service   = "s3"
+	// unsignedPayload is signed in place of the hash of a body that is not known yet.
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// sign adds the AWS signature version 4 headers to the request,
+// every header already set on the request is signed along with the host.
+func sign(req *http.Request, payload []byte, accessKey, secretKey, region string, now time.Time) {
+	now = now.UTC()
+
+	payloadHash := sha256.Sum256(payload)
+	req.Header.Set("X-Amz-Date", now.Format("20060102T150405Z"))
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+
+	signedHeaders, canonicalHeaders := canonicalHeaders(req)
+	signature := signature(req, secretKey, region, now, canonicalHeaders, signedHeaders, hex.EncodeToString(payloadHash[:]))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		algorithm, accessKey, scope(now, region), signedHeaders, signature))
+}
+
+// presign adds the AWS signature version 4 to the query of the request, the url can then
+// be used without credentials until it expires. The headers set on the request are signed
+// and must be sent as they are.
+func presign(req *http.Request, accessKey, secretKey, region string, now time.Time, expires time.Duration) {
+	now = now.UTC()
+
+	signedHeaders, canonicalHeaders := canonicalHeaders(req)
+
+	query := req.URL.Query()
+	query.Set("X-Amz-Algorithm", algorithm)
+	query.Set("X-Amz-Credential", accessKey+"/"+scope(now, region))
+	query.Set("X-Amz-Date", now.Format("20060102T150405Z"))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expires.Seconds())))
+	query.Set("X-Amz-SignedHeaders", signedHeaders)
+	req.URL.RawQuery = query.Encode()
+
+	signature := signature(req, secretKey, region, now, canonicalHeaders, signedHeaders, unsignedPayload)
+	req.URL.RawQuery += "&X-Amz-Signature=" + signature
+}
+
+func signature(req *http.Request, secretKey, region string, now time.Time, canonicalHeaders, signedHeaders, payloadHash string) string {
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		escapePath(req.URL.Path),
+		canonicalQuery(req),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := algorithm + "\n" + now.Format("20060102T150405Z") + "\n" + scope(now, region) + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSha256([]byte("AWS4"+secretKey), now.Format("20060102"))
+	key = hmacSha256(key, region)
+	key = hmacSha256(key, service)
+	key = hmacSha256(key, "aws4_request")
+
+	return hex.EncodeToString(hmacSha256(key, stringToSign))
+}
+
+func scope(now time.Time, region string) string {
+	return now.Format("20060102") + "/" + region + "/" + service + "/aws4_request"
+}
+
+// canonicalHeaders returns the names of the signed headers and the headers
+// in the form they are signed, the host and every header set on the request.
+func canonicalHeaders(req *http.Request) (string, string) {
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonical strings.Builder
+	for _, name := range names {
+		canonical.WriteString(name + ":" + headers[name] + "\n")
+	}
+
+	return strings.Join(names, ";"), canonical.String()
+}
+
+func canonicalQuery(req *http.Request) string {
+	query := req.URL.Query()
+
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, key := range keys {
+		values := query[key]
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, uriEncode(key)+"="+uriEncode(value))
+		}
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// uriEncode percent encodes everything but the unreserved characters.
+func uriEncode(value string) string {
+	var encoded strings.Builder
+	for _, b := range []byte(value) {
+		switch {
+		case b >= 'A' && b <= 'Z', b >= 'a' && b <= 'z', b >= '0' && b <= '9', b == '-', b == '_', b == '.', b == '~':
+			encoded.WriteByte(b)
+		default:
+			fmt.Fprintf(&encoded, "%%%02X", b)
+		}
+	}
+
+	return encoded.String()
+}
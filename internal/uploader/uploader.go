@@ -3,8 +3,23 @@ package uploader
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"errors"
 	"io"
 	"mime/multipart"
+	"path"
+	"strings"
+	"time"
+)
+
+// UploadsPrefix is where files uploaded by clients to a presigned target are kept,
+// only these can be read back with ReadFile.
+const UploadsPrefix = "uploads/"
+
+var (
+	ErrPresignNotSupported = errors.New("uploader: direct uploads are not supported")
+	ErrFileNotFound        = errors.New("uploader: file not found")
+	ErrFileTooLarge        = errors.New("uploader: file too large")
 )
 
 type FileInput struct {
@@ -14,9 +29,50 @@ type FileInput struct {
 	Content  []byte
 }
 
+// PresignInput describes the file a client is about to upload.
+type PresignInput struct {
+	Name     string
+	MimeType string
+	Size     int64
+	Expires  time.Duration
+}
+
+// UploadTarget is where a client uploads a file directly without going through the api.
+type UploadTarget struct {
+	// Key reads the file back once it is uploaded.
+	Key    string
+	Url    string
+	Method string
+	// Headers must be sent with the upload as they are signed.
+	Headers map[string]string
+	// FormField is set when the file is sent as multipart/form-data in this field
+	// instead of as the body.
+	FormField string
+	ExpiresAt time.Time
+}
+
+// StoredFile is a file kept by an uploader.
+type StoredFile struct {
+	// Key is what DeleteFile expects.
+	Key        string
+	Size       int64
+	ModifiedAt time.Time
+}
+
 type Uploader interface {
 	UploadFile(ctx context.Context, file FileInput) (string, error)
 	DeleteFile(ctx context.Context, filename string) error
+	// FileKey returns what DeleteFile expects for a url returned by UploadFile,
+	// empty when the url is not stored by this uploader.
+	FileKey(url string) string
+	// PresignUpload returns a short lived target for the client to upload the file to,
+	// ErrPresignNotSupported when the uploader can not issue one.
+	PresignUpload(ctx context.Context, file PresignInput) (UploadTarget, error)
+	// ReadFile reads a file uploaded to a target by its key, ErrFileNotFound when it
+	// was not uploaded and ErrFileTooLarge when it is bigger than limit.
+	ReadFile(ctx context.Context, key string, limit int64) (FileInput, error)
+	// List returns every file kept by the uploader, renditions and uploads alike.
+	List(ctx context.Context) ([]StoredFile, error)
 }
 
 // CreateMultipartBody builds a multipart/form-data body with a single file field
@@ -42,3 +98,38 @@ func CreateMultipartBody(file FileInput) (*bytes.Buffer, string, error) {
 
 	return &buf, writer.FormDataContentType(), nil
 }
+
+// StoreName names a stored file by a random part followed by its sanitized name,
+// every upload gets a name of its own even with the same content, so deleting
+// a file never removes one another image still refers to, and a name never
+// leaves its directory.
+func StoreName(name string) string {
+	return strings.ToLower(rand.Text()) + "/" + sanitizeName(name)
+}
+
+// UploadName names a file uploaded to a presigned target under UploadsPrefix.
+func UploadName(name string) string {
+	return UploadsPrefix + StoreName(name)
+}
+
+// sanitizeName keeps the base name of the file with only url safe characters.
+func sanitizeName(name string) string {
+	name = strings.ToLower(path.Base(strings.ReplaceAll(name, "\\", "/")))
+
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		default:
+			return '-'
+		}
+	}, name)
+
+	// no hidden files nor . and ..
+	name = strings.TrimLeft(name, ".")
+	if name == "" {
+		return "file"
+	}
+
+	return name
+}
@@ -9,6 +9,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -27,6 +28,7 @@ type Uploadthing struct {
 	metaUrl       string
 	callbackUrl   string
 	deleteUrl     string
+	listUrl       string
 	appId         string
 }
 
@@ -67,10 +69,13 @@ type ResponsePoolUpload struct {
 
 const (
 	contentDisposition = "inline"
-	expiresIn          = 300
+	// expiresIn is how long an upload url lasts in seconds when the caller does not say.
+	expiresIn = 300
+	// uploadCustomIdPrefix marks the files clients upload to a presigned url.
+	uploadCustomIdPrefix = "upload-"
 )
 
-func New(apiKey, presign, poolUpload, acl, slg, act, mturl, cllbckurl, dltUrl, appId string) *Uploadthing {
+func New(apiKey, presign, poolUpload, acl, slg, act, mturl, cllbckurl, dltUrl, lstUrl, appId string) *Uploadthing {
 
 	return &Uploadthing{
 		apiKey:        apiKey,
@@ -82,6 +87,7 @@ func New(apiKey, presign, poolUpload, acl, slg, act, mturl, cllbckurl, dltUrl, a
 		metaUrl:       mturl,
 		callbackUrl:   cllbckurl,
 		deleteUrl:     dltUrl,
+		listUrl:       lstUrl,
 		appId:         appId,
 	}
 }
@@ -94,8 +100,48 @@ func (u *Uploadthing) UploadFile(_ context.Context, file uploader.FileInput) (st
 
 	client := &http.Client{Timeout: 20 * time.Second}
 
+	if err := u.registerMetadata(client, registerResult.FileKey); err != nil {
+		return "", err
+	}
+
+	body, contentType, err := uploader.CreateMultipartBody(file)
+	if err != nil {
+		return "", err
+	}
+
+	putReq, err := http.NewRequest("PUT", registerResult.UploadURL, body)
+	if err != nil {
+		return "", err
+	}
+	putReq.Header.Set("Content-Type", contentType)
+
+	putResp, err := client.Do(putReq)
+	if err != nil {
+		return "", fmt.Errorf("upload failed: %v", err)
+	}
+	defer putResp.Body.Close()
+
+	if putResp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(putResp.Body)
+		return "", fmt.Errorf("upload error: %s", string(b))
+	}
+
+	checkUpload, err := u.PoolUpload(registerResult.FileKey)
+	if err != nil {
+		return "", err
+	}
+
+	if checkUpload.Status != "done" {
+		return "", errors.New("upload failed: upload not done")
+	}
+
+	return u.GetUrls(registerResult.FileKey), nil
+}
+
+// registerMetadata tells uploadthing who uploads the file and where to call back once it is uploaded.
+func (u *Uploadthing) registerMetadata(client *http.Client, fileKey string) error {
 	metaPayload := map[string]any{
-		"fileKeys": []string{registerResult.FileKey},
+		"fileKeys": []string{fileKey},
 		"metadata": map[string]string{
 			"uploadedBy": u.actor,
 		},
@@ -107,59 +153,95 @@ func (u *Uploadthing) UploadFile(_ context.Context, file uploader.FileInput) (st
 
 	metaJSON, err := json.Marshal(metaPayload)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal metadata payload: %w", err)
+		return fmt.Errorf("failed to marshal metadata payload: %w", err)
 	}
 
 	metaReq, err := http.NewRequest("POST", u.metaUrl, bytes.NewBuffer(metaJSON))
 	if err != nil {
-		return "", fmt.Errorf("failed to create metadata request: %w", err)
+		return fmt.Errorf("failed to create metadata request: %w", err)
 	}
 	metaReq.Header.Set("Content-Type", "application/json")
 	metaReq.Header.Set("x-uploadthing-api-key", u.apiKey)
 
 	metaResp, err := client.Do(metaReq)
 	if err != nil {
-		return "", fmt.Errorf("metadata registration failed: %w", err)
+		return fmt.Errorf("metadata registration failed: %w", err)
 	}
 	defer metaResp.Body.Close()
 
 	if metaResp.StatusCode != http.StatusOK {
 		b, _ := io.ReadAll(metaResp.Body)
-		return "", fmt.Errorf("metadata error: %s", string(b))
+		return fmt.Errorf("metadata error: %s", string(b))
 	}
 
-	body, contentType, err := uploader.CreateMultipartBody(file)
-	if err != nil {
-		return "", err
+	return nil
+}
+
+// PresignUpload registers the file and returns its ingest url, the client sends the file
+// to it as multipart form data. The url lasts file.Expires, expiresIn seconds when it is not set.
+func (u *Uploadthing) PresignUpload(_ context.Context, file uploader.PresignInput) (uploader.UploadTarget, error) {
+	uid := utils.UUID{Plaintoken: uuid.New().String()}
+
+	expires := int(file.Expires / time.Second)
+	if expires <= 0 {
+		expires = expiresIn
 	}
 
-	putReq, err := http.NewRequest("PUT", registerResult.UploadURL, body)
+	registerResult, err := u.register(file.Name, file.MimeType, int(file.Size), uploadCustomIdPrefix+uid.Generate(), expires)
 	if err != nil {
-		return "", err
+		return uploader.UploadTarget{}, err
 	}
-	putReq.Header.Set("Content-Type", contentType)
 
-	putResp, err := client.Do(putReq)
+	if err := u.registerMetadata(&http.Client{Timeout: 20 * time.Second}, registerResult.FileKey); err != nil {
+		return uploader.UploadTarget{}, err
+	}
+
+	return uploader.UploadTarget{
+		Key:       registerResult.FileKey,
+		Url:       registerResult.UploadURL,
+		Method:    http.MethodPut,
+		FormField: "file",
+		ExpiresAt: time.Now().Add(time.Duration(expires) * time.Second),
+	}, nil
+}
+
+// ReadFile downloads a file uploaded to a presigned url, files uploaded any other way are never read.
+func (u *Uploadthing) ReadFile(_ context.Context, filekey string, limit int64) (uploader.FileInput, error) {
+	uploaded, err := u.PoolUpload(filekey)
+	if err != nil || uploaded.Status != "done" || !strings.HasPrefix(uploaded.CustomId, uploadCustomIdPrefix) {
+		return uploader.FileInput{}, uploader.ErrFileNotFound
+	}
+
+	if size, err := strconv.ParseInt(uploaded.FileSize, 10, 64); err == nil && size > limit {
+		return uploader.FileInput{}, uploader.ErrFileTooLarge
+	}
+
+	client := &http.Client{Timeout: 20 * time.Second}
+	res, err := client.Get(u.GetUrls(filekey))
 	if err != nil {
-		return "", fmt.Errorf("upload failed: %v", err)
+		return uploader.FileInput{}, err
 	}
-	defer putResp.Body.Close()
+	defer res.Body.Close()
 
-	if putResp.StatusCode != http.StatusOK {
-		b, _ := io.ReadAll(putResp.Body)
-		return "", fmt.Errorf("upload error: %s", string(b))
+	if res.StatusCode != http.StatusOK {
+		return uploader.FileInput{}, uploader.ErrFileNotFound
 	}
 
-	checkUpload, err := u.PoolUpload(registerResult.FileKey)
+	content, err := io.ReadAll(io.LimitReader(res.Body, limit+1))
 	if err != nil {
-		return "", err
+		return uploader.FileInput{}, err
 	}
 
-	if checkUpload.Status != "done" {
-		return "", errors.New("upload failed: upload not done")
+	if int64(len(content)) > limit {
+		return uploader.FileInput{}, uploader.ErrFileTooLarge
 	}
 
-	return u.GetUrls(registerResult.FileKey), nil
+	return uploader.FileInput{
+		Name:     uploaded.Filename,
+		Size:     int64(len(content)),
+		MimeType: uploaded.FileType,
+		Content:  content,
+	}, nil
 }
 
 func (u *Uploadthing) DeleteFile(_ context.Context, filekey string) error {
@@ -193,10 +275,76 @@ func (u *Uploadthing) DeleteFile(_ context.Context, filekey string) error {
 	return nil
 }
 
+type listFilesResponse struct {
+	HasMore bool `json:"hasMore"`
+	Files   []struct {
+		Key  string `json:"key"`
+		Size int64  `json:"size"`
+		// UploadedAt is in milliseconds since the epoch.
+		UploadedAt int64 `json:"uploadedAt"`
+	} `json:"files"`
+}
+
+// List lists every file of the app a page at a time.
+func (u *Uploadthing) List(ctx context.Context) ([]uploader.StoredFile, error) {
+	const limit = 500
+
+	var (
+		files  []uploader.StoredFile
+		client = &http.Client{Timeout: 20 * time.Second}
+	)
+
+	for offset := 0; ; offset += limit {
+		payload, err := json.Marshal(map[string]int{"limit": limit, "offset": offset})
+		if err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "POST", u.listUrl, bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("x-uploadthing-api-key", u.apiKey)
+
+		res, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if res.StatusCode != http.StatusOK {
+			b, _ := io.ReadAll(res.Body)
+			res.Body.Close()
+			return nil, fmt.Errorf("list error: %s", string(b))
+		}
+
+		var page listFilesResponse
+		err = json.NewDecoder(res.Body).Decode(&page)
+		res.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("decode response: %w", err)
+		}
+
+		for _, file := range page.Files {
+			files = append(files, uploader.StoredFile{Key: file.Key, Size: file.Size, ModifiedAt: time.UnixMilli(file.UploadedAt)})
+		}
+
+		if !page.HasMore || len(page.Files) == 0 {
+			return files, nil
+		}
+	}
+}
+
 func (u *Uploadthing) Register(filename, filetype string, filesize int) (*RegisterResponse, error) {
 
 	uid := utils.UUID{Plaintoken: uuid.New().String()}
 
+	return u.register(filename, filetype, filesize, uid.Generate(), expiresIn)
+}
+
+// register asks for an upload url that lasts expires seconds.
+func (u *Uploadthing) register(filename, filetype string, filesize int, customId string, expires int) (*RegisterResponse, error) {
+
 	registerReq := struct {
 		Filename           string `json:"fileName"`
 		Filesize           int    `json:"fileSize"`
@@ -211,10 +359,10 @@ func (u *Uploadthing) Register(filename, filetype string, filesize int) (*Regist
 		Filesize:           filesize,
 		Slug:               u.slug,
 		Filetype:           filetype,
-		CustomId:           uid.Generate(),
+		CustomId:           customId,
 		ContentDisposition: contentDisposition,
 		Acl:                u.acl,
-		ExpiresIn:          expiresIn,
+		ExpiresIn:          expires,
 	}
 
 	body, _ := json.Marshal(registerReq)
@@ -297,6 +445,10 @@ func (u *Uploadthing) GetUrls(filekey string) string {
 	return urlBuilder.String()
 }
 
+func (u *Uploadthing) FileKey(url string) string {
+	return GetFileKey(url)
+}
+
 func GetFileKey(url string) string {
 	if url == "" {
 		return ""
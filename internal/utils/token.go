@@ -26,3 +26,12 @@ func (u UUID) Generate() string {
 	return hex.EncodeToString(hash[:])
 
 }
+
+// HashToken hashes a plaintext token with sha256,
+// so only the digest of the token is kept in storage.
+func HashToken(plaintext string) string {
+
+	hash := sha256.Sum256([]byte(plaintext))
+
+	return hex.EncodeToString(hash[:])
+}